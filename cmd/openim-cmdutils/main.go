@@ -25,6 +25,8 @@ func main() {
 	clearCmd := cmd.NewClearCmd()
 	seqCmd := cmd.NewSeqCmd()
 	msgCmd := cmd.NewMsgCmd()
+	exportCmd := cmd.NewExportCmd()
+	conversationCmd := cmd.NewConversationCmd()
 	getCmd.AddCommand(seqCmd.GetSeqCmd(), msgCmd.GetMsgCmd())
 	getCmd.AddSuperGroupIDFlag()
 	getCmd.AddUserIDFlag()
@@ -52,7 +54,16 @@ func main() {
 	// openIM clear msg --userID=xxx --beginSeq=100 --limit=10
 	// openIM clear msg --superGroupID=xxx --beginSeq=100 --limit=10
 	// openIM clear msg --clearAll
-	msgUtilsCmd.AddCommand(&getCmd.Command, &fixCmd.Command, &clearCmd.Command)
+
+	exportCmd.AddCommand(conversationCmd.ExportConversationCmd())
+	exportCmd.AddConfFlag()
+	exportCmd.AddConversationIDFlag()
+	exportCmd.AddUserIDFlag()
+	exportCmd.AddFormatFlag()
+	exportCmd.AddOutputFlag()
+	// openIM export conversation --conversationID=xxx --format=html --output=./xxx.html
+	// openIM export conversation --conversationID=xxx --userID=xxx --format=json
+	msgUtilsCmd.AddCommand(&getCmd.Command, &fixCmd.Command, &clearCmd.Command, &exportCmd.Command)
 	if err := msgUtilsCmd.Execute(); err != nil {
 		panic(err)
 	}
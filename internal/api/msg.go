@@ -121,6 +121,118 @@ func (m *MessageApi) SetRedPacketMsgStatus(c *gin.Context) {
 	a2r.Call(msg.MsgClient.SetRedPacketMsgStatus, m.Client, c)
 }
 
+func (m *MessageApi) AddMsgReaction(c *gin.Context) {
+	a2r.Call(msg.MsgClient.AddMsgReaction, m.Client, c)
+}
+
+func (m *MessageApi) DelMsgReaction(c *gin.Context) {
+	a2r.Call(msg.MsgClient.DelMsgReaction, m.Client, c)
+}
+
+func (m *MessageApi) GetMsgReactions(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetMsgReactions, m.Client, c)
+}
+
+func (m *MessageApi) GetMsgReactors(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetMsgReactors, m.Client, c)
+}
+
+func (m *MessageApi) EditMsg(c *gin.Context) {
+	a2r.Call(msg.MsgClient.EditMsg, m.Client, c)
+}
+
+func (m *MessageApi) GetMsgEditHistory(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetMsgEditHistory, m.Client, c)
+}
+
+func (m *MessageApi) CreateThread(c *gin.Context) {
+	a2r.Call(msg.MsgClient.CreateThread, m.Client, c)
+}
+
+func (m *MessageApi) GetThreadSummaries(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetThreadSummaries, m.Client, c)
+}
+
+func (m *MessageApi) GetGroupThreads(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetGroupThreads, m.Client, c)
+}
+
+func (m *MessageApi) SubscribeThread(c *gin.Context) {
+	a2r.Call(msg.MsgClient.SubscribeThread, m.Client, c)
+}
+
+func (m *MessageApi) UnsubscribeThread(c *gin.Context) {
+	a2r.Call(msg.MsgClient.UnsubscribeThread, m.Client, c)
+}
+
+func (m *MessageApi) GetSubscribedThreads(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetSubscribedThreads, m.Client, c)
+}
+
+func (m *MessageApi) MarkThreadAsRead(c *gin.Context) {
+	a2r.Call(msg.MsgClient.MarkThreadAsRead, m.Client, c)
+}
+
+func (m *MessageApi) PullThreadMsgs(c *gin.Context) {
+	a2r.Call(msg.MsgClient.PullThreadMsgs, m.Client, c)
+}
+
+func (m *MessageApi) SetThreadArchived(c *gin.Context) {
+	a2r.Call(msg.MsgClient.SetThreadArchived, m.Client, c)
+}
+
+func (m *MessageApi) GetScheduledMsgs(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetScheduledMsgs, m.Client, c)
+}
+
+func (m *MessageApi) EditScheduledMsg(c *gin.Context) {
+	a2r.Call(msg.MsgClient.EditScheduledMsg, m.Client, c)
+}
+
+func (m *MessageApi) CancelScheduledMsg(c *gin.Context) {
+	a2r.Call(msg.MsgClient.CancelScheduledMsg, m.Client, c)
+}
+
+func (m *MessageApi) SearchUserMsgs(c *gin.Context) {
+	a2r.Call(msg.MsgClient.SearchUserMsgs, m.Client, c)
+}
+
+func (m *MessageApi) PullMsgsByTime(c *gin.Context) {
+	a2r.Call(msg.MsgClient.PullMsgsByTime, m.Client, c)
+}
+
+func (m *MessageApi) GetGroupMsgReadMembers(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetGroupMsgReadMembers, m.Client, c)
+}
+
+func (m *MessageApi) CreatePoll(c *gin.Context) {
+	a2r.Call(msg.MsgClient.CreatePoll, m.Client, c)
+}
+
+func (m *MessageApi) VotePoll(c *gin.Context) {
+	a2r.Call(msg.MsgClient.VotePoll, m.Client, c)
+}
+
+func (m *MessageApi) UnvotePoll(c *gin.Context) {
+	a2r.Call(msg.MsgClient.UnvotePoll, m.Client, c)
+}
+
+func (m *MessageApi) ClosePoll(c *gin.Context) {
+	a2r.Call(msg.MsgClient.ClosePoll, m.Client, c)
+}
+
+func (m *MessageApi) GetPollResult(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetPollResult, m.Client, c)
+}
+
+func (m *MessageApi) ExportConversation(c *gin.Context) {
+	a2r.Call(msg.MsgClient.ExportConversation, m.Client, c)
+}
+
+func (m *MessageApi) GetConversationExport(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetConversationExport, m.Client, c)
+}
+
 func (m *MessageApi) MarkMsgsAsRead(c *gin.Context) {
 	a2r.Call(msg.MsgClient.MarkMsgsAsRead, m.Client, c)
 }
@@ -369,6 +481,10 @@ func (m *MessageApi) GetActiveGroup(c *gin.Context) {
 	a2r.Call(msg.MsgClient.GetActiveGroup, m.Client, c)
 }
 
+func (m *MessageApi) GetPushFunnel(c *gin.Context) {
+	a2r.Call(msg.MsgClient.GetPushFunnel, m.Client, c)
+}
+
 func (m *MessageApi) SearchMsg(c *gin.Context) {
 	a2r.Call(msg.MsgClient.SearchMessage, m.Client, c)
 }
@@ -0,0 +1,50 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenIMSDK/protocol/push"
+	"github.com/OpenIMSDK/tools/a2r"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient"
+)
+
+type PushApi rpcclient.Push
+
+func NewPushApi(client rpcclient.Push) PushApi {
+	return PushApi(client)
+}
+
+func (o *PushApi) GetFailedPushes(c *gin.Context) {
+	a2r.Call(push.PushMsgServiceClient.GetFailedPushes, o.Client, c)
+}
+
+func (o *PushApi) ReplayFailedPushes(c *gin.Context) {
+	a2r.Call(push.PushMsgServiceClient.ReplayFailedPushes, o.Client, c)
+}
+
+func (o *PushApi) GetPushTemplate(c *gin.Context) {
+	a2r.Call(push.PushMsgServiceClient.GetPushTemplate, o.Client, c)
+}
+
+func (o *PushApi) SetPushTemplate(c *gin.Context) {
+	a2r.Call(push.PushMsgServiceClient.SetPushTemplate, o.Client, c)
+}
+
+func (o *PushApi) AckOfflinePush(c *gin.Context) {
+	a2r.Call(push.PushMsgServiceClient.AckOfflinePush, o.Client, c)
+}
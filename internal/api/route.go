@@ -61,6 +61,7 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 	thirdRpc := rpcclient.NewThird(discov)
 	clubRpc := rpcclient.NewClub(discov)
 	cronRpc := rpcclient.NewCron(discov)
+	pushRpc := rpcclient.NewPush(discov)
 
 	u := NewUserApi(*userRpc)
 	m := NewMessageApi(messageRpc, userRpc)
@@ -76,9 +77,14 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 		userRouterGroup.POST("/get_users", ParseToken, u.GetUsers)
 		userRouterGroup.POST("/get_users_online_status", ParseToken, u.GetUsersOnlineStatus)
 		userRouterGroup.POST("/get_users_online_token_detail", ParseToken, u.GetUsersOnlineTokenDetail)
+		userRouterGroup.POST("/get_user_conns", ParseToken, u.GetUserConns)
+		userRouterGroup.POST("/close_user_conn", ParseToken, u.CloseUserConn)
 		userRouterGroup.POST("/subscribe_users_status", ParseToken, u.SubscriberStatus)
 		userRouterGroup.POST("/get_users_status", ParseToken, u.GetUserStatus)
 		userRouterGroup.POST("/get_subscribe_users_status", ParseToken, u.GetSubscribeUsersStatus)
+		userRouterGroup.POST("/set_quiet_hours", ParseToken, u.SetQuietHours)
+		userRouterGroup.POST("/del_quiet_hours", ParseToken, u.DelQuietHours)
+		userRouterGroup.POST("/get_quiet_hours", ParseToken, u.GetQuietHours)
 	}
 	// friend routing group
 	friendRouterGroup := r.Group("/friend", ParseToken)
@@ -151,6 +157,11 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 		thirdGroup.GET("/prometheus", GetPrometheus)
 		t := NewThirdApi(*thirdRpc)
 		thirdGroup.POST("/fcm_update_token", t.FcmUpdateToken)
+		thirdGroup.POST("/apns_update_token", t.APNsUpdateToken)
+		thirdGroup.POST("/voip_update_token", t.VoIPUpdateToken)
+		thirdGroup.POST("/web_push_subscribe", t.WebPushSubscribe)
+		thirdGroup.POST("/web_push_unsubscribe", t.WebPushUnsubscribe)
+		thirdGroup.POST("/vendor_update_token", t.VendorUpdateToken)
 		thirdGroup.POST("/set_app_badge", t.SetAppBadge)
 
 		logs := thirdGroup.Group("/logs")
@@ -179,9 +190,11 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 		msgGroup.POST("/send_msg", m.SendMessage)
 		msgGroup.POST("/send_business_notification", m.SendBusinessNotification)
 		msgGroup.POST("/pull_msg_by_seq", m.PullMsgBySeqs)
+		msgGroup.POST("/pull_msgs_by_time", m.PullMsgsByTime)
 		msgGroup.POST("/revoke_msg", m.RevokeMsg)
 		msgGroup.POST("/mark_msgs_as_read", m.MarkMsgsAsRead)
 		msgGroup.POST("/mark_conversation_as_read", m.MarkConversationAsRead)
+		msgGroup.POST("/get_group_msg_read_members", m.GetGroupMsgReadMembers)
 		msgGroup.POST("/get_conversations_has_read_and_max_seq", m.GetConversationsHasReadAndMaxSeq)
 		msgGroup.POST("/set_conversation_has_read_seq", m.SetConversationHasReadSeq)
 
@@ -196,6 +209,36 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 		msgGroup.POST("/get_server_time", m.GetServerTime)
 
 		msgGroup.POST("/set_red_packet_msg_status", m.SetRedPacketMsgStatus)
+
+		msgGroup.POST("/add_msg_reaction", m.AddMsgReaction)
+		msgGroup.POST("/del_msg_reaction", m.DelMsgReaction)
+		msgGroup.POST("/get_msg_reactions", m.GetMsgReactions)
+		msgGroup.POST("/get_msg_reactors", m.GetMsgReactors)
+
+		msgGroup.POST("/edit_msg", m.EditMsg)
+		msgGroup.POST("/get_msg_edit_history", m.GetMsgEditHistory)
+
+		msgGroup.POST("/create_thread", m.CreateThread)
+		msgGroup.POST("/get_thread_summaries", m.GetThreadSummaries)
+		msgGroup.POST("/get_group_threads", m.GetGroupThreads)
+		msgGroup.POST("/subscribe_thread", m.SubscribeThread)
+		msgGroup.POST("/unsubscribe_thread", m.UnsubscribeThread)
+		msgGroup.POST("/get_subscribed_threads", m.GetSubscribedThreads)
+		msgGroup.POST("/mark_thread_as_read", m.MarkThreadAsRead)
+		msgGroup.POST("/pull_thread_msgs", m.PullThreadMsgs)
+		msgGroup.POST("/set_thread_archived", m.SetThreadArchived)
+
+		msgGroup.POST("/get_scheduled_msgs", m.GetScheduledMsgs)
+		msgGroup.POST("/edit_scheduled_msg", m.EditScheduledMsg)
+		msgGroup.POST("/cancel_scheduled_msg", m.CancelScheduledMsg)
+		msgGroup.POST("/search_user_msgs", m.SearchUserMsgs)
+		msgGroup.POST("/create_poll", m.CreatePoll)
+		msgGroup.POST("/vote_poll", m.VotePoll)
+		msgGroup.POST("/unvote_poll", m.UnvotePoll)
+		msgGroup.POST("/close_poll", m.ClosePoll)
+		msgGroup.POST("/get_poll_result", m.GetPollResult)
+		msgGroup.POST("/export_conversation", m.ExportConversation)
+		msgGroup.POST("/get_conversation_export", m.GetConversationExport)
 	}
 	// Conversation
 	conversationGroup := r.Group("/conversation", ParseToken)
@@ -214,6 +257,7 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 		statisticsGroup.POST("/user/active", m.GetActiveUser)
 		statisticsGroup.POST("/group/create", g.GroupCreateCount)
 		statisticsGroup.POST("/group/active", m.GetActiveGroup)
+		statisticsGroup.POST("/push/funnel", m.GetPushFunnel)
 	}
 
 	//club
@@ -287,6 +331,17 @@ func NewGinRouter(discov discoveryregistry.SvcDiscoveryRegistry, rdb redis.Unive
 		cronGroup.POST("/get_clear_msg_job", c.GetClearMsgJob)
 	}
 
+	// offline push
+	pushGroup := r.Group("/push", ParseToken)
+	{
+		p := NewPushApi(*pushRpc)
+		pushGroup.POST("/get_failed_pushes", p.GetFailedPushes)
+		pushGroup.POST("/replay_failed_pushes", p.ReplayFailedPushes)
+		pushGroup.POST("/get_push_template", p.GetPushTemplate)
+		pushGroup.POST("/set_push_template", p.SetPushTemplate)
+		pushGroup.POST("/ack_offline_push", p.AckOfflinePush)
+	}
+
 	return r
 }
 
@@ -41,6 +41,26 @@ func (o *ThirdApi) FcmUpdateToken(c *gin.Context) {
 	a2r.Call(third.ThirdClient.FcmUpdateToken, o.Client, c)
 }
 
+func (o *ThirdApi) APNsUpdateToken(c *gin.Context) {
+	a2r.Call(third.ThirdClient.APNsUpdateToken, o.Client, c)
+}
+
+func (o *ThirdApi) VoIPUpdateToken(c *gin.Context) {
+	a2r.Call(third.ThirdClient.VoIPUpdateToken, o.Client, c)
+}
+
+func (o *ThirdApi) WebPushSubscribe(c *gin.Context) {
+	a2r.Call(third.ThirdClient.WebPushSubscribe, o.Client, c)
+}
+
+func (o *ThirdApi) WebPushUnsubscribe(c *gin.Context) {
+	a2r.Call(third.ThirdClient.WebPushUnsubscribe, o.Client, c)
+}
+
+func (o *ThirdApi) VendorUpdateToken(c *gin.Context) {
+	a2r.Call(third.ThirdClient.VendorUpdateToken, o.Client, c)
+}
+
 func (o *ThirdApi) SetAppBadge(c *gin.Context) {
 	a2r.Call(third.ThirdClient.SetAppBadge, o.Client, c)
 }
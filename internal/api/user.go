@@ -185,6 +185,26 @@ func (u *UserApi) GetUsersOnlineTokenDetail(c *gin.Context) {
 	apiresp.GinSuccess(c, respResult)
 }
 
+// GetUserConns List the live connections of a user on all gateway nodes.
+func (u *UserApi) GetUserConns(c *gin.Context) {
+	conn, err := u.Discov.GetConn(c, config.Config.RpcRegisterName.OpenImMessageGatewayName)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	a2r.Call(msggateway.MsgGatewayClient.GetUserConns, msggateway.NewMsgGatewayClient(conn), c)
+}
+
+// CloseUserConn Close a single connection of a user by connID.
+func (u *UserApi) CloseUserConn(c *gin.Context) {
+	conn, err := u.Discov.GetConn(c, config.Config.RpcRegisterName.OpenImMessageGatewayName)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	a2r.Call(msggateway.MsgGatewayClient.CloseUserConn, msggateway.NewMsgGatewayClient(conn), c)
+}
+
 // SubscriberStatus Presence status of subscribed users.
 func (u *UserApi) SubscriberStatus(c *gin.Context) {
 	a2r.Call(user.UserClient.SubscribeOrCancelUsersStatus, u.Client, c)
@@ -199,3 +219,18 @@ func (u *UserApi) GetUserStatus(c *gin.Context) {
 func (u *UserApi) GetSubscribeUsersStatus(c *gin.Context) {
 	a2r.Call(user.UserClient.GetSubscribeUsersStatus, u.Client, c)
 }
+
+// SetQuietHours Set the quiet hours of the user globally, for a conversation or for a server.
+func (u *UserApi) SetQuietHours(c *gin.Context) {
+	a2r.Call(user.UserClient.SetQuietHours, u.Client, c)
+}
+
+// DelQuietHours Delete the quiet hours of the user for a target.
+func (u *UserApi) DelQuietHours(c *gin.Context) {
+	a2r.Call(user.UserClient.DelQuietHours, u.Client, c)
+}
+
+// GetQuietHours Get all the quiet hours of the user.
+func (u *UserApi) GetQuietHours(c *gin.Context) {
+	a2r.Call(user.UserClient.GetQuietHours, u.Client, c)
+}
@@ -22,11 +22,13 @@ import (
 	"sync"
 	"sync/atomic"
 
+	imerrs "github.com/openimsdk/open-im-server/v3/pkg/common/errs"
 	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
 
 	"google.golang.org/protobuf/proto"
 
 	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msggateway"
 	"github.com/OpenIMSDK/protocol/sdkws"
 	"github.com/OpenIMSDK/tools/apiresp"
 	"github.com/OpenIMSDK/tools/log"
@@ -67,6 +69,7 @@ type Client struct {
 	conn           LongConn
 	PlatformID     int    `json:"platformID"`
 	IsCompress     bool   `json:"isCompress"`
+	CompressProto  string `json:"compressProto"`
 	UserID         string `json:"userID"`
 	IsBackground   bool   `json:"isBackground"`
 	ctx            *UserConnContext
@@ -74,30 +77,42 @@ type Client struct {
 	closed         atomic.Bool
 	closedErr      error
 	token          string
+	rateLimit      *connRateLimiter
+	compressor     Compressor
+	connectTime    int64
+	lastActiveTime atomic.Int64
+	bytesIn        atomic.Int64
+	bytesOut       atomic.Int64
 }
 
-func newClient(ctx *UserConnContext, conn LongConn, isCompress bool) *Client {
+func newClient(ctx *UserConnContext, conn LongConn, compressProto string, compressor Compressor) *Client {
 	return &Client{
-		w:          new(sync.Mutex),
-		conn:       conn,
-		PlatformID: utils.StringToInt(ctx.GetPlatformID()),
-		IsCompress: isCompress,
-		UserID:     ctx.GetUserID(),
-		ctx:        ctx,
+		w:             new(sync.Mutex),
+		conn:          conn,
+		PlatformID:    utils.StringToInt(ctx.GetPlatformID()),
+		IsCompress:    compressor != nil,
+		CompressProto: compressProto,
+		UserID:        ctx.GetUserID(),
+		ctx:           ctx,
+		compressor:    compressor,
 	}
 }
 
 func (c *Client) ResetClient(
 	ctx *UserConnContext,
 	conn LongConn,
-	isBackground, isCompress bool,
+	isBackground bool,
+	compressProto string,
+	compressor Compressor,
 	longConnServer LongConnServer,
 	token string,
 ) {
 	c.w = new(sync.Mutex)
 	c.conn = conn
 	c.PlatformID = utils.StringToInt(ctx.GetPlatformID())
-	c.IsCompress = isCompress
+	c.IsCompress = compressor != nil
+	c.CompressProto = compressProto
+	c.compressor = compressor
 	c.IsBackground = isBackground
 	c.UserID = ctx.GetUserID()
 	c.ctx = ctx
@@ -106,6 +121,11 @@ func (c *Client) ResetClient(
 	c.closed.Store(false)
 	c.closedErr = nil
 	c.token = token
+	c.rateLimit = nil
+	c.connectTime = utils.GetCurrentTimestampByMill()
+	c.lastActiveTime.Store(c.connectTime)
+	c.bytesIn.Store(0)
+	c.bytesOut.Store(0)
 }
 
 func (c *Client) pingHandler(_ string) error {
@@ -135,6 +155,8 @@ func (c *Client) readMessage() {
 		}
 
 		log.ZDebug(c.ctx, "readMessage", "messageType", messageType)
+		c.lastActiveTime.Store(utils.GetCurrentTimestampByMill())
+		c.bytesIn.Add(int64(len(message)))
 		if c.closed.Load() { // 连接刚置位已经关闭，但是协程还没退出的场景
 			c.closedErr = ErrConnClosed
 			return
@@ -167,7 +189,7 @@ func (c *Client) readMessage() {
 func (c *Client) handleMessage(message []byte) error {
 	if c.IsCompress {
 		var err error
-		message, err = c.longConnServer.DecompressWithPool(message)
+		message, err = c.compressor.DecompressWithPool(message)
 		if err != nil {
 			return utils.Wrap(err, "")
 		}
@@ -195,6 +217,15 @@ func (c *Client) handleMessage(message []byte) error {
 
 	log.ZDebug(ctx, "gateway req message", "req", binaryReq.String())
 
+	if err := c.longConnServer.AllowRequest(ctx, c, binaryReq.ReqIdentifier); err != nil {
+		if errors.Is(err, ErrRateLimitExceeded) {
+			log.ZWarn(ctx, "close conn for exceeding rate limit", err, "reqIdentifier", binaryReq.ReqIdentifier)
+			_ = c.replyMessage(ctx, binaryReq, imerrs.ErrConnRateLimit.Wrap(), nil)
+			return err
+		}
+		return c.replyMessage(ctx, binaryReq, err, nil)
+	}
+
 	var (
 		resp       []byte
 		messageErr error
@@ -302,6 +333,16 @@ func (c *Client) KickOnlineMessage() error {
 	return err
 }
 
+// ReconnectMessage asks the client to reconnect, the gateway is going to be taken offline.
+func (c *Client) ReconnectMessage() error {
+	resp := Resp{
+		ReqIdentifier: WSReconnectMsg,
+	}
+	err := c.writeBinaryMsg(resp)
+	c.close()
+	return err
+}
+
 func (c *Client) writeBinaryMsg(resp Resp) error {
 	if c.closed.Load() {
 		return nil
@@ -317,16 +358,35 @@ func (c *Client) writeBinaryMsg(resp Resp) error {
 
 	_ = c.conn.SetWriteDeadline(writeWait)
 	if c.IsCompress {
-		resultBuf, compressErr := c.longConnServer.CompressWithPool(encodedBuf)
+		resultBuf, compressErr := c.compressor.CompressWithPool(encodedBuf)
 		if compressErr != nil {
 			return utils.Wrap(compressErr, "")
 		}
-		return c.conn.WriteMessage(MessageBinary, resultBuf)
+		encodedBuf = resultBuf
 	}
 
+	c.bytesOut.Add(int64(len(encodedBuf)))
 	return c.conn.WriteMessage(MessageBinary, encodedBuf)
 }
 
+// connInfo is what the admin connection inspector reports about this conn.
+func (c *Client) connInfo(node string) *msggateway.ConnInfo {
+	return &msggateway.ConnInfo{
+		ConnID:         c.ctx.GetConnID(),
+		UserID:         c.UserID,
+		PlatformID:     int32(c.PlatformID),
+		Platform:       constant.PlatformIDToName(c.PlatformID),
+		RemoteAddr:     c.ctx.GetRemoteAddr(),
+		ConnectTime:    c.connectTime,
+		LastActiveTime: c.lastActiveTime.Load(),
+		IsBackground:   c.IsBackground,
+		CompressProto:  c.CompressProto,
+		BytesIn:        c.bytesIn.Load(),
+		BytesOut:       c.bytesOut.Load(),
+		Node:           node,
+	}
+}
+
 func (c *Client) writePongMsg() error {
 	if c.closed.Load() {
 		return nil
@@ -16,12 +16,18 @@ package msggateway
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"errors"
 	"io"
+	"os"
 	"sync"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 )
 
 var (
@@ -29,6 +35,39 @@ var (
 	gzipReaderPool = sync.Pool{New: func() any { return new(gzip.Reader) }}
 )
 
+// newCompressors builds the compressors a client may negotiate, keyed by protocol name.
+func newCompressors() (map[string]Compressor, error) {
+	conf := config.Config.LongConnSvr.Compression
+	var dict []byte
+	if conf.Dictionary != "" {
+		var err error
+		dict, err = os.ReadFile(conf.Dictionary)
+		if err != nil {
+			return nil, utils.Wrap(err, "read compression dictionary failed")
+		}
+	}
+	compressors := map[string]Compressor{GzipCompressionProtocol: NewGzipCompressor()}
+	if conf.Zstd {
+		zstdCompressor, err := NewZstdCompressor(conf.DictionaryID, dict)
+		if err != nil {
+			return nil, err
+		}
+		compressors[ZstdCompressionProtocol] = zstdCompressor
+	}
+	if conf.Deflate {
+		level := conf.DeflateLevel
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		deflateCompressor, err := NewDeflateCompressor(level, dict)
+		if err != nil {
+			return nil, err
+		}
+		compressors[DeflateCompressionProtocol] = deflateCompressor
+	}
+	return compressors, nil
+}
+
 type Compressor interface {
 	Compress(rawData []byte) ([]byte, error)
 	CompressWithPool(rawData []byte) ([]byte, error)
@@ -104,3 +143,132 @@ func (g *GzipCompressor) DecompressWithPool(compressedData []byte) ([]byte, erro
 	_ = reader.Close()
 	return compressedData, nil
 }
+
+// ZstdCompressor is safe for concurrent use, zstd encoders and decoders pool their own state.
+type ZstdCompressor struct {
+	compressProtocol string
+	encoder          *zstd.Encoder
+	decoder          *zstd.Decoder
+}
+
+// NewZstdCompressor creates a zstd compressor, a non-empty dict is used as a raw content dictionary
+// registered under dictID, which helps a lot with small frames. Both peers must use the same dict.
+func NewZstdCompressor(dictID uint32, dict []byte) (*ZstdCompressor, error) {
+	encOpts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	decOpts := []zstd.DOption{zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxMessageSize * 64)}
+	if len(dict) > 0 {
+		encOpts = append(encOpts, zstd.WithEncoderDictRaw(dictID, dict))
+		decOpts = append(decOpts, zstd.WithDecoderDictRaw(dictID, dict))
+	}
+	encoder, err := zstd.NewWriter(nil, encOpts...)
+	if err != nil {
+		return nil, utils.Wrap(err, "NewWriter failed")
+	}
+	decoder, err := zstd.NewReader(nil, decOpts...)
+	if err != nil {
+		return nil, utils.Wrap(err, "NewReader failed")
+	}
+	return &ZstdCompressor{compressProtocol: ZstdCompressionProtocol, encoder: encoder, decoder: decoder}, nil
+}
+
+func (z *ZstdCompressor) Compress(rawData []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(rawData, make([]byte, 0, len(rawData))), nil
+}
+
+func (z *ZstdCompressor) CompressWithPool(rawData []byte) ([]byte, error) {
+	return z.Compress(rawData)
+}
+
+func (z *ZstdCompressor) DeCompress(compressedData []byte) ([]byte, error) {
+	data, err := z.decoder.DecodeAll(compressedData, nil)
+	if err != nil {
+		return nil, utils.Wrap(err, "DecodeAll failed")
+	}
+	return data, nil
+}
+
+func (z *ZstdCompressor) DecompressWithPool(compressedData []byte) ([]byte, error) {
+	return z.DeCompress(compressedData)
+}
+
+// DeflateCompressor writes raw deflate streams (RFC 1951), without the gzip header and checksum.
+type DeflateCompressor struct {
+	compressProtocol string
+	level            int
+	dict             []byte
+	writerPool       sync.Pool
+	readerPool       sync.Pool
+}
+
+// NewDeflateCompressor creates a deflate compressor, a non-empty dict presets the sliding window.
+// Both peers must use the same dict.
+func NewDeflateCompressor(level int, dict []byte) (*DeflateCompressor, error) {
+	if _, err := flate.NewWriterDict(io.Discard, level, dict); err != nil {
+		return nil, utils.Wrap(err, "NewWriterDict failed")
+	}
+	d := &DeflateCompressor{compressProtocol: DeflateCompressionProtocol, level: level, dict: dict}
+	d.writerPool.New = func() any {
+		w, _ := flate.NewWriterDict(nil, d.level, d.dict)
+		return w
+	}
+	d.readerPool.New = func() any {
+		return flate.NewReaderDict(nil, d.dict)
+	}
+	return d, nil
+}
+
+func (d *DeflateCompressor) Compress(rawData []byte) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	w, err := flate.NewWriterDict(&buffer, d.level, d.dict)
+	if err != nil {
+		return nil, utils.Wrap(err, "")
+	}
+	if _, err := w.Write(rawData); err != nil {
+		return nil, utils.Wrap(err, "")
+	}
+	if err := w.Close(); err != nil {
+		return nil, utils.Wrap(err, "")
+	}
+	return buffer.Bytes(), nil
+}
+
+func (d *DeflateCompressor) CompressWithPool(rawData []byte) ([]byte, error) {
+	w := d.writerPool.Get().(*flate.Writer)
+	defer d.writerPool.Put(w)
+
+	buffer := bytes.Buffer{}
+	w.Reset(&buffer)
+
+	if _, err := w.Write(rawData); err != nil {
+		return nil, utils.Wrap(err, "")
+	}
+	if err := w.Close(); err != nil {
+		return nil, utils.Wrap(err, "")
+	}
+	return buffer.Bytes(), nil
+}
+
+func (d *DeflateCompressor) DeCompress(compressedData []byte) ([]byte, error) {
+	reader := flate.NewReaderDict(bytes.NewReader(compressedData), d.dict)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, utils.Wrap(err, "ReadAll failed")
+	}
+	_ = reader.Close()
+	return data, nil
+}
+
+func (d *DeflateCompressor) DecompressWithPool(compressedData []byte) ([]byte, error) {
+	reader := d.readerPool.Get().(io.ReadCloser)
+	defer d.readerPool.Put(reader)
+
+	if err := reader.(flate.Resetter).Reset(bytes.NewReader(compressedData), d.dict); err != nil {
+		return nil, utils.Wrap(err, "Reset failed")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, utils.Wrap(err, "ReadAll failed")
+	}
+	_ = reader.Close()
+	return data, nil
+}
@@ -1,7 +1,9 @@
 package msggateway
 
 import (
+	"compress/flate"
 	"crypto/rand"
+	"fmt"
 	"sync"
 	"testing"
 
@@ -105,3 +107,110 @@ func BenchmarkDecompressWithSyncPool(b *testing.B) {
 		assert.Equal(b, nil, err)
 	}
 }
+
+// mockFrame looks like a small push frame, field names repeat across frames while ids do not.
+func mockFrame() []byte {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return []byte(fmt.Sprintf(`{"reqIdentifier":2001,"errCode":0,"errMsg":"","operationID":"%x",`+
+		`"data":{"sendID":"%x","recvID":"%x","clientMsgID":"%x","contentType":101,"sessionType":1,`+
+		`"content":"{\"content\":\"hello\"}","seq":%d}}`, id, id[:4], id[4:8], id, len(id)))
+}
+
+func mockDictionary() []byte {
+	var dict []byte
+	for i := 0; i < 8; i++ {
+		dict = append(dict, mockFrame()...)
+	}
+	return dict
+}
+
+func mockCompressors(t testing.TB) map[string]Compressor {
+	zstdCompressor, err := NewZstdCompressor(1, nil)
+	assert.Nil(t, err)
+	zstdDictCompressor, err := NewZstdCompressor(1, mockDictionary())
+	assert.Nil(t, err)
+	deflateCompressor, err := NewDeflateCompressor(flate.DefaultCompression, nil)
+	assert.Nil(t, err)
+	deflateDictCompressor, err := NewDeflateCompressor(flate.DefaultCompression, mockDictionary())
+	assert.Nil(t, err)
+	return map[string]Compressor{
+		"gzip":         NewGzipCompressor(),
+		"zstd":         zstdCompressor,
+		"zstd-dict":    zstdDictCompressor,
+		"deflate":      deflateCompressor,
+		"deflate-dict": deflateDictCompressor,
+	}
+}
+
+func TestCompressorsCompressDecompress(t *testing.T) {
+	for name, compressor := range mockCompressors(t) {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				src := mockFrame()
+
+				dest, err := compressor.Compress(src)
+				assert.Equal(t, nil, err)
+				res, err := compressor.DeCompress(dest)
+				assert.Equal(t, nil, err)
+				assert.EqualValues(t, src, res)
+
+				dest, err = compressor.CompressWithPool(src)
+				assert.Equal(t, nil, err)
+				res, err = compressor.DecompressWithPool(dest)
+				assert.Equal(t, nil, err)
+				assert.EqualValues(t, src, res)
+			}
+		})
+	}
+}
+
+func TestCompressorsWithConcurrency(t *testing.T) {
+	for name, compressor := range mockCompressors(t) {
+		t.Run(name, func(t *testing.T) {
+			wg := sync.WaitGroup{}
+			for i := 0; i < 200; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					src := mockRandom()
+					dest, err := compressor.CompressWithPool(src)
+					assert.Equal(t, nil, err)
+					res, err := compressor.DecompressWithPool(dest)
+					assert.Equal(t, nil, err)
+					assert.EqualValues(t, src, res)
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkCompressors(b *testing.B) {
+	src := mockFrame()
+	for name, compressor := range mockCompressors(b) {
+		b.Run(name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				dest, err := compressor.CompressWithPool(src)
+				assert.Equal(b, nil, err)
+				size = len(dest)
+			}
+			b.ReportMetric(float64(size)/float64(len(src)), "ratio")
+		})
+	}
+}
+
+func BenchmarkDecompressors(b *testing.B) {
+	src := mockFrame()
+	for name, compressor := range mockCompressors(b) {
+		b.Run(name, func(b *testing.B) {
+			comdata, err := compressor.Compress(src)
+			assert.Equal(b, nil, err)
+			for i := 0; i < b.N; i++ {
+				_, err := compressor.DecompressWithPool(comdata)
+				assert.Equal(b, nil, err)
+			}
+		})
+	}
+}
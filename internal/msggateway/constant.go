@@ -25,11 +25,25 @@ const (
 	OperationID             = "operationID"
 	Compression             = "compression"
 	GzipCompressionProtocol = "gzip"
-	BackgroundStatus        = "isBackground"
+	// ZstdCompressionProtocol and DeflateCompressionProtocol are negotiated like gzip, the client
+	// lists the protocols it supports in order of preference, e.g. compression=zstd,gzip.
+	ZstdCompressionProtocol    = "zstd"
+	DeflateCompressionProtocol = "deflate"
+	BackgroundStatus           = "isBackground"
 )
 
 const (
 	WebSocket = iota + 1
+	ServerSentEvents
+	LongPolling
+)
+
+const (
+	// Paths of the http transports, for networks that block websocket.
+	SSEPath             = "/sse"
+	LongPollingPath     = "/poll"
+	LongPollingRecvPath = "/poll/recv"
+	HTTPSendPath        = "/send"
 )
 
 const (
@@ -42,6 +56,7 @@ const (
 	WSKickOnlineMsg       = 2002
 	WsLogoutMsg           = 2003
 	WsSetBackgroundStatus = 2004
+	WSReconnectMsg        = 2005
 	WSDataError           = 3001
 )
 
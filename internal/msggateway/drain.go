@@ -0,0 +1,104 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	defaultDrainBatchSize     = 500
+	defaultDrainBatchInterval = time.Second
+	defaultDrainTimeout       = 5 * time.Minute
+)
+
+type drainConf struct {
+	batchSize     int
+	batchInterval time.Duration
+	timeout       time.Duration
+}
+
+func newDrainConf() drainConf {
+	conf := drainConf{
+		batchSize:     config.Config.LongConnSvr.Drain.BatchSize,
+		batchInterval: time.Duration(config.Config.LongConnSvr.Drain.BatchInterval) * time.Millisecond,
+		timeout:       time.Duration(config.Config.LongConnSvr.Drain.Timeout) * time.Second,
+	}
+	if conf.batchSize <= 0 {
+		conf.batchSize = defaultDrainBatchSize
+	}
+	if conf.batchInterval <= 0 {
+		conf.batchInterval = defaultDrainBatchInterval
+	}
+	if conf.timeout <= 0 {
+		conf.timeout = defaultDrainTimeout
+	}
+	return conf
+}
+
+// Drain stops accepting new connections and asks connected clients to reconnect to other nodes,
+// batchSize clients every batchInterval. The ws server stops once no connection is left or the
+// timeout passes. It returns false if the node is already draining.
+func (ws *WsServer) Drain(timeout time.Duration) bool {
+	if !ws.draining.CompareAndSwap(false, true) {
+		return false
+	}
+	if timeout <= 0 {
+		timeout = ws.drainConf.timeout
+	}
+	go ws.drain(timeout)
+	return true
+}
+
+func (ws *WsServer) IsDraining() bool {
+	return ws.draining.Load()
+}
+
+func (ws *WsServer) drain(timeout time.Duration) {
+	ctx := mcontext.SetOperationID(context.Background(), "drain_"+utils.OperationIDGenerator())
+	deadline := time.Now().Add(timeout)
+	log.ZInfo(ctx, "gateway drain start", "online user conn Num", ws.onlineUserConnNum.Load(), "timeout", timeout)
+	// pushes must no longer be routed to this node by the presence registry.
+	ws.presence.leave(ctx)
+
+	ticker := time.NewTicker(ws.drainConf.batchInterval)
+	defer ticker.Stop()
+	for ws.onlineUserConnNum.Load() > 0 {
+		if time.Now().After(deadline) {
+			log.ZWarn(ctx, "gateway drain timeout", nil, "online user conn Num", ws.onlineUserConnNum.Load())
+			break
+		}
+		for _, client := range ws.clients.openClients(ws.drainConf.batchSize) {
+			if err := client.ReconnectMessage(); err != nil {
+				log.ZWarn(ctx, "ReconnectMessage failed", err, "userID", client.UserID, "platformID", client.PlatformID)
+			}
+		}
+		<-ticker.C
+	}
+
+	log.ZInfo(ctx, "gateway drain finished", "online user conn Num", ws.onlineUserConnNum.Load())
+	shutdownCtx, cancel := context.WithTimeout(ctx, ws.drainConf.batchInterval)
+	defer cancel()
+	if err := ws.httpServer.Shutdown(shutdownCtx); err != nil {
+		log.ZWarn(ctx, "ws http server shutdown failed", err)
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenIMSDK/tools/apiresp"
+	"github.com/OpenIMSDK/tools/errs"
+)
+
+var (
+	ErrReadTimeout    = errors.New("http conn read timeout")
+	ErrSendQueueFull  = errors.New("http conn send queue is full")
+	ErrNotSupportDial = errors.New("http conn not support dial")
+)
+
+const (
+	// How long a long-polling request is held when there is nothing to deliver.
+	longPollingWait = 25 * time.Second
+	// Frames queued for a long-polling conn before writes start failing.
+	longPollingQueueSize = 256
+	// Frames returned by a single long-polling request.
+	longPollingMaxBatch = 64
+	// Requests posted to a conn and not read by the client goroutine yet.
+	httpRecvQueueSize = 16
+	// SSE comment lines keep proxies from closing an idle stream.
+	sseHeartbeatPeriod = pongWait / 2
+)
+
+// HTTPLongConn implements LongConn over plain http for networks that block websocket.
+// Requests are posted to HTTPSendPath, one binary frame per body. Server frames are either
+// streamed as base64 Server-Sent Events, or queued and fetched by long-polling LongPollingRecvPath.
+type HTTPLongConn struct {
+	protocolType int
+	connID       string
+	token        string
+
+	w  http.ResponseWriter // the SSE stream, nil for long-polling
+	mu sync.Mutex
+
+	recv      chan []byte
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	onClose   func()
+
+	readLimit   atomic.Int64
+	readTimeout atomic.Int64
+	lastActive  atomic.Int64
+}
+
+func newHTTPLongConn(protocolType int, connID, token string, onClose func()) *HTTPLongConn {
+	c := &HTTPLongConn{
+		protocolType: protocolType,
+		connID:       connID,
+		token:        token,
+		recv:         make(chan []byte, httpRecvQueueSize),
+		done:         make(chan struct{}),
+		onClose:      onClose,
+	}
+	if protocolType == LongPolling {
+		c.send = make(chan []byte, longPollingQueueSize)
+	}
+	c.touch()
+	return c
+}
+
+func (c *HTTPLongConn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// Close waits for an in-flight SSE write, the stream must not be written once its handler returned.
+func (c *HTTPLongConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		close(c.done)
+		c.mu.Unlock()
+		if c.onClose != nil {
+			c.onClose()
+		}
+	})
+	return nil
+}
+
+func (c *HTTPLongConn) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// GenerateLongConn answers the opening request with the connID the client must pass when
+// posting requests or polling, and for SSE keeps the response open as the event stream.
+func (c *HTTPLongConn) GenerateLongConn(w http.ResponseWriter, r *http.Request) error {
+	if c.protocolType == LongPolling {
+		apiresp.HttpSuccess(w, map[string]string{ConnID: c.connID})
+		return nil
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		return errs.ErrArgs.Wrap("streaming unsupported")
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	c.w = w
+	return c.writeEvent("event: open\ndata: " + c.connID + "\n\n")
+}
+
+func (c *HTTPLongConn) writeEvent(event string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed() {
+		return ErrConnClosed
+	}
+	if _, err := io.WriteString(c.w, event); err != nil {
+		return err
+	}
+	c.w.(http.Flusher).Flush()
+	c.touch()
+	return nil
+}
+
+// WriteMessage only carries binary frames, control frames have no meaning over http.
+func (c *HTTPLongConn) WriteMessage(messageType int, message []byte) error {
+	if messageType != MessageBinary {
+		return nil
+	}
+	if c.isClosed() {
+		return ErrConnClosed
+	}
+	if c.protocolType == ServerSentEvents {
+		return c.writeEvent("data: " + base64.StdEncoding.EncodeToString(message) + "\n\n")
+	}
+	select {
+	case c.send <- message:
+		return nil
+	default:
+		return ErrSendQueueFull
+	}
+}
+
+func (c *HTTPLongConn) ReadMessage() (int, []byte, error) {
+	for {
+		timeout := time.Duration(c.readTimeout.Load())
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if timeout > 0 {
+			wait := time.Until(time.Unix(0, c.lastActive.Load()).Add(timeout))
+			if wait <= 0 {
+				return 0, nil, ErrReadTimeout
+			}
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case message := <-c.recv:
+			if timer != nil {
+				timer.Stop()
+			}
+			return MessageBinary, message, nil
+		case <-c.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return CloseMessage, nil, nil
+		case <-expired:
+			// activity may have been recorded meanwhile, the deadline is checked again.
+		}
+	}
+}
+
+// SetReadDeadline is relative to the last activity on the conn: a posted request, a poll or an SSE write.
+func (c *HTTPLongConn) SetReadDeadline(timeout time.Duration) error {
+	c.readTimeout.Store(int64(timeout))
+	c.touch()
+	return nil
+}
+
+func (c *HTTPLongConn) SetWriteDeadline(timeout time.Duration) error {
+	return nil
+}
+
+func (c *HTTPLongConn) Dial(urlStr string, requestHeader http.Header) (*http.Response, error) {
+	return nil, ErrNotSupportDial
+}
+
+func (c *HTTPLongConn) IsNil() bool {
+	return c.isClosed()
+}
+
+func (c *HTTPLongConn) SetConnNil() {
+	_ = c.Close()
+}
+
+func (c *HTTPLongConn) SetReadLimit(limit int64) {
+	c.readLimit.Store(limit)
+}
+
+func (c *HTTPLongConn) SetPongHandler(handler PingPongHandler) {}
+
+func (c *HTTPLongConn) SetPingHandler(handler PingPongHandler) {}
+
+// post hands a request body to the client read goroutine.
+func (c *HTTPLongConn) post(message []byte) error {
+	c.touch()
+	select {
+	case c.recv <- message:
+		return nil
+	case <-c.done:
+		return ErrConnClosed
+	}
+}
+
+// poll waits for queued frames until wait passes, frames queued before the conn closed are still returned.
+func (c *HTTPLongConn) poll(wait time.Duration) [][]byte {
+	c.touch()
+	defer c.touch()
+	frames := make([][]byte, 0, 1)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case message := <-c.send:
+		frames = append(frames, message)
+	case <-c.done:
+	case <-timer.C:
+		return frames
+	}
+	for len(frames) < longPollingMaxBatch {
+		select {
+		case message := <-c.send:
+			frames = append(frames, message)
+		default:
+			return frames
+		}
+	}
+	return frames
+}
+
+func (ws *WsServer) newHTTPConn(protocolType int, connContext *UserConnContext, token string) *HTTPLongConn {
+	connID := connContext.GetConnID()
+	conn := newHTTPLongConn(protocolType, connID, token, func() {
+		ws.httpConns.Delete(connID)
+	})
+	ws.httpConns.Store(connID, conn)
+	return conn
+}
+
+// getHTTPConn finds the conn of a send or poll request, which must carry the token it was opened with.
+func (ws *WsServer) getHTTPConn(connContext *UserConnContext) (*HTTPLongConn, error) {
+	connID, ok := connContext.Query(ConnID)
+	if !ok {
+		return nil, errs.ErrConnArgsErr
+	}
+	v, ok := ws.httpConns.Load(connID)
+	if !ok {
+		return nil, errs.ErrConnArgsErr.Wrap("http conn not exist")
+	}
+	conn := v.(*HTTPLongConn)
+	if token, _ := connContext.Query(Token); token != conn.token {
+		return nil, errs.ErrTokenInvalid.Wrap()
+	}
+	return conn, nil
+}
+
+// httpLongConnHandler answers CORS preflights with the headers the api router uses, browsers being
+// the usual SSE and long-polling clients, and rejects every method but the allowed ones.
+func httpLongConnHandler(handler http.HandlerFunc, methods ...string) http.HandlerFunc {
+	allowMethods := strings.Join(append(methods, http.MethodOptions), ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		header.Set("Access-Control-Allow-Headers", "*")
+		header.Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, "+Compression)
+		header.Set("Access-Control-Max-Age", "172800")
+		header.Set("Access-Control-Allow-Credentials", "false")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		for _, method := range methods {
+			if r.Method == method {
+				handler(w, r)
+				return
+			}
+		}
+		header.Set("Allow", allowMethods)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (ws *WsServer) sseHandler(w http.ResponseWriter, r *http.Request) {
+	connContext := newContext(w, r)
+	token, err := ws.authConn(connContext)
+	if err != nil {
+		httpError(connContext, err)
+		return
+	}
+	compressProto, compressor := ws.negotiateCompression(connContext)
+	conn := ws.newHTTPConn(ServerSentEvents, connContext, token)
+	if err := conn.GenerateLongConn(w, r); err != nil {
+		_ = conn.Close()
+		httpError(connContext, err)
+		return
+	}
+	ws.registerConn(connContext, conn, token, compressProto, compressor)
+
+	// the stream lives as long as this handler does.
+	ticker := time.NewTicker(sseHeartbeatPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.writeEvent(":\n\n"); err != nil {
+				_ = conn.Close()
+				return
+			}
+		case <-r.Context().Done():
+			_ = conn.Close()
+			return
+		case <-conn.done:
+			return
+		}
+	}
+}
+
+func (ws *WsServer) longPollingHandler(w http.ResponseWriter, r *http.Request) {
+	connContext := newContext(w, r)
+	token, err := ws.authConn(connContext)
+	if err != nil {
+		httpError(connContext, err)
+		return
+	}
+	compressProto, compressor := ws.negotiateCompression(connContext)
+	conn := ws.newHTTPConn(LongPolling, connContext, token)
+	_ = conn.GenerateLongConn(w, r)
+	ws.registerConn(connContext, conn, token, compressProto, compressor)
+}
+
+func (ws *WsServer) longPollingRecvHandler(w http.ResponseWriter, r *http.Request) {
+	connContext := newContext(w, r)
+	conn, err := ws.getHTTPConn(connContext)
+	if err != nil {
+		httpError(connContext, err)
+		return
+	}
+	frames := conn.poll(longPollingWait)
+	if len(frames) == 0 && conn.isClosed() {
+		httpError(connContext, errs.ErrConnArgsErr.Wrap("http conn not exist"))
+		return
+	}
+	apiresp.HttpSuccess(w, frames)
+}
+
+func (ws *WsServer) httpSendHandler(w http.ResponseWriter, r *http.Request) {
+	connContext := newContext(w, r)
+	conn, err := ws.getHTTPConn(connContext)
+	if err != nil {
+		httpError(connContext, err)
+		return
+	}
+	body := r.Body
+	if limit := conn.readLimit.Load(); limit > 0 {
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	message, err := io.ReadAll(body)
+	if err != nil {
+		httpError(connContext, errs.ErrArgs.Wrap(err.Error()))
+		return
+	}
+	if err := conn.post(message); err != nil {
+		httpError(connContext, errs.ErrConnArgsErr.Wrap("http conn not exist"))
+		return
+	}
+	apiresp.HttpSuccess(w, nil)
+}
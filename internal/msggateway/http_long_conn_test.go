@@ -0,0 +1,194 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type httpLongConnResp struct {
+	ErrCode int      `json:"errCode"`
+	Data    [][]byte `json:"data"`
+}
+
+func newTestHTTPConn(ws *WsServer, protocolType int, connID string) *HTTPLongConn {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	connContext := newContext(httptest.NewRecorder(), req)
+	connContext.ConnID = connID
+	return ws.newHTTPConn(protocolType, connContext, "token")
+}
+
+func TestHTTPLongConnHandler(t *testing.T) {
+	var called int
+	handler := httpLongConnHandler(func(w http.ResponseWriter, r *http.Request) {
+		called++
+	}, http.MethodPost)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodOptions, HTTPSendPath, nil)
+	r.Header.Set("Origin", "https://web.example.com")
+	handler(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, 0, called)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, HTTPSendPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+	assert.Equal(t, 0, called)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, HTTPSendPath, nil))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, 1, called)
+}
+
+func TestSSEConnectAndDeliver(t *testing.T) {
+	conns := make(chan *HTTPLongConn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := newHTTPLongConn(ServerSentEvents, "sse-conn", "token", nil)
+		if err := conn.GenerateLongConn(w, r); err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+		select {
+		case <-r.Context().Done():
+			_ = conn.Close()
+		case <-conn.done:
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var event bytes.Buffer
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				return event.String()
+			}
+			event.WriteString(line)
+		}
+	}
+	assert.Equal(t, "event: open\ndata: sse-conn\n", readEvent())
+
+	conn := <-conns
+	// control frames have no meaning over http and are dropped.
+	assert.NoError(t, conn.WriteMessage(PongMessage, nil))
+	assert.NoError(t, conn.WriteMessage(MessageBinary, []byte("hello")))
+	assert.Equal(t, "data: "+base64.StdEncoding.EncodeToString([]byte("hello"))+"\n", readEvent())
+
+	assert.NoError(t, conn.Close())
+	assert.ErrorIs(t, conn.WriteMessage(MessageBinary, []byte("late")), ErrConnClosed)
+}
+
+func TestLongPollingDeliver(t *testing.T) {
+	ws := &WsServer{}
+	conn := newTestHTTPConn(ws, LongPolling, "poll-conn")
+	assert.NoError(t, conn.WriteMessage(MessageBinary, []byte("first")))
+	assert.NoError(t, conn.WriteMessage(MessageBinary, []byte("second")))
+
+	w := httptest.NewRecorder()
+	ws.longPollingRecvHandler(w, httptest.NewRequest(http.MethodGet,
+		LongPollingRecvPath+"?"+ConnID+"=poll-conn&"+Token+"=token", nil))
+	var resp httpLongConnResp
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.ErrCode)
+	assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, resp.Data)
+
+	// a poll must carry the token the conn was opened with.
+	w = httptest.NewRecorder()
+	ws.longPollingRecvHandler(w, httptest.NewRequest(http.MethodGet,
+		LongPollingRecvPath+"?"+ConnID+"=poll-conn&"+Token+"=other", nil))
+	resp = httpLongConnResp{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEqual(t, 0, resp.ErrCode)
+
+	// closing the conn forgets it, later polls fail.
+	assert.NoError(t, conn.Close())
+	_, ok := ws.httpConns.Load("poll-conn")
+	assert.False(t, ok)
+}
+
+func TestHTTPSend(t *testing.T) {
+	ws := &WsServer{}
+	conn := newTestHTTPConn(ws, LongPolling, "send-conn")
+	conn.SetReadLimit(8)
+
+	w := httptest.NewRecorder()
+	ws.httpSendHandler(w, httptest.NewRequest(http.MethodPost,
+		HTTPSendPath+"?"+ConnID+"=send-conn&"+Token+"=token", bytes.NewReader([]byte("request"))))
+	var resp httpLongConnResp
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.ErrCode)
+	messageType, message, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, MessageBinary, messageType)
+	assert.Equal(t, []byte("request"), message)
+
+	// bodies over the read limit are rejected.
+	w = httptest.NewRecorder()
+	ws.httpSendHandler(w, httptest.NewRequest(http.MethodPost,
+		HTTPSendPath+"?"+ConnID+"=send-conn&"+Token+"=token", bytes.NewReader([]byte("too long request"))))
+	resp = httpLongConnResp{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEqual(t, 0, resp.ErrCode)
+	_ = conn.Close()
+}
+
+func TestHTTPLongConnTimeout(t *testing.T) {
+	conn := newHTTPLongConn(LongPolling, "timeout-conn", "token", nil)
+
+	start := time.Now()
+	assert.Empty(t, conn.poll(50*time.Millisecond))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// the read deadline counts from the last activity on the conn.
+	assert.NoError(t, conn.SetReadDeadline(50*time.Millisecond))
+	_, _, err := conn.ReadMessage()
+	assert.ErrorIs(t, err, ErrReadTimeout)
+
+	// a closed conn still returns queued frames, then answers polls at once.
+	assert.NoError(t, conn.WriteMessage(MessageBinary, []byte("queued")))
+	assert.NoError(t, conn.Close())
+	assert.Equal(t, [][]byte{[]byte("queued")}, conn.poll(time.Minute))
+	start = time.Now()
+	assert.Empty(t, conn.poll(time.Minute))
+	assert.Less(t, time.Since(start), time.Second)
+	messageType, _, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, CloseMessage, messageType)
+}
@@ -16,11 +16,16 @@ package msggateway
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 
 	"github.com/OpenIMSDK/protocol/constant"
 	"github.com/OpenIMSDK/protocol/msggateway"
+	"github.com/OpenIMSDK/tools/apiresp"
 	"github.com/OpenIMSDK/tools/discoveryregistry"
 	"github.com/OpenIMSDK/tools/errs"
 	"github.com/OpenIMSDK/tools/log"
@@ -40,8 +45,11 @@ func (s *Server) InitServer(disCov discoveryregistry.SvcDiscoveryRegistry, serve
 	}
 
 	msgModel := cache.NewMsgCacheModel(rdb)
+	s.disCov = disCov
 	s.LongConnServer.SetDiscoveryRegistry(disCov)
 	s.LongConnServer.SetCacheHandler(msgModel)
+	s.LongConnServer.SetRateLimitCache(cache.NewRateLimitCacheRedis(rdb))
+	s.LongConnServer.SetPresenceCache(cache.NewPresenceCacheRedis(rdb))
 	msggateway.RegisterMsgGatewayServer(server, s)
 	return nil
 }
@@ -60,6 +68,7 @@ type Server struct {
 	prometheusPort int
 	LongConnServer LongConnServer
 	pushTerminal   []int
+	disCov         discoveryregistry.SvcDiscoveryRegistry
 }
 
 func (s *Server) SetLongConnServer(LongConnServer LongConnServer) {
@@ -226,3 +235,124 @@ func (s *Server) MultiTerminalLoginCheck(
 	}
 	return &msggateway.MultiTerminalLoginCheckResp{}, nil
 }
+
+// Drain deregisters the node from discovery so no more users are routed here, then drains the ws server.
+func (s *Server) Drain(ctx context.Context, timeout time.Duration) {
+	if s.LongConnServer.IsDraining() {
+		return
+	}
+	if s.disCov != nil {
+		if err := s.disCov.UnRegister(); err != nil {
+			log.ZWarn(ctx, "UnRegister failed", err)
+		}
+	}
+	s.LongConnServer.Drain(timeout)
+}
+
+func (s *Server) DrainNode(ctx context.Context, req *msggateway.DrainNodeReq) (*msggateway.DrainNodeResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	log.ZInfo(ctx, "drain node", "timeout", req.Timeout)
+	s.Drain(ctx, time.Duration(req.Timeout)*time.Second)
+	return &msggateway.DrainNodeResp{}, nil
+}
+
+// GetUserConns lists the live connections of a user. Unless LocalOnly is set, the other gateway
+// nodes are asked as well, so the result covers the whole cluster.
+func (s *Server) GetUserConns(ctx context.Context, req *msggateway.GetUserConnsReq) (*msggateway.GetUserConnsResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	var resp msggateway.GetUserConnsResp
+	clients, _ := s.LongConnServer.GetUserAllCons(req.UserID)
+	for _, client := range clients {
+		if client == nil || client.closed.Load() {
+			continue
+		}
+		resp.Conns = append(resp.Conns, client.connInfo(s.disCov.GetSelfConnTarget()))
+	}
+	if req.LocalOnly {
+		return &resp, nil
+	}
+	var mu sync.Mutex
+	err := s.forOtherNodes(ctx, func(client msggateway.MsgGatewayClient) error {
+		reply, err := client.GetUserConns(ctx, &msggateway.GetUserConnsReq{UserID: req.UserID, LocalOnly: true})
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		resp.Conns = append(resp.Conns, reply.Conns...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CloseUserConn closes a single connection by connID, wherever it lives, leaving the other
+// connections of the user untouched.
+func (s *Server) CloseUserConn(ctx context.Context, req *msggateway.CloseUserConnReq) (*msggateway.CloseUserConnResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	clients, _ := s.LongConnServer.GetUserAllCons(req.UserID)
+	for _, client := range clients {
+		if client == nil || client.ctx.GetConnID() != req.ConnID {
+			continue
+		}
+		log.ZInfo(ctx, "close user conn", "userID", req.UserID, "connID", req.ConnID, "platformID", client.PlatformID)
+		client.close()
+		return &msggateway.CloseUserConnResp{Closed: true}, nil
+	}
+	if req.LocalOnly {
+		return &msggateway.CloseUserConnResp{}, nil
+	}
+	var closed atomic.Bool
+	err := s.forOtherNodes(ctx, func(client msggateway.MsgGatewayClient) error {
+		reply, err := client.CloseUserConn(ctx, &msggateway.CloseUserConnReq{UserID: req.UserID, ConnID: req.ConnID, LocalOnly: true})
+		if err != nil {
+			return err
+		}
+		if reply.Closed {
+			closed.Store(true)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !closed.Load() {
+		return nil, errs.ErrRecordNotFound.Wrap("conn not found")
+	}
+	return &msggateway.CloseUserConnResp{Closed: true}, nil
+}
+
+// forOtherNodes calls fn for every other gateway node, a node that fails is logged and skipped
+// unless it denies the permission.
+func (s *Server) forOtherNodes(ctx context.Context, fn func(client msggateway.MsgGatewayClient) error) error {
+	conns, err := s.disCov.GetConns(ctx, config.Config.RpcRegisterName.OpenImMessageGatewayName)
+	if err != nil {
+		return err
+	}
+	wg := errgroup.Group{}
+	wg.SetLimit(concurrentRequest)
+	for _, v := range conns {
+		v := v // safe closure var
+		if v.Target() == s.disCov.GetSelfConnTarget() {
+			continue
+		}
+		wg.Go(func() error {
+			if err := fn(msggateway.NewMsgGatewayClient(v)); err != nil {
+				if apiresp.ParseError(err).ErrCode == errs.NoPermissionError {
+					return err
+				}
+				log.ZWarn(ctx, "gateway node rpc failed", err, "node", v.Target())
+			}
+			return nil
+		})
+	}
+	return wg.Wait()
+}
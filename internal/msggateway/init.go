@@ -15,7 +15,11 @@
 package msggateway
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/OpenIMSDK/tools/utils"
@@ -50,5 +54,12 @@ func RunWsAndServer(rpcPort, wsPort, prometheusPort int) error {
 			panic(utils.Wrap1(err))
 		}
 	}()
+	go func() {
+		// SIGTERM drains the node instead of dropping every connection at once.
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM)
+		<-sigs
+		hubServer.Drain(context.Background(), 0)
+	}()
 	return hubServer.LongConnServer.Run()
 }
@@ -70,7 +70,8 @@ func (d *GWebSocket) GenerateLongConn(w http.ResponseWriter, r *http.Request) er
 		upgrader.WriteBufferSize = d.writeBufferSize
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	// headers set before the upgrade, such as the negotiated compression, are sent with the handshake.
+	conn, err := upgrader.Upgrade(w, r, w.Header())
 	if err != nil {
 		return err
 	}
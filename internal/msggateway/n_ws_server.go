@@ -19,6 +19,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,6 +38,7 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+	imerrs "github.com/openimsdk/open-im-server/v3/pkg/common/errs"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient"
 )
@@ -48,10 +50,15 @@ type LongConnServer interface {
 	GetUserPlatformCons(userID string, platform int) ([]*Client, bool, bool)
 	Validate(s interface{}) error
 	SetCacheHandler(cache cache.MsgModel)
+	SetRateLimitCache(cache cache.RateLimitCache)
+	SetPresenceCache(cache cache.PresenceCache)
+	AllowRequest(ctx context.Context, client *Client, reqIdentifier int32) error
 	SetDiscoveryRegistry(client discoveryregistry.SvcDiscoveryRegistry)
 	KickUserConn(client *Client) error
 	UnRegister(c *Client)
 	SetKickHandlerInfo(i *kickHandler)
+	Drain(timeout time.Duration) bool
+	IsDraining() bool
 	Compressor
 	Encoder
 	MessageHandler
@@ -73,6 +80,13 @@ type WsServer struct {
 	cache             cache.MsgModel
 	userClient        *rpcclient.UserRpcClient
 	disCov            discoveryregistry.SvcDiscoveryRegistry
+	rateLimiter       *rateLimiter
+	drainConf         drainConf
+	draining          atomic.Bool
+	httpServer        *http.Server
+	httpConns         sync.Map // connID -> *HTTPLongConn
+	compressors       map[string]Compressor
+	presence          *presence
 	Compressor
 	Encoder
 	MessageHandler
@@ -88,6 +102,7 @@ func (ws *WsServer) SetDiscoveryRegistry(disCov discoveryregistry.SvcDiscoveryRe
 	u := rpcclient.NewUserRpcClient(disCov)
 	ws.userClient = &u
 	ws.disCov = disCov
+	ws.presence.node = disCov.GetSelfConnTarget
 }
 
 func (ws *WsServer) SetUserOnlineStatus(ctx context.Context, client *Client, status int32) {
@@ -113,6 +128,18 @@ func (ws *WsServer) SetCacheHandler(cache cache.MsgModel) {
 	ws.cache = cache
 }
 
+func (ws *WsServer) SetRateLimitCache(cache cache.RateLimitCache) {
+	ws.rateLimiter.setCache(cache)
+}
+
+func (ws *WsServer) SetPresenceCache(cache cache.PresenceCache) {
+	ws.presence.cache = cache
+}
+
+func (ws *WsServer) AllowRequest(ctx context.Context, client *Client, reqIdentifier int32) error {
+	return ws.rateLimiter.allowRequest(ctx, client, reqIdentifier)
+}
+
 func (ws *WsServer) UnRegister(c *Client) {
 	ws.unregisterChan <- c
 }
@@ -135,6 +162,10 @@ func NewWsServer(opts ...Option) (*WsServer, error) {
 		o(&config)
 	}
 	v := validator.New()
+	compressors, err := newCompressors()
+	if err != nil {
+		return nil, err
+	}
 	return &WsServer{
 		port:             config.port,
 		wsMaxConnNum:     config.maxConnNum,
@@ -150,7 +181,12 @@ func NewWsServer(opts ...Option) (*WsServer, error) {
 		kickHandlerChan: make(chan *kickHandler, 1000),
 		validate:        v,
 		clients:         newUserMap(),
-		Compressor:      NewGzipCompressor(),
+		rateLimiter:     newRateLimiter(),
+		drainConf:       newDrainConf(),
+		httpServer:      &http.Server{Addr: ":" + utils.IntToString(config.port)},
+		presence:        newPresence(),
+		compressors:     compressors,
+		Compressor:      compressors[GzipCompressionProtocol],
 		Encoder:         NewGobEncoder(),
 	}, nil
 }
@@ -169,9 +205,20 @@ func (ws *WsServer) Run() error {
 			}
 		}
 	}()
+	go ws.rateLimiter.sweepIPBuckets()
+	go ws.presence.refresh(ws.clients)
 	http.HandleFunc("/", ws.wsHandler)
+	http.HandleFunc(SSEPath, httpLongConnHandler(ws.sseHandler, http.MethodGet))
+	http.HandleFunc(LongPollingPath, httpLongConnHandler(ws.longPollingHandler, http.MethodGet, http.MethodPost))
+	http.HandleFunc(LongPollingRecvPath, httpLongConnHandler(ws.longPollingRecvHandler, http.MethodGet))
+	http.HandleFunc(HTTPSendPath, httpLongConnHandler(ws.httpSendHandler, http.MethodPost))
 	// http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {})
-	return http.ListenAndServe(":"+utils.IntToString(ws.port), nil) // Start listening
+	// a node drained before Run returns at once, ListenAndServe fails with ErrServerClosed.
+	err := ws.httpServer.ListenAndServe() // Start listening
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
 var concurrentRequest = 3
@@ -240,6 +287,7 @@ func (ws *WsServer) registerClient(client *Client) {
 			ws.onlineUserConnNum.Add(1)
 		}
 	}
+	ws.presence.online(client.ctx, client.UserID)
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -426,6 +474,7 @@ func (ws *WsServer) unregisterClient(client *Client) {
 	if isDeleteUser {
 		ws.onlineUserNum.Add(-1)
 		prommetrics.OnlineUserGauge.Dec()
+		ws.presence.offline(client.ctx, client.UserID)
 	}
 	ws.onlineUserConnNum.Add(-1)
 	ws.SetUserOnlineStatus(client.ctx, client, constant.Offline)
@@ -436,82 +485,102 @@ func (ws *WsServer) unregisterClient(client *Client) {
 
 func (ws *WsServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 	connContext := newContext(w, r)
-	if ws.onlineUserConnNum.Load() >= ws.wsMaxConnNum {
-		httpError(connContext, errs.ErrConnOverMaxNumLimit)
+	token, err := ws.authConn(connContext)
+	if err != nil {
+		httpError(connContext, err)
 		return
 	}
+	compressProto, compressor := ws.negotiateCompression(connContext)
+	wsLongConn := newGWebSocket(WebSocket, ws.handshakeTimeout, ws.writeBufferSize)
+	err = wsLongConn.GenerateLongConn(w, r)
+	if err != nil {
+		httpError(connContext, err)
+		return
+	}
+	ws.registerConn(connContext, wsLongConn, token, compressProto, compressor)
+}
+
+// authConn checks whether a new connection may be accepted, whatever the transport, and returns its token.
+func (ws *WsServer) authConn(connContext *UserConnContext) (string, error) {
+	if ws.draining.Load() {
+		return "", imerrs.ErrConnServerDraining.Wrap()
+	}
+	if ws.onlineUserConnNum.Load() >= ws.wsMaxConnNum {
+		return "", errs.ErrConnOverMaxNumLimit
+	}
+	if !ws.rateLimiter.allowHandshake(connContext.GetRemoteAddr()) {
+		return "", imerrs.ErrConnHandshakeRateLimit.Wrap()
+	}
 	var (
 		token         string
 		userID        string
 		platformIDStr string
 		exists        bool
-		compression   bool
 	)
 
 	token, exists = connContext.Query(Token)
 	if !exists {
-		httpError(connContext, errs.ErrConnArgsErr)
-		return
+		return "", errs.ErrConnArgsErr
 	}
 	userID, exists = connContext.Query(WsUserID)
 	if !exists {
-		httpError(connContext, errs.ErrConnArgsErr)
-		return
+		return "", errs.ErrConnArgsErr
 	}
 	platformIDStr, exists = connContext.Query(PlatformID)
 	if !exists {
-		httpError(connContext, errs.ErrConnArgsErr)
-		return
+		return "", errs.ErrConnArgsErr
 	}
 	platformID, err := strconv.Atoi(platformIDStr)
 	if err != nil {
-		httpError(connContext, errs.ErrConnArgsErr)
-		return
+		return "", errs.ErrConnArgsErr
 	}
 	if err = authverify.WsVerifyToken(token, userID, platformID); err != nil {
-		httpError(connContext, err)
-		return
+		return "", err
 	}
 	m, err := ws.cache.GetTokensWithoutError(context.Background(), userID, platformID)
 	if err != nil {
-		httpError(connContext, err)
-		return
+		return "", err
 	}
 	if v, ok := m[token]; ok {
 		switch v {
 		case constant.NormalToken:
 		case constant.KickedToken:
-			httpError(connContext, errs.ErrTokenKicked.Wrap())
-			return
+			return "", errs.ErrTokenKicked.Wrap()
 		default:
-			httpError(connContext, errs.ErrTokenUnknown.Wrap())
-			return
+			return "", errs.ErrTokenUnknown.Wrap()
 		}
 	} else {
-		httpError(connContext, errs.ErrTokenNotExist.Wrap())
-		return
+		return "", errs.ErrTokenNotExist.Wrap()
 	}
+	return token, nil
+}
 
-	wsLongConn := newGWebSocket(WebSocket, ws.handshakeTimeout, ws.writeBufferSize)
-	err = wsLongConn.GenerateLongConn(w, r)
-	if err != nil {
-		httpError(connContext, err)
-		return
-	}
+// negotiateCompression picks the first protocol of the client's preference list this node supports,
+// and echoes it in the response header. A nil Compressor means the conn is not compressed.
+func (ws *WsServer) negotiateCompression(connContext *UserConnContext) (string, Compressor) {
 	compressProtoc, exists := connContext.Query(Compression)
-	if exists {
-		if compressProtoc == GzipCompressionProtocol {
-			compression = true
-		}
+	if !exists {
+		compressProtoc, exists = connContext.GetHeader(Compression)
+	}
+	if !exists {
+		return "", nil
 	}
-	compressProtoc, exists = connContext.GetHeader(Compression)
-	if exists {
-		if compressProtoc == GzipCompressionProtocol {
-			compression = true
+	for _, proto := range strings.Split(compressProtoc, ",") {
+		proto = strings.TrimSpace(proto)
+		if compressor, ok := ws.compressors[proto]; ok {
+			connContext.SetHeader(Compression, proto)
+			return proto, compressor
 		}
 	}
+	return "", nil
+}
+
+// registerConn binds an established long connection to a pooled Client and starts reading from it.
+func (ws *WsServer) registerConn(connContext *UserConnContext, longConn LongConn, token, compressProto string,
+	compressor Compressor) *Client {
 	client := ws.clientPool.Get().(*Client)
-	client.ResetClient(connContext, wsLongConn, connContext.GetBackground(), compression, ws, token)
+	client.ResetClient(connContext, longConn, connContext.GetBackground(), compressProto, compressor, ws, token)
 	ws.registerChan <- client
 	go client.readMessage()
+	return client
 }
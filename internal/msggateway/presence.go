@@ -0,0 +1,150 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/OpenIMSDK/tools/utils/splitter"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+)
+
+const (
+	defaultPresenceRefreshInterval = 30 * time.Second
+	presenceRefreshBatch           = 500
+	presenceTimeout                = 3 * time.Second
+)
+
+// presence publishes the users connected to this node to the shared registry, so the push
+// service only calls the nodes holding the recipients.
+type presence struct {
+	enable          bool
+	refreshInterval time.Duration
+	cache           cache.PresenceCache
+	node            func() string
+	left            atomic.Bool
+}
+
+func newPresence() *presence {
+	conf := config.Config.LongConnSvr.Presence
+	p := &presence{
+		enable:          conf.Enable,
+		refreshInterval: time.Duration(conf.RefreshInterval) * time.Second,
+	}
+	if p.refreshInterval <= 0 {
+		p.refreshInterval = defaultPresenceRefreshInterval
+	}
+	return p
+}
+
+// expire lets entries of a node that died without cleaning up disappear after a few missed refreshes.
+func (p *presence) expire() time.Duration {
+	return p.refreshInterval * 3
+}
+
+func (p *presence) ready() bool {
+	return p.enable && p.cache != nil && p.node != nil && !p.left.Load()
+}
+
+// leave stops the heartbeat and removes the node from the registry, the entries of its users
+// then count as stale and pushes to them are broadcast to the remaining nodes.
+func (p *presence) leave(ctx context.Context) {
+	if !p.ready() || !p.left.CompareAndSwap(false, true) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, presenceTimeout)
+	defer cancel()
+	node := p.node()
+	if err := p.cache.DelNodeAlive(ctx, node); err != nil {
+		log.ZWarn(ctx, "DelNodeAlive failed", err, "node", node)
+	}
+}
+
+func (p *presence) online(ctx context.Context, userID string) {
+	if !p.ready() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, presenceTimeout)
+	defer cancel()
+	if err := p.cache.AddUserNodes(ctx, p.node(), []string{userID}, p.expire()); err != nil {
+		log.ZWarn(ctx, "AddUserNodes failed", err, "userID", userID)
+		p.distrust(ctx)
+	}
+}
+
+// distrust drops the node heartbeat after a failed write, the registry misses users of this node
+// until the next full refresh, meanwhile pushes are broadcast and every node checks its own conns.
+func (p *presence) distrust(ctx context.Context) {
+	// ctx may be the one the failed write ran out of.
+	ctx, cancel := context.WithTimeout(mcontext.SetOperationID(context.Background(), mcontext.GetOperationID(ctx)), presenceTimeout)
+	defer cancel()
+	node := p.node()
+	if err := p.cache.DelNodeAlive(ctx, node); err != nil {
+		log.ZWarn(ctx, "DelNodeAlive failed", err, "node", node)
+	}
+}
+
+func (p *presence) offline(ctx context.Context, userID string) {
+	if !p.ready() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, presenceTimeout)
+	defer cancel()
+	if err := p.cache.DelUserNode(ctx, p.node(), userID); err != nil {
+		log.ZWarn(ctx, "DelUserNode failed", err, "userID", userID)
+	}
+}
+
+// refresh keeps the node heartbeat and the entries of every user online here from expiring.
+func (p *presence) refresh(clients *UserMap) {
+	if !p.enable {
+		return
+	}
+	ticker := time.NewTicker(p.refreshInterval)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if !p.ready() {
+			continue
+		}
+		ctx := mcontext.SetOperationID(context.Background(), "presence_"+utils.OperationIDGenerator())
+		node := p.node()
+		// the heartbeat vouches for the user entries, it is only set once all of them are written.
+		written := true
+		for _, v := range splitter.NewSplitter(presenceRefreshBatch, clients.userIDs()).GetSplitResult() {
+			if err := p.cache.AddUserNodes(ctx, node, v.Item, p.expire()); err != nil {
+				log.ZWarn(ctx, "AddUserNodes failed", err, "node", node, "num", len(v.Item))
+				written = false
+				break
+			}
+		}
+		if !written {
+			p.distrust(ctx)
+			continue
+		}
+		if p.left.Load() {
+			return
+		}
+		if err := p.cache.SetNodeAlive(ctx, node, p.expire()); err != nil {
+			log.ZWarn(ctx, "SetNodeAlive failed", err, "node", node)
+		}
+	}
+}
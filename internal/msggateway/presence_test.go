@@ -0,0 +1,108 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePresenceCache struct {
+	addErr error
+	users  map[string]bool
+	alive  map[string]bool
+}
+
+func newFakePresenceCache() *fakePresenceCache {
+	return &fakePresenceCache{users: make(map[string]bool), alive: make(map[string]bool)}
+}
+
+func (f *fakePresenceCache) AddUserNodes(ctx context.Context, node string, userIDs []string, expire time.Duration) error {
+	if f.addErr != nil {
+		return f.addErr
+	}
+	for _, userID := range userIDs {
+		f.users[userID] = true
+	}
+	return nil
+}
+
+func (f *fakePresenceCache) DelUserNode(ctx context.Context, node string, userID string) error {
+	delete(f.users, userID)
+	return nil
+}
+
+func (f *fakePresenceCache) GetUsersNodes(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (f *fakePresenceCache) SetNodeAlive(ctx context.Context, node string, expire time.Duration) error {
+	f.alive[node] = true
+	return nil
+}
+
+func (f *fakePresenceCache) DelNodeAlive(ctx context.Context, node string) error {
+	delete(f.alive, node)
+	return nil
+}
+
+func (f *fakePresenceCache) GetAliveNodes(ctx context.Context, nodes []string) (map[string]bool, error) {
+	return f.alive, nil
+}
+
+func newTestPresence(cache *fakePresenceCache) *presence {
+	return &presence{
+		enable:          true,
+		refreshInterval: time.Second,
+		cache:           cache,
+		node:            func() string { return "node1" },
+	}
+}
+
+func TestPresenceWriteFailure(t *testing.T) {
+	cache := newFakePresenceCache()
+	p := newTestPresence(cache)
+	ctx := context.Background()
+	cache.alive["node1"] = true
+
+	p.online(ctx, "u1")
+	assert.True(t, cache.users["u1"])
+	assert.True(t, cache.alive["node1"])
+
+	// a user missing from the registry must not look offline, the node stops vouching for its entries.
+	cache.addErr = errors.New("redis down")
+	p.online(ctx, "u2")
+	assert.False(t, cache.users["u2"])
+	assert.False(t, cache.alive["node1"])
+}
+
+func TestPresenceLeave(t *testing.T) {
+	cache := newFakePresenceCache()
+	p := newTestPresence(cache)
+	ctx := context.Background()
+	cache.alive["node1"] = true
+
+	p.leave(ctx)
+	assert.False(t, cache.alive["node1"])
+	assert.False(t, p.ready())
+
+	// a node that left no longer writes to the registry.
+	p.online(ctx, "u1")
+	assert.False(t, cache.users["u1"])
+}
@@ -0,0 +1,184 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+	imerrs "github.com/openimsdk/open-im-server/v3/pkg/common/errs"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
+)
+
+var ErrRateLimitExceeded = errors.New("conn exceeded rate limit too many times")
+
+const (
+	// idle ip buckets are dropped after this period, they are full again by then.
+	ipBucketIdleTimeout = 5 * time.Minute
+	ipBucketSweepPeriod = time.Minute
+)
+
+type ipBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter holds the limit rules shared by all connections of this node.
+type rateLimiter struct {
+	enable          bool
+	rules           map[int32]config.RateLimitRule
+	maxViolations   int
+	violationWindow time.Duration
+	handshake       config.TokenBucketConf
+	cache           cache.RateLimitCache
+
+	ipLock    sync.Mutex
+	ipBuckets map[string]*ipBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	conf := config.Config.LongConnSvr.RateLimit
+	rules := make(map[int32]config.RateLimitRule, len(conf.Rules))
+	for _, rule := range conf.Rules {
+		rules[rule.ReqIdentifier] = rule
+	}
+	return &rateLimiter{
+		enable:          conf.Enable,
+		rules:           rules,
+		maxViolations:   conf.MaxViolations,
+		violationWindow: time.Duration(conf.ViolationWindow) * time.Second,
+		handshake:       conf.HandshakePerIP,
+		ipBuckets:       make(map[string]*ipBucket),
+	}
+}
+
+func (r *rateLimiter) setCache(cache cache.RateLimitCache) {
+	r.cache = cache
+}
+
+func (r *rateLimiter) allowHandshake(remoteAddr string) bool {
+	if !r.enable || r.handshake.Rate <= 0 || r.handshake.Burst <= 0 {
+		return true
+	}
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+	now := time.Now()
+	r.ipLock.Lock()
+	defer r.ipLock.Unlock()
+	b, ok := r.ipBuckets[ip]
+	if !ok {
+		b = &ipBucket{limiter: rate.NewLimiter(rate.Limit(r.handshake.Rate), r.handshake.Burst)}
+		r.ipBuckets[ip] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1)
+}
+
+func (r *rateLimiter) sweepIPBuckets() {
+	if !r.enable {
+		return
+	}
+	ticker := time.NewTicker(ipBucketSweepPeriod)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		r.ipLock.Lock()
+		for ip, b := range r.ipBuckets {
+			if now.Sub(b.lastSeen) > ipBucketIdleTimeout {
+				delete(r.ipBuckets, ip)
+			}
+		}
+		r.ipLock.Unlock()
+	}
+}
+
+// allowRequest returns ErrConnRateLimit when the request is rejected, and ErrRateLimitExceeded
+// once the connection keeps being rejected for longer than the configured tolerance.
+func (r *rateLimiter) allowRequest(ctx context.Context, client *Client, reqIdentifier int32) error {
+	if !r.enable {
+		return nil
+	}
+	rule, ok := r.rules[reqIdentifier]
+	if !ok {
+		return nil
+	}
+	if client.rateLimit == nil {
+		client.rateLimit = newConnRateLimiter()
+	}
+	allowed := client.rateLimit.allow(reqIdentifier, rule.Conn)
+	if allowed && r.cache != nil {
+		var err error
+		allowed, err = r.cache.AllowUserRequest(ctx, client.UserID, reqIdentifier, rule.User.Rate, rule.User.Burst)
+		if err != nil {
+			log.ZWarn(ctx, "AllowUserRequest failed, skip user rate limit", err, "reqIdentifier", reqIdentifier)
+			allowed = true
+		}
+	}
+	if allowed {
+		return nil
+	}
+	prommetrics.MsgGatewayRateLimitedCounter.Inc()
+	if client.rateLimit.violate(r.maxViolations, r.violationWindow) {
+		return ErrRateLimitExceeded
+	}
+	return imerrs.ErrConnRateLimit.Wrap()
+}
+
+// connRateLimiter is owned by a single client and only used from its read goroutine.
+type connRateLimiter struct {
+	buckets     map[int32]*rate.Limiter
+	violations  int
+	windowStart time.Time
+}
+
+func newConnRateLimiter() *connRateLimiter {
+	return &connRateLimiter{buckets: make(map[int32]*rate.Limiter)}
+}
+
+func (c *connRateLimiter) allow(reqIdentifier int32, conf config.TokenBucketConf) bool {
+	if conf.Rate <= 0 || conf.Burst <= 0 {
+		return true
+	}
+	limiter, ok := c.buckets[reqIdentifier]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(conf.Rate), conf.Burst)
+		c.buckets[reqIdentifier] = limiter
+	}
+	return limiter.Allow()
+}
+
+// violate records a rejected request and reports whether the connection should be closed.
+func (c *connRateLimiter) violate(maxViolations int, window time.Duration) bool {
+	if maxViolations <= 0 {
+		return false
+	}
+	now := time.Now()
+	if c.windowStart.IsZero() || now.Sub(c.windowStart) > window {
+		c.windowStart = now
+		c.violations = 0
+	}
+	c.violations++
+	return c.violations > maxViolations
+}
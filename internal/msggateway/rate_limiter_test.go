@@ -0,0 +1,138 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msggateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	imerrs "github.com/openimsdk/open-im-server/v3/pkg/common/errs"
+)
+
+type fakeRateLimitCache struct {
+	allowed bool
+	err     error
+	calls   int
+}
+
+func (f *fakeRateLimitCache) AllowUserRequest(ctx context.Context, userID string, reqIdentifier int32, rate float64, burst int) (bool, error) {
+	f.calls++
+	return f.allowed, f.err
+}
+
+func newTestRateLimiter(rule config.RateLimitRule, maxViolations int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		enable:          true,
+		rules:           map[int32]config.RateLimitRule{rule.ReqIdentifier: rule},
+		maxViolations:   maxViolations,
+		violationWindow: window,
+		ipBuckets:       make(map[string]*ipBucket),
+	}
+}
+
+func TestConnRateLimiterBurst(t *testing.T) {
+	c := newConnRateLimiter()
+	conf := config.TokenBucketConf{Rate: 1, Burst: 3}
+	for i := 0; i < 3; i++ {
+		assert.True(t, c.allow(1003, conf), "request %d within burst", i)
+	}
+	assert.False(t, c.allow(1003, conf))
+	// buckets are per request identifier.
+	assert.True(t, c.allow(1004, conf))
+	// a rule without rate or burst does not limit.
+	for i := 0; i < 10; i++ {
+		assert.True(t, c.allow(1005, config.TokenBucketConf{}))
+	}
+}
+
+func TestConnRateLimiterRefill(t *testing.T) {
+	c := newConnRateLimiter()
+	conf := config.TokenBucketConf{Rate: 50, Burst: 1}
+	assert.True(t, c.allow(1003, conf))
+	assert.False(t, c.allow(1003, conf))
+	time.Sleep(40 * time.Millisecond)
+	assert.True(t, c.allow(1003, conf))
+}
+
+func TestConnRateLimiterViolationWindow(t *testing.T) {
+	c := newConnRateLimiter()
+	window := 50 * time.Millisecond
+	assert.False(t, c.violate(2, window))
+	assert.False(t, c.violate(2, window))
+	assert.True(t, c.violate(2, window))
+
+	// the count starts over once the window passed.
+	time.Sleep(2 * window)
+	assert.False(t, c.violate(2, window))
+	assert.False(t, c.violate(2, window))
+
+	// no maximum never closes the connection.
+	for i := 0; i < 10; i++ {
+		assert.False(t, c.violate(0, window))
+	}
+}
+
+func TestAllowHandshakePerIP(t *testing.T) {
+	r := newTestRateLimiter(config.RateLimitRule{}, 0, time.Second)
+	r.handshake = config.TokenBucketConf{Rate: 1, Burst: 2}
+	assert.True(t, r.allowHandshake("10.0.0.1:1000"))
+	assert.True(t, r.allowHandshake("10.0.0.1:1001"))
+	assert.False(t, r.allowHandshake("10.0.0.1:1002"))
+	assert.True(t, r.allowHandshake("10.0.0.2:1000"))
+
+	r.enable = false
+	assert.True(t, r.allowHandshake("10.0.0.1:1003"))
+}
+
+func TestAllowRequestRejection(t *testing.T) {
+	rule := config.RateLimitRule{ReqIdentifier: 1003, Conn: config.TokenBucketConf{Rate: 0.001, Burst: 1}}
+	r := newTestRateLimiter(rule, 1, time.Minute)
+	client := &Client{UserID: "u1"}
+	ctx := context.Background()
+
+	assert.NoError(t, r.allowRequest(ctx, client, 1003))
+	// requests without a rule are never limited.
+	assert.NoError(t, r.allowRequest(ctx, client, 1001))
+
+	err := r.allowRequest(ctx, client, 1003)
+	assert.True(t, imerrs.ErrConnRateLimit.Is(err), "got %v", err)
+	assert.ErrorIs(t, r.allowRequest(ctx, client, 1003), ErrRateLimitExceeded)
+}
+
+func TestAllowRequestUserBucket(t *testing.T) {
+	rule := config.RateLimitRule{ReqIdentifier: 1003, User: config.TokenBucketConf{Rate: 1, Burst: 1}}
+	r := newTestRateLimiter(rule, 0, time.Minute)
+	ctx := context.Background()
+
+	cache := &fakeRateLimitCache{allowed: false}
+	r.setCache(cache)
+	err := r.allowRequest(ctx, &Client{UserID: "u1"}, 1003)
+	assert.True(t, imerrs.ErrConnRateLimit.Is(err), "got %v", err)
+	assert.Equal(t, 1, cache.calls)
+
+	// the user bucket is skipped when redis fails.
+	cache.err = errors.New("redis down")
+	assert.NoError(t, r.allowRequest(ctx, &Client{UserID: "u1"}, 1003))
+
+	r.enable = false
+	cache.err = nil
+	assert.NoError(t, r.allowRequest(ctx, &Client{UserID: "u1"}, 1003))
+	assert.Equal(t, 2, cache.calls)
+}
@@ -125,3 +125,29 @@ func (u *UserMap) deleteClients(key string, clients []*Client) (isDeleteUser boo
 func (u *UserMap) DeleteAll(key string) {
 	u.m.Delete(key)
 }
+
+// openClients returns at most limit clients that are not closed yet.
+func (u *UserMap) openClients(limit int) []*Client {
+	var clients []*Client
+	u.m.Range(func(key, value any) bool {
+		for _, client := range value.([]*Client) {
+			if len(clients) >= limit {
+				return false
+			}
+			if !client.closed.Load() {
+				clients = append(clients, client)
+			}
+		}
+		return len(clients) < limit
+	})
+	return clients
+}
+
+func (u *UserMap) userIDs() []string {
+	var userIDs []string
+	u.m.Range(func(key, value any) bool {
+		userIDs = append(userIDs, key.(string))
+		return true
+	})
+	return userIDs
+}
@@ -82,20 +82,25 @@ func StartTransfer(prometheusPort int) error {
 	msgMysModel := relation.NewChatLogGorm(db)
 	chatLogDatabase := controller.NewChatLogDatabase(msgMysModel)
 	msgDatabase := controller.NewCommonMsgDatabase(msgDocModel, msgModel)
+	msgSearchModel, err := unrelation.NewMsgSearchMongo(mongo.GetDatabase())
+	if err != nil {
+		return err
+	}
+	msgSearchDatabase := controller.NewMsgSearchDatabase(msgSearchModel)
 	conversationRpcClient := rpcclient.NewConversationRpcClient(client)
 	groupRpcClient := rpcclient.NewGroupRpcClient(client)
 	clubRpcClient := rpcclient.NewClubRpcClient(client)
-	msgTransfer := NewMsgTransfer(chatLogDatabase, msgDatabase, &conversationRpcClient, &groupRpcClient, &clubRpcClient)
+	msgTransfer := NewMsgTransfer(chatLogDatabase, msgDatabase, msgSearchDatabase, &conversationRpcClient, &groupRpcClient, &clubRpcClient)
 	return msgTransfer.Start(prometheusPort)
 }
 
 func NewMsgTransfer(chatLogDatabase controller.ChatLogDatabase,
-	msgDatabase controller.CommonMsgDatabase,
+	msgDatabase controller.CommonMsgDatabase, msgSearchDatabase controller.MsgSearchDatabase,
 	conversationRpcClient *rpcclient.ConversationRpcClient, groupRpcClient *rpcclient.GroupRpcClient, clubRpcClient *rpcclient.ClubRpcClient,
 ) *MsgTransfer {
 	return &MsgTransfer{
 		persistentCH: NewPersistentConsumerHandler(chatLogDatabase), historyCH: NewOnlineHistoryRedisConsumerHandler(msgDatabase, conversationRpcClient, groupRpcClient, clubRpcClient),
-		historyMongoCH: NewOnlineHistoryMongoConsumerHandler(msgDatabase),
+		historyMongoCH: NewOnlineHistoryMongoConsumerHandler(msgDatabase, msgSearchDatabase),
 	}
 }
 
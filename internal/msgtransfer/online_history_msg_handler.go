@@ -302,6 +302,10 @@ func (och *OnlineHistoryRedisConsumerHandler) handleMsg(
 					}
 				}
 			case constant.ServerGroupChatType:
+				if storageList[0].ThreadID != "" {
+					// threads are followed by subscription, members get no conversation of them.
+					break
+				}
 				log.ZInfo(ctx, "server group chat first create conversation", "conversationID", conversationID)
 				userIDs, err := och.clubRpcClient.GetServerGroupMemberIDs(ctx, storageList[0].GroupID)
 				if err != nil {
@@ -32,16 +32,18 @@ import (
 type OnlineHistoryMongoConsumerHandler struct {
 	historyConsumerGroup *kfk.MConsumerGroup
 	msgDatabase          controller.CommonMsgDatabase
+	msgSearchDatabase    controller.MsgSearchDatabase
 }
 
-func NewOnlineHistoryMongoConsumerHandler(database controller.CommonMsgDatabase) *OnlineHistoryMongoConsumerHandler {
+func NewOnlineHistoryMongoConsumerHandler(database controller.CommonMsgDatabase, msgSearchDatabase controller.MsgSearchDatabase) *OnlineHistoryMongoConsumerHandler {
 	mc := &OnlineHistoryMongoConsumerHandler{
 		historyConsumerGroup: kfk.NewMConsumerGroup(&kfk.MConsumerGroupConfig{
 			KafkaVersion:   sarama.V2_0_0_0,
 			OffsetsInitial: sarama.OffsetNewest, IsReturnErr: false,
 		}, []string{config.Config.Kafka.MsgToMongo.Topic},
 			config.Config.Kafka.Addr, config.Config.Kafka.ConsumerGroupID.MsgToMongo),
-		msgDatabase: database,
+		msgDatabase:       database,
+		msgSearchDatabase: msgSearchDatabase,
 	}
 	return mc
 }
@@ -78,6 +80,10 @@ func (mc *OnlineHistoryMongoConsumerHandler) handleChatWs2Mongo(
 		prommetrics.MsgInsertMongoFailedCounter.Inc()
 	} else {
 		prommetrics.MsgInsertMongoSuccessCounter.Inc()
+		// the search index only covers msgs that made it to mongo, a failed index is logged and not retried.
+		if err := mc.msgSearchDatabase.IndexMsgs(ctx, msgFromMQ.ConversationID, msgFromMQ.MsgData); err != nil {
+			log.ZError(ctx, "index msgs for search err", err, "conversationID", msgFromMQ.ConversationID)
+		}
 	}
 	var seqs []int64
 	for _, msg := range msgFromMQ.MsgData {
@@ -0,0 +1,123 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	defaultBadgeExpire = 10 * time.Minute
+	// maxComputedBadges caps the badges computed from the seqs for one message, each one scans the
+	// conversations of the user. The users left out get theirs on the next messages, once the first
+	// ones are cached.
+	maxComputedBadges = 1000
+)
+
+// getBadges returns the badge of each user for a new message. Cached badges only need the message added,
+// the others are computed from the read and max seqs of the user's conversations and cached.
+func (p *Pusher) getBadges(ctx context.Context, userIDs []string) map[string]int {
+	if !config.Config.Push.Badge.Enable || len(userIDs) == 0 {
+		return nil
+	}
+	badges, err := p.database.IncrUsersBadge(ctx, userIDs)
+	if err != nil {
+		log.ZWarn(ctx, "IncrUsersBadge failed", err, "num", len(userIDs))
+	}
+	if badges == nil {
+		badges = make(map[string]int, len(userIDs))
+	}
+	missing := make([]string, 0, len(userIDs)-len(badges))
+	for _, userID := range userIDs {
+		if _, ok := badges[userID]; !ok {
+			missing = append(missing, userID)
+		}
+	}
+	if len(missing) == 0 {
+		return badges
+	}
+	if len(missing) > maxComputedBadges {
+		missing = missing[:maxComputedBadges]
+	}
+	// the seqs already count the message being pushed.
+	unreadCounts, err := p.msgRpcClient.GetUsersUnreadCount(ctx, missing)
+	if err != nil {
+		log.ZWarn(ctx, "GetUsersUnreadCount failed", err, "num", len(missing))
+		return badges
+	}
+	computed := make(map[string]int, len(unreadCounts))
+	for userID, unreadCount := range unreadCounts {
+		computed[userID] = int(unreadCount)
+		badges[userID] = int(unreadCount)
+	}
+	expire := time.Duration(config.Config.Push.Badge.Expire) * time.Second
+	if expire <= 0 {
+		expire = defaultBadgeExpire
+	}
+	if err := p.database.SetUsersBadge(ctx, computed, expire); err != nil {
+		log.ZWarn(ctx, "SetUsersBadge failed", err, "num", len(computed))
+	}
+	return badges
+}
+
+// dropBadges drops the cached badges of the recipients of a message the offline push didn't count it
+// for, it was delivered online or not pushed. Their badges are computed from the seqs again.
+func (p *Pusher) dropBadges(ctx context.Context, userIDs []string, countedUserIDs []string) {
+	if !config.Config.Push.Badge.Enable {
+		return
+	}
+	userIDs = utils.SliceSub(userIDs, countedUserIDs)
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := p.database.DelUsersBadge(ctx, userIDs); err != nil {
+		log.ZWarn(ctx, "DelUsersBadge failed", err, "num", len(userIDs))
+	}
+}
+
+// countIOSBadges adds the message to the badge counter of the users without an exact badge, when the
+// sender asked for it. The counters go into opts, a retried push reuses them instead of counting again.
+// The counter shares its key with the badges computed from the seqs, it isn't used when those are enabled.
+func (p *Pusher) countIOSBadges(ctx context.Context, opts *offlinepush.Opts, userIDs []string) {
+	if !opts.IOSBadgeCount || config.Config.Push.Badge.Enable {
+		return
+	}
+	missing := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := opts.Badges[userID]; !ok {
+			missing = append(missing, userID)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	badges, err := p.database.CountUsersBadge(ctx, missing)
+	if err != nil {
+		log.ZWarn(ctx, "CountUsersBadge failed", err, "num", len(missing), "counted", len(badges))
+	}
+	if opts.Badges == nil {
+		opts.Badges = make(map[string]int, len(badges))
+	}
+	for userID, badge := range badges {
+		opts.Badges[userID] = badge
+	}
+}
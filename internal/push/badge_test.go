@@ -0,0 +1,59 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+func TestDropBadges(t *testing.T) {
+	defer func(enable bool) { config.Config.Push.Badge.Enable = enable }(config.Config.Push.Badge.Enable)
+	tests := []struct {
+		name    string
+		enable  bool
+		userIDs []string
+		counted []string
+		dropped []string
+	}{
+		{"delivered online", true, []string{"a", "b", "c"}, []string{"b"}, []string{"a", "c"}},
+		{"not pushed offline", true, []string{"a", "b"}, nil, []string{"a", "b"}},
+		{"all counted", true, []string{"a", "b"}, []string{"a", "b"}, nil},
+		{"badges disabled", false, []string{"a", "b"}, nil, nil},
+	}
+	for _, test := range tests {
+		config.Config.Push.Badge.Enable = test.enable
+		db := newMockPushDatabase()
+		newRetryTestPusher(db).dropBadges(context.Background(), test.userIDs, test.counted)
+		if !reflect.DeepEqual(db.droppedBadges, test.dropped) {
+			t.Errorf("%s: dropped %v, want %v", test.name, db.droppedBadges, test.dropped)
+		}
+	}
+}
+
+func TestCountIOSBadgesSkipsComputedBadges(t *testing.T) {
+	defer func(enable bool) { config.Config.Push.Badge.Enable = enable }(config.Config.Push.Badge.Enable)
+	config.Config.Push.Badge.Enable = true
+	opts := &offlinepush.Opts{IOSBadgeCount: true, Badges: map[string]int{"a": 3}}
+	// the mock database would panic on CountUsersBadge, the counter shares the key of the computed badges.
+	newRetryTestPusher(newMockPushDatabase()).countIOSBadges(context.Background(), opts, []string{"a", "b"})
+	if len(opts.Badges) != 1 {
+		t.Errorf("badges %v", opts.Badges)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/protocol/user"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/utils/splitter"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+)
+
+// defaultOfflinePushBatchSize is used for offline pushers without a limit of their own.
+const defaultOfflinePushBatchSize = 1000
+
+// renderKey holds the settings that change how a push is rendered.
+type renderKey struct {
+	language         string
+	allowPushContent int32
+	allowBeep        int32
+}
+
+// payloadKey identifies recipients getting exactly the same push.
+type payloadKey struct {
+	title   string
+	content string
+	sound   string
+}
+
+// offlinePushMsg pushes msg to offlinePushUserIDs with as few provider calls as possible: push settings
+// are loaded in bulk, the payload is rendered once per distinct language and content mode, and users
+// getting the same payload share batches sized for the provider.
+func (p *Pusher) offlinePushMsg(ctx context.Context, conversationID string, msg *sdkws.MsgData, offlinePushUserIDs []string) error {
+	if p.offlinePusher == nil {
+		return errNoOfflinePusher
+	}
+	opts, err := p.GetOfflinePushOpts(ctx, msg)
+	if err != nil {
+		return err
+	}
+	opts.Receipt = offlinepush.NewReceipt()
+	// badges count msg for users in quiet hours too, their next push shows the right number.
+	opts.Badges = p.getBadges(ctx, offlinePushUserIDs)
+	p.countIOSBadges(ctx, opts, offlinePushUserIDs)
+	offlinePushUserIDs = p.filterQuietHours(ctx, msg, opts, offlinePushUserIDs)
+	if len(offlinePushUserIDs) == 0 {
+		return nil
+	}
+	settings, err := p.userRpcClient.GetUsersSetting(ctx, offlinePushUserIDs)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[renderKey][]string)
+	for _, userID := range offlinePushUserIDs {
+		setting, ok := settings[userID]
+		if !ok {
+			continue
+		}
+		// user not allow offline push
+		if setting.GlobalRecvMsgOpt == constant.ReceiveNotPushMessage {
+			continue
+		}
+		key := renderKey{language: setting.Language, allowPushContent: setting.AllowPushContent, allowBeep: setting.AllowBeep}
+		groups[key] = append(groups[key], userID)
+	}
+
+	payloads := make(map[payloadKey][]string)
+	for key, userIDs := range groups {
+		setting := &user.GetUserSettingResp{Language: key.language, AllowPushContent: key.allowPushContent, AllowBeep: key.allowBeep}
+		title, content, err := p.renderOfflinePush(ctx, msg, setting)
+		if err != nil {
+			log.ZWarn(ctx, "renderOfflinePush failed", err, "conversationID", conversationID, "userIDs", userIDs)
+			continue
+		}
+		sound := opts.IOSPushSound
+		if key.allowBeep == constant.NewMsgPushSettingAllowed {
+			sound = "default"
+		}
+		pk := payloadKey{title: title, content: content, sound: sound}
+		payloads[pk] = append(payloads[pk], userIDs...)
+	}
+
+	batchSize := p.offlinePushBatchSize()
+	for pk, userIDs := range payloads {
+		payloadOpts := *opts
+		payloadOpts.IOSPushSound = pk.sound
+		for _, batch := range splitter.NewSplitter(batchSize, userIDs).GetSplitResult() {
+			err := p.offlinePusher.Push(ctx, batch.Item, pk.title, pk.content, &payloadOpts)
+			p.recordPushed(batch.Item, &payloadOpts, err)
+			if err != nil {
+				job := newOfflinePushJob(ctx, conversationID, batch.Item, pk.title, pk.content, &payloadOpts)
+				p.handleOfflinePushFailure(ctx, job, err)
+			}
+		}
+	}
+	return nil
+}
+
+// offlinePushBatchSize is how many users the offline pusher accepts in one Push call.
+func (p *Pusher) offlinePushBatchSize() int {
+	if limiter, ok := p.offlinePusher.(offlinepush.BatchLimiter); ok && limiter.BatchSize() > 0 {
+		return limiter.BatchSize()
+	}
+	return defaultOfflinePushBatchSize
+}
@@ -0,0 +1,305 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+
+	pbpush "github.com/OpenIMSDK/protocol/push"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
+)
+
+const (
+	defaultPushRetryMaxAttempts    = 5
+	defaultPushRetryInitialBackoff = 10 * time.Second
+	defaultPushRetryMaxBackoff     = 10 * time.Minute
+	defaultPushDeadLetterExpire    = 7 * 24 * time.Hour
+	pushRetryPollInterval          = time.Second
+	pushRetryPollBatch             = 10
+	pushRetryTimeout               = 30 * time.Second
+	pushRetryJitterRatio           = 5 // up to 1/5 of the backoff is added at random
+	// a batch is pushed sequentially, its jobs are leased long enough for all of them to time out.
+	pushRetryLease = pushRetryPollBatch * pushRetryTimeout
+)
+
+// offlinePushJob is an offline push batch that failed, as kept in the retry queue and the dead-letter store.
+type offlinePushJob struct {
+	ID             string            `json:"id"`
+	OperationID    string            `json:"operationID"`
+	ConversationID string            `json:"conversationID"`
+	UserIDs        []string          `json:"userIDs"`
+	Title          string            `json:"title"`
+	Content        string            `json:"content"`
+	Opts           *offlinepush.Opts `json:"opts"`
+	Attempt        int               `json:"attempt"`
+	LastErr        string            `json:"lastErr"`
+	FailTime       int64             `json:"failTime"`
+}
+
+type pushRetryConf struct {
+	enable           bool
+	maxAttempts      int
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+	deadLetterExpire time.Duration
+}
+
+func newPushRetryConf() *pushRetryConf {
+	conf := config.Config.Push.Retry
+	c := &pushRetryConf{
+		enable:           conf.Enable,
+		maxAttempts:      conf.MaxAttempts,
+		initialBackoff:   time.Duration(conf.InitialBackoff) * time.Second,
+		maxBackoff:       time.Duration(conf.MaxBackoff) * time.Second,
+		deadLetterExpire: time.Duration(conf.DeadLetterExpire) * 24 * time.Hour,
+	}
+	if c.maxAttempts <= 0 {
+		c.maxAttempts = defaultPushRetryMaxAttempts
+	}
+	if c.initialBackoff <= 0 {
+		c.initialBackoff = defaultPushRetryInitialBackoff
+	}
+	if c.maxBackoff < c.initialBackoff {
+		c.maxBackoff = defaultPushRetryMaxBackoff
+	}
+	if c.deadLetterExpire <= 0 {
+		c.deadLetterExpire = defaultPushDeadLetterExpire
+	}
+	return c
+}
+
+// backoff returns how long to wait before the given attempt, doubling from initialBackoff with some jitter
+// so the jobs of a provider outage don't all come back at once.
+func (c *pushRetryConf) backoff(attempt int) time.Duration {
+	d := c.maxBackoff
+	if shift := attempt - 1; shift < 32 && c.initialBackoff<<shift < c.maxBackoff {
+		d = c.initialBackoff << shift
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/pushRetryJitterRatio+1))
+}
+
+func newOfflinePushJob(ctx context.Context, conversationID string, userIDs []string, title, content string, opts *offlinepush.Opts) *offlinePushJob {
+	if len(opts.Badges) > 0 {
+		// opts is shared by all batches of a message, keep only the badges of this one.
+		batchOpts := *opts
+		batchOpts.Badges = make(map[string]int, len(userIDs))
+		for _, userID := range userIDs {
+			if badge, ok := opts.Badges[userID]; ok {
+				batchOpts.Badges[userID] = badge
+			}
+		}
+		opts = &batchOpts
+	}
+	return &offlinePushJob{
+		ID:             uuid.New().String(),
+		OperationID:    mcontext.GetOperationID(ctx),
+		ConversationID: conversationID,
+		UserIDs:        userIDs,
+		Title:          title,
+		Content:        content,
+		Opts:           opts,
+	}
+}
+
+// forPusher copies the job for a retry through the named pusher only.
+func (j *offlinePushJob) forPusher(name string) *offlinePushJob {
+	job := *j
+	job.ID = uuid.New().String()
+	opts := *j.Opts
+	opts.Pushers = []string{name}
+	job.Opts = &opts
+	return &job
+}
+
+// handleOfflinePushFailure either schedules the job again or moves it to the dead-letter store when
+// retrying can't help or the attempts are used up. When several pushers are enabled, each pusher that
+// failed gets its own job, pushers that delivered aren't called again. It returns an error if a job
+// could not be stored.
+func (p *Pusher) handleOfflinePushFailure(ctx context.Context, job *offlinePushJob, err error) error {
+	prommetrics.MsgOfflinePushFailedCounter.Add(float64(len(job.UserIDs)))
+	log.ZWarn(ctx, "offline push batch failed", err, "conversationID", job.ConversationID, "num", len(job.UserIDs), "attempt", job.Attempt)
+	var pushErr *offlinepush.PushError
+	if errors.As(err, &pushErr) && len(pushErr.Pushers) > 0 {
+		var storeErr error
+		for name, pusherErr := range pushErr.Pushers {
+			if err := p.scheduleOfflinePushRetry(ctx, job.forPusher(name), pusherErr); err != nil {
+				storeErr = err
+			}
+		}
+		return storeErr
+	}
+	return p.scheduleOfflinePushRetry(ctx, job, err)
+}
+
+func (p *Pusher) scheduleOfflinePushRetry(ctx context.Context, job *offlinePushJob, err error) error {
+	var permanent bool
+	var pushErr *offlinepush.PushError
+	if errors.As(err, &pushErr) {
+		if len(pushErr.RetryUserIDs) > 0 {
+			job.UserIDs = pushErr.RetryUserIDs
+		} else if pushErr.Permanent && len(pushErr.Unregistered) > 0 {
+			// every failure was a stale token, nothing is left to deliver.
+			p.recordPushFailed(utils.Distinct(utils.Slice(pushErr.Unregistered, func(token offlinepush.UnregisteredToken) string {
+				return token.UserID
+			})), job.Opts)
+			return nil
+		}
+		permanent = pushErr.Permanent
+	}
+	if !p.retryConf.enable {
+		p.recordPushFailed(job.UserIDs, job.Opts)
+		return nil
+	}
+	now := time.Now()
+	job.Attempt++
+	job.LastErr = err.Error()
+	job.FailTime = now.UnixMilli()
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.ZError(ctx, "marshal offline push job failed", err, "jobID", job.ID)
+		return nil
+	}
+	if permanent || job.Attempt >= p.retryConf.maxAttempts {
+		p.recordPushFailed(job.UserIDs, job.Opts)
+		if err := p.database.AddPushDeadLetter(ctx, job.ID, string(data), now, p.retryConf.deadLetterExpire); err != nil {
+			log.ZError(ctx, "AddPushDeadLetter failed", err, "jobID", job.ID)
+			return err
+		}
+		return nil
+	}
+	if err := p.database.AddPushRetryJob(ctx, job.ID, string(data), now.Add(p.retryConf.backoff(job.Attempt))); err != nil {
+		log.ZError(ctx, "AddPushRetryJob failed", err, "jobID", job.ID)
+		return err
+	}
+	return nil
+}
+
+// runPushRetry sends the jobs of the retry queue once they are due. Jobs are leased atomically, so every
+// push instance can run it, and a job leased by an instance that stopped is sent again once its lease expires.
+func (p *Pusher) runPushRetry() {
+	if !p.retryConf.enable || p.offlinePusher == nil {
+		return
+	}
+	ticker := time.NewTicker(pushRetryPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			ctx := mcontext.SetOperationID(context.Background(), "push_retry_"+utils.OperationIDGenerator())
+			jobs, err := p.database.LeaseDuePushRetryJobs(ctx, time.Now(), pushRetryPollBatch, pushRetryLease)
+			if err != nil {
+				log.ZError(ctx, "LeaseDuePushRetryJobs failed", err)
+				break
+			}
+			for jobID, data := range jobs {
+				if !p.retryOfflinePush(data) {
+					continue
+				}
+				if err := p.database.AckPushRetryJob(ctx, jobID); err != nil {
+					log.ZError(ctx, "AckPushRetryJob failed", err, "jobID", jobID)
+				}
+			}
+			if len(jobs) < pushRetryPollBatch {
+				break
+			}
+		}
+	}
+}
+
+// retryOfflinePush sends a leased job, it returns false if the job must stay leased to be sent again.
+func (p *Pusher) retryOfflinePush(data string) bool {
+	var job offlinePushJob
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		log.ZError(context.Background(), "unmarshal offline push job failed", err, "job", data)
+		return true
+	}
+	ctx, cancel := context.WithTimeout(mcontext.SetOperationID(context.Background(), job.OperationID), pushRetryTimeout)
+	defer cancel()
+	job.Opts.Receipt = offlinepush.NewReceipt()
+	err := p.offlinePusher.Push(ctx, job.UserIDs, job.Title, job.Content, job.Opts)
+	p.recordPushed(job.UserIDs, job.Opts, err)
+	if err != nil {
+		return p.handleOfflinePushFailure(ctx, &job, err) == nil
+	}
+	log.ZInfo(ctx, "offline push retry succeeded", "jobID", job.ID, "attempt", job.Attempt, "num", len(job.UserIDs))
+	return true
+}
+
+// getFailedPushes pages through the dead-letter store, pageNumber starts at 1.
+func (p *Pusher) getFailedPushes(ctx context.Context, pageNumber, showNumber int32) (int64, []*pbpush.FailedPush, error) {
+	if pageNumber <= 0 {
+		pageNumber = 1
+	}
+	if showNumber <= 0 {
+		showNumber = 20
+	}
+	total, jobs, err := p.database.GetPushDeadLetters(ctx, int((pageNumber-1)*showNumber), int(showNumber))
+	if err != nil {
+		return 0, nil, err
+	}
+	failedPushes := make([]*pbpush.FailedPush, 0, len(jobs))
+	for _, data := range jobs {
+		var job offlinePushJob
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			log.ZWarn(ctx, "unmarshal offline push job failed", err, "job", data)
+			continue
+		}
+		failedPushes = append(failedPushes, &pbpush.FailedPush{
+			JobID:          job.ID,
+			ConversationID: job.ConversationID,
+			UserIDs:        job.UserIDs,
+			Title:          job.Title,
+			Content:        job.Content,
+			Attempt:        int32(job.Attempt),
+			LastErr:        job.LastErr,
+			FailTime:       job.FailTime,
+		})
+	}
+	return total, failedPushes, nil
+}
+
+// replayFailedPushes moves dead letters back to the retry queue with a fresh attempt budget.
+func (p *Pusher) replayFailedPushes(ctx context.Context, jobIDs []string) ([]string, error) {
+	deadLetters, err := p.database.FindPushDeadLetters(ctx, jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make(map[string]string, len(deadLetters))
+	for jobID, data := range deadLetters {
+		var job offlinePushJob
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			log.ZWarn(ctx, "unmarshal offline push job failed", err, "job", data)
+			continue
+		}
+		job.Attempt = 0
+		value, err := json.Marshal(&job)
+		if err != nil {
+			return nil, err
+		}
+		jobs[jobID] = string(value)
+	}
+	return p.database.ReplayPushDeadLetters(ctx, jobs, time.Now())
+}
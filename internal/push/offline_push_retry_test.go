@@ -0,0 +1,195 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
+)
+
+// mockPushDatabase records what the retry logic stores, other methods are not used.
+type mockPushDatabase struct {
+	controller.PushDatabase
+	retryJobs     map[string]string
+	deadLetters   map[string]string
+	droppedBadges []string
+}
+
+func newMockPushDatabase() *mockPushDatabase {
+	return &mockPushDatabase{retryJobs: make(map[string]string), deadLetters: make(map[string]string)}
+}
+
+func (m *mockPushDatabase) DelUsersBadge(_ context.Context, userIDs []string) error {
+	m.droppedBadges = append(m.droppedBadges, userIDs...)
+	return nil
+}
+
+func (m *mockPushDatabase) AddPushRetryJob(_ context.Context, jobID string, job string, _ time.Time) error {
+	m.retryJobs[jobID] = job
+	return nil
+}
+
+func (m *mockPushDatabase) AddPushDeadLetter(_ context.Context, jobID string, job string, _ time.Time, _ time.Duration) error {
+	m.deadLetters[jobID] = job
+	return nil
+}
+
+func newRetryTestPusher(db *mockPushDatabase) *Pusher {
+	return &Pusher{
+		database: db,
+		retryConf: &pushRetryConf{
+			enable:           true,
+			maxAttempts:      3,
+			initialBackoff:   10 * time.Second,
+			maxBackoff:       10 * time.Minute,
+			deadLetterExpire: time.Hour,
+		},
+	}
+}
+
+func decodeJob(t *testing.T, data string) *offlinePushJob {
+	t.Helper()
+	var job offlinePushJob
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		t.Fatal(err)
+	}
+	return &job
+}
+
+func TestPushRetryBackoff(t *testing.T) {
+	conf := &pushRetryConf{initialBackoff: 10 * time.Second, maxBackoff: 10 * time.Minute}
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{7, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			d := conf.backoff(test.attempt)
+			if d < test.base || d > test.base+test.base/pushRetryJitterRatio {
+				t.Fatalf("attempt %d: backoff %v out of [%v, %v]", test.attempt, d, test.base, test.base+test.base/pushRetryJitterRatio)
+			}
+		}
+	}
+}
+
+func TestNewOfflinePushJobBadges(t *testing.T) {
+	opts := &offlinepush.Opts{IOSBadgeCount: true, Badges: map[string]int{"a": 1, "b": 2, "c": 3}}
+	job := newOfflinePushJob(context.Background(), "si_a_b", []string{"a", "b"}, "title", "content", opts)
+	if want := map[string]int{"a": 1, "b": 2}; !reflect.DeepEqual(job.Opts.Badges, want) {
+		t.Errorf("job badges %v, want %v", job.Opts.Badges, want)
+	}
+	if len(opts.Badges) != 3 {
+		t.Errorf("shared opts changed: %v", opts.Badges)
+	}
+}
+
+func TestHandleOfflinePushFailure(t *testing.T) {
+	opts := &offlinepush.Opts{IOSBadgeCount: true, Badges: map[string]int{"a": 4, "b": 5, "c": 6}}
+	tests := []struct {
+		name       string
+		attempt    int
+		err        error
+		retry      []string
+		deadLetter []string
+	}{
+		{"transient", 0, errors.New("timeout"), []string{"a", "b", "c"}, nil},
+		{"partial", 0, &offlinepush.PushError{Err: errors.New("busy"), RetryUserIDs: []string{"b"}}, []string{"b"}, nil},
+		{"permanent", 0, &offlinepush.PushError{Err: errors.New("bad payload"), Permanent: true}, nil, []string{"a", "b", "c"}},
+		{"attempts used up", 2, errors.New("timeout"), nil, []string{"a", "b", "c"}},
+		{
+			"only stale tokens", 0,
+			&offlinepush.PushError{Err: errors.New("gone"), Permanent: true, Unregistered: []offlinepush.UnregisteredToken{{UserID: "a", PlatformID: 1}}},
+			nil, nil,
+		},
+	}
+	for _, test := range tests {
+		db := newMockPushDatabase()
+		p := newRetryTestPusher(db)
+		job := newOfflinePushJob(context.Background(), "si_a_b", []string{"a", "b", "c"}, "title", "content", opts)
+		job.Attempt = test.attempt
+		p.handleOfflinePushFailure(context.Background(), job, test.err)
+
+		check := func(kind string, stored map[string]string, want []string) {
+			if want == nil {
+				if len(stored) != 0 {
+					t.Errorf("%s: unexpected %s %v", test.name, kind, stored)
+				}
+				return
+			}
+			if len(stored) != 1 {
+				t.Fatalf("%s: %d %s, want 1", test.name, len(stored), kind)
+			}
+			for _, data := range stored {
+				got := decodeJob(t, data)
+				if !reflect.DeepEqual(got.UserIDs, want) {
+					t.Errorf("%s: %s users %v, want %v", test.name, kind, got.UserIDs, want)
+				}
+				if got.Attempt != test.attempt+1 {
+					t.Errorf("%s: %s attempt %d", test.name, kind, got.Attempt)
+				}
+				// the badges computed for the message travel with the job, a retry doesn't count again.
+				if !reflect.DeepEqual(got.Opts.Badges, opts.Badges) || !got.Opts.IOSBadgeCount {
+					t.Errorf("%s: %s opts %+v", test.name, kind, got.Opts)
+				}
+			}
+		}
+		check("retry job", db.retryJobs, test.retry)
+		check("dead letter", db.deadLetters, test.deadLetter)
+	}
+}
+
+func TestHandleOfflinePushFailurePerPusher(t *testing.T) {
+	db := newMockPushDatabase()
+	p := newRetryTestPusher(db)
+	job := newOfflinePushJob(context.Background(), "si_a_b", []string{"a", "b", "c"}, "title", "content", &offlinepush.Opts{})
+	err := &offlinepush.PushError{
+		Err: errors.New("busy"),
+		Pushers: map[string]error{
+			"fcm":  &offlinepush.PushError{Err: errors.New("busy"), RetryUserIDs: []string{"b"}},
+			"apns": errors.New("timeout"),
+		},
+	}
+	p.handleOfflinePushFailure(context.Background(), job, err)
+
+	if len(db.retryJobs) != 2 {
+		t.Fatalf("%d retry jobs, want one per failed pusher", len(db.retryJobs))
+	}
+	want := map[string][]string{"fcm": {"b"}, "apns": {"a", "b", "c"}}
+	for _, data := range db.retryJobs {
+		got := decodeJob(t, data)
+		if len(got.Opts.Pushers) != 1 {
+			t.Fatalf("pushers %v", got.Opts.Pushers)
+		}
+		if !reflect.DeepEqual(got.UserIDs, want[got.Opts.Pushers[0]]) {
+			t.Errorf("%s: users %v", got.Opts.Pushers[0], got.UserIDs)
+		}
+	}
+	if len(job.Opts.Pushers) != 0 {
+		t.Errorf("the original job changed: %v", job.Opts.Pushers)
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/OpenIMSDK/protocol/constant"
 	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/log"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/i18n"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient"
 )
@@ -45,16 +46,31 @@ type OfflineInfo interface {
 type OfflineInfoParse struct {
 	groupRpcClient *rpcclient.GroupRpcClient
 	clubRpcClient  *rpcclient.ClubRpcClient
+	templates      *TemplateStore
 }
 
-func NewOfflineInfoParse(groupRpcClient *rpcclient.GroupRpcClient, clubRpcClient *rpcclient.ClubRpcClient) *OfflineInfoParse {
+func NewOfflineInfoParse(groupRpcClient *rpcclient.GroupRpcClient, clubRpcClient *rpcclient.ClubRpcClient, templates *TemplateStore) *OfflineInfoParse {
 	return &OfflineInfoParse{
 		groupRpcClient: groupRpcClient,
 		clubRpcClient:  clubRpcClient,
+		templates:      templates,
 	}
 }
 
+// GetOfflineInfo renders the push of msg with the best matching operator template, messages no template
+// matches keep the built-in wording of their session type.
 func (o *OfflineInfoParse) GetOfflineInfo(ctx context.Context, msg *sdkws.MsgData, pushContentMode int32, lang i18n.Language) (*OfflineMsg, error) {
+	if t := o.templates.get().match(msg.SessionType, msg.ContentType, string(lang)); t != nil {
+		data, err := o.templateData(ctx, msg, pushContentMode, lang)
+		if err != nil {
+			return nil, err
+		}
+		info, err := t.render(data)
+		if err == nil {
+			return info, nil
+		}
+		log.ZWarn(ctx, "render push template failed", err, "sessionType", msg.SessionType, "contentType", msg.ContentType)
+	}
 	var info OfflineInfo
 	rpc := rpc{
 		groupRpcClient: o.groupRpcClient,
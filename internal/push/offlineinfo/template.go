@@ -0,0 +1,229 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlineinfo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/OpenIMSDK/protocol/club"
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/utils"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/i18n"
+)
+
+// defaultPushLanguage is used for users who never set a language, unless the template set names another one.
+const defaultPushLanguage = "zh_CN"
+
+// Template is the wording of the offline push of the messages it matches. Zero SessionType and
+// ContentType and an empty Language match everything. Title and Content are text/template sources
+// executed with TemplateData.
+type Template struct {
+	SessionType int32  `yaml:"sessionType"`
+	ContentType int32  `yaml:"contentType"`
+	Language    string `yaml:"language"`
+	Title       string `yaml:"title"`
+	Content     string `yaml:"content"`
+}
+
+// TemplateConfig is the operator-editable template set, as stored in the config registry.
+type TemplateConfig struct {
+	DefaultLanguage string      `yaml:"defaultLanguage"`
+	Templates       []*Template `yaml:"templates"`
+}
+
+// TemplateData is what templates can refer to. Hidden is set for users who don't allow push content,
+// Sender, Group and Server are empty then and Preview is the generic new message text.
+type TemplateData struct {
+	Sender  string
+	Group   string
+	Server  string
+	Preview string
+	Hidden  bool
+	lang    i18n.Language
+}
+
+// Tr translates key from the i18n bundles in the language of the user, {{ .Tr "msg.push.common.title" }}.
+func (d *TemplateData) Tr(key string) string {
+	return i18n.Tr(d.lang, key)
+}
+
+type compiledTemplate struct {
+	*Template
+	title   *template.Template
+	content *template.Template
+}
+
+// score ranks the templates matching a message, a matching content type beats a matching session type
+// which beats a matching language.
+func (t *compiledTemplate) score(sessionType, contentType int32, lang string) int {
+	score := 0
+	switch t.ContentType {
+	case 0:
+	case contentType:
+		score += 4
+	default:
+		return -1
+	}
+	switch t.SessionType {
+	case 0:
+	case sessionType:
+		score += 2
+	default:
+		return -1
+	}
+	switch t.Language {
+	case "":
+	case lang:
+		score++
+	default:
+		return -1
+	}
+	return score
+}
+
+func (t *compiledTemplate) render(data *TemplateData) (*OfflineMsg, error) {
+	var title, content bytes.Buffer
+	if err := t.title.Execute(&title, data); err != nil {
+		return nil, err
+	}
+	if err := t.content.Execute(&content, data); err != nil {
+		return nil, err
+	}
+	return &OfflineMsg{Title: title.String(), Content: content.String()}, nil
+}
+
+type templateSet struct {
+	defaultLanguage string
+	templates       []*compiledTemplate
+}
+
+// parseTemplates parses and compiles a template set, so a broken one is refused before it is stored.
+func parseTemplates(data []byte) (*templateSet, error) {
+	var conf TemplateConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return nil, err
+	}
+	set := &templateSet{defaultLanguage: conf.DefaultLanguage}
+	if set.defaultLanguage == "" {
+		set.defaultLanguage = defaultPushLanguage
+	}
+	for i, t := range conf.Templates {
+		if t == nil {
+			continue
+		}
+		name := fmt.Sprintf("templates[%d]", i)
+		title, err := template.New(name + ".title").Parse(t.Title)
+		if err != nil {
+			return nil, err
+		}
+		content, err := template.New(name + ".content").Parse(t.Content)
+		if err != nil {
+			return nil, err
+		}
+		compiled := &compiledTemplate{Template: t, title: title, content: content}
+		// fields that don't exist only fail when executed.
+		for _, sample := range []*TemplateData{{}, {Hidden: true}} {
+			if _, err := compiled.render(sample); err != nil {
+				return nil, err
+			}
+		}
+		set.templates = append(set.templates, compiled)
+	}
+	return set, nil
+}
+
+func (s *templateSet) match(sessionType, contentType int32, lang string) *compiledTemplate {
+	var (
+		best      *compiledTemplate
+		bestScore = -1
+	)
+	for _, t := range s.templates {
+		if score := t.score(sessionType, contentType, lang); score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
+
+// templateData collects the names and the localized preview of msg, names are only loaded when shown.
+func (o *OfflineInfoParse) templateData(ctx context.Context, msg *sdkws.MsgData, pushContentMode int32, lang i18n.Language) (*TemplateData, error) {
+	data := &TemplateData{lang: lang}
+	if pushContentMode != constant.NewMsgPushSettingAllowed {
+		data.Hidden = true
+		data.Preview = data.Tr("msg.push.common.base")
+		return data, nil
+	}
+	data.Sender = msg.SenderNickname
+	switch msg.SessionType {
+	case constant.SuperGroupChatType:
+		groupInfo, err := o.groupRpcClient.GetGroupInfo(ctx, msg.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		data.Group = groupInfo.GroupName
+	case constant.ServerGroupChatType:
+		resp, err := o.clubRpcClient.Client.GetServerGroupBaseInfos(ctx, &club.GetServerGroupBaseInfosReq{GroupIDs: []string{msg.GroupID}})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.ServerGroupBaseInfos) > 0 {
+			data.Group = resp.ServerGroupBaseInfos[0].GroupName
+			data.Server = resp.ServerGroupBaseInfos[0].ServerName
+		}
+	}
+	data.Preview = preview(msg, data)
+	return data, nil
+}
+
+// preview is the localized one line summary of msg.
+func preview(msg *sdkws.MsgData, data *TemplateData) string {
+	switch msg.ContentType {
+	case constant.Text:
+		t := apistruct.TextElem{}
+		if err := utils.JsonStringToStruct(string(msg.Content), &t); err == nil {
+			return t.Content
+		}
+	case constant.AtText:
+		t := apistruct.AtElem{}
+		if err := utils.JsonStringToStruct(string(msg.Content), &t); err == nil {
+			return t.Text
+		}
+	case constant.Picture:
+		return data.Tr("msg.push.common.picture")
+	case constant.Voice:
+		return data.Tr("msg.push.common.voice")
+	case constant.Video:
+		return data.Tr("msg.push.common.video")
+	case constant.File:
+		return data.Tr("msg.push.common.file")
+	case constant.Card:
+		return data.Tr("msg.push.common.businessCard")
+	case constant.SignalingInvitedNotification:
+		return data.Tr("msg.push.common.voiceCall")
+	case constant.RedPacket:
+		t := apistruct.RedPacketElem{}
+		if err := utils.JsonStringToStruct(string(msg.Content), &t); err == nil {
+			return i18n.TrWithData(data.lang, "msg.push.common.redPacket", map[string]interface{}{"greetings": t.Greetings})
+		}
+	}
+	return data.Tr("msg.push.common.base")
+}
@@ -0,0 +1,120 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlineinfo
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenIMSDK/tools/discoveryregistry"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+// templateReloadInterval is how often the template set is read from the config registry, which has no watch.
+const templateReloadInterval = 30 * time.Second
+
+// TemplateStore holds the offline push templates. They come from the config registry, or the local
+// push template file as long as the registry has none, and are reloaded when the registry changes.
+type TemplateStore struct {
+	registry discoveryregistry.SvcDiscoveryRegistry
+	mu       sync.RWMutex
+	raw      []byte
+	set      *templateSet
+	loaded   bool
+}
+
+func NewTemplateStore(registry discoveryregistry.SvcDiscoveryRegistry) *TemplateStore {
+	s := &TemplateStore{registry: registry, set: &templateSet{defaultLanguage: defaultPushLanguage}}
+	s.reload()
+	return s
+}
+
+// Watch reloads the template set from the config registry until the process exits.
+func (s *TemplateStore) Watch() {
+	ticker := time.NewTicker(templateReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.reload()
+	}
+}
+
+func (s *TemplateStore) reload() {
+	data, err := s.registry.GetConfFromRegistry(config.PushTemplateConfKey)
+	s.mu.RLock()
+	raw, loaded := s.raw, s.loaded
+	s.mu.RUnlock()
+	if err != nil && loaded {
+		// the registry has no template set or can't be reached, keep the one in use.
+		return
+	}
+	if len(data) == 0 {
+		data = config.PushTemplate
+	}
+	if bytes.Equal(data, raw) {
+		return
+	}
+	set, err := parseTemplates(data)
+	if err != nil {
+		// keep pushing with the last good templates.
+		log.ZError(context.Background(), "parse push templates failed", err)
+		return
+	}
+	s.swap(data, set)
+	log.ZInfo(context.Background(), "push templates loaded", "num", len(set.templates), "defaultLanguage", set.defaultLanguage)
+}
+
+func (s *TemplateStore) swap(data []byte, set *templateSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.raw = data
+	s.set = set
+	s.loaded = true
+}
+
+func (s *TemplateStore) get() *templateSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set
+}
+
+// Raw returns the source of the template set in use.
+func (s *TemplateStore) Raw() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.raw
+}
+
+// Set checks data, stores it in the config registry for every push instance to pick up and uses it at once.
+func (s *TemplateStore) Set(data []byte) error {
+	set, err := parseTemplates(data)
+	if err != nil {
+		return errs.ErrArgs.Wrap("invalid push template: " + err.Error())
+	}
+	if err := s.registry.RegisterConf2Registry(config.PushTemplateConfKey, data); err != nil {
+		return errs.Wrap(err)
+	}
+	s.swap(data, set)
+	return nil
+}
+
+// DefaultLanguage is the language of the pushes to users who never set one.
+func (s *TemplateStore) DefaultLanguage() string {
+	return s.get().defaultLanguage
+}
@@ -0,0 +1,104 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlineinfo
+
+import (
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+)
+
+const testTemplates = `
+templates:
+  - title: "{{ .Sender }}"
+    content: "{{ .Preview }}"
+  - sessionType: 3
+    title: "{{ .Group }}"
+    content: "{{ if .Hidden }}{{ .Preview }}{{ else }}{{ .Sender }}: {{ .Preview }}{{ end }}"
+  - sessionType: 3
+    contentType: 102
+    language: en_US
+    title: "{{ .Group }}"
+    content: "{{ .Sender }} sent a picture"
+`
+
+func TestParseTemplates(t *testing.T) {
+	set, err := parseTemplates([]byte(testTemplates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.defaultLanguage != defaultPushLanguage {
+		t.Errorf("default language %q, want %q", set.defaultLanguage, defaultPushLanguage)
+	}
+	for _, data := range []string{
+		"templates:\n  - title: \"{{ .Nickname }}\"\n",
+		"templates:\n  - content: \"{{ .Sender \"\n",
+		"templates: {",
+	} {
+		if _, err := parseTemplates([]byte(data)); err == nil {
+			t.Errorf("parseTemplates(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestTemplateMatch(t *testing.T) {
+	set, err := parseTemplates([]byte(testTemplates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		sessionType int32
+		contentType int32
+		lang        string
+		want        int
+	}{
+		{"single chat", constant.SingleChatType, constant.Text, "en_US", 0},
+		{"group text", constant.SuperGroupChatType, constant.Text, "en_US", 1},
+		{"group picture", constant.SuperGroupChatType, constant.Picture, "en_US", 2},
+		{"group picture other language", constant.SuperGroupChatType, constant.Picture, "zh_CN", 1},
+	}
+	for _, test := range tests {
+		got := set.match(test.sessionType, test.contentType, test.lang)
+		if got != set.templates[test.want] {
+			t.Errorf("%s: matched %+v, want templates[%d]", test.name, got.Template, test.want)
+		}
+	}
+	if (&templateSet{}).match(constant.SingleChatType, constant.Text, "en_US") != nil {
+		t.Error("empty set matched a template")
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	set, err := parseTemplates([]byte(testTemplates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := set.templates[1]
+	msg, err := group.render(&TemplateData{Sender: "alice", Group: "team", Preview: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Title != "team" || msg.Content != "alice: hi" {
+		t.Errorf("got %+v", msg)
+	}
+	msg, err = group.render(&TemplateData{Hidden: true, Preview: "new message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "new message" {
+		t.Errorf("hidden content %q", msg.Content)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apns
+
+type Alert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type Aps struct {
+	Alert          *Alert `json:"alert,omitempty"`
+	Sound          string `json:"sound,omitempty"`
+	Badge          *int   `json:"badge,omitempty"`
+	ThreadID       string `json:"thread-id,omitempty"`
+	MutableContent int    `json:"mutable-content,omitempty"`
+}
+
+type Payload struct {
+	Aps            Aps    `json:"aps"`
+	Ex             string `json:"ex,omitempty"`
+	ConversationID string `json:"conversationID,omitempty"`
+	ServerID       string `json:"serverID,omitempty"`
+	ContentType    int32  `json:"contentType,omitempty"`
+}
+
+// Resp is the body APNs returns with an error status.
+type Resp struct {
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
@@ -0,0 +1,294 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	productionHost  = "https://api.push.apple.com"
+	developmentHost = "https://api.sandbox.push.apple.com"
+
+	requestTimeout   = 10 * time.Second
+	concurrentLimit  = 32
+	SingleBatchLimit = 1000
+	collapseIDMaxLen = 64
+)
+
+const (
+	pushTypeAlert   = "alert"
+	pushTypeVoIP    = "voip"
+	voipTopicSuffix = ".voip"
+)
+
+// reasons APNs gives for a rejected request.
+const (
+	reasonBadDeviceToken         = "BadDeviceToken"
+	reasonDeviceTokenNotForTopic = "DeviceTokenNotForTopic"
+	reasonUnregistered           = "Unregistered"
+	reasonExpiredProviderToken   = "ExpiredProviderToken"
+	reasonInvalidProviderToken   = "InvalidProviderToken"
+)
+
+// sendResult classifies the outcome of a request for the retry logic.
+type sendResult int
+
+const (
+	sendOK       sendResult = iota
+	sendRetry               // transient failure, worth retrying later
+	sendRejected            // the request itself is refused, retrying fails the same way
+	sendBadToken            // the device token is not valid anymore
+)
+
+// tokenCache is the part of cache.MsgModel the provider uses.
+type tokenCache interface {
+	GetAPNsToken(ctx context.Context, account string) (string, error)
+	DelAPNsToken(ctx context.Context, account string) error
+	GetVoIPToken(ctx context.Context, account string) (string, error)
+	DelVoIPToken(ctx context.Context, account string) error
+}
+
+// APNs pushes to iOS devices directly through the APNs HTTP/2 API, authenticated with a .p8 key.
+// Call invitations go out as VoIP pushes to the devices that registered a VoIP token.
+type APNs struct {
+	cache    tokenCache
+	client   *http.Client
+	host     string
+	bundleID string
+	token    *providerToken
+}
+
+func NewClient(cache tokenCache) (*APNs, error) {
+	conf := config.Config.Push.Apns
+	p8, err := os.ReadFile(filepath.Join(config.GetProjectRoot(), "config", conf.KeyFile))
+	if err != nil {
+		return nil, err
+	}
+	token, err := newProviderToken(p8, conf.KeyID, conf.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	host := developmentHost
+	if config.Config.IOSPush.Production {
+		host = productionHost
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			ForceAttemptHTTP2:   true,
+			MaxIdleConnsPerHost: concurrentLimit,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		Timeout: requestTimeout,
+	}
+	return newClient(cache, client, host, conf.BundleID, token), nil
+}
+
+func newClient(cache tokenCache, client *http.Client, host, bundleID string, token *providerToken) *APNs {
+	return &APNs{cache: cache, client: client, host: host, bundleID: bundleID, token: token}
+}
+
+func (a *APNs) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (a *APNs) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
+	voip := opts.Msg != nil && opts.Msg.ContentType == constant.SignalingInvitedNotification
+	var (
+		mu        sync.Mutex
+		pushErr   = &offlinepush.PushError{}
+		transient bool
+		g         errgroup.Group
+	)
+	g.SetLimit(concurrentLimit)
+	for _, userID := range userIDs {
+		userID := userID
+		g.Go(func() error {
+			result, err := a.pushUser(ctx, userID, title, content, opts, voip)
+			if result == sendOK {
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			pushErr.Err = err
+			switch result {
+			case sendBadToken:
+				pushErr.Unregistered = append(pushErr.Unregistered, offlinepush.UnregisteredToken{
+					UserID: userID, PlatformID: constant.IOSPlatformID,
+				})
+			case sendRetry:
+				transient = true
+				pushErr.RetryUserIDs = append(pushErr.RetryUserIDs, userID)
+			default:
+				pushErr.RetryUserIDs = append(pushErr.RetryUserIDs, userID)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if pushErr.Err == nil {
+		return nil
+	}
+	pushErr.Permanent = !transient
+	return pushErr
+}
+
+// pushUser sends a VoIP push for call invitations when the user registered a VoIP token, and an alert
+// otherwise. Users without an iOS token are skipped.
+func (a *APNs) pushUser(ctx context.Context, userID string, title, content string, opts *offlinepush.Opts, voip bool) (sendResult, error) {
+	if voip {
+		if voipToken, err := a.cache.GetVoIPToken(ctx, userID); err == nil && voipToken != "" {
+			result, err := a.send(ctx, voipToken, pushTypeVoIP, a.bundleID+voipTopicSuffix, "", a.voipPayload(opts))
+			if result != sendBadToken {
+				opts.Receipt.Add(userID, constant.IOSPlatformID, result == sendOK)
+				return result, err
+			}
+			log.ZWarn(ctx, "voip token rejected, fall back to alert", err, "userID", userID)
+			if err := a.cache.DelVoIPToken(ctx, userID); err != nil {
+				log.ZWarn(ctx, "DelVoIPToken failed", err, "userID", userID)
+			}
+		}
+	}
+	deviceToken, err := a.cache.GetAPNsToken(ctx, userID)
+	if err != nil || deviceToken == "" {
+		return sendOK, nil
+	}
+	payload := a.alertPayload(userID, title, content, opts)
+	var collapseID string
+	if opts.Signal != nil && len(opts.Signal.ClientMsgID) <= collapseIDMaxLen {
+		// a retried push replaces the one that may have been delivered already.
+		collapseID = opts.Signal.ClientMsgID
+	}
+	result, err := a.send(ctx, deviceToken, pushTypeAlert, a.bundleID, collapseID, payload)
+	opts.Receipt.Add(userID, constant.IOSPlatformID, result == sendOK)
+	if result == sendBadToken {
+		if err := a.cache.DelAPNsToken(ctx, userID); err != nil {
+			log.ZWarn(ctx, "DelAPNsToken failed", err, "userID", userID)
+		}
+	}
+	return result, err
+}
+
+// alertPayload only sets the badge the push service computed, it is computed once per message so a
+// retried push shows the same number.
+func (a *APNs) alertPayload(userID string, title, content string, opts *offlinepush.Opts) *Payload {
+	payload := a.customPayload(opts)
+	payload.Aps = Aps{
+		Alert:          &Alert{Title: title, Body: content},
+		Sound:          opts.IOSPushSound,
+		MutableContent: 1,
+	}
+	if opts.Msg != nil {
+		payload.Aps.ThreadID = opts.Msg.ConversationID
+	}
+	if badge, ok := opts.Badges[userID]; ok {
+		payload.Aps.Badge = &badge
+	}
+	return payload
+}
+
+// voipPayload carries no alert, the app reports the incoming call to CallKit itself.
+func (a *APNs) voipPayload(opts *offlinepush.Opts) *Payload {
+	return a.customPayload(opts)
+}
+
+func (a *APNs) customPayload(opts *offlinepush.Opts) *Payload {
+	payload := &Payload{Ex: opts.Ex}
+	if opts.Msg != nil {
+		payload.ConversationID = opts.Msg.ConversationID
+		payload.ContentType = opts.Msg.ContentType
+	}
+	if opts.Server != nil {
+		payload.ServerID = opts.Server.ServerID
+	}
+	return payload
+}
+
+// send posts payload to a device, a request refused for an expired provider token is sent once more
+// with a new token.
+func (a *APNs) send(ctx context.Context, deviceToken, pushType, topic, collapseID string, payload *Payload) (sendResult, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return sendRejected, err
+	}
+	result, bearer, err := a.request(ctx, deviceToken, pushType, topic, collapseID, body)
+	if result == sendRetry && bearer != "" {
+		a.token.expire(bearer)
+		result, _, err = a.request(ctx, deviceToken, pushType, topic, collapseID, body)
+	}
+	return result, err
+}
+
+// request returns the bearer used when APNs refused it, so the caller can replace it.
+func (a *APNs) request(ctx context.Context, deviceToken, pushType, topic, collapseID string, body []byte) (sendResult, string, error) {
+	bearer, err := a.token.get(time.Now())
+	if err != nil {
+		return sendRejected, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.host+"/3/device/"+deviceToken, bytes.NewReader(body))
+	if err != nil {
+		return sendRejected, "", err
+	}
+	req.Header.Set("authorization", "bearer "+bearer)
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("apns-topic", topic)
+	req.Header.Set("apns-push-type", pushType)
+	req.Header.Set("apns-priority", "10")
+	if collapseID != "" {
+		req.Header.Set("apns-collapse-id", collapseID)
+	}
+	if pushType == pushTypeVoIP {
+		// a call invitation is useless once the call is over.
+		req.Header.Set("apns-expiration", "0")
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return sendRetry, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return sendOK, "", nil
+	}
+	var r Resp
+	_ = json.NewDecoder(resp.Body).Decode(&r)
+	err = fmt.Errorf("apns status %d, reason %s, apns-id %s", resp.StatusCode, r.Reason, resp.Header.Get("apns-id"))
+	switch {
+	case resp.StatusCode == http.StatusGone, r.Reason == reasonUnregistered,
+		r.Reason == reasonBadDeviceToken, r.Reason == reasonDeviceTokenNotForTopic:
+		return sendBadToken, "", err
+	case r.Reason == reasonExpiredProviderToken, r.Reason == reasonInvalidProviderToken:
+		return sendRetry, bearer, err
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= http.StatusInternalServerError:
+		return sendRetry, "", err
+	default:
+		return sendRejected, "", err
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/OpenIMSDK/protocol/constant"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+)
+
+const (
+	mockBundleID = "io.openim.test"
+	mockKeyID    = "ABC123DEFG"
+	mockTeamID   = "DEF123GHIJ"
+)
+
+type mockTokenCache struct {
+	mu         sync.Mutex
+	tokens     map[string]string
+	voipTokens map[string]string
+}
+
+func (m *mockTokenCache) GetAPNsToken(_ context.Context, account string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if token, ok := m.tokens[account]; ok {
+		return token, nil
+	}
+	return "", redis.Nil
+}
+
+func (m *mockTokenCache) DelAPNsToken(_ context.Context, account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, account)
+	return nil
+}
+
+func (m *mockTokenCache) GetVoIPToken(_ context.Context, account string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if token, ok := m.voipTokens[account]; ok {
+		return token, nil
+	}
+	return "", redis.Nil
+}
+
+func (m *mockTokenCache) DelVoIPToken(_ context.Context, account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.voipTokens, account)
+	return nil
+}
+
+// mockRequest is what the stub server received.
+type mockRequest struct {
+	deviceToken string
+	header      http.Header
+	payload     Payload
+}
+
+// mockAPNs is a local HTTP/2 server answering like APNs, according to the device token:
+// "gone-*" are unregistered, "bad-*" malformed, "busy-*" hit a server error, "large-*" a too large payload.
+type mockAPNs struct {
+	server   *httptest.Server
+	key      *ecdsa.PrivateKey
+	mu       sync.Mutex
+	requests []*mockRequest
+	// expireTokens is the number of requests refused for an expired provider token.
+	expireTokens atomic.Int32
+}
+
+func newMockAPNs(t *testing.T) *mockAPNs {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockAPNs{key: key}
+	m.server = httptest.NewUnstartedServer(http.HandlerFunc(m.handle))
+	m.server.EnableHTTP2 = true
+	m.server.StartTLS()
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockAPNs) reply(w http.ResponseWriter, status int, reason string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&Resp{Reason: reason})
+}
+
+func (m *mockAPNs) handle(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		m.reply(w, http.StatusBadRequest, "NotHTTP2")
+		return
+	}
+	bearer := strings.TrimPrefix(r.Header.Get("authorization"), "bearer ")
+	token, err := jwt.Parse(bearer, func(token *jwt.Token) (any, error) {
+		if token.Header["kid"] != mockKeyID {
+			return nil, errors.New("unknown kid")
+		}
+		return &m.key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
+	if err != nil || token.Claims.(jwt.MapClaims)["iss"] != mockTeamID {
+		m.reply(w, http.StatusForbidden, reasonInvalidProviderToken)
+		return
+	}
+	if m.expireTokens.Add(-1) >= 0 {
+		m.reply(w, http.StatusForbidden, reasonExpiredProviderToken)
+		return
+	}
+	req := &mockRequest{deviceToken: strings.TrimPrefix(r.URL.Path, "/3/device/"), header: r.Header.Clone()}
+	if err := json.NewDecoder(r.Body).Decode(&req.payload); err != nil {
+		m.reply(w, http.StatusBadRequest, "BadPayload")
+		return
+	}
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	m.mu.Unlock()
+	switch {
+	case strings.HasPrefix(req.deviceToken, "gone-"):
+		m.reply(w, http.StatusGone, reasonUnregistered)
+	case strings.HasPrefix(req.deviceToken, "bad-"):
+		m.reply(w, http.StatusBadRequest, reasonBadDeviceToken)
+	case strings.HasPrefix(req.deviceToken, "busy-"):
+		m.reply(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+	case strings.HasPrefix(req.deviceToken, "large-"):
+		m.reply(w, http.StatusRequestEntityTooLarge, "PayloadTooLarge")
+	default:
+		w.Header().Set("apns-id", "mock")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (m *mockAPNs) client(t *testing.T, cache tokenCache) *APNs {
+	der, err := x509.MarshalPKCS8PrivateKey(m.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	token, err := newProviderToken(p8, mockKeyID, mockTeamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newClient(cache, m.server.Client(), m.server.URL, mockBundleID, token)
+}
+
+func (m *mockAPNs) request(deviceToken string) *mockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, req := range m.requests {
+		if req.deviceToken == deviceToken {
+			return req
+		}
+	}
+	return nil
+}
+
+func mockOpts(contentType int32) *offlinepush.Opts {
+	return &offlinepush.Opts{
+		Signal:       &offlinepush.Signal{ClientMsgID: "client-msg-id"},
+		IOSPushSound: "ring.caf",
+		Ex:           "ex",
+		Server:       &offlinepush.Server{},
+		Msg:          &offlinepush.Msg{ConversationID: "si_a_b", ContentType: contentType},
+	}
+}
+
+func TestPushAlert(t *testing.T) {
+	stub := newMockAPNs(t)
+	cache := &mockTokenCache{tokens: map[string]string{"u1": "token-u1"}}
+	opts := mockOpts(constant.Text)
+	opts.IOSBadgeCount = true
+	opts.Badges = map[string]int{"u1": 3}
+	if err := stub.client(t, cache).Push(context.Background(), []string{"u1", "no-token"}, "title", "content", opts); err != nil {
+		t.Fatal(err)
+	}
+	req := stub.request("token-u1")
+	if req == nil {
+		t.Fatal("no request sent")
+	}
+	if got := req.header.Get("apns-push-type"); got != pushTypeAlert {
+		t.Errorf("apns-push-type %q", got)
+	}
+	if got := req.header.Get("apns-topic"); got != mockBundleID {
+		t.Errorf("apns-topic %q", got)
+	}
+	if got := req.header.Get("apns-collapse-id"); got != "client-msg-id" {
+		t.Errorf("apns-collapse-id %q", got)
+	}
+	aps := req.payload.Aps
+	if aps.Alert == nil || aps.Alert.Title != "title" || aps.Alert.Body != "content" {
+		t.Errorf("alert %+v", aps.Alert)
+	}
+	if aps.Sound != "ring.caf" || aps.ThreadID != "si_a_b" {
+		t.Errorf("sound %q, thread-id %q", aps.Sound, aps.ThreadID)
+	}
+	if aps.Badge == nil || *aps.Badge != 3 {
+		t.Errorf("badge %v", aps.Badge)
+	}
+	if req.payload.Ex != "ex" || req.payload.ContentType != constant.Text {
+		t.Errorf("payload %+v", req.payload)
+	}
+}
+
+func TestPushBadge(t *testing.T) {
+	stub := newMockAPNs(t)
+	cache := &mockTokenCache{tokens: map[string]string{"u1": "token-u1", "u2": "token-u2"}}
+	opts := mockOpts(constant.Text)
+	opts.Badges = map[string]int{"u1": 7}
+	client := stub.client(t, cache)
+	for i := 0; i < 2; i++ {
+		// a retry sends the same badge again.
+		if err := client.Push(context.Background(), []string{"u1", "u2"}, "title", "content", opts); err != nil {
+			t.Fatal(err)
+		}
+		if req := stub.request("token-u1"); req == nil || req.payload.Aps.Badge == nil || *req.payload.Aps.Badge != 7 {
+			t.Errorf("u1 should get the server badge, request %+v", req)
+		}
+	}
+	if req := stub.request("token-u2"); req == nil || req.payload.Aps.Badge != nil {
+		t.Errorf("u2 has no badge computed, the badge must be left alone, request %+v", req)
+	}
+}
+
+func TestPushClassifiesFailures(t *testing.T) {
+	stub := newMockAPNs(t)
+	cache := &mockTokenCache{
+		tokens: map[string]string{
+			"ok": "token-ok", "gone": "gone-1", "bad": "bad-1", "busy": "busy-1", "large": "large-1",
+		},
+	}
+	err := stub.client(t, cache).Push(context.Background(), []string{"ok", "gone", "bad", "busy", "large"}, "title", "content", mockOpts(constant.Text))
+	var pushErr *offlinepush.PushError
+	if !errors.As(err, &pushErr) {
+		t.Fatalf("expected PushError, got %v", err)
+	}
+	unregistered := make(map[string]bool)
+	for _, token := range pushErr.Unregistered {
+		if token.PlatformID != constant.IOSPlatformID {
+			t.Errorf("platformID %d", token.PlatformID)
+		}
+		unregistered[token.UserID] = true
+	}
+	if len(unregistered) != 2 || !unregistered["gone"] || !unregistered["bad"] {
+		t.Errorf("unregistered %v", pushErr.Unregistered)
+	}
+	for _, userID := range []string{"gone", "bad"} {
+		if _, ok := cache.tokens[userID]; ok {
+			t.Errorf("token of %s wasn't deleted", userID)
+		}
+	}
+	if _, ok := cache.tokens["busy"]; !ok {
+		t.Error("a transient failure must keep the token")
+	}
+	retry := make(map[string]bool)
+	for _, userID := range pushErr.RetryUserIDs {
+		retry[userID] = true
+	}
+	if len(retry) != 2 || !retry["busy"] || !retry["large"] {
+		t.Errorf("retry %v", pushErr.RetryUserIDs)
+	}
+	if pushErr.Permanent {
+		t.Error("a server error is transient")
+	}
+}
+
+func TestPushOnlyRejected(t *testing.T) {
+	stub := newMockAPNs(t)
+	cache := &mockTokenCache{tokens: map[string]string{"gone": "gone-1", "large": "large-1"}}
+	err := stub.client(t, cache).Push(context.Background(), []string{"gone", "large"}, "title", "content", mockOpts(constant.Text))
+	var pushErr *offlinepush.PushError
+	if !errors.As(err, &pushErr) {
+		t.Fatalf("expected PushError, got %v", err)
+	}
+	if !pushErr.Permanent {
+		t.Error("retrying a rejected request can't succeed")
+	}
+}
+
+func TestPushVoIP(t *testing.T) {
+	stub := newMockAPNs(t)
+	cache := &mockTokenCache{
+		tokens:     map[string]string{"u1": "token-u1", "u2": "token-u2"},
+		voipTokens: map[string]string{"u1": "voip-u1", "u2": "gone-voip-u2"},
+	}
+	opts := mockOpts(constant.SignalingInvitedNotification)
+	if err := stub.client(t, cache).Push(context.Background(), []string{"u1", "u2"}, "title", "content", opts); err != nil {
+		t.Fatal(err)
+	}
+	req := stub.request("voip-u1")
+	if req == nil {
+		t.Fatal("no voip push sent")
+	}
+	if got := req.header.Get("apns-push-type"); got != pushTypeVoIP {
+		t.Errorf("apns-push-type %q", got)
+	}
+	if got := req.header.Get("apns-topic"); got != mockBundleID+voipTopicSuffix {
+		t.Errorf("apns-topic %q", got)
+	}
+	if got := req.header.Get("apns-expiration"); got != "0" {
+		t.Errorf("apns-expiration %q", got)
+	}
+	if req.payload.Aps.Alert != nil || req.payload.ContentType != constant.SignalingInvitedNotification {
+		t.Errorf("payload %+v", req.payload)
+	}
+	if stub.request("token-u1") != nil {
+		t.Error("alert sent although the voip push succeeded")
+	}
+	// u2's voip token is gone, it falls back to an alert and the token is dropped.
+	if stub.request("token-u2") == nil {
+		t.Error("no fallback alert sent")
+	}
+	if _, err := cache.GetVoIPToken(context.Background(), "u2"); err == nil {
+		t.Error("unregistered voip token kept")
+	}
+}
+
+func TestPushRefreshesExpiredProviderToken(t *testing.T) {
+	stub := newMockAPNs(t)
+	stub.expireTokens.Store(1)
+	cache := &mockTokenCache{tokens: map[string]string{"u1": "token-u1"}}
+	if err := stub.client(t, cache).Push(context.Background(), []string{"u1"}, "title", "content", mockOpts(constant.Text)); err != nil {
+		t.Fatal(err)
+	}
+	if stub.request("token-u1") == nil {
+		t.Fatal("push not sent again with a new provider token")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apns
+
+import (
+	"crypto/ecdsa"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// tokenRefreshInterval stays below the hour APNs accepts a provider token for, and above the
+// 20 minutes it wants between two refreshes.
+const tokenRefreshInterval = 50 * time.Minute
+
+// providerToken signs and caches the JWT authenticating requests with a .p8 key.
+type providerToken struct {
+	key    *ecdsa.PrivateKey
+	keyID  string
+	teamID string
+
+	mu       sync.Mutex
+	bearer   string
+	issuedAt time.Time
+}
+
+func newProviderToken(p8 []byte, keyID, teamID string) (*providerToken, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(p8)
+	if err != nil {
+		return nil, err
+	}
+	return &providerToken{key: key, keyID: keyID, teamID: teamID}, nil
+}
+
+// get returns the current token, signing a new one when it is about to expire.
+func (t *providerToken) get(now time.Time) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.bearer != "" && now.Sub(t.issuedAt) < tokenRefreshInterval {
+		return t.bearer, nil
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": t.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = t.keyID
+	bearer, err := token.SignedString(t.key)
+	if err != nil {
+		return "", err
+	}
+	t.bearer = bearer
+	t.issuedAt = now
+	return bearer, nil
+}
+
+// expire drops bearer, APNs rejected it.
+func (t *providerToken) expire(bearer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.bearer == bearer {
+		t.bearer = ""
+	}
+}
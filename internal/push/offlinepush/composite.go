@@ -0,0 +1,117 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlinepush
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// NamedPusher is a pusher of a Composite, the name tells it apart in Opts.Pushers and PushError.Pushers.
+type NamedPusher struct {
+	Name string
+	OfflinePusher
+}
+
+// Composite fans every push out to several pushers, each reaching the devices it has tokens for.
+type Composite struct {
+	pushers []NamedPusher
+}
+
+func NewComposite(pushers ...NamedPusher) *Composite {
+	return &Composite{pushers: pushers}
+}
+
+// BatchSize is the smallest limit of the pushers.
+func (c *Composite) BatchSize() int {
+	size := 0
+	for _, pusher := range c.pushers {
+		if limiter, ok := pusher.OfflinePusher.(BatchLimiter); ok && limiter.BatchSize() > 0 && (size == 0 || limiter.BatchSize() < size) {
+			size = limiter.BatchSize()
+		}
+	}
+	return size
+}
+
+// Push merges the failures of the pushers, and keeps the error of each one in PushError.Pushers so a
+// retry only goes through the pushers that failed.
+func (c *Composite) Push(ctx context.Context, userIDs []string, title, content string, opts *Opts) error {
+	pushers := c.selectPushers(opts)
+	errs := make([]error, len(pushers))
+	var wg sync.WaitGroup
+	for i, pusher := range pushers {
+		wg.Add(1)
+		go func(i int, pusher OfflinePusher) {
+			defer wg.Done()
+			errs[i] = pusher.Push(ctx, userIDs, title, content, opts)
+		}(i, pusher.OfflinePusher)
+	}
+	wg.Wait()
+
+	var (
+		merged   = &PushError{Permanent: true, Pushers: make(map[string]error)}
+		retry    = make(map[string]struct{})
+		retryAll bool
+	)
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		merged.Err = err
+		merged.Pushers[pushers[i].Name] = err
+		var pushErr *PushError
+		if !errors.As(err, &pushErr) {
+			merged.Permanent = false
+			retryAll = true
+			continue
+		}
+		merged.Unregistered = append(merged.Unregistered, pushErr.Unregistered...)
+		merged.Permanent = merged.Permanent && pushErr.Permanent
+		if len(pushErr.RetryUserIDs) == 0 && len(pushErr.Unregistered) == 0 {
+			retryAll = true
+		}
+		for _, userID := range pushErr.RetryUserIDs {
+			retry[userID] = struct{}{}
+		}
+	}
+	if merged.Err == nil {
+		return nil
+	}
+	if retryAll {
+		merged.RetryUserIDs = userIDs
+	} else {
+		for userID := range retry {
+			merged.RetryUserIDs = append(merged.RetryUserIDs, userID)
+		}
+	}
+	return merged
+}
+
+func (c *Composite) selectPushers(opts *Opts) []NamedPusher {
+	if opts == nil || len(opts.Pushers) == 0 {
+		return c.pushers
+	}
+	pushers := make([]NamedPusher, 0, len(opts.Pushers))
+	for _, pusher := range c.pushers {
+		for _, name := range opts.Pushers {
+			if pusher.Name == name {
+				pushers = append(pushers, pusher)
+				break
+			}
+		}
+	}
+	return pushers
+}
@@ -0,0 +1,72 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlinepush
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+)
+
+type mockPusher struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (m *mockPusher) Push(context.Context, []string, string, string, *Opts) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	return m.err
+}
+
+func TestCompositePerPusherFailures(t *testing.T) {
+	ok := &mockPusher{}
+	partial := &mockPusher{err: &PushError{Err: errors.New("busy"), RetryUserIDs: []string{"b"}}}
+	down := &mockPusher{err: errors.New("timeout")}
+	c := NewComposite(NamedPusher{"ok", ok}, NamedPusher{"partial", partial}, NamedPusher{"down", down})
+
+	err := c.Push(context.Background(), []string{"a", "b"}, "title", "content", &Opts{})
+	var pushErr *PushError
+	if !errors.As(err, &pushErr) {
+		t.Fatalf("expected PushError, got %v", err)
+	}
+	if len(pushErr.Pushers) != 2 || pushErr.Pushers["partial"] != partial.err || pushErr.Pushers["down"] != down.err {
+		t.Errorf("pushers %v", pushErr.Pushers)
+	}
+	sort.Strings(pushErr.RetryUserIDs)
+	if len(pushErr.RetryUserIDs) != 2 {
+		t.Errorf("retry %v, a pusher failing for everyone retries everyone", pushErr.RetryUserIDs)
+	}
+
+	// a retry only goes through the pushers named in the opts.
+	err = c.Push(context.Background(), []string{"b"}, "title", "content", &Opts{Pushers: []string{"partial"}})
+	if ok.calls != 1 || down.calls != 1 || partial.calls != 2 {
+		t.Errorf("calls ok %d, partial %d, down %d", ok.calls, partial.calls, down.calls)
+	}
+	if !errors.As(err, &pushErr) || len(pushErr.Pushers) != 1 {
+		t.Errorf("retry error %v", err)
+	}
+}
+
+func TestCompositeAllDelivered(t *testing.T) {
+	c := NewComposite(NamedPusher{"a", &mockPusher{}}, NamedPusher{"b", &mockPusher{}})
+	if err := c.Push(context.Background(), []string{"a"}, "title", "content", &Opts{}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"google.golang.org/api/option"
 
 	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/tools/log"
 
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
@@ -56,39 +57,94 @@ func NewClient(cache cache.MsgModel) *Fcm {
 	return &Fcm{fcmMsgCli: fcmMsgClient, cache: cache}
 }
 
+func (f *Fcm) BatchSize() int {
+	return SinglePushCountLimit
+}
+
+// fcmTarget is the user and platform a message of a batch was sent to.
+type fcmTarget struct {
+	userID     string
+	platformID int
+}
+
 func (f *Fcm) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
 	// accounts->registrationToken
-	allTokens := make(map[string][]string, 0)
+	allTokens := make(map[string]map[int]string, 0)
 	for _, account := range userIDs {
-		var personTokens []string
+		personTokens := make(map[int]string)
 		for _, v := range Terminal {
 			Token, err := f.cache.GetFcmToken(ctx, account, v)
 			if err == nil {
-				personTokens = append(personTokens, Token)
+				personTokens[v] = Token
 			}
 		}
 		allTokens[account] = personTokens
 	}
-	Success := 0
-	Fail := 0
+	var (
+		Success   = 0
+		Fail      = 0
+		pushErr   = &offlinepush.PushError{}
+		retryUser = make(map[string]struct{})
+		transient bool
+	)
+	sendAll := func(messages []*messaging.Message, targets []fcmTarget) {
+		response, err := f.fcmMsgCli.SendAll(ctx, messages)
+		if err != nil {
+			Fail = Fail + len(messages)
+			pushErr.Err = err
+			transient = true
+			for _, target := range targets {
+				retryUser[target.userID] = struct{}{}
+				opts.Receipt.Add(target.userID, target.platformID, false)
+			}
+			return
+		}
+		Success = Success + response.SuccessCount
+		Fail = Fail + response.FailureCount
+		for i, resp := range response.Responses {
+			if i >= len(targets) {
+				continue
+			}
+			opts.Receipt.Add(targets[i].userID, targets[i].platformID, resp.Success)
+			if resp.Success {
+				continue
+			}
+			pushErr.Err = resp.Error
+			switch {
+			case messaging.IsRegistrationTokenNotRegistered(resp.Error) || messaging.IsMismatchedCredential(resp.Error):
+				// UNREGISTERED or SENDER_ID_MISMATCH, the token itself is unusable.
+				pushErr.Unregistered = append(pushErr.Unregistered, offlinepush.UnregisteredToken{
+					UserID: targets[i].userID, PlatformID: targets[i].platformID,
+				})
+				continue
+			case messaging.IsInvalidArgument(resp.Error):
+				// the message was rejected, e.g. data too large, the token is kept.
+			default:
+				transient = true
+			}
+			retryUser[targets[i].userID] = struct{}{}
+		}
+	}
 	notification := &messaging.Notification{}
 	notification.Body = content
 	notification.Title = title
-	var messages []*messaging.Message
+	var (
+		messages []*messaging.Message
+		targets  []fcmTarget
+	)
 	for userID, personTokens := range allTokens {
 		apns := &messaging.APNSConfig{Payload: &messaging.APNSPayload{Aps: &messaging.Aps{Sound: opts.IOSPushSound}}}
 		messageCount := len(messages)
 		if messageCount >= SinglePushCountLimit {
-			response, err := f.fcmMsgCli.SendAll(ctx, messages)
-			if err != nil {
-				Fail = Fail + messageCount
-			} else {
-				Success = Success + response.SuccessCount
-				Fail = Fail + response.FailureCount
-			}
+			sendAll(messages, targets)
 			messages = messages[0:0]
+			targets = targets[0:0]
 		}
-		if opts.IOSBadgeCount {
+		var android *messaging.AndroidConfig
+		if badge, ok := opts.Badges[userID]; ok {
+			apns.Payload.Aps.Badge = &badge
+			android = &messaging.AndroidConfig{Notification: &messaging.AndroidNotification{NotificationCount: &badge}}
+		} else if opts.IOSBadgeCount {
 			unreadCountSum, err := f.cache.IncrUserBadgeUnreadCountSum(ctx, userID)
 			if err == nil {
 				apns.Payload.Aps.Badge = &unreadCountSum
@@ -110,26 +166,33 @@ func (f *Fcm) Push(ctx context.Context, userIDs []string, title, content string,
 				continue
 			}
 		}
-		for _, token := range personTokens {
+		for platformID, token := range personTokens {
 			temp := &messaging.Message{
 				Data:         map[string]string{"ex": opts.Ex},
 				Token:        token,
 				Notification: notification,
+				Android:      android,
 				APNS:         apns,
 			}
 			messages = append(messages, temp)
+			targets = append(targets, fcmTarget{userID: userID, platformID: platformID})
 		}
 	}
-	messageCount := len(messages)
-	if messageCount > 0 {
-		response, err := f.fcmMsgCli.SendAll(ctx, messages)
-		if err != nil {
-			Fail = Fail + messageCount
-			// log.Info(operationID, "some token push err", err.Error(), messageCount)
-		} else {
-			Success = Success + response.SuccessCount
-			Fail = Fail + response.FailureCount
+	if len(messages) > 0 {
+		sendAll(messages, targets)
+	}
+	if pushErr.Err == nil {
+		return nil
+	}
+	for userID := range retryUser {
+		pushErr.RetryUserIDs = append(pushErr.RetryUserIDs, userID)
+	}
+	for _, token := range pushErr.Unregistered {
+		if err := f.cache.DelFcmToken(ctx, token.UserID, token.PlatformID); err != nil {
+			log.ZWarn(ctx, "DelFcmToken failed", err, "userID", token.UserID, "platformID", token.PlatformID)
 		}
 	}
-	return nil
+	// sending again can't help when only unregistered tokens or rejected messages failed.
+	pushErr.Permanent = !transient
+	return pushErr
 }
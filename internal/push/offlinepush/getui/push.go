@@ -53,6 +53,8 @@ const (
 	tokenExpireCode = 10001
 	tokenExpireTime = 60 * 60 * 23
 	taskIDTTL       = 1000 * 60 * 60 * 24
+	// aliases accepted by one batchPushURL request.
+	batchPushLimit = 999
 )
 
 type Client struct {
@@ -65,6 +67,10 @@ func NewClient(cache cache.MsgModel) *Client {
 	return &Client{cache: cache, tokenExpireTime: tokenExpireTime, taskIDTTL: taskIDTTL}
 }
 
+func (g *Client) BatchSize() int {
+	return batchPushLimit
+}
+
 func (g *Client) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
 	token, err := g.cache.GetGetuiToken(ctx)
 	if err != nil {
@@ -81,9 +87,8 @@ func (g *Client) Push(ctx context.Context, userIDs []string, title, content stri
 	pushReq := newPushReq(title, content)
 	pushReq.setPushChannel(title, content)
 	if len(userIDs) > 1 {
-		maxNum := 999
-		if len(userIDs) > maxNum {
-			s := splitter.NewSplitter(maxNum, userIDs)
+		if len(userIDs) > batchPushLimit {
+			s := splitter.NewSplitter(batchPushLimit, userIDs)
 			wg := sync.WaitGroup{}
 			wg.Add(len(s.GetSplitResult()))
 			for i, v := range s.GetSplitResult() {
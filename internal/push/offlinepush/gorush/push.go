@@ -46,29 +46,46 @@ func NewClient(cache cache.MsgModel) *Gorush {
 	return &Gorush{cache: cache}
 }
 
+// BatchSize keeps the notifications of one Push, one per terminal of every user, in a single request.
+func (g *Gorush) BatchSize() int {
+	return chunkSize / len(Terminal)
+}
+
+// target is the user and platform of a notification.
+type target struct {
+	userID     string
+	platformID int
+}
+
 func (g *Gorush) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
-	var notifications []*Notification
+	var (
+		notifications []*Notification
+		targets       []target
+	)
 	for _, userID := range userIDs {
 		for _, v := range Terminal {
 			token, err := g.cache.GetFcmToken(ctx, userID, v)
 			if err != nil {
 				continue
 			}
-			badge := 0
-			if v == constant.IOSPlatformID {
-				unreadCountSum, err := g.cache.IncrUserBadgeUnreadCountSum(ctx, userID)
-				if err == nil {
+			badge, ok := opts.Badges[userID]
+			if !ok {
+				if v == constant.IOSPlatformID {
+					unreadCountSum, err := g.cache.IncrUserBadgeUnreadCountSum(ctx, userID)
+					if err == nil {
+						badge = unreadCountSum
+					}
+				}
+				unreadCountSum, err := g.cache.GetUserBadgeUnreadCountSum(ctx, userID)
+				if err == nil && unreadCountSum != 0 {
 					badge = unreadCountSum
+				} else if err == redis.Nil || unreadCountSum == 0 {
+					badge = 1
 				}
 			}
-			unreadCountSum, err := g.cache.GetUserBadgeUnreadCountSum(ctx, userID)
-			if err == nil && unreadCountSum != 0 {
-				badge = unreadCountSum
-			} else if err == redis.Nil || unreadCountSum == 0 {
-				badge = 1
-			}
 			notification := NewNotification([]string{token}, v, title, content, opts, badge)
 			notifications = append(notifications, notification)
+			targets = append(targets, target{userID: userID, platformID: v})
 		}
 	}
 	for i := 0; i < len(notifications); i += chunkSize {
@@ -77,9 +94,12 @@ func (g *Gorush) Push(ctx context.Context, userIDs []string, title, content stri
 			end = len(notifications)
 		}
 		chunk := notifications[i:end]
-		if err := g.request(ctx, Notifications{Notifications: chunk}); err != nil {
+		err := g.request(ctx, Notifications{Notifications: chunk})
+		if err != nil {
 			log.ZError(ctx, "gorush push notifications failed", err, "notifications length", len(chunk), "title", title)
-			continue
+		}
+		for _, t := range targets[i:end] {
+			opts.Receipt.Add(t.userID, t.platformID, err == nil)
 		}
 	}
 	return nil
@@ -46,6 +46,11 @@ func (j *JPush) getAuthorization(appKey string, masterSecret string) string {
 	return Authorization
 }
 
+// BatchSize jpush accepts up to 1000 aliases in one audience.
+func (j *JPush) BatchSize() int {
+	return 1000
+}
+
 func (j *JPush) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
 	var pf body.Platform
 	pf.SetAll()
@@ -23,6 +23,11 @@ type OfflinePusher interface {
 	Push(ctx context.Context, userIDs []string, title, content string, opts *Opts) error
 }
 
+// BatchLimiter is implemented by offline pushers that accept at most BatchSize users in one Push call.
+type BatchLimiter interface {
+	BatchSize() int
+}
+
 // Opts opts.
 type Opts struct {
 	Signal        *Signal
@@ -31,6 +36,14 @@ type Opts struct {
 	Ex            string
 	Server        *Server
 	Msg           *Msg
+	// Badges is the exact badge of each user, computed by the push service. Users missing from it get
+	// the badge the pusher keeps on its own.
+	Badges map[string]int
+	// Pushers limits a Composite to the pushers of these names, a retry only goes through the pushers
+	// that failed. Empty means all.
+	Pushers []string
+	// Receipt, when set, collects the platforms the pushers sent to.
+	Receipt *Receipt `json:"-"`
 }
 
 // Signal message id.
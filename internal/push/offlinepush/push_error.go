@@ -0,0 +1,46 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlinepush
+
+import "fmt"
+
+// UnregisteredToken is a device token the provider no longer accepts. The pusher owning the token
+// already deleted it, tokens of other pushers are kept.
+type UnregisteredToken struct {
+	UserID     string
+	PlatformID int
+}
+
+// PushError lets a pusher tell which part of a Push call failed and whether retrying can help.
+// Errors of another type are considered transient for every user of the call.
+type PushError struct {
+	Err error
+	// Permanent means sending the same request again fails the same way.
+	Permanent bool
+	// RetryUserIDs, when not empty, are the only users worth retrying.
+	RetryUserIDs []string
+	Unregistered []UnregisteredToken
+	// Pushers is set by a Composite, the error of each pusher that failed by pusher name.
+	Pushers map[string]error
+}
+
+func (e *PushError) Error() string {
+	return fmt.Sprintf("offline push failed, permanent: %t, retry: %d, unregistered: %d, err: %v",
+		e.Permanent, len(e.RetryUserIDs), len(e.Unregistered), e.Err)
+}
+
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
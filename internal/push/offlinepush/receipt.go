@@ -0,0 +1,57 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlinepush
+
+import "sync"
+
+// Receipt collects the platforms of the devices a push was sent to, pushers that know the device of a
+// token report each send to it. Reports to a nil Receipt are ignored.
+type Receipt struct {
+	mu        sync.Mutex
+	platforms map[string]map[int]bool
+}
+
+func NewReceipt() *Receipt {
+	return &Receipt{platforms: make(map[string]map[int]bool)}
+}
+
+// Add reports a send to a device of the user, a device that accepted the push once stays accepted.
+func (r *Receipt) Add(userID string, platformID int, ok bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	platforms := r.platforms[userID]
+	if platforms == nil {
+		platforms = make(map[int]bool)
+		r.platforms[userID] = platforms
+	}
+	platforms[platformID] = platforms[platformID] || ok
+}
+
+// Platforms returns whether the push was accepted by each reported platform of the user.
+func (r *Receipt) Platforms(userID string) map[int]bool {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	platforms := make(map[int]bool, len(r.platforms[userID]))
+	for platformID, ok := range r.platforms[userID] {
+		platforms[platformID] = ok
+	}
+	return platforms
+}
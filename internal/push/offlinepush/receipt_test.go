@@ -0,0 +1,57 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offlinepush
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestReceipt(t *testing.T) {
+	r := NewReceipt()
+	r.Add("a", 1, false)
+	r.Add("a", 1, true)
+	r.Add("a", 1, false)
+	r.Add("a", 2, false)
+	if got, want := r.Platforms("a"), map[int]bool{1: true, 2: false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("platforms %v, want %v", got, want)
+	}
+	if got := r.Platforms("b"); len(got) != 0 {
+		t.Errorf("platforms of an unreported user %v", got)
+	}
+
+	var nilReceipt *Receipt
+	nilReceipt.Add("a", 1, true)
+	if got := nilReceipt.Platforms("a"); got != nil {
+		t.Errorf("nil receipt platforms %v", got)
+	}
+}
+
+func TestReceiptNotMarshaled(t *testing.T) {
+	opts := &Opts{Receipt: NewReceipt()}
+	opts.Receipt.Add("a", 1, true)
+	data, err := json.Marshal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Opts
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Receipt != nil {
+		t.Errorf("receipt was marshaled: %s", data)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package honor
+
+// clickActionOpenApp opens the app when the notification is tapped.
+const clickActionOpenApp = 3
+
+type AuthResp struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Error       string `json:"error"`
+}
+
+type ClickAction struct {
+	Type int `json:"type"`
+}
+
+type AndroidNotification struct {
+	Title       string      `json:"title"`
+	Body        string      `json:"body"`
+	Tag         string      `json:"tag,omitempty"`
+	ClickAction ClickAction `json:"clickAction"`
+	Importance  string      `json:"importance,omitempty"`
+}
+
+type Android struct {
+	Notification AndroidNotification `json:"notification"`
+}
+
+type PushReq struct {
+	Data    string   `json:"data,omitempty"`
+	Android Android  `json:"android"`
+	Token   []string `json:"token"`
+}
+
+type PushResult struct {
+	SendResult   bool     `json:"sendResult"`
+	RequestID    string   `json:"requestId"`
+	FailTokens   []string `json:"failTokens"`
+	ExpireTokens []string `json:"expireTokens"`
+}
+
+type PushResp struct {
+	Code    int        `json:"code"`
+	Message string     `json:"message"`
+	Data    PushResult `json:"data"`
+}
@@ -0,0 +1,126 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package honor
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	authURL = "https://iam.developer.honor.com/auth/token"
+	pushURL = "https://push-api.cloud.honor.com/api/v1/%s/sendMessage"
+
+	SingleBatchLimit = 1000
+)
+
+// result codes of Honor Push.
+const (
+	codeSuccess          = 200
+	codeAllTokensInvalid = 80300007
+	codeAuthExpired      = 80200003
+)
+
+// Honor sends through Honor Push.
+type Honor struct {
+	appID   string
+	pushURL string
+	token   *vendorpush.AccessToken
+}
+
+func NewClient() *Honor {
+	conf := config.Config.Push.Vendor.Honor
+	return newClient(conf.AppID, conf.ClientID, conf.ClientSecret, authURL, pushURL)
+}
+
+func newClient(appID, clientID, clientSecret, authURL, pushURL string) *Honor {
+	h := &Honor{appID: appID, pushURL: pushURL}
+	h.token = vendorpush.NewAccessToken(func(ctx context.Context) (string, time.Duration, error) {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {clientSecret},
+		}
+		var resp AuthResp
+		if err := vendorpush.PostForm(ctx, authURL, nil, form, &resp); err != nil {
+			return "", 0, err
+		}
+		if resp.AccessToken == "" {
+			return "", 0, fmt.Errorf("%w: honor auth failed, %s", vendorpush.ErrRejected, resp.Error)
+		}
+		return resp.AccessToken, time.Duration(resp.ExpiresIn) * time.Second, nil
+	})
+	return h
+}
+
+func (h *Honor) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (h *Honor) Send(ctx context.Context, tokens []string, n *vendorpush.Notification) (*vendorpush.Result, error) {
+	req := &PushReq{
+		Data: n.Payload(),
+		Android: Android{
+			Notification: AndroidNotification{
+				Title:       n.Title,
+				Body:        n.Content,
+				Tag:         n.ClientMsgID,
+				ClickAction: ClickAction{Type: clickActionOpenApp},
+				Importance:  "NORMAL",
+			},
+		},
+		Token: tokens,
+	}
+	resp, err := h.send(ctx, req)
+	if err == nil && resp.Code == codeAuthExpired {
+		resp, err = h.send(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch resp.Code {
+	case codeSuccess:
+		return &vendorpush.Result{InvalidTokens: resp.Data.ExpireTokens}, nil
+	case codeAllTokensInvalid:
+		return &vendorpush.Result{InvalidTokens: tokens}, nil
+	default:
+		return nil, fmt.Errorf("%w: honor code %d, message %s", vendorpush.ErrRejected, resp.Code, resp.Message)
+	}
+}
+
+func (h *Honor) send(ctx context.Context, req *PushReq) (*PushResp, error) {
+	token, err := h.token.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp PushResp
+	header := map[string]string{
+		"Authorization": "Bearer " + token,
+		"timestamp":     strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}
+	if err := vendorpush.PostJSON(ctx, fmt.Sprintf(h.pushURL, h.appID), header, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Code == codeAuthExpired {
+		h.token.Expire(token)
+	}
+	return &resp, nil
+}
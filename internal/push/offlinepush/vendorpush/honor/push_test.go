@@ -0,0 +1,133 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package honor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/vendorpushtest"
+)
+
+const (
+	mockAppID        = "app-id"
+	mockClientID     = "client-id"
+	mockClientSecret = "client-secret"
+)
+
+// mockHonor answers like Honor Push according to the first token of a push, "expire-*" are invalid one
+// by one.
+type mockHonor struct {
+	*vendorpushtest.Server
+	pushes []*PushReq
+}
+
+func newMockHonor(t *testing.T) *mockHonor {
+	m := &mockHonor{}
+	m.Server = vendorpushtest.NewAuthServer(t, "/auth/token", m)
+	return m
+}
+
+func (m *mockHonor) Auth(w http.ResponseWriter, r *http.Request, token string) bool {
+	_ = r.ParseForm()
+	if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_id") != mockClientID ||
+		r.PostForm.Get("client_secret") != mockClientSecret {
+		_ = json.NewEncoder(w).Encode(&AuthResp{Error: "invalid_client"})
+		return false
+	}
+	_ = json.NewEncoder(w).Encode(&AuthResp{AccessToken: token, ExpiresIn: 3600})
+	return true
+}
+
+func (m *mockHonor) AccessToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func (m *mockHonor) Expired(w http.ResponseWriter) {
+	_ = json.NewEncoder(w).Encode(&PushResp{Code: codeAuthExpired})
+}
+
+func (m *mockHonor) Push(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/"+mockAppID+"/sendMessage" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if _, err := strconv.ParseInt(r.Header.Get("timestamp"), 10, 64); err != nil {
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: 80100001, Message: "no timestamp"})
+		return
+	}
+	var req PushReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.pushes = append(m.pushes, &req)
+	switch token := req.Token[0]; {
+	case strings.HasPrefix(token, "gone-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: codeAllTokensInvalid})
+	case strings.HasPrefix(token, "expire-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: codeSuccess, Data: PushResult{ExpireTokens: req.Token[1:]}})
+	case strings.HasPrefix(token, "busy-"):
+		w.WriteHeader(http.StatusInternalServerError)
+	case strings.HasPrefix(token, "bad-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: 80100003, Message: "illegal payload"})
+	default:
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: codeSuccess})
+	}
+}
+
+func (m *mockHonor) client(clientSecret string) *Honor {
+	return newClient(mockAppID, mockClientID, clientSecret, m.URL+"/auth/token", m.URL+"/api/v1/%s/sendMessage")
+}
+
+func TestSend(t *testing.T) {
+	stub := newMockHonor(t)
+	client := stub.client(mockClientSecret)
+	for i := 0; i < 2; i++ {
+		res, err := client.Send(context.Background(), []string{"ok-1", "ok-2"}, vendorpushtest.Notification())
+		if err != nil || len(res.InvalidTokens) != 0 {
+			t.Fatalf("send: %+v, %v", res, err)
+		}
+	}
+	if stub.Auths != 1 {
+		t.Errorf("%d auths, the access token is reused", stub.Auths)
+	}
+	req := stub.pushes[0]
+	if req.Android.Notification.Title != "title" || req.Android.Notification.Body != "content" || req.Android.Notification.Tag != "client-msg-id" {
+		t.Errorf("notification %+v", req.Android.Notification)
+	}
+	if !strings.Contains(req.Data, `"conversationID":"si_a_b"`) || len(req.Token) != 2 {
+		t.Errorf("request %+v", req)
+	}
+}
+
+func TestSendRefreshesExpiredToken(t *testing.T) {
+	stub := newMockHonor(t)
+	vendorpushtest.CheckRefreshesExpiredToken(t, stub.Server, stub.client(mockClientSecret))
+}
+
+func TestSendClassifiesFailures(t *testing.T) {
+	stub := newMockHonor(t)
+	vendorpushtest.CheckFailures(t, stub.client(mockClientSecret), stub.client("wrong"), []vendorpushtest.Failure{
+		{Tokens: []string{"gone-1", "gone-2"}, Invalid: 2},
+		{Tokens: []string{"expire-1", "expire-2", "expire-3"}, Invalid: 2},
+		{Tokens: []string{"busy-1"}, Err: true},
+		{Tokens: []string{"bad-1"}, Err: true, Rejected: true},
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package huawei
+
+// clickActionOpenApp opens the app when the notification is tapped.
+const clickActionOpenApp = 3
+
+type AuthResp struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            int    `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type ClickAction struct {
+	Type int `json:"type"`
+}
+
+type AndroidNotification struct {
+	Title       string      `json:"title"`
+	Body        string      `json:"body"`
+	Tag         string      `json:"tag,omitempty"`
+	ClickAction ClickAction `json:"click_action"`
+}
+
+type Android struct {
+	Urgency      string              `json:"urgency,omitempty"`
+	Category     string              `json:"category,omitempty"`
+	Notification AndroidNotification `json:"notification"`
+}
+
+type Message struct {
+	Data    string   `json:"data,omitempty"`
+	Android Android  `json:"android"`
+	Token   []string `json:"token"`
+}
+
+type PushReq struct {
+	ValidateOnly bool    `json:"validate_only"`
+	Message      Message `json:"message"`
+}
+
+type PushResp struct {
+	Code      string `json:"code"`
+	Msg       string `json:"msg"`
+	RequestID string `json:"requestId"`
+}
+
+// PartialResult is the msg of a partial success.
+type PartialResult struct {
+	Success       int      `json:"success"`
+	Failure       int      `json:"failure"`
+	IllegalTokens []string `json:"illegal_tokens"`
+}
@@ -0,0 +1,130 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package huawei
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	authURL = "https://oauth-login.cloud.huawei.com/oauth2/v3/token"
+	pushURL = "https://push-api.cloud.huawei.com/v1/%s/messages:send"
+
+	SingleBatchLimit = 1000
+)
+
+// result codes of Push Kit.
+const (
+	codeSuccess          = "80000000"
+	codePartialSuccess   = "80100000"
+	codeAllTokensInvalid = "80300007"
+	codeAuthExpired      = "80200003"
+)
+
+// Huawei sends through Huawei Push Kit.
+type Huawei struct {
+	appID   string
+	pushURL string
+	token   *vendorpush.AccessToken
+}
+
+func NewClient() *Huawei {
+	conf := config.Config.Push.Vendor.Huawei
+	return newClient(conf.AppID, conf.AppSecret, authURL, pushURL)
+}
+
+func newClient(appID, appSecret, authURL, pushURL string) *Huawei {
+	h := &Huawei{appID: appID, pushURL: pushURL}
+	h.token = vendorpush.NewAccessToken(func(ctx context.Context) (string, time.Duration, error) {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {appID},
+			"client_secret": {appSecret},
+		}
+		var resp AuthResp
+		if err := vendorpush.PostForm(ctx, authURL, nil, form, &resp); err != nil {
+			return "", 0, err
+		}
+		if resp.AccessToken == "" {
+			return "", 0, fmt.Errorf("%w: huawei auth failed, %d %s", vendorpush.ErrRejected, resp.Error, resp.ErrorDescription)
+		}
+		return resp.AccessToken, time.Duration(resp.ExpiresIn) * time.Second, nil
+	})
+	return h
+}
+
+func (h *Huawei) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (h *Huawei) Send(ctx context.Context, tokens []string, n *vendorpush.Notification) (*vendorpush.Result, error) {
+	req := &PushReq{Message: Message{
+		Data: n.Payload(),
+		Android: Android{
+			Urgency:  "HIGH",
+			Category: "IM",
+			Notification: AndroidNotification{
+				Title:       n.Title,
+				Body:        n.Content,
+				Tag:         n.ClientMsgID,
+				ClickAction: ClickAction{Type: clickActionOpenApp},
+			},
+		},
+		Token: tokens,
+	}}
+	resp, err := h.send(ctx, req)
+	if err == nil && resp.Code == codeAuthExpired {
+		resp, err = h.send(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch resp.Code {
+	case codeSuccess:
+		return &vendorpush.Result{}, nil
+	case codePartialSuccess:
+		// the message of a partial success lists the tokens that failed.
+		var partial PartialResult
+		_ = json.Unmarshal([]byte(resp.Msg), &partial)
+		return &vendorpush.Result{InvalidTokens: partial.IllegalTokens}, nil
+	case codeAllTokensInvalid:
+		return &vendorpush.Result{InvalidTokens: tokens}, nil
+	default:
+		return nil, fmt.Errorf("%w: huawei code %s, msg %s", vendorpush.ErrRejected, resp.Code, resp.Msg)
+	}
+}
+
+func (h *Huawei) send(ctx context.Context, req *PushReq) (*PushResp, error) {
+	token, err := h.token.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp PushResp
+	header := map[string]string{"Authorization": "Bearer " + token}
+	if err := vendorpush.PostJSON(ctx, fmt.Sprintf(h.pushURL, h.appID), header, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Code == codeAuthExpired {
+		h.token.Expire(token)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package huawei
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/vendorpushtest"
+)
+
+const (
+	mockAppID     = "app-id"
+	mockAppSecret = "app-secret"
+)
+
+// mockHuawei answers like Push Kit according to the first token of a push, "partial-*" have invalid
+// "gone-*" tokens among them.
+type mockHuawei struct {
+	*vendorpushtest.Server
+	pushes []*PushReq
+}
+
+func newMockHuawei(t *testing.T) *mockHuawei {
+	m := &mockHuawei{}
+	m.Server = vendorpushtest.NewAuthServer(t, "/oauth2/v3/token", m)
+	return m
+}
+
+func (m *mockHuawei) Auth(w http.ResponseWriter, r *http.Request, token string) bool {
+	_ = r.ParseForm()
+	if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_id") != mockAppID ||
+		r.PostForm.Get("client_secret") != mockAppSecret {
+		_ = json.NewEncoder(w).Encode(&AuthResp{Error: 1101, ErrorDescription: "invalid client"})
+		return false
+	}
+	_ = json.NewEncoder(w).Encode(&AuthResp{AccessToken: token, ExpiresIn: 3600})
+	return true
+}
+
+func (m *mockHuawei) AccessToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func (m *mockHuawei) Expired(w http.ResponseWriter) {
+	_ = json.NewEncoder(w).Encode(&PushResp{Code: codeAuthExpired})
+}
+
+func (m *mockHuawei) Push(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/"+mockAppID+"/messages:send" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req PushReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.pushes = append(m.pushes, &req)
+	tokens := req.Message.Token
+	switch {
+	case strings.HasPrefix(tokens[0], "gone-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: codeAllTokensInvalid})
+	case strings.HasPrefix(tokens[0], "partial-"):
+		partial := PartialResult{}
+		for _, token := range tokens {
+			if strings.HasPrefix(token, "gone-") {
+				partial.IllegalTokens = append(partial.IllegalTokens, token)
+			}
+		}
+		msg, _ := json.Marshal(&partial)
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: codePartialSuccess, Msg: string(msg)})
+	case strings.HasPrefix(tokens[0], "busy-"):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case strings.HasPrefix(tokens[0], "bad-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: "80100003", Msg: "Illegal payload"})
+	default:
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: codeSuccess})
+	}
+}
+
+func (m *mockHuawei) client(appSecret string) *Huawei {
+	return newClient(mockAppID, appSecret, m.URL+"/oauth2/v3/token", m.URL+"/v1/%s/messages:send")
+}
+
+func TestSend(t *testing.T) {
+	stub := newMockHuawei(t)
+	client := stub.client(mockAppSecret)
+	for i := 0; i < 2; i++ {
+		res, err := client.Send(context.Background(), []string{"ok-1", "ok-2"}, vendorpushtest.Notification())
+		if err != nil || len(res.InvalidTokens) != 0 {
+			t.Fatalf("send: %+v, %v", res, err)
+		}
+	}
+	if stub.Auths != 1 {
+		t.Errorf("%d auths, the access token is reused", stub.Auths)
+	}
+	msg := stub.pushes[0].Message
+	if msg.Android.Notification.Title != "title" || msg.Android.Notification.Body != "content" || msg.Android.Notification.Tag != "client-msg-id" {
+		t.Errorf("notification %+v", msg.Android.Notification)
+	}
+	if !strings.Contains(msg.Data, `"conversationID":"si_a_b"`) || len(msg.Token) != 2 {
+		t.Errorf("message %+v", msg)
+	}
+}
+
+func TestSendRefreshesExpiredToken(t *testing.T) {
+	stub := newMockHuawei(t)
+	vendorpushtest.CheckRefreshesExpiredToken(t, stub.Server, stub.client(mockAppSecret))
+}
+
+func TestSendClassifiesFailures(t *testing.T) {
+	stub := newMockHuawei(t)
+	vendorpushtest.CheckFailures(t, stub.client(mockAppSecret), stub.client("wrong"), []vendorpushtest.Failure{
+		{Tokens: []string{"gone-1", "gone-2"}, Invalid: 2},
+		{Tokens: []string{"partial-1", "gone-2", "gone-3"}, Invalid: 2},
+		{Tokens: []string{"busy-1"}, Err: true},
+		{Tokens: []string{"bad-1"}, Err: true, Rejected: true},
+	})
+}
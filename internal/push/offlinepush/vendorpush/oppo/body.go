@@ -0,0 +1,53 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oppo
+
+type AuthData struct {
+	AuthToken  string `json:"auth_token"`
+	CreateTime int64  `json:"create_time"`
+}
+
+type AuthResp struct {
+	Code    int      `json:"code"`
+	Message string   `json:"message"`
+	Data    AuthData `json:"data"`
+}
+
+type Notification struct {
+	Title            string `json:"title"`
+	Content          string `json:"content"`
+	ClickActionType  int    `json:"click_action_type"`
+	ActionParameters string `json:"action_parameters,omitempty"`
+	ChannelID        string `json:"channel_id,omitempty"`
+}
+
+type Message struct {
+	TargetType   int          `json:"target_type"`
+	TargetValue  string       `json:"target_value"`
+	Notification Notification `json:"notification"`
+}
+
+type MessageResult struct {
+	MessageID      string `json:"messageId"`
+	RegistrationID string `json:"registrationId"`
+	ErrorCode      int    `json:"errorCode"`
+	ErrorMessage   string `json:"errorMessage"`
+}
+
+type PushResp struct {
+	Code    int              `json:"code"`
+	Message string           `json:"message"`
+	Data    []*MessageResult `json:"data"`
+}
@@ -0,0 +1,142 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oppo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	authURL = "https://api.push.oppomobile.com/server/v1/auth"
+	pushURL = "https://api.push.oppomobile.com/server/v1/message/notification/unicast_batch"
+
+	SingleBatchLimit = 1000
+	// tokenExpire is how long an auth token of OPPO Push stays valid.
+	tokenExpire = 24 * time.Hour
+)
+
+// result codes of OPPO Push.
+const (
+	codeSuccess      = 0
+	codeAuthInvalid  = 11
+	codeRegIDInvalid = 10000
+)
+
+const (
+	targetTypeRegID      = 2
+	clickActionLaunchApp = 0
+)
+
+// OPPO sends through OPPO Push.
+type OPPO struct {
+	channelID string
+	pushURL   string
+	token     *vendorpush.AccessToken
+}
+
+func NewClient() *OPPO {
+	conf := config.Config.Push.Vendor.OPPO
+	return newClient(conf.AppKey, conf.MasterSecret, conf.ChannelID, authURL, pushURL)
+}
+
+func newClient(appKey, masterSecret, channelID, authURL, pushURL string) *OPPO {
+	o := &OPPO{channelID: channelID, pushURL: pushURL}
+	o.token = vendorpush.NewAccessToken(func(ctx context.Context) (string, time.Duration, error) {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		sign := sha256.Sum256([]byte(appKey + timestamp + masterSecret))
+		form := url.Values{
+			"app_key":   {appKey},
+			"sign":      {hex.EncodeToString(sign[:])},
+			"timestamp": {timestamp},
+		}
+		var resp AuthResp
+		if err := vendorpush.PostForm(ctx, authURL, nil, form, &resp); err != nil {
+			return "", 0, err
+		}
+		if resp.Code != codeSuccess {
+			return "", 0, fmt.Errorf("%w: oppo auth code %d, message %s", vendorpush.ErrRejected, resp.Code, resp.Message)
+		}
+		return resp.Data.AuthToken, tokenExpire, nil
+	})
+	return o
+}
+
+func (o *OPPO) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (o *OPPO) Send(ctx context.Context, tokens []string, n *vendorpush.Notification) (*vendorpush.Result, error) {
+	messages := make([]*Message, 0, len(tokens))
+	for _, token := range tokens {
+		messages = append(messages, &Message{
+			TargetType:  targetTypeRegID,
+			TargetValue: token,
+			Notification: Notification{
+				Title:            n.Title,
+				Content:          n.Content,
+				ClickActionType:  clickActionLaunchApp,
+				ActionParameters: n.Payload(),
+				ChannelID:        o.channelID,
+			},
+		})
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{"messages": {string(data)}}
+	resp, err := o.send(ctx, form)
+	if err == nil && resp.Code == codeAuthInvalid {
+		resp, err = o.send(ctx, form)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != codeSuccess {
+		return nil, fmt.Errorf("%w: oppo code %d, message %s", vendorpush.ErrRejected, resp.Code, resp.Message)
+	}
+	res := &vendorpush.Result{}
+	for _, r := range resp.Data {
+		if r.ErrorCode == codeRegIDInvalid {
+			res.InvalidTokens = append(res.InvalidTokens, r.RegistrationID)
+		}
+	}
+	return res, nil
+}
+
+func (o *OPPO) send(ctx context.Context, form url.Values) (*PushResp, error) {
+	token, err := o.token.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp PushResp
+	if err := vendorpush.PostForm(ctx, o.pushURL, map[string]string{"auth_token": token}, form, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Code == codeAuthInvalid {
+		o.token.Expire(token)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,128 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oppo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/vendorpushtest"
+)
+
+const (
+	mockAppKey       = "app-key"
+	mockMasterSecret = "master-secret"
+)
+
+// mockOPPO answers like OPPO Push according to the registration ids.
+type mockOPPO struct {
+	*vendorpushtest.Server
+	messages []*Message
+}
+
+func newMockOPPO(t *testing.T) *mockOPPO {
+	m := &mockOPPO{}
+	m.Server = vendorpushtest.NewAuthServer(t, "/auth", m)
+	return m
+}
+
+func (m *mockOPPO) Auth(w http.ResponseWriter, r *http.Request, token string) bool {
+	_ = r.ParseForm()
+	sign := sha256.Sum256([]byte(mockAppKey + r.PostForm.Get("timestamp") + mockMasterSecret))
+	if r.PostForm.Get("app_key") != mockAppKey || r.PostForm.Get("sign") != hex.EncodeToString(sign[:]) {
+		_ = json.NewEncoder(w).Encode(&AuthResp{Code: 14, Message: "invalid sign"})
+		return false
+	}
+	_ = json.NewEncoder(w).Encode(&AuthResp{Code: codeSuccess, Data: AuthData{AuthToken: token}})
+	return true
+}
+
+func (m *mockOPPO) AccessToken(r *http.Request) string {
+	return r.Header.Get("auth_token")
+}
+
+func (m *mockOPPO) Expired(w http.ResponseWriter) {
+	_ = json.NewEncoder(w).Encode(&PushResp{Code: codeAuthInvalid})
+}
+
+func (m *mockOPPO) Push(w http.ResponseWriter, r *http.Request) {
+	var messages []*Message
+	if err := r.ParseForm(); err != nil || json.Unmarshal([]byte(r.PostForm.Get("messages")), &messages) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.messages = append(m.messages, messages...)
+	switch {
+	case strings.HasPrefix(messages[0].TargetValue, "busy-"):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case strings.HasPrefix(messages[0].TargetValue, "bad-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Code: 33, Message: "invalid message"})
+	default:
+		resp := &PushResp{Code: codeSuccess}
+		for _, message := range messages {
+			result := &MessageResult{MessageID: "msg-id", RegistrationID: message.TargetValue}
+			if strings.HasPrefix(message.TargetValue, "gone-") {
+				result.ErrorCode = codeRegIDInvalid
+			}
+			resp.Data = append(resp.Data, result)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (m *mockOPPO) client(masterSecret string) *OPPO {
+	return newClient(mockAppKey, masterSecret, "channel-id", m.URL+"/auth", m.URL+"/unicast_batch")
+}
+
+func TestSend(t *testing.T) {
+	stub := newMockOPPO(t)
+	client := stub.client(mockMasterSecret)
+	for i := 0; i < 2; i++ {
+		res, err := client.Send(context.Background(), []string{"ok-1", "ok-2"}, vendorpushtest.Notification())
+		if err != nil || len(res.InvalidTokens) != 0 {
+			t.Fatalf("send: %+v, %v", res, err)
+		}
+	}
+	if stub.Auths != 1 {
+		t.Errorf("%d auths, the auth token is reused", stub.Auths)
+	}
+	message := stub.messages[0]
+	if message.TargetType != targetTypeRegID || message.TargetValue != "ok-1" || message.Notification.Title != "title" ||
+		message.Notification.Content != "content" || message.Notification.ChannelID != "channel-id" {
+		t.Errorf("message %+v", message)
+	}
+	if !strings.Contains(message.Notification.ActionParameters, `"clientMsgID":"client-msg-id"`) {
+		t.Errorf("action parameters %s", message.Notification.ActionParameters)
+	}
+}
+
+func TestSendRefreshesExpiredToken(t *testing.T) {
+	stub := newMockOPPO(t)
+	vendorpushtest.CheckRefreshesExpiredToken(t, stub.Server, stub.client(mockMasterSecret))
+}
+
+func TestSendClassifiesFailures(t *testing.T) {
+	stub := newMockOPPO(t)
+	vendorpushtest.CheckFailures(t, stub.client(mockMasterSecret), stub.client("wrong"), []vendorpushtest.Failure{
+		{Tokens: []string{"ok-1", "gone-2", "gone-3"}, Invalid: 2},
+		{Tokens: []string{"busy-1"}, Err: true},
+		{Tokens: []string{"bad-1"}, Err: true, Rejected: true},
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vendorpush
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/OpenIMSDK/tools/utils/splitter"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+)
+
+const SingleBatchLimit = 1000
+
+var Terminal = []int{constant.AndroidPlatformID, constant.AndroidPadPlatformID}
+
+// tokenCache is the part of cache.MsgModel the router uses.
+type tokenCache interface {
+	GetVendorPushToken(ctx context.Context, account string, platformID int) (*cache.VendorPushToken, error)
+	DelVendorPushToken(ctx context.Context, account string, platformID int) error
+}
+
+type device struct {
+	userID     string
+	platformID int
+}
+
+// Client pushes to every android device through the push service of the vendor it registered with.
+// Devices of vendors without a configured sender are skipped, tokens a vendor doesn't know are deleted.
+// Users whose tokens could not be loaded are retried.
+type Client struct {
+	cache   tokenCache
+	senders map[string]Sender
+}
+
+func NewClient(cache tokenCache, senders map[string]Sender) *Client {
+	return &Client{cache: cache, senders: senders}
+}
+
+func (c *Client) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (c *Client) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
+	var (
+		mu        sync.Mutex
+		pushErr   = &offlinepush.PushError{}
+		retry     = make(map[string]struct{})
+		transient bool
+		g         errgroup.Group
+	)
+	// vendor -> token -> device
+	routes := make(map[string]map[string]device)
+	for _, userID := range userIDs {
+		for _, platformID := range Terminal {
+			token, err := c.cache.GetVendorPushToken(ctx, userID, platformID)
+			if errs.Unwrap(err) == redis.Nil {
+				continue
+			} else if err != nil {
+				// the devices of the user are unknown, the user is retried.
+				log.ZWarn(ctx, "GetVendorPushToken failed", err, "userID", userID, "platformID", platformID)
+				pushErr.Err, transient = err, true
+				retry[userID] = struct{}{}
+				continue
+			}
+			if _, ok := c.senders[token.Vendor]; !ok {
+				log.ZDebug(ctx, "no sender for vendor", "vendor", token.Vendor, "userID", userID)
+				continue
+			}
+			if routes[token.Vendor] == nil {
+				routes[token.Vendor] = make(map[string]device)
+			}
+			routes[token.Vendor][token.Token] = device{userID: userID, platformID: platformID}
+		}
+	}
+	n := NewNotification(title, content, opts)
+	fail := func(vendor string, devices map[string]device, err error, tokens ...string) {
+		log.ZWarn(ctx, "vendor push failed", err, "vendor", vendor, "num", len(tokens))
+		mu.Lock()
+		defer mu.Unlock()
+		pushErr.Err = err
+		if !errors.Is(err, ErrRejected) {
+			transient = true
+		}
+		for _, token := range tokens {
+			retry[devices[token].userID] = struct{}{}
+			opts.Receipt.Add(devices[token].userID, devices[token].platformID, false)
+		}
+	}
+	for vendor, devices := range routes {
+		sender := c.senders[vendor]
+		tokens := make([]string, 0, len(devices))
+		for token := range devices {
+			tokens = append(tokens, token)
+		}
+		for _, batch := range splitter.NewSplitter(sender.BatchSize(), tokens).GetSplitResult() {
+			vendor, devices, batch := vendor, devices, batch.Item
+			g.Go(func() error {
+				res, err := sender.Send(ctx, batch, n)
+				if err != nil {
+					fail(vendor, devices, err, batch...)
+					return nil
+				}
+				c.delInvalidTokens(ctx, vendor, devices, res.InvalidTokens)
+				for token, err := range res.FailedTokens {
+					fail(vendor, devices, err, token)
+				}
+				for _, token := range batch {
+					if _, failed := res.FailedTokens[token]; !failed {
+						opts.Receipt.Add(devices[token].userID, devices[token].platformID, !utils.Contain(token, res.InvalidTokens...))
+					}
+				}
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+	if pushErr.Err == nil {
+		return nil
+	}
+	for userID := range retry {
+		pushErr.RetryUserIDs = append(pushErr.RetryUserIDs, userID)
+	}
+	pushErr.Permanent = !transient
+	return pushErr
+}
+
+func (c *Client) delInvalidTokens(ctx context.Context, vendor string, devices map[string]device, tokens []string) {
+	for _, token := range tokens {
+		d, ok := devices[token]
+		if !ok {
+			continue
+		}
+		if err := c.cache.DelVendorPushToken(ctx, d.userID, d.platformID); err != nil {
+			log.ZWarn(ctx, "DelVendorPushToken failed", err, "vendor", vendor, "userID", d.userID)
+		}
+	}
+}
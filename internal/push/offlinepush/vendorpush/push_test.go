@@ -0,0 +1,192 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vendorpush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+)
+
+type mockTokenCache struct {
+	mu sync.Mutex
+	// userID -> token
+	tokens map[string]*cache.VendorPushToken
+	// the users whose tokens fail to load.
+	errUserIDs map[string]bool
+}
+
+func (m *mockTokenCache) GetVendorPushToken(_ context.Context, account string, platformID int) (*cache.VendorPushToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.errUserIDs[account] {
+		return nil, errors.New("connection refused")
+	}
+	if token, ok := m.tokens[account]; ok && platformID == constant.AndroidPlatformID {
+		return token, nil
+	}
+	return nil, redis.Nil
+}
+
+func (m *mockTokenCache) DelVendorPushToken(_ context.Context, account string, _ int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, account)
+	return nil
+}
+
+// mockSender answers according to the token: "gone-*" are invalid, "busy-*" and "bad-*" fail on their
+// own with a transient and a rejected error. err fails the whole call.
+type mockSender struct {
+	mu    sync.Mutex
+	sent  []string
+	err   error
+	batch int
+}
+
+func (m *mockSender) BatchSize() int {
+	return m.batch
+}
+
+func (m *mockSender) Send(_ context.Context, tokens []string, _ *Notification) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, tokens...)
+	if m.err != nil {
+		return nil, m.err
+	}
+	res := &Result{FailedTokens: make(map[string]error)}
+	for _, token := range tokens {
+		switch token[:4] {
+		case "gone":
+			res.InvalidTokens = append(res.InvalidTokens, token)
+		case "busy":
+			res.FailedTokens[token] = errors.New("timeout")
+		case "bad-":
+			res.FailedTokens[token] = fmt.Errorf("%w: bad", ErrRejected)
+		}
+	}
+	return res, nil
+}
+
+func newMockTokenCache(vendorTokens map[string]string) *mockTokenCache {
+	c := &mockTokenCache{tokens: make(map[string]*cache.VendorPushToken)}
+	for userID, vendorToken := range vendorTokens {
+		vendor, token, _ := strings.Cut(vendorToken, " ")
+		c.tokens[userID] = &cache.VendorPushToken{Vendor: vendor, Token: token}
+	}
+	return c
+}
+
+func TestClientRoutesByVendor(t *testing.T) {
+	huawei, xiaomi := &mockSender{batch: 2}, &mockSender{batch: 10}
+	tokens := newMockTokenCache(map[string]string{
+		"u1": "huawei ok-1", "u2": "huawei ok-2", "u3": "huawei ok-3", "u4": "xiaomi ok-4", "u5": "meizu ok-5",
+	})
+	client := NewClient(tokens, map[string]Sender{"huawei": huawei, "xiaomi": xiaomi})
+	err := client.Push(context.Background(), []string{"u1", "u2", "u3", "u4", "u5", "u6"}, "title", "content", &offlinepush.Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(huawei.sent)
+	if fmt.Sprint(huawei.sent) != "[ok-1 ok-2 ok-3]" || fmt.Sprint(xiaomi.sent) != "[ok-4]" {
+		t.Errorf("huawei %v, xiaomi %v", huawei.sent, xiaomi.sent)
+	}
+}
+
+func TestClientPerUserFailures(t *testing.T) {
+	sender := &mockSender{batch: 10}
+	tokens := newMockTokenCache(map[string]string{
+		"ok": "vivo ok-1", "gone": "vivo gone-1", "busy": "vivo busy-1", "bad": "vivo bad-1",
+	})
+	client := NewClient(tokens, map[string]Sender{"vivo": sender})
+	opts := &offlinepush.Opts{Receipt: offlinepush.NewReceipt()}
+	err := client.Push(context.Background(), []string{"ok", "gone", "busy", "bad"}, "title", "content", opts)
+	var pushErr *offlinepush.PushError
+	if !errors.As(err, &pushErr) {
+		t.Fatalf("expected PushError, got %v", err)
+	}
+	sort.Strings(pushErr.RetryUserIDs)
+	if fmt.Sprint(pushErr.RetryUserIDs) != "[bad busy]" {
+		t.Errorf("retry %v, only the users that failed", pushErr.RetryUserIDs)
+	}
+	if pushErr.Permanent {
+		t.Error("a transient failure makes the push transient")
+	}
+	if _, ok := tokens.tokens["gone"]; ok {
+		t.Error("the invalid token wasn't deleted")
+	}
+	if len(tokens.tokens) != 3 {
+		t.Errorf("tokens %v", tokens.tokens)
+	}
+	for userID, accepted := range map[string]bool{"ok": true, "gone": false, "busy": false, "bad": false} {
+		if got := opts.Receipt.Platforms(userID); len(got) != 1 || got[constant.AndroidPlatformID] != accepted {
+			t.Errorf("receipt of %s: %v", userID, got)
+		}
+	}
+}
+
+func TestClientBatchFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"transient", errors.New("status 503"), false},
+		{"rejected", fmt.Errorf("%w: bad app id", ErrRejected), true},
+	}
+	for _, test := range tests {
+		sender := &mockSender{batch: 10, err: test.err}
+		tokens := newMockTokenCache(map[string]string{"u1": "oppo gone-1", "u2": "oppo ok-2"})
+		err := NewClient(tokens, map[string]Sender{"oppo": sender}).Push(context.Background(), []string{"u1", "u2"}, "title", "content", &offlinepush.Opts{})
+		var pushErr *offlinepush.PushError
+		if !errors.As(err, &pushErr) {
+			t.Fatalf("%s: expected PushError, got %v", test.name, err)
+		}
+		if pushErr.Permanent != test.permanent || len(pushErr.RetryUserIDs) != 2 {
+			t.Errorf("%s: %+v", test.name, pushErr)
+		}
+		if len(tokens.tokens) != 2 {
+			t.Errorf("%s: a failed call must keep the tokens", test.name)
+		}
+	}
+}
+
+func TestClientTokenLoadFailure(t *testing.T) {
+	sender := &mockSender{batch: 10}
+	tokens := newMockTokenCache(map[string]string{"u1": "honor ok-1", "u2": "honor ok-2"})
+	tokens.errUserIDs = map[string]bool{"u2": true}
+	err := NewClient(tokens, map[string]Sender{"honor": sender}).Push(context.Background(), []string{"u1", "u2"}, "title", "content", &offlinepush.Opts{})
+	var pushErr *offlinepush.PushError
+	if !errors.As(err, &pushErr) {
+		t.Fatalf("expected PushError, got %v", err)
+	}
+	if pushErr.Permanent || fmt.Sprint(pushErr.RetryUserIDs) != "[u2]" {
+		t.Errorf("the user whose token failed to load must be retried: %+v", pushErr)
+	}
+	if fmt.Sprint(sender.sent) != "[ok-1]" {
+		t.Errorf("sent %v", sender.sent)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vendorpush routes offline pushes of android devices to the push service of their phone vendor.
+package vendorpush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+)
+
+const requestTimeout = 10 * time.Second
+
+// ErrRejected is wrapped by the errors of requests a push service refused for good, sending them
+// again fails the same way. Other errors are considered transient.
+var ErrRejected = errors.New("rejected by vendor push service")
+
+// Notification is what every vendor shows, built once per push.
+type Notification struct {
+	Title          string `json:"-"`
+	Content        string `json:"-"`
+	ClientMsgID    string `json:"clientMsgID,omitempty"`
+	Ex             string `json:"ex,omitempty"`
+	ConversationID string `json:"conversationID,omitempty"`
+	ServerID       string `json:"serverID,omitempty"`
+	ContentType    int32  `json:"contentType,omitempty"`
+}
+
+func NewNotification(title, content string, opts *offlinepush.Opts) *Notification {
+	n := &Notification{Title: title, Content: content, Ex: opts.Ex}
+	if opts.Signal != nil {
+		n.ClientMsgID = opts.Signal.ClientMsgID
+	}
+	if opts.Msg != nil {
+		n.ConversationID = opts.Msg.ConversationID
+		n.ContentType = opts.Msg.ContentType
+	}
+	if opts.Server != nil {
+		n.ServerID = opts.Server.ServerID
+	}
+	return n
+}
+
+// Payload is the custom data handed to the app when the notification is opened.
+func (n *Notification) Payload() string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+// Result is the outcome of a Send call that reached the push service.
+type Result struct {
+	// InvalidTokens are the tokens the push service doesn't know anymore.
+	InvalidTokens []string
+	// FailedTokens are the tokens a sender sending each token on its own couldn't deliver, with the
+	// error of each. The other tokens of the call were delivered.
+	FailedTokens map[string]error
+}
+
+// Sender sends notifications through the push service of a phone vendor.
+type Sender interface {
+	Send(ctx context.Context, tokens []string, n *Notification) (*Result, error)
+	// BatchSize is the number of tokens a Send call accepts.
+	BatchSize() int
+}
+
+// AccessToken caches the access token a push service requires, fetching a new one before it expires.
+type AccessToken struct {
+	fetch func(ctx context.Context) (token string, expire time.Duration, err error)
+
+	mu       sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+func NewAccessToken(fetch func(ctx context.Context) (string, time.Duration, error)) *AccessToken {
+	return &AccessToken{fetch: fetch}
+}
+
+func (a *AccessToken) Get(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expireAt) {
+		return a.token, nil
+	}
+	token, expire, err := a.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	a.token = token
+	// leave a margin for requests in flight.
+	a.expireAt = time.Now().Add(expire * 9 / 10)
+	return token, nil
+}
+
+// Expire drops token, the push service refused it.
+func (a *AccessToken) Expire(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == token {
+		a.token = ""
+	}
+}
+
+var client = &http.Client{Timeout: requestTimeout}
+
+// PostJSON posts input as JSON and decodes the JSON response into output.
+func PostJSON(ctx context.Context, endpoint string, header map[string]string, input, output any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	return post(ctx, endpoint, "application/json; charset=utf-8", header, bytes.NewReader(data), output)
+}
+
+// PostForm posts form url encoded and decodes the JSON response into output.
+func PostForm(ctx context.Context, endpoint string, header map[string]string, form url.Values, output any) error {
+	return post(ctx, endpoint, "application/x-www-form-urlencoded", header, strings.NewReader(form.Encode()), output)
+}
+
+func post(ctx context.Context, endpoint string, contentType string, header map[string]string, body io.Reader, output any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("status %d, %s", resp.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("%w: status %d, %s", ErrRejected, resp.StatusCode, data)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vendorpush
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAccessToken(t *testing.T) {
+	var fetches int
+	token := NewAccessToken(func(ctx context.Context) (string, time.Duration, error) {
+		fetches++
+		if fetches == 3 {
+			return "", 0, errors.New("auth down")
+		}
+		return "token-" + string(rune('0'+fetches)), time.Hour, nil
+	})
+	for i := 0; i < 2; i++ {
+		if got, err := token.Get(context.Background()); err != nil || got != "token-1" {
+			t.Fatalf("get %q, %v", got, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("%d fetches, the token is cached until it expires", fetches)
+	}
+
+	// expiring a token that was already replaced keeps the current one.
+	token.Expire("token-0")
+	if got, _ := token.Get(context.Background()); got != "token-1" {
+		t.Errorf("get %q after expiring another token", got)
+	}
+	token.Expire("token-1")
+	if got, _ := token.Get(context.Background()); got != "token-2" {
+		t.Errorf("get %q after expire, want a new token", got)
+	}
+	token.Expire("token-2")
+	if _, err := token.Get(context.Background()); err == nil {
+		t.Error("a failed fetch must fail Get")
+	}
+}
+
+func TestPostClassifiesErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" || r.Header.Get("auth") != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("k") != "v" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":1}`))
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/html":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<html>forbidden</html>"))
+		}
+	}))
+	defer server.Close()
+
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if err := PostForm(context.Background(), server.URL+"/ok", map[string]string{"auth": "secret"}, url.Values{"k": {"v"}}, &resp); err != nil || resp.Code != 1 {
+		t.Errorf("ok: %v, %+v", err, resp)
+	}
+	tests := []struct {
+		path     string
+		rejected bool
+	}{
+		{"/busy", false},
+		{"/limited", false},
+		{"/html", true},
+	}
+	for _, test := range tests {
+		err := PostJSON(context.Background(), server.URL+test.path, nil, map[string]string{}, &resp)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.path)
+			continue
+		}
+		if errors.Is(err, ErrRejected) != test.rejected {
+			t.Errorf("%s: rejected %t, err %v", test.path, !test.rejected, err)
+		}
+	}
+}
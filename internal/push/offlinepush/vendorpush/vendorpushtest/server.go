@@ -0,0 +1,164 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vendorpushtest runs a local push service for the tests of the vendor senders. The tokens sent
+// tell the service how to answer: "ok-*" are delivered, "gone-*" are unknown, "busy-*" hit a server error
+// and "bad-*" are refused, each vendor reporting it its own way.
+package vendorpushtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+)
+
+// Service answers the push requests of a sender like the push service of its vendor.
+type Service interface {
+	Push(w http.ResponseWriter, r *http.Request)
+}
+
+// AuthService is a Service issuing access tokens, its pushes are only answered with a valid one.
+type AuthService interface {
+	Service
+	// Auth answers a request for an access token, issuing token when the credentials are right.
+	// It returns whether token was issued.
+	Auth(w http.ResponseWriter, r *http.Request, token string) bool
+	// AccessToken returns the access token a push request carries.
+	AccessToken(r *http.Request) string
+	// Expired answers a push request whose access token expired.
+	Expired(w http.ResponseWriter)
+}
+
+// Server is a local push service answering one request at a time. With an AuthService, requests to the
+// auth path go to Auth and the other ones to Push once their access token is checked.
+type Server struct {
+	*httptest.Server
+	service  Service
+	auth     AuthService
+	authPath string
+
+	mu sync.Mutex
+	// Auths is the number of access tokens issued, Pushes the number of push requests answered.
+	Auths  int
+	Pushes int
+	// ExpireTokens is the number of push requests refused for an expired access token.
+	ExpireTokens int
+}
+
+// NewServer runs a service checking the credentials of each push itself.
+func NewServer(t *testing.T, service Service) *Server {
+	return newServer(t, &Server{service: service})
+}
+
+// NewAuthServer runs a service issuing access tokens at authPath.
+func NewAuthServer(t *testing.T, authPath string, service AuthService) *Server {
+	return newServer(t, &Server{service: service, auth: service, authPath: authPath})
+}
+
+func newServer(t *testing.T, s *Server) *Server {
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// AccessToken returns the access token the server issued last.
+func (s *Server) AccessToken() string {
+	return fmt.Sprintf("access-%d", s.Auths)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.auth != nil {
+		if r.URL.Path == s.authPath {
+			if s.auth.Auth(w, r, fmt.Sprintf("access-%d", s.Auths+1)) {
+				s.Auths++
+			}
+			return
+		}
+		if s.auth.AccessToken(r) != s.AccessToken() {
+			s.auth.Expired(w)
+			return
+		}
+		if s.ExpireTokens > 0 {
+			s.ExpireTokens--
+			s.auth.Expired(w)
+			return
+		}
+	}
+	s.Pushes++
+	s.service.Push(w, r)
+}
+
+// Notification returns the notification the tests send.
+func Notification() *vendorpush.Notification {
+	return &vendorpush.Notification{Title: "title", Content: "content", ClientMsgID: "client-msg-id", ConversationID: "si_a_b"}
+}
+
+// CheckRefreshesExpiredToken checks that client gets a new access token when a push is refused for an
+// expired one, and sends the push again.
+func CheckRefreshesExpiredToken(t *testing.T, s *Server, client vendorpush.Sender) {
+	t.Helper()
+	s.ExpireTokens = 1
+	res, err := client.Send(context.Background(), []string{"ok-1"}, Notification())
+	if err != nil || len(res.FailedTokens) != 0 {
+		t.Fatalf("send: %+v, %v", res, err)
+	}
+	if s.Auths != 2 || s.Pushes != 1 {
+		t.Errorf("auths %d, pushes %d", s.Auths, s.Pushes)
+	}
+}
+
+// Failure is a push failing as a whole, or with Invalid tokens reported unknown.
+type Failure struct {
+	Tokens   []string
+	Invalid  int
+	Err      bool
+	Rejected bool
+}
+
+// CheckFailures checks how client reports each failure, and that wrong, a client with wrong credentials,
+// is refused.
+func CheckFailures(t *testing.T, client, wrong vendorpush.Sender, failures []Failure) {
+	t.Helper()
+	for _, failure := range failures {
+		res, err := client.Send(context.Background(), failure.Tokens, Notification())
+		if (err != nil) != failure.Err || errors.Is(err, vendorpush.ErrRejected) != failure.Rejected {
+			t.Errorf("%v: err %v", failure.Tokens, err)
+			continue
+		}
+		if err == nil && len(res.InvalidTokens) != failure.Invalid {
+			t.Errorf("%v: invalid %v", failure.Tokens, res.InvalidTokens)
+		}
+	}
+	CheckRejected(t, wrong)
+}
+
+// CheckRejected checks that a push of client is refused, as a whole or token by token.
+func CheckRejected(t *testing.T, client vendorpush.Sender) {
+	t.Helper()
+	res, err := client.Send(context.Background(), []string{"ok-1"}, Notification())
+	if err == nil {
+		err = res.FailedTokens["ok-1"]
+	}
+	if !errors.Is(err, vendorpush.ErrRejected) {
+		t.Errorf("wrong credentials: %v", err)
+	}
+}
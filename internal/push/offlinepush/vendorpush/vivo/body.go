@@ -0,0 +1,45 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vivo
+
+type AuthReq struct {
+	AppID     string `json:"appId"`
+	AppKey    string `json:"appKey"`
+	Timestamp int64  `json:"timestamp"`
+	Sign      string `json:"sign"`
+}
+
+type AuthResp struct {
+	Result    int    `json:"result"`
+	Desc      string `json:"desc"`
+	AuthToken string `json:"authToken"`
+}
+
+type PushReq struct {
+	RegID           string            `json:"regId"`
+	NotifyType      int               `json:"notifyType"`
+	Title           string            `json:"title"`
+	Content         string            `json:"content"`
+	SkipType        int               `json:"skipType"`
+	Classification  int               `json:"classification"`
+	RequestID       string            `json:"requestId"`
+	ClientCustomMap map[string]string `json:"clientCustomMap,omitempty"`
+}
+
+type PushResp struct {
+	Result int    `json:"result"`
+	Desc   string `json:"desc"`
+	TaskID string `json:"taskId"`
+}
@@ -0,0 +1,157 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vivo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	authURL = "https://api-push.vivo.com.cn/message/auth"
+	pushURL = "https://api-push.vivo.com.cn/message/send"
+
+	SingleBatchLimit = 1000
+	concurrentLimit  = 16
+	// tokenExpire is how long an auth token of vivo Push stays valid.
+	tokenExpire = 24 * time.Hour
+)
+
+// result codes of vivo Push.
+const (
+	codeSuccess      = 0
+	codeAuthInvalid  = 10000
+	codeRegIDInvalid = 10302
+)
+
+const (
+	notifyTypeAll        = 4
+	skipTypeOpenApp      = 1
+	classificationSystem = 1
+)
+
+// Vivo sends through vivo Push, one request per device.
+type Vivo struct {
+	pushURL string
+	token   *vendorpush.AccessToken
+}
+
+func NewClient() *Vivo {
+	conf := config.Config.Push.Vendor.Vivo
+	return newClient(conf.AppID, conf.AppKey, conf.AppSecret, authURL, pushURL)
+}
+
+func newClient(appID, appKey, appSecret, authURL, pushURL string) *Vivo {
+	v := &Vivo{pushURL: pushURL}
+	v.token = vendorpush.NewAccessToken(func(ctx context.Context) (string, time.Duration, error) {
+		timestamp := time.Now().UnixMilli()
+		sign := md5.Sum([]byte(appID + appKey + strconv.FormatInt(timestamp, 10) + appSecret))
+		req := &AuthReq{AppID: appID, AppKey: appKey, Timestamp: timestamp, Sign: hex.EncodeToString(sign[:])}
+		var resp AuthResp
+		if err := vendorpush.PostJSON(ctx, authURL, nil, req, &resp); err != nil {
+			return "", 0, err
+		}
+		if resp.Result != codeSuccess {
+			return "", 0, fmt.Errorf("%w: vivo auth result %d, desc %s", vendorpush.ErrRejected, resp.Result, resp.Desc)
+		}
+		return resp.AuthToken, tokenExpire, nil
+	})
+	return v
+}
+
+func (v *Vivo) BatchSize() int {
+	return SingleBatchLimit
+}
+
+// Send sends to every token, the tokens that failed are in the FailedTokens of the result.
+func (v *Vivo) Send(ctx context.Context, tokens []string, n *vendorpush.Notification) (*vendorpush.Result, error) {
+	var (
+		mu  sync.Mutex
+		res = &vendorpush.Result{FailedTokens: make(map[string]error)}
+		g   errgroup.Group
+	)
+	g.SetLimit(concurrentLimit)
+	for _, token := range tokens {
+		token := token
+		g.Go(func() error {
+			invalid, err := v.sendOne(ctx, token, n)
+			mu.Lock()
+			defer mu.Unlock()
+			if invalid {
+				res.InvalidTokens = append(res.InvalidTokens, token)
+			}
+			if err != nil {
+				res.FailedTokens[token] = err
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return res, nil
+}
+
+func (v *Vivo) sendOne(ctx context.Context, regID string, n *vendorpush.Notification) (invalid bool, err error) {
+	req := &PushReq{
+		RegID:           regID,
+		NotifyType:      notifyTypeAll,
+		Title:           n.Title,
+		Content:         n.Content,
+		SkipType:        skipTypeOpenApp,
+		Classification:  classificationSystem,
+		RequestID:       uuid.New().String(),
+		ClientCustomMap: map[string]string{"payload": n.Payload()},
+	}
+	resp, err := v.send(ctx, req)
+	if err == nil && resp.Result == codeAuthInvalid {
+		resp, err = v.send(ctx, req)
+	}
+	if err != nil {
+		return false, err
+	}
+	switch resp.Result {
+	case codeSuccess:
+		return false, nil
+	case codeRegIDInvalid:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: vivo result %d, desc %s", vendorpush.ErrRejected, resp.Result, resp.Desc)
+	}
+}
+
+func (v *Vivo) send(ctx context.Context, req *PushReq) (*PushResp, error) {
+	token, err := v.token.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp PushResp
+	if err := vendorpush.PostJSON(ctx, v.pushURL, map[string]string{"authToken": token}, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Result == codeAuthInvalid {
+		v.token.Expire(token)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,139 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vivo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/vendorpushtest"
+)
+
+const (
+	mockAppID     = "app-id"
+	mockAppKey    = "app-key"
+	mockAppSecret = "app-secret"
+)
+
+// mockVivo answers like vivo Push according to the regId of each request.
+type mockVivo struct {
+	*vendorpushtest.Server
+	pushes []*PushReq
+}
+
+func newMockVivo(t *testing.T) *mockVivo {
+	m := &mockVivo{}
+	m.Server = vendorpushtest.NewAuthServer(t, "/auth", m)
+	return m
+}
+
+func (m *mockVivo) Auth(w http.ResponseWriter, r *http.Request, token string) bool {
+	var req AuthReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+	sign := md5.Sum([]byte(mockAppID + mockAppKey + strconv.FormatInt(req.Timestamp, 10) + mockAppSecret))
+	if req.AppID != mockAppID || req.AppKey != mockAppKey || req.Sign != hex.EncodeToString(sign[:]) {
+		_ = json.NewEncoder(w).Encode(&AuthResp{Result: 10005, Desc: "invalid sign"})
+		return false
+	}
+	_ = json.NewEncoder(w).Encode(&AuthResp{Result: codeSuccess, AuthToken: token})
+	return true
+}
+
+func (m *mockVivo) AccessToken(r *http.Request) string {
+	return r.Header.Get("authToken")
+}
+
+func (m *mockVivo) Expired(w http.ResponseWriter) {
+	_ = json.NewEncoder(w).Encode(&PushResp{Result: codeAuthInvalid})
+}
+
+func (m *mockVivo) Push(w http.ResponseWriter, r *http.Request) {
+	var req PushReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.pushes = append(m.pushes, &req)
+	switch {
+	case strings.HasPrefix(req.RegID, "gone-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Result: codeRegIDInvalid})
+	case strings.HasPrefix(req.RegID, "busy-"):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case strings.HasPrefix(req.RegID, "bad-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Result: 10070, Desc: "invalid content"})
+	default:
+		_ = json.NewEncoder(w).Encode(&PushResp{Result: codeSuccess, TaskID: "task-id"})
+	}
+}
+
+func (m *mockVivo) client(appSecret string) *Vivo {
+	return newClient(mockAppID, mockAppKey, appSecret, m.URL+"/auth", m.URL+"/send")
+}
+
+func TestSend(t *testing.T) {
+	stub := newMockVivo(t)
+	res, err := stub.client(mockAppSecret).Send(context.Background(), []string{"ok-1", "ok-2"}, vendorpushtest.Notification())
+	if err != nil || len(res.InvalidTokens) != 0 || len(res.FailedTokens) != 0 {
+		t.Fatalf("send: %+v, %v", res, err)
+	}
+	if stub.Auths != 1 || len(stub.pushes) != 2 {
+		t.Errorf("auths %d, pushes %d, one request per device with the same auth token", stub.Auths, len(stub.pushes))
+	}
+	req := stub.pushes[0]
+	if req.Title != "title" || req.Content != "content" || req.RequestID == "" || req.RequestID == stub.pushes[1].RequestID {
+		t.Errorf("request %+v", req)
+	}
+	if !strings.Contains(req.ClientCustomMap["payload"], `"clientMsgID":"client-msg-id"`) {
+		t.Errorf("payload %s", req.ClientCustomMap["payload"])
+	}
+}
+
+func TestSendRefreshesExpiredToken(t *testing.T) {
+	stub := newMockVivo(t)
+	vendorpushtest.CheckRefreshesExpiredToken(t, stub.Server, stub.client(mockAppSecret))
+}
+
+// vivo pushes one device per request, the failures are reported token by token.
+func TestSendClassifiesFailures(t *testing.T) {
+	stub := newMockVivo(t)
+	res, err := stub.client(mockAppSecret).Send(context.Background(), []string{"ok-1", "gone-2", "busy-3", "bad-4"}, vendorpushtest.Notification())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.InvalidTokens) != 1 || res.InvalidTokens[0] != "gone-2" {
+		t.Errorf("invalid %v", res.InvalidTokens)
+	}
+	if len(res.FailedTokens) != 2 {
+		t.Fatalf("failed %v, only the devices that failed", res.FailedTokens)
+	}
+	if err := res.FailedTokens["busy-3"]; err == nil || errors.Is(err, vendorpush.ErrRejected) {
+		t.Errorf("busy: %v, a server error is transient", err)
+	}
+	if err := res.FailedTokens["bad-4"]; !errors.Is(err, vendorpush.ErrRejected) {
+		t.Errorf("bad: %v", err)
+	}
+	vendorpushtest.CheckRejected(t, stub.client("wrong"))
+}
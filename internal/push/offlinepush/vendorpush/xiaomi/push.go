@@ -0,0 +1,88 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xiaomi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+const (
+	pushURL = "https://api.xmpush.xiaomi.com/v3/message/regid"
+
+	SingleBatchLimit = 1000
+)
+
+const (
+	resultOK = "ok"
+	// notifyTypeAll plays the default sound, vibrates and lights up.
+	notifyTypeAll = "-1"
+	// notifyEffectLauncher opens the launcher activity of the app.
+	notifyEffectLauncher = "1"
+)
+
+// Xiaomi sends through Mi Push, authenticated with the app secret.
+type Xiaomi struct {
+	appSecret   string
+	packageName string
+	channelID   string
+	pushURL     string
+}
+
+func NewClient() *Xiaomi {
+	conf := config.Config.Push.Vendor.Xiaomi
+	return newClient(conf.AppSecret, conf.PackageName, conf.ChannelID, pushURL)
+}
+
+func newClient(appSecret, packageName, channelID, pushURL string) *Xiaomi {
+	return &Xiaomi{appSecret: appSecret, packageName: packageName, channelID: channelID, pushURL: pushURL}
+}
+
+func (x *Xiaomi) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (x *Xiaomi) Send(ctx context.Context, tokens []string, n *vendorpush.Notification) (*vendorpush.Result, error) {
+	form := url.Values{
+		"registration_id":         {strings.Join(tokens, ",")},
+		"restricted_package_name": {x.packageName},
+		"title":                   {n.Title},
+		"description":             {n.Content},
+		"payload":                 {n.Payload()},
+		"pass_through":            {"0"},
+		"notify_type":             {notifyTypeAll},
+		"extra.notify_effect":     {notifyEffectLauncher},
+	}
+	if x.channelID != "" {
+		form.Set("extra.channel_id", x.channelID)
+	}
+	var resp PushResp
+	if err := vendorpush.PostForm(ctx, x.pushURL, map[string]string{"Authorization": "key=" + x.appSecret}, form, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Result != resultOK {
+		return nil, fmt.Errorf("%w: xiaomi code %d, reason %s", vendorpush.ErrRejected, resp.Code, resp.Reason)
+	}
+	var invalid []string
+	if resp.Data.BadRegIDs != "" {
+		invalid = strings.Split(resp.Data.BadRegIDs, ",")
+	}
+	return &vendorpush.Result{InvalidTokens: invalid}, nil
+}
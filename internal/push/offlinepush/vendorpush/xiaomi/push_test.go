@@ -0,0 +1,100 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xiaomi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/vendorpushtest"
+)
+
+const (
+	mockAppSecret   = "app-secret"
+	mockPackageName = "io.openim.test"
+)
+
+// mockXiaomi answers like Mi Push according to the registration ids, the app secret is checked on
+// each push.
+type mockXiaomi struct {
+	*vendorpushtest.Server
+	pushes []url.Values
+}
+
+func newMockXiaomi(t *testing.T) *mockXiaomi {
+	m := &mockXiaomi{}
+	m.Server = vendorpushtest.NewServer(t, m)
+	return m
+}
+
+func (m *mockXiaomi) Push(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "key="+mockAppSecret {
+		_ = json.NewEncoder(w).Encode(&PushResp{Result: "error", Code: 22006, Reason: "Invalid appSecret"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.pushes = append(m.pushes, r.PostForm)
+	regIDs := strings.Split(r.PostForm.Get("registration_id"), ",")
+	switch {
+	case strings.HasPrefix(regIDs[0], "busy-"):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case strings.HasPrefix(regIDs[0], "bad-"):
+		_ = json.NewEncoder(w).Encode(&PushResp{Result: "error", Code: 10017, Reason: "invalid payload"})
+	default:
+		var gone []string
+		for _, regID := range regIDs {
+			if strings.HasPrefix(regID, "gone-") {
+				gone = append(gone, regID)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(&PushResp{Result: resultOK, Data: PushData{ID: "msg-id", BadRegIDs: strings.Join(gone, ",")}})
+	}
+}
+
+func (m *mockXiaomi) client(appSecret string) *Xiaomi {
+	return newClient(appSecret, mockPackageName, "channel-id", m.URL)
+}
+
+func TestSend(t *testing.T) {
+	stub := newMockXiaomi(t)
+	res, err := stub.client(mockAppSecret).Send(context.Background(), []string{"ok-1", "ok-2"}, vendorpushtest.Notification())
+	if err != nil || len(res.InvalidTokens) != 0 {
+		t.Fatalf("send: %+v, %v", res, err)
+	}
+	form := stub.pushes[0]
+	if form.Get("registration_id") != "ok-1,ok-2" || form.Get("restricted_package_name") != mockPackageName ||
+		form.Get("title") != "title" || form.Get("description") != "content" || form.Get("extra.channel_id") != "channel-id" {
+		t.Errorf("form %v", form)
+	}
+	if !strings.Contains(form.Get("payload"), `"clientMsgID":"client-msg-id"`) {
+		t.Errorf("payload %s", form.Get("payload"))
+	}
+}
+
+func TestSendClassifiesFailures(t *testing.T) {
+	stub := newMockXiaomi(t)
+	vendorpushtest.CheckFailures(t, stub.client(mockAppSecret), stub.client("wrong"), []vendorpushtest.Failure{
+		{Tokens: []string{"ok-1", "gone-2", "gone-3"}, Invalid: 2},
+		{Tokens: []string{"busy-1"}, Err: true},
+		{Tokens: []string{"bad-1"}, Err: true, Rejected: true},
+	})
+}
@@ -0,0 +1,26 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+// Payload is the JSON the service worker of the web client receives, it shows the notification itself.
+type Payload struct {
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	Ex             string `json:"ex,omitempty"`
+	ConversationID string `json:"conversationID,omitempty"`
+	ServerID       string `json:"serverID,omitempty"`
+	ContentType    int32  `json:"contentType,omitempty"`
+	ClientMsgID    string `json:"clientMsgID,omitempty"`
+}
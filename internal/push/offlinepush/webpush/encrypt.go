@@ -0,0 +1,124 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	recordSize     = 4096
+	saltLen        = 16
+	authSecretLen  = 16
+	publicKeyLen   = 65
+	gcmTagLen      = 16
+	headerLen      = saltLen + 4 + 1 + publicKeyLen
+	lastRecordMark = 0x02
+)
+
+// MaxPayloadSize is the largest plaintext that fits the single record of a push message.
+const MaxPayloadSize = recordSize - headerLen - gcmTagLen - 1
+
+var (
+	errPayloadTooLarge = errors.New("web push payload too large")
+	errInvalidKey      = errors.New("invalid subscription key")
+)
+
+// encrypt encrypts plaintext for a subscription as specified by RFC 8291: aes128gcm content coding
+// (RFC 8188) in a single record. uaPublic and authSecret are the p256dh and auth keys of the subscription.
+func encrypt(plaintext, uaPublic, authSecret []byte) ([]byte, error) {
+	asPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return seal(plaintext, uaPublic, authSecret, asPrivate, salt)
+}
+
+// seal does the work of encrypt with the ephemeral key and salt given.
+func seal(plaintext, uaPublic, authSecret []byte, asPrivate *ecdsa.PrivateKey, salt []byte) ([]byte, error) {
+	if len(plaintext) > MaxPayloadSize {
+		return nil, errPayloadTooLarge
+	}
+	if len(authSecret) != authSecretLen || len(uaPublic) != publicKeyLen {
+		return nil, errInvalidKey
+	}
+	curve := elliptic.P256()
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublic)
+	if uaX == nil {
+		return nil, errInvalidKey
+	}
+	asPublic := elliptic.Marshal(curve, asPrivate.X, asPrivate.Y)
+	sharedX, _ := curve.ScalarMult(uaX, uaY, asPrivate.D.Bytes())
+	ecdhSecret := make([]byte, 32)
+	sharedX.FillBytes(ecdhSecret)
+
+	// key_info = "WebPush: info" || 0x00 || ua_public || as_public
+	keyInfo := make([]byte, 0, 14+2*publicKeyLen)
+	keyInfo = append(keyInfo, "WebPush: info\x00"...)
+	keyInfo = append(keyInfo, uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := expand(hkdf.Extract(sha256.New, ecdhSecret, authSecret), keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek, err := expand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := expand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// header: salt || rs || idlen || keyid, the sender public key is the key id.
+	body := make([]byte, headerLen, headerLen+len(plaintext)+1+gcmTagLen)
+	copy(body, salt)
+	binary.BigEndian.PutUint32(body[saltLen:], recordSize)
+	body[saltLen+4] = publicKeyLen
+	copy(body[saltLen+5:], asPublic)
+	record := append(append(make([]byte, 0, len(plaintext)+1), plaintext...), lastRecordMark)
+	return gcm.Seal(body, nonce, record, nil), nil
+}
+
+func expand(prk, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
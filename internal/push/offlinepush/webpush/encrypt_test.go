@@ -0,0 +1,79 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+// the example of RFC 8291 section 5.
+const (
+	mockPlaintext  = "When I grow up, I want to be a watermelon"
+	mockUAPublic   = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	mockAuthSecret = "BTBZMqHH6r4Tts7J_aSIgg"
+	mockASPrivate  = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	mockSalt       = "DGv6ra1nlYgDCS1FRnbzlw"
+	mockBody       = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func mockDecode(t *testing.T, s string) []byte {
+	b, err := decodeKey(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSealRFC8291Example(t *testing.T) {
+	d := mockDecode(t, mockASPrivate)
+	curve := elliptic.P256()
+	asPrivate := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	asPrivate.PublicKey.Curve = curve
+	asPrivate.PublicKey.X, asPrivate.PublicKey.Y = curve.ScalarBaseMult(d)
+
+	body, err := seal([]byte(mockPlaintext), mockDecode(t, mockUAPublic), mockDecode(t, mockAuthSecret), asPrivate, mockDecode(t, mockSalt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := base64.RawURLEncoding.EncodeToString(body); got != mockBody {
+		t.Errorf("body\n got  %s\n want %s", got, mockBody)
+	}
+}
+
+func TestEncryptRandomizes(t *testing.T) {
+	uaPublic, authSecret := mockDecode(t, mockUAPublic), mockDecode(t, mockAuthSecret)
+	a, err := encrypt([]byte(mockPlaintext), uaPublic, authSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := encrypt([]byte(mockPlaintext), uaPublic, authSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("two encryptions of a payload are identical")
+	}
+	if _, err := encrypt(make([]byte, MaxPayloadSize+1), uaPublic, authSecret); err != errPayloadTooLarge {
+		t.Errorf("expected errPayloadTooLarge, got %v", err)
+	}
+	if _, err := encrypt([]byte(mockPlaintext), uaPublic[1:], authSecret); err != errInvalidKey {
+		t.Errorf("expected errInvalidKey, got %v", err)
+	}
+}
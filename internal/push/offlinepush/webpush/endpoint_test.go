@@ -0,0 +1,73 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		ok       bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", true},
+		{"https://8.8.8.8/push", true},
+		{"http://fcm.googleapis.com/fcm/send/abc", false},
+		{"https:///push", false},
+		{"https://localhost/push", false},
+		{"https://LOCALHOST./push", false},
+		{"https://push.localhost/push", false},
+		{"https://127.0.0.1:8443/push", false},
+		{"https://10.0.0.8/push", false},
+		{"https://172.16.3.4/push", false},
+		{"https://192.168.1.1/push", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://0.0.0.0/push", false},
+		{"https://[::1]/push", false},
+		{"https://[fd00::1]/push", false},
+		{"https://[fe80::1]/push", false},
+		{"::not a url", false},
+	}
+	for _, test := range tests {
+		if err := CheckEndpoint(test.endpoint); (err == nil) != test.ok {
+			t.Errorf("%s: err %v, want ok %v", test.endpoint, err, test.ok)
+		}
+	}
+}
+
+func TestPublicOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		ok      bool
+	}{
+		{"142.250.74.106:443", true},
+		{"[2607:f8b0:4004:c1b::5f]:443", true},
+		{"127.0.0.1:443", false},
+		{"10.1.2.3:443", false},
+		{"[::1]:443", false},
+	}
+	for _, test := range tests {
+		err := publicOnly("tcp", test.address, nil)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: err %v, want ok %v", test.address, err, test.ok)
+		}
+		if err != nil && !errors.Is(err, errEndpointNotAllowed) {
+			t.Errorf("%s: unexpected err %v", test.address, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+)
+
+const (
+	requestTimeout   = 10 * time.Second
+	concurrentLimit  = 32
+	SingleBatchLimit = 1000
+	defaultTTL       = 24 * 60 * 60
+	topicMaxLen      = 32
+)
+
+// sendResult classifies the outcome of a request for the retry logic.
+type sendResult int
+
+const (
+	sendOK       sendResult = iota
+	sendRetry               // transient failure, worth retrying later
+	sendRejected            // the request itself is refused, retrying fails the same way
+	sendGone                // the subscription expired or was revoked
+)
+
+// subscriptionCache is the part of cache.MsgModel the provider uses.
+type subscriptionCache interface {
+	GetWebPushSubscriptions(ctx context.Context, account string) ([]*cache.WebPushSubscription, error)
+	DelWebPushSubscription(ctx context.Context, account string, endpoints ...string) error
+}
+
+// WebPush pushes to the browser subscriptions of the users, so web clients are notified while closed.
+// Subscriptions the push service reports as gone are deleted.
+type WebPush struct {
+	cache  subscriptionCache
+	client *http.Client
+	vapid  *vapid
+	ttl    int
+}
+
+func NewClient(cache subscriptionCache) (*WebPush, error) {
+	conf := config.Config.Push.WebPush
+	v, err := newVapid(conf.PrivateKey, conf.PublicKey, conf.Subject)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: requestTimeout, KeepAlive: 30 * time.Second, Control: publicOnly}).DialContext
+	return newClient(cache, &http.Client{Transport: transport, Timeout: requestTimeout}, v, conf.TTL), nil
+}
+
+func newClient(cache subscriptionCache, client *http.Client, v *vapid, ttl int) *WebPush {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	return &WebPush{cache: cache, client: client, vapid: v, ttl: ttl}
+}
+
+func (w *WebPush) BatchSize() int {
+	return SingleBatchLimit
+}
+
+func (w *WebPush) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
+	payload := &Payload{Title: title, Body: content, Ex: opts.Ex}
+	if opts.Msg != nil {
+		payload.ConversationID = opts.Msg.ConversationID
+		payload.ContentType = opts.Msg.ContentType
+	}
+	if opts.Server != nil {
+		payload.ServerID = opts.Server.ServerID
+	}
+	var topic string
+	if opts.Signal != nil {
+		payload.ClientMsgID = opts.Signal.ClientMsgID
+		if len(opts.Signal.ClientMsgID) <= topicMaxLen {
+			// a retried push replaces the one still waiting in the push service.
+			topic = opts.Signal.ClientMsgID
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var (
+		mu        sync.Mutex
+		pushErr   = &offlinepush.PushError{}
+		transient bool
+		g         errgroup.Group
+	)
+	g.SetLimit(concurrentLimit)
+	for _, userID := range userIDs {
+		userID := userID
+		g.Go(func() error {
+			result, err := w.pushUser(ctx, userID, topic, data, opts.Receipt)
+			if result == sendOK {
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			pushErr.Err = err
+			pushErr.RetryUserIDs = append(pushErr.RetryUserIDs, userID)
+			if result == sendRetry {
+				transient = true
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if pushErr.Err == nil {
+		return nil
+	}
+	pushErr.Permanent = !transient
+	return pushErr
+}
+
+// pushUser sends data to every subscription of the user. The user counts as failed when a subscription
+// failed for another reason than being gone, a transient failure wins over a rejection. The web platform
+// is reported accepted when one subscription accepted the push.
+func (w *WebPush) pushUser(ctx context.Context, userID string, topic string, data []byte, receipt *offlinepush.Receipt) (sendResult, error) {
+	subscriptions, err := w.cache.GetWebPushSubscriptions(ctx, userID)
+	if err != nil {
+		return sendRetry, err
+	}
+	var (
+		result  = sendOK
+		lastErr error
+		gone    []string
+	)
+	for _, subscription := range subscriptions {
+		res, err := w.send(ctx, subscription, topic, data)
+		receipt.Add(userID, constant.WebPlatformID, res == sendOK)
+		switch res {
+		case sendOK:
+		case sendGone:
+			gone = append(gone, subscription.Endpoint)
+		case sendRetry:
+			result, lastErr = sendRetry, err
+		default:
+			if result != sendRetry {
+				result, lastErr = sendRejected, err
+			}
+		}
+	}
+	if len(gone) > 0 {
+		if err := w.cache.DelWebPushSubscription(ctx, userID, gone...); err != nil {
+			log.ZWarn(ctx, "DelWebPushSubscription failed", err, "userID", userID, "endpoints", gone)
+		}
+	}
+	return result, lastErr
+}
+
+func (w *WebPush) send(ctx context.Context, subscription *cache.WebPushSubscription, topic string, data []byte) (sendResult, error) {
+	if err := CheckEndpoint(subscription.Endpoint); err != nil {
+		return sendGone, err
+	}
+	uaPublic, err := decodeKey(subscription.P256dh)
+	if err != nil {
+		return sendGone, err
+	}
+	authSecret, err := decodeKey(subscription.Auth)
+	if err != nil {
+		return sendGone, err
+	}
+	body, err := encrypt(data, uaPublic, authSecret)
+	if err == errInvalidKey {
+		return sendGone, err
+	} else if err != nil {
+		return sendRejected, err
+	}
+	authorization, err := w.vapid.authorization(subscription.Endpoint, time.Now())
+	if err != nil {
+		// the token is signed with the key of the server, the subscription isn't at fault.
+		return sendRejected, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return sendGone, err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(w.ttl))
+	req.Header.Set("Urgency", "high")
+	if topic != "" {
+		req.Header.Set("Topic", topic)
+	}
+	resp, err := w.client.Do(req)
+	if errors.Is(err, errEndpointNotAllowed) {
+		return sendGone, err
+	} else if err != nil {
+		return sendRetry, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return sendOK, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("web push status %d, %s", resp.StatusCode, msg)
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return sendGone, err
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		// the vapid token or key is refused, a config fix makes the subscription work again.
+		return sendRejected, err
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= http.StatusInternalServerError:
+		return sendRetry, err
+	default:
+		return sendRejected, err
+	}
+}
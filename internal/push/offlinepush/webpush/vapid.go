@@ -0,0 +1,115 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// vapidExpire is below the 24 hours push services accept.
+	vapidExpire  = 12 * time.Hour
+	vapidRefresh = time.Hour
+)
+
+type vapidToken struct {
+	value    string
+	issuedAt time.Time
+}
+
+// vapid signs the JWT identifying the application server to push services (RFC 8292).
+// Tokens are cached per push service, they are only bound to its origin. The tokens not refreshed
+// for vapidRefresh are swept, so push services no longer used don't stay cached.
+type vapid struct {
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+
+	mu        sync.Mutex
+	tokens    map[string]vapidToken
+	sweepTime time.Time
+}
+
+// newVapid loads the key pair as generated by the usual web push tools: base64url encoded raw private
+// key, and uncompressed public key. publicKey may be left empty, it is derived from the private key.
+func newVapid(privateKey, publicKey, subject string) (*vapid, error) {
+	d, err := decodeKey(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("invalid vapid private key")
+	}
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+	derived := base64.RawURLEncoding.EncodeToString(elliptic.Marshal(curve, key.X, key.Y))
+	if publicKey != "" && strings.TrimRight(publicKey, "=") != derived {
+		return nil, errors.New("vapid public key doesn't match the private key")
+	}
+	return &vapid{key: key, publicKey: derived, subject: subject, tokens: make(map[string]vapidToken)}, nil
+}
+
+// authorization returns the Authorization header for a request to endpoint.
+func (v *vapid) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", errors.New("invalid endpoint " + endpoint)
+	}
+	audience := u.Scheme + "://" + u.Host
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	token, ok := v.tokens[audience]
+	if !ok || now.Sub(token.issuedAt) >= vapidRefresh {
+		value, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"aud": audience,
+			"exp": now.Add(vapidExpire).Unix(),
+			"sub": v.subject,
+		}).SignedString(v.key)
+		if err != nil {
+			return "", err
+		}
+		token = vapidToken{value: value, issuedAt: now}
+		v.tokens[audience] = token
+		v.sweep(now)
+	}
+	return "vapid t=" + token.value + ", k=" + v.publicKey, nil
+}
+
+// sweep drops the tokens due for a refresh, at most once per vapidRefresh.
+func (v *vapid) sweep(now time.Time) {
+	if now.Sub(v.sweepTime) < vapidRefresh {
+		return
+	}
+	v.sweepTime = now
+	for audience, token := range v.tokens {
+		if now.Sub(token.issuedAt) >= vapidRefresh {
+			delete(v.tokens, audience)
+		}
+	}
+}
+
+// decodeKey decodes the base64url keys of subscriptions and config, with or without padding.
+func decodeKey(key string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(key, "="))
+}
@@ -0,0 +1,41 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webpush
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVapidTokenSweep(t *testing.T) {
+	v, err := newVapid(mockASPrivate, "", "mailto:admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	first, err := v.authorization("https://a.example.com/push/1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := v.authorization("https://a.example.com/push/2", now.Add(time.Minute)); again != first {
+		t.Error("the token of an origin is not reused")
+	}
+	if _, err := v.authorization("https://b.example.com/push/1", now.Add(2*vapidRefresh)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.tokens["https://a.example.com"]; ok || len(v.tokens) != 1 {
+		t.Errorf("stale tokens kept: %d", len(v.tokens))
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+// routeOnlinePush assigns the users to the gateway nodes holding their connections, according to the
+// presence registry. Users held by no node are returned as offline. routed is false when the registry
+// can't be trusted, every node must then be asked about every user.
+func (p *Pusher) routeOnlinePush(ctx context.Context, conns []*grpc.ClientConn, userIDs []string) (routes map[string][]string, offlineUserIDs []string, routed bool) {
+	if !config.Config.LongConnSvr.Presence.Enable || len(conns) == 0 {
+		return nil, nil, false
+	}
+	targets := make([]string, 0, len(conns))
+	for _, conn := range conns {
+		targets = append(targets, conn.Target())
+	}
+	alive, err := p.database.GetAliveGatewayNodes(ctx, targets)
+	if err != nil {
+		log.ZWarn(ctx, "GetAliveGatewayNodes failed, broadcast", err)
+		return nil, nil, false
+	}
+	for _, target := range targets {
+		if !alive[target] {
+			log.ZDebug(ctx, "gateway node not in presence registry, broadcast", "node", target)
+			return nil, nil, false
+		}
+	}
+	usersNodes, err := p.database.GetUsersGatewayNodes(ctx, userIDs)
+	if err != nil {
+		log.ZWarn(ctx, "GetUsersGatewayNodes failed, broadcast", err)
+		return nil, nil, false
+	}
+
+	routes = make(map[string][]string, len(targets))
+	for _, userID := range userIDs {
+		nodes, ok := usersNodes[userID]
+		if !ok {
+			offlineUserIDs = append(offlineUserIDs, userID)
+			continue
+		}
+		// a node that left discovery may still be listed, then the user may be anywhere.
+		stale := false
+		for _, node := range nodes {
+			if !alive[node] {
+				stale = true
+				break
+			}
+		}
+		if stale {
+			nodes = targets
+		}
+		for _, node := range nodes {
+			routes[node] = append(routes[node], userID)
+		}
+	}
+	return routes, offlineUserIDs, true
+}
@@ -0,0 +1,259 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
+)
+
+const (
+	pushRecordBufferSize    = 10000
+	pushRecordFlushBatch    = 1000
+	pushRecordFlushInterval = time.Second
+	pushRecordWriteTimeout  = 10 * time.Second
+)
+
+var pushResultNames = map[int32]string{
+	unrelation.PushResultOK:           "ok",
+	unrelation.PushResultRetry:        "retry",
+	unrelation.PushResultPermanent:    "permanent",
+	unrelation.PushResultUnregistered: "unregistered",
+}
+
+// pushRecorder writes the push records in the background, pushes never wait for the database. Records
+// are dropped when the database can't keep up.
+type pushRecorder struct {
+	database controller.PushRecordDatabase
+	records  chan *unrelation.PushRecordModel
+	dropped  int64
+}
+
+func newPushRecorder(database controller.PushRecordDatabase) *pushRecorder {
+	return &pushRecorder{database: database, records: make(chan *unrelation.PushRecordModel, pushRecordBufferSize)}
+}
+
+func (r *pushRecorder) record(records []*unrelation.PushRecordModel) {
+	for _, record := range records {
+		select {
+		case r.records <- record:
+		default:
+			atomic.AddInt64(&r.dropped, 1)
+		}
+	}
+}
+
+func (r *pushRecorder) run() {
+	ticker := time.NewTicker(pushRecordFlushInterval)
+	defer ticker.Stop()
+	batch := make([]*unrelation.PushRecordModel, 0, pushRecordFlushBatch)
+	for {
+		select {
+		case record := <-r.records:
+			batch = append(batch, record)
+			if len(batch) < pushRecordFlushBatch {
+				continue
+			}
+		case <-ticker.C:
+		}
+		r.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (r *pushRecorder) flush(batch []*unrelation.PushRecordModel) {
+	ctx := mcontext.SetOperationID(context.Background(), "push_record_"+utils.OperationIDGenerator())
+	if dropped := atomic.SwapInt64(&r.dropped, 0); dropped > 0 {
+		log.ZWarn(ctx, "push records dropped", nil, "num", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, pushRecordWriteTimeout)
+	defer cancel()
+	if err := r.database.AddPushRecords(ctx, batch); err != nil {
+		log.ZError(ctx, "AddPushRecords failed", err, "num", len(batch))
+	}
+}
+
+// recordingPusher measures the Push calls of one provider, for the metrics and the push records.
+type recordingPusher struct {
+	provider string
+	pusher   offlinepush.OfflinePusher
+	recorder *pushRecorder
+}
+
+func (r *recordingPusher) BatchSize() int {
+	if limiter, ok := r.pusher.(offlinepush.BatchLimiter); ok {
+		return limiter.BatchSize()
+	}
+	return 0
+}
+
+func (r *recordingPusher) Push(ctx context.Context, userIDs []string, title, content string, opts *offlinepush.Opts) error {
+	start := time.Now()
+	err := r.pusher.Push(ctx, userIDs, title, content, opts)
+	latency := time.Since(start)
+	prommetrics.MsgOfflinePushProviderLatency.WithLabelValues(r.provider).Observe(latency.Seconds())
+
+	codes := pushResultCodes(userIDs, err)
+	counts := make(map[int32]int)
+	for _, code := range codes {
+		counts[code]++
+	}
+	for code, count := range counts {
+		prommetrics.MsgOfflinePushProviderCounter.WithLabelValues(r.provider, pushResultNames[code]).Add(float64(count))
+	}
+	if r.recorder == nil {
+		return err
+	}
+	var clientMsgID string
+	var contentType int32
+	if opts.Signal != nil {
+		clientMsgID = opts.Signal.ClientMsgID
+	}
+	if opts.Msg != nil {
+		contentType = opts.Msg.ContentType
+	}
+	records := make([]*unrelation.PushRecordModel, 0, len(userIDs))
+	for _, userID := range userIDs {
+		records = append(records, &unrelation.PushRecordModel{
+			Event:       unrelation.PushEventAttempt,
+			ClientMsgID: clientMsgID,
+			UserID:      userID,
+			ContentType: contentType,
+			Provider:    r.provider,
+			Code:        codes[userID],
+			Latency:     latency.Milliseconds(),
+			CreateTime:  start,
+		})
+	}
+	r.recorder.record(records)
+	return err
+}
+
+// pushResultCodes tells the result of a Push call for each user. Without details from the pusher,
+// the error applies to every user.
+func pushResultCodes(userIDs []string, err error) map[string]int32 {
+	codes := make(map[string]int32, len(userIDs))
+	var pushErr *offlinepush.PushError
+	switch {
+	case err == nil:
+		for _, userID := range userIDs {
+			codes[userID] = unrelation.PushResultOK
+		}
+	case !errors.As(err, &pushErr):
+		for _, userID := range userIDs {
+			codes[userID] = unrelation.PushResultRetry
+		}
+	default:
+		failed := int32(unrelation.PushResultRetry)
+		if pushErr.Permanent {
+			failed = unrelation.PushResultPermanent
+		}
+		rest := failed
+		if len(pushErr.RetryUserIDs) > 0 || len(pushErr.Unregistered) > 0 {
+			rest = unrelation.PushResultOK
+		}
+		for _, userID := range userIDs {
+			codes[userID] = rest
+		}
+		for _, token := range pushErr.Unregistered {
+			codes[token.UserID] = unrelation.PushResultUnregistered
+		}
+		for _, userID := range pushErr.RetryUserIDs {
+			codes[userID] = failed
+		}
+	}
+	return codes
+}
+
+// recordPushed records Sent for the users a Push call reached, once per platform a pusher reported as
+// accepted, or on platform 0 when the pushers didn't tell the platform. Pushes of no message, like the
+// quiet hours digests, are not recorded.
+func (p *Pusher) recordPushed(userIDs []string, opts *offlinepush.Opts, err error) {
+	if p.recorder == nil || opts.Signal == nil || opts.Signal.ClientMsgID == "" {
+		return
+	}
+	codes := pushResultCodes(userIDs, err)
+	var records []*unrelation.PushRecordModel
+	for _, userID := range userIDs {
+		platforms := opts.Receipt.Platforms(userID)
+		var accepted []int
+		for platformID, ok := range platforms {
+			if ok {
+				accepted = append(accepted, platformID)
+			}
+		}
+		if len(platforms) == 0 && codes[userID] == unrelation.PushResultOK {
+			accepted = []int{0}
+		}
+		records = append(records, pushEventRecords(unrelation.PushEventSent, userID, accepted, opts)...)
+	}
+	p.recorder.record(records)
+}
+
+// recordPushFailed records Failed for users the push gave up on, once per platform a pusher reported as
+// failed, or on platform 0 when the pushers didn't tell the platform.
+func (p *Pusher) recordPushFailed(userIDs []string, opts *offlinepush.Opts) {
+	if p.recorder == nil || opts.Signal == nil || opts.Signal.ClientMsgID == "" {
+		return
+	}
+	var records []*unrelation.PushRecordModel
+	for _, userID := range userIDs {
+		var failed []int
+		for platformID, ok := range opts.Receipt.Platforms(userID) {
+			if !ok {
+				failed = append(failed, platformID)
+			}
+		}
+		if len(failed) == 0 {
+			failed = []int{0}
+		}
+		records = append(records, pushEventRecords(unrelation.PushEventFailed, userID, failed, opts)...)
+	}
+	p.recorder.record(records)
+}
+
+func pushEventRecords(event int32, userID string, platformIDs []int, opts *offlinepush.Opts) []*unrelation.PushRecordModel {
+	var contentType int32
+	if opts.Msg != nil {
+		contentType = opts.Msg.ContentType
+	}
+	now := time.Now()
+	records := make([]*unrelation.PushRecordModel, 0, len(platformIDs))
+	for _, platformID := range platformIDs {
+		records = append(records, &unrelation.PushRecordModel{
+			Event:       event,
+			ClientMsgID: opts.Signal.ClientMsgID,
+			UserID:      userID,
+			PlatformID:  int32(platformID),
+			ContentType: contentType,
+			CreateTime:  now,
+		})
+	}
+	return records
+}
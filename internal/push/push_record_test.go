@@ -0,0 +1,108 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func TestPushResultCodes(t *testing.T) {
+	userIDs := []string{"a", "b", "c"}
+	tests := []struct {
+		name string
+		err  error
+		want map[string]int32
+	}{
+		{"ok", nil, map[string]int32{"a": unrelation.PushResultOK, "b": unrelation.PushResultOK, "c": unrelation.PushResultOK}},
+		{"transient", errors.New("timeout"), map[string]int32{"a": unrelation.PushResultRetry, "b": unrelation.PushResultRetry, "c": unrelation.PushResultRetry}},
+		{"permanent", &offlinepush.PushError{Permanent: true}, map[string]int32{"a": unrelation.PushResultPermanent, "b": unrelation.PushResultPermanent, "c": unrelation.PushResultPermanent}},
+		{
+			"partial",
+			&offlinepush.PushError{RetryUserIDs: []string{"b"}, Unregistered: []offlinepush.UnregisteredToken{{UserID: "c", PlatformID: 1}}},
+			map[string]int32{"a": unrelation.PushResultOK, "b": unrelation.PushResultRetry, "c": unrelation.PushResultUnregistered},
+		},
+	}
+	for _, test := range tests {
+		if got := pushResultCodes(userIDs, test.err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// drainRecords returns the buffered records as event:user:platform.
+func drainRecords(r *pushRecorder) []string {
+	var records []string
+	for {
+		select {
+		case record := <-r.records:
+			records = append(records, fmt.Sprintf("%d:%s:%d", record.Event, record.UserID, record.PlatformID))
+		default:
+			sort.Strings(records)
+			return records
+		}
+	}
+}
+
+func TestRecordPushed(t *testing.T) {
+	p := &Pusher{recorder: newPushRecorder(nil)}
+	opts := &offlinepush.Opts{Signal: &offlinepush.Signal{ClientMsgID: "m"}, Msg: &offlinepush.Msg{}, Receipt: offlinepush.NewReceipt()}
+	opts.Receipt.Add("a", 1, true)
+	opts.Receipt.Add("a", 2, false)
+	opts.Receipt.Add("d", 1, false)
+	err := &offlinepush.PushError{Err: errors.New("busy"), RetryUserIDs: []string{"c", "d"}}
+
+	p.recordPushed([]string{"a", "b", "c", "d"}, opts, err)
+	if got, want := drainRecords(p.recorder), []string{"1:a:1", "1:b:0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %v, want %v", got, want)
+	}
+
+	p.recordPushFailed([]string{"a", "c", "d"}, opts)
+	if got, want := drainRecords(p.recorder), []string{"2:a:2", "2:c:0", "2:d:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failed %v, want %v", got, want)
+	}
+
+	// pushes of no message, like digests, are not part of the funnel.
+	p.recordPushed([]string{"a"}, &offlinepush.Opts{Signal: &offlinepush.Signal{}}, nil)
+	if got := drainRecords(p.recorder); len(got) != 0 {
+		t.Errorf("digest recorded %v", got)
+	}
+}
+
+func TestDeadLetterRecordsFailed(t *testing.T) {
+	p := newRetryTestPusher(newMockPushDatabase())
+	p.recorder = newPushRecorder(nil)
+	opts := &offlinepush.Opts{Signal: &offlinepush.Signal{ClientMsgID: "m"}, Receipt: offlinepush.NewReceipt()}
+	job := newOfflinePushJob(context.Background(), "si_a_b", []string{"a", "b"}, "title", "content", opts)
+
+	p.scheduleOfflinePushRetry(context.Background(), job, &offlinepush.PushError{Err: errors.New("rejected"), RetryUserIDs: []string{"b"}, Permanent: true})
+	if got, want := drainRecords(p.recorder), []string{"2:b:0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failed %v, want %v", got, want)
+	}
+
+	// a job going back to the queue isn't failed yet.
+	job = newOfflinePushJob(context.Background(), "si_a_b", []string{"a"}, "title", "content", opts)
+	p.scheduleOfflinePushRetry(context.Background(), job, errors.New("timeout"))
+	if got := drainRecords(p.recorder); len(got) != 0 {
+		t.Errorf("retried job recorded %v", got)
+	}
+}
@@ -23,26 +23,53 @@ import (
 	"github.com/OpenIMSDK/protocol/constant"
 	pbpush "github.com/OpenIMSDK/protocol/push"
 	"github.com/OpenIMSDK/tools/discoveryregistry"
+	"github.com/OpenIMSDK/tools/errs"
 	"github.com/OpenIMSDK/tools/log"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/localcache"
+	tableunrelation "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/unrelation"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient"
 )
 
 type pushServer struct {
-	pusher *Pusher
+	pusher             *Pusher
+	pushRecordDatabase controller.PushRecordDatabase
 }
 
+// maxAckClientMsgIDs is how many messages one offline push ack can carry.
+const maxAckClientMsgIDs = 500
+
 func Start(client discoveryregistry.SvcDiscoveryRegistry, server *grpc.Server) error {
 	rdb, err := cache.NewRedis()
 	if err != nil {
 		return err
 	}
 	cacheModel := cache.NewMsgCacheModel(rdb)
-	offlinePusher := NewOfflinePusher(cacheModel)
-	database := controller.NewPushDatabase(cacheModel)
+	var (
+		pushRecordDatabase controller.PushRecordDatabase
+		recorder           *pushRecorder
+	)
+	if config.Config.Push.Analytics.Enable {
+		mongo, err := unrelation.NewMongo()
+		if err != nil {
+			return err
+		}
+		pushRecordDB, err := unrelation.NewPushRecordMongo(mongo.GetDatabase(), controller.PushRecordExpire())
+		if err != nil {
+			return err
+		}
+		pushRecordDatabase = controller.NewPushRecordDatabase(pushRecordDB)
+		recorder = newPushRecorder(pushRecordDatabase)
+		go recorder.run()
+	}
+	offlinePusher := NewOfflinePusher(cacheModel, recorder)
+	database := controller.NewPushDatabase(cacheModel, cache.NewPresenceCacheRedis(rdb), cache.NewPushRetryCacheRedis(rdb), cache.NewQuietDigestCacheRedis(rdb))
 	groupRpcClient := rpcclient.NewGroupRpcClient(client)
 	conversationRpcClient := rpcclient.NewConversationRpcClient(client)
 	clubRpcClient := rpcclient.NewClubRpcClient(client)
@@ -60,13 +87,18 @@ func Start(client discoveryregistry.SvcDiscoveryRegistry, server *grpc.Server) e
 		&msgRpcClient,
 		&userRpcClient,
 		&clubRpcClient,
+		recorder,
 	)
+	go pusher.runPushRetry()
+	go pusher.runQuietDigest()
+	go pusher.pushTemplates.Watch()
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
 		pbpush.RegisterPushMsgServiceServer(server, &pushServer{
-			pusher: pusher,
+			pusher:             pusher,
+			pushRecordDatabase: pushRecordDatabase,
 		})
 	}()
 	go func() {
@@ -105,3 +137,81 @@ func (r *pushServer) DelUserPushToken(
 	}
 	return &pbpush.DelUserPushTokenResp{}, nil
 }
+
+// GetFailedPushes lists the offline pushes given up on, most recent first.
+func (r *pushServer) GetFailedPushes(ctx context.Context, req *pbpush.GetFailedPushesReq) (*pbpush.GetFailedPushesResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	var pageNumber, showNumber int32
+	if req.Pagination != nil {
+		pageNumber = req.Pagination.PageNumber
+		showNumber = req.Pagination.ShowNumber
+	}
+	total, failedPushes, err := r.pusher.getFailedPushes(ctx, pageNumber, showNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &pbpush.GetFailedPushesResp{Total: total, FailedPushes: failedPushes}, nil
+}
+
+// ReplayFailedPushes sends failed offline pushes again through the retry queue.
+func (r *pushServer) ReplayFailedPushes(ctx context.Context, req *pbpush.ReplayFailedPushesReq) (*pbpush.ReplayFailedPushesResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	if len(req.JobIDs) == 0 {
+		return nil, errs.ErrArgs.Wrap("jobIDs is empty")
+	}
+	if !config.Config.Push.Retry.Enable {
+		return nil, errs.ErrArgs.Wrap("push retry is disabled")
+	}
+	jobIDs, err := r.pusher.replayFailedPushes(ctx, req.JobIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &pbpush.ReplayFailedPushesResp{JobIDs: jobIDs}, nil
+}
+
+// GetPushTemplate returns the offline push template set in use, as yaml.
+func (r *pushServer) GetPushTemplate(ctx context.Context, req *pbpush.GetPushTemplateReq) (*pbpush.GetPushTemplateResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	return &pbpush.GetPushTemplateResp{Template: string(r.pusher.pushTemplates.Raw())}, nil
+}
+
+// SetPushTemplate replaces the offline push template set, every push instance picks it up from the config registry.
+func (r *pushServer) SetPushTemplate(ctx context.Context, req *pbpush.SetPushTemplateReq) (*pbpush.SetPushTemplateResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	if err := r.pusher.pushTemplates.Set([]byte(req.Template)); err != nil {
+		return nil, err
+	}
+	return &pbpush.SetPushTemplateResp{}, nil
+}
+
+// AckOfflinePush records that offline pushes reached a device of the user or were opened there, Event is
+// PushEventDelivered or PushEventOpened. Acks of messages that were not pushed to the user are ignored.
+func (r *pushServer) AckOfflinePush(ctx context.Context, req *pbpush.AckOfflinePushReq) (*pbpush.AckOfflinePushResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if req.Event != tableunrelation.PushEventDelivered && req.Event != tableunrelation.PushEventOpened {
+		return nil, errs.ErrArgs.Wrap("invalid event")
+	}
+	if constant.PlatformIDToName(int(req.PlatformID)) == "" {
+		return nil, errs.ErrArgs.Wrap("invalid platformID")
+	}
+	if len(req.ClientMsgIDs) == 0 || len(req.ClientMsgIDs) > maxAckClientMsgIDs {
+		return nil, errs.ErrArgs.Wrap("clientMsgIDs is empty or too long")
+	}
+	if r.pushRecordDatabase == nil {
+		return nil, errs.ErrArgs.Wrap("push analytics is disabled")
+	}
+	if err := r.pushRecordDatabase.AckPushes(ctx, req.UserID, req.PlatformID, req.Event, req.ClientMsgIDs); err != nil {
+		return nil, err
+	}
+	return &pbpush.AckOfflinePushResp{}, nil
+}
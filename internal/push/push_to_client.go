@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
@@ -35,11 +36,19 @@ import (
 
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlineinfo"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/apns"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/dummy"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/fcm"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/getui"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/gorush"
 	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/jpush"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/honor"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/huawei"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/oppo"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/vivo"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/vendorpush/xiaomi"
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/webpush"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
@@ -62,6 +71,9 @@ type Pusher struct {
 	offlineInfoParse       *offlineinfo.OfflineInfoParse
 	userRpcClient          *rpcclient.UserRpcClient
 	clubRpcClient          *rpcclient.ClubRpcClient
+	retryConf              *pushRetryConf
+	pushTemplates          *offlineinfo.TemplateStore
+	recorder               *pushRecorder
 }
 
 var errNoOfflinePusher = errors.New("no offlinePusher is configured")
@@ -69,8 +81,9 @@ var errNoOfflinePusher = errors.New("no offlinePusher is configured")
 func NewPusher(discov discoveryregistry.SvcDiscoveryRegistry, offlinePusher offlinepush.OfflinePusher, database controller.PushDatabase,
 	groupLocalCache *localcache.GroupLocalCache, conversationLocalCache *localcache.ConversationLocalCache, serverLocalCache *localcache.ServerLocalCache,
 	conversationRpcClient *rpcclient.ConversationRpcClient, groupRpcClient *rpcclient.GroupRpcClient, msgRpcClient *rpcclient.MessageRpcClient,
-	userRpcClient *rpcclient.UserRpcClient, clubRpcClient *rpcclient.ClubRpcClient,
+	userRpcClient *rpcclient.UserRpcClient, clubRpcClient *rpcclient.ClubRpcClient, recorder *pushRecorder,
 ) *Pusher {
+	pushTemplates := offlineinfo.NewTemplateStore(discov)
 	return &Pusher{
 		discov:                 discov,
 		database:               database,
@@ -81,15 +94,41 @@ func NewPusher(discov discoveryregistry.SvcDiscoveryRegistry, offlinePusher offl
 		msgRpcClient:           msgRpcClient,
 		conversationRpcClient:  conversationRpcClient,
 		groupRpcClient:         groupRpcClient,
-		offlineInfoParse:       offlineinfo.NewOfflineInfoParse(groupRpcClient, clubRpcClient),
+		offlineInfoParse:       offlineinfo.NewOfflineInfoParse(groupRpcClient, clubRpcClient, pushTemplates),
 		userRpcClient:          userRpcClient,
 		clubRpcClient:          clubRpcClient,
+		retryConf:              newPushRetryConf(),
+		pushTemplates:          pushTemplates,
+		recorder:               recorder,
 	}
 }
 
-func NewOfflinePusher(cache cache.MsgModel) offlinepush.OfflinePusher {
+// NewOfflinePusher builds the pushers listed in push.enable, several pushers separated by commas all
+// get every push. The attempts of each pusher are measured, and recorded when recorder isn't nil.
+func NewOfflinePusher(cache cache.MsgModel, recorder *pushRecorder) offlinepush.OfflinePusher {
+	var pushers []offlinepush.NamedPusher
+	for _, name := range strings.Split(config.Config.Push.Enable, ",") {
+		name = strings.TrimSpace(name)
+		if pusher := newOfflinePusher(name, cache); pusher != nil {
+			pushers = append(pushers, offlinepush.NamedPusher{
+				Name:          name,
+				OfflinePusher: &recordingPusher{provider: name, pusher: pusher, recorder: recorder},
+			})
+		}
+	}
+	switch len(pushers) {
+	case 0:
+		return dummy.NewClient()
+	case 1:
+		return pushers[0].OfflinePusher
+	default:
+		return offlinepush.NewComposite(pushers...)
+	}
+}
+
+func newOfflinePusher(name string, cache cache.MsgModel) offlinepush.OfflinePusher {
 	var offlinePusher offlinepush.OfflinePusher
-	switch config.Config.Push.Enable {
+	switch name {
 	case "getui":
 		offlinePusher = getui.NewClient(cache)
 	case "fcm":
@@ -98,12 +137,46 @@ func NewOfflinePusher(cache cache.MsgModel) offlinepush.OfflinePusher {
 		offlinePusher = jpush.NewClient()
 	case "gorush":
 		offlinePusher = gorush.NewClient(cache)
-	default:
-		offlinePusher = dummy.NewClient()
+	case "apns":
+		client, err := apns.NewClient(cache)
+		if err != nil {
+			panic(err)
+		}
+		offlinePusher = client
+	case "webpush":
+		client, err := webpush.NewClient(cache)
+		if err != nil {
+			panic(err)
+		}
+		offlinePusher = client
+	case "vendor":
+		offlinePusher = vendorpush.NewClient(cache, newVendorSenders())
 	}
 	return offlinePusher
 }
 
+// newVendorSenders returns a sender for every vendor push service with credentials configured.
+func newVendorSenders() map[string]vendorpush.Sender {
+	conf := config.Config.Push.Vendor
+	senders := make(map[string]vendorpush.Sender)
+	if conf.Huawei.AppID != "" {
+		senders[cache.PushVendorHuawei] = huawei.NewClient()
+	}
+	if conf.Honor.AppID != "" {
+		senders[cache.PushVendorHonor] = honor.NewClient()
+	}
+	if conf.Xiaomi.AppSecret != "" {
+		senders[cache.PushVendorXiaomi] = xiaomi.NewClient()
+	}
+	if conf.OPPO.AppKey != "" {
+		senders[cache.PushVendorOPPO] = oppo.NewClient()
+	}
+	if conf.Vivo.AppID != "" {
+		senders[cache.PushVendorVivo] = vivo.NewClient()
+	}
+	return senders
+}
+
 func (p *Pusher) DeleteMemberAndSetConversationSeq(ctx context.Context, groupID string, userIDs []string) error {
 	conevrsationID := msgprocessor.GetConversationIDBySessionType(constant.SuperGroupChatType, groupID)
 	maxSeq, err := p.msgRpcClient.GetConversationMaxSeq(ctx, conevrsationID)
@@ -129,25 +202,22 @@ func (p *Pusher) Push2User(ctx context.Context, userIDs []string, msg *sdkws.Msg
 	isOfflinePush := utils.GetSwitchFromOptions(msg.Options, constant.IsOfflinePush)
 	log.ZDebug(ctx, "push_result", "ws push result", wsResults, "sendData", msg, "isOfflinePush", isOfflinePush, "push_to_userID", userIDs)
 
-	if !isOfflinePush {
-		return nil
-	}
-
-	for _, v := range wsResults {
-		if msg.SendID != v.UserID && (!v.OnlinePush) {
-			if err = callbackOfflinePush(ctx, userIDs, msg, &[]string{}); err != nil {
-				return err
-			}
-
-			//newMsgPushMode
-			//p.userRpcClient.Client.GetUserSetting()
-			err = p.offlinePushMsg(ctx, msg.SendID, msg, []string{v.UserID})
-			if err != nil {
-				return err
+	var offlinePushUserIDs []string
+	if isOfflinePush {
+		for _, v := range wsResults {
+			if msg.SendID != v.UserID && (!v.OnlinePush) {
+				offlinePushUserIDs = append(offlinePushUserIDs, v.UserID)
 			}
 		}
 	}
-	return nil
+	p.dropBadges(ctx, userIDs, offlinePushUserIDs)
+	if len(offlinePushUserIDs) == 0 {
+		return nil
+	}
+	if err = callbackOfflinePush(ctx, userIDs, msg, &[]string{}); err != nil {
+		return err
+	}
+	return p.offlinePushMsg(ctx, msg.SendID, msg, offlinePushUserIDs)
 }
 
 func (p *Pusher) UnmarshalNotificationElem(bytes []byte, t interface{}) error {
@@ -278,19 +348,21 @@ func (p *Pusher) Push2SuperGroup(ctx context.Context, groupID string, msg *sdkws
 			if len(offlinePushUserIDs) > 0 {
 				needOfflinePushUserIDs = offlinePushUserIDs
 			}
-
-			if len(needOfflinePushUserIDs) > 0 {
-				err = p.offlinePushMsg(ctx, groupID, msg, needOfflinePushUserIDs)
-				if err != nil {
-					log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg)
-					return err
-				}
-				if _, err := p.GetConnsAndOnlinePush(ctx, msg, utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs)); err != nil {
-					log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg, "userIDs", utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs))
-					return err
-				}
+		}
+		p.dropBadges(ctx, pushToUserIDs, needOfflinePushUserIDs)
+		if len(needOfflinePushUserIDs) > 0 {
+			err = p.offlinePushMsg(ctx, groupID, msg, needOfflinePushUserIDs)
+			if err != nil {
+				log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg)
+				return err
+			}
+			if _, err := p.GetConnsAndOnlinePush(ctx, msg, utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs)); err != nil {
+				log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg, "userIDs", utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs))
+				return err
 			}
 		}
+	} else {
+		p.dropBadges(ctx, pushToUserIDs, nil)
 	}
 	return nil
 }
@@ -368,19 +440,21 @@ func (p *Pusher) Push2ServerGroup(ctx context.Context, groupID string, msg *sdkw
 			if len(offlinePushUserIDs) > 0 {
 				needOfflinePushUserIDs = offlinePushUserIDs
 			}
-
-			if len(needOfflinePushUserIDs) > 0 {
-				err = p.offlinePushMsg(ctx, groupID, msg, needOfflinePushUserIDs)
-				if err != nil {
-					log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg)
-					return err
-				}
-				if _, err := p.GetConnsAndOnlinePush(ctx, msg, utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs)); err != nil {
-					log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg, "userIDs", utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs))
-					return err
-				}
+		}
+		p.dropBadges(ctx, pushToUserIDs, needOfflinePushUserIDs)
+		if len(needOfflinePushUserIDs) > 0 {
+			err = p.offlinePushMsg(ctx, groupID, msg, needOfflinePushUserIDs)
+			if err != nil {
+				log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg)
+				return err
+			}
+			if _, err := p.GetConnsAndOnlinePush(ctx, msg, utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs)); err != nil {
+				log.ZError(ctx, "offlinePushMsg failed", err, "groupID", groupID, "msg", msg, "userIDs", utils.IntersectString(needOfflinePushUserIDs, webAndPcBackgroundUserIDs))
+				return err
 			}
 		}
+	} else {
+		p.dropBadges(ctx, pushToUserIDs, nil)
 	}
 	return nil
 }
@@ -395,10 +469,11 @@ func (p *Pusher) GetConnsAndOnlinePush(ctx context.Context, msg *sdkws.MsgData,
 	var (
 		mu         sync.Mutex
 		wg         = errgroup.Group{}
-		input      = &msggateway.OnlineBatchPushOneMsgReq{MsgData: msg, PushToUserIDs: pushToUserIDs}
 		maxWorkers = config.Config.Push.MaxConcurrentWorkers
 	)
 
+	routes, offlineUserIDs, routed := p.routeOnlinePush(ctx, conns, pushToUserIDs)
+
 	if maxWorkers < 3 {
 		maxWorkers = 3
 	}
@@ -408,6 +483,13 @@ func (p *Pusher) GetConnsAndOnlinePush(ctx context.Context, msg *sdkws.MsgData,
 	// Online push message
 	for _, conn := range conns {
 		conn := conn // loop var safe
+		input := &msggateway.OnlineBatchPushOneMsgReq{MsgData: msg, PushToUserIDs: pushToUserIDs}
+		if routed {
+			input.PushToUserIDs = routes[conn.Target()]
+			if len(input.PushToUserIDs) == 0 {
+				continue
+			}
+		}
 		wg.Go(func() error {
 			msgClient := msggateway.NewMsgGatewayClient(conn)
 			reply, err := msgClient.SuperGroupOnlineBatchPushOneMsg(ctx, input)
@@ -428,60 +510,18 @@ func (p *Pusher) GetConnsAndOnlinePush(ctx context.Context, msg *sdkws.MsgData,
 
 	_ = wg.Wait()
 
-	// always return nil
-	return wsResults, nil
-}
-
-func (p *Pusher) offlinePushMsg(ctx context.Context, conversationID string, msg *sdkws.MsgData, offlinePushUserIDs []string) error {
-	for _, userID := range offlinePushUserIDs {
-		p.offlinePushMsgToUser(ctx, conversationID, msg, userID)
-	}
-	return nil
-}
-
-func (p *Pusher) offlinePushMsgToUser(ctx context.Context, conversationID string, msg *sdkws.MsgData, userID string) error {
-
-	userPushSetting, err := p.userRpcClient.Client.GetUserSetting(ctx, &user.GetUserSettingReq{UserID: userID})
-	if err != nil {
-		return err
-	}
-
-	//user not allow offline push
-	if userPushSetting.GlobalRecvMsgOpt == constant.ReceiveNotPushMessage {
-		return nil
-	}
-
-	title, content, opts, err := p.getOfflinePushInfos(ctx, conversationID, userID, msg, userPushSetting)
-	if err != nil {
-		return err
+	// users no gateway holds still get a result, so they are pushed offline.
+	for _, userID := range offlineUserIDs {
+		wsResults = append(wsResults, &msggateway.SingleMsgToUserResults{UserID: userID})
 	}
 
-	// pus msg content
-
-	// if msg.ContentType == constant.AtText {
-	// 	if utils.Contain(userID, msg.AtUserIDList...) {
-	// 		content = constant.ContentType2PushContentI18n[constant.AtText]
-	// 	}
-	// 	err := p.offlinePusher.Push(ctx, []string{userID}, title, constant.ContentType2PushContentI18n[constant.Common], opts)
-
-	// 	if err != nil {
-	// 		prommetrics.MsgOfflinePushFailedCounter.Inc()
-	// 		return err
-	// 	}
-	// 	return nil
-	// }
-
-	err = p.offlinePusher.Push(ctx, []string{userID}, title, content, opts)
-	if err != nil {
-		prommetrics.MsgOfflinePushFailedCounter.Inc()
-		return err
-	}
-	return nil
+	// always return nil
+	return wsResults, nil
 }
 
 func (p *Pusher) GetOfflinePushOpts(ctx context.Context, msg *sdkws.MsgData) (opts *offlinepush.Opts, err error) {
 	opts = &offlinepush.Opts{
-		Signal: &offlinepush.Signal{},
+		Signal: &offlinepush.Signal{ClientMsgID: msg.ClientMsgID},
 		Server: &offlinepush.Server{},
 		Msg: &offlinepush.Msg{
 			ConversationID: msgprocessor.GetConversationIDByMsg(msg),
@@ -504,34 +544,14 @@ func (p *Pusher) GetOfflinePushOpts(ctx context.Context, msg *sdkws.MsgData) (op
 	return opts, nil
 }
 
-func (p *Pusher) getOfflinePushInfos(ctx context.Context, conversationID, userID string, msg *sdkws.MsgData, userPushSetting *user.GetUserSettingResp) (title, content string, opts *offlinepush.Opts, err error) {
-	if p.offlinePusher == nil {
-		err = errNoOfflinePusher
-		return
-	}
-
-	opts, err = p.GetOfflinePushOpts(ctx, msg)
-	if err != nil {
-		return
-	}
-
-	if userPushSetting.AllowBeep == constant.NewMsgPushSettingAllowed {
-		opts.IOSPushSound = "default"
-	}
-	if userPushSetting.AllowVibration == constant.NewMsgPushSettingAllowed {
-
-	}
-
+// renderOfflinePush renders the title and content a user with this setting sees for msg.
+func (p *Pusher) renderOfflinePush(ctx context.Context, msg *sdkws.MsgData, userPushSetting *user.GetUserSettingResp) (title, content string, err error) {
 	if msg.OfflinePushInfo != nil {
 		title = msg.OfflinePushInfo.Title
 		content = msg.OfflinePushInfo.Desc
 	}
 
-	language := "zh_CN"
-	if userPushSetting.Language != "" {
-		language = userPushSetting.Language
-	}
-	lang := i18n.Language(language)
+	lang := p.pushLanguage(userPushSetting.Language)
 
 	if title == "" {
 		var offlineMsg *offlineinfo.OfflineMsg
@@ -548,3 +568,11 @@ func (p *Pusher) getOfflinePushInfos(ctx context.Context, conversationID, userID
 	}
 	return
 }
+
+// pushLanguage is the language of the pushes to a user with this language setting.
+func (p *Pusher) pushLanguage(language string) i18n.Language {
+	if language == "" {
+		return i18n.Language(p.pushTemplates.DefaultLanguage())
+	}
+	return i18n.Language(language)
+}
@@ -0,0 +1,227 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/protocol/user"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/OpenIMSDK/tools/utils/splitter"
+
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	tablerelation "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/i18n"
+)
+
+const (
+	quietDigestPollInterval = 10 * time.Second
+	quietDigestPollBatch    = 500
+	quietDigestTimeout      = 30 * time.Second
+	quietDigestRetryDelay   = time.Minute
+)
+
+// quietHoursLocations caches the time zones of quiet hours, loading one reads the zoneinfo database.
+var quietHoursLocations sync.Map
+
+func quietHoursLocation(name string) *time.Location {
+	if loc, ok := quietHoursLocations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	quietHoursLocations.Store(name, loc)
+	return loc
+}
+
+// pickQuietHours returns the most specific quiet hours: conversation, then server, then global.
+func pickQuietHours(quietHours []*user.QuietHours) *user.QuietHours {
+	var picked *user.QuietHours
+	rank := func(q *user.QuietHours) int {
+		switch q.TargetType {
+		case tablerelation.QuietHoursConversation:
+			return 3
+		case tablerelation.QuietHoursServer:
+			return 2
+		default:
+			return 1
+		}
+	}
+	for _, q := range quietHours {
+		if picked == nil || rank(q) > rank(picked) {
+			picked = q
+		}
+	}
+	return picked
+}
+
+// quietUntil reports whether now falls in the quiet hours q, and when they end.
+func quietUntil(q *user.QuietHours, now time.Time) (time.Time, bool) {
+	loc := quietHoursLocation(q.TimeZone)
+	local := now.In(loc)
+	minute := int32(local.Hour()*60 + local.Minute())
+	endOn := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, 0, int(q.End), 0, 0, loc)
+	}
+	if q.Start < q.End {
+		if minute >= q.Start && minute < q.End {
+			return endOn(0), true
+		}
+		return time.Time{}, false
+	}
+	// the window crosses midnight.
+	if minute >= q.Start {
+		return endOn(1), true
+	}
+	if minute < q.End {
+		return endOn(0), true
+	}
+	return time.Time{}, false
+}
+
+func isMentioned(msg *sdkws.MsgData, userID string) bool {
+	for _, atUserID := range msg.AtUserIDList {
+		if atUserID == userID || atUserID == constant.AtAllString {
+			return true
+		}
+	}
+	return false
+}
+
+// filterQuietHours drops the users in their quiet hours from userIDs, unless they allow mentions through and
+// msg mentions them. Held back pushes are counted for the digest sent once the quiet hours are over.
+func (p *Pusher) filterQuietHours(ctx context.Context, msg *sdkws.MsgData, opts *offlinepush.Opts, userIDs []string) []string {
+	if !config.Config.Push.QuietHours.Enable || len(userIDs) == 0 {
+		return userIDs
+	}
+	quietHours, err := p.userRpcClient.GetUsersQuietHours(ctx, userIDs, opts.Msg.ConversationID, opts.Server.ServerID)
+	if err != nil {
+		// rather push during quiet hours than lose the push.
+		log.ZWarn(ctx, "GetUsersQuietHours failed", err, "conversationID", opts.Msg.ConversationID)
+		return userIDs
+	}
+	if len(quietHours) == 0 {
+		return userIDs
+	}
+	now := time.Now()
+	held := make(map[string]time.Time)
+	pushUserIDs := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		q := pickQuietHours(quietHours[userID])
+		if q == nil || (q.AllowMention && isMentioned(msg, userID)) {
+			pushUserIDs = append(pushUserIDs, userID)
+			continue
+		}
+		end, ok := quietUntil(q, now)
+		if !ok {
+			pushUserIDs = append(pushUserIDs, userID)
+			continue
+		}
+		held[userID] = end
+	}
+	if len(held) > 0 {
+		log.ZDebug(ctx, "offline push held back by quiet hours", "conversationID", opts.Msg.ConversationID, "num", len(held))
+		if config.Config.Push.QuietHours.Digest {
+			if err := p.database.AddQuietDigest(ctx, held); err != nil {
+				log.ZError(ctx, "AddQuietDigest failed", err, "conversationID", opts.Msg.ConversationID)
+			}
+		}
+	}
+	return pushUserIDs
+}
+
+// runQuietDigest sends the users whose quiet hours ended one push summing up what was held back. Users are
+// taken atomically, so every push instance can run it.
+func (p *Pusher) runQuietDigest() {
+	if !config.Config.Push.QuietHours.Enable || !config.Config.Push.QuietHours.Digest || p.offlinePusher == nil {
+		return
+	}
+	ticker := time.NewTicker(quietDigestPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			ctx, cancel := context.WithTimeout(mcontext.SetOperationID(context.Background(), "quiet_digest_"+utils.OperationIDGenerator()), quietDigestTimeout)
+			digests, err := p.database.PopDueQuietDigests(ctx, time.Now(), quietDigestPollBatch)
+			if err != nil {
+				log.ZError(ctx, "PopDueQuietDigests failed", err)
+				cancel()
+				break
+			}
+			p.pushQuietDigests(ctx, digests)
+			cancel()
+			if len(digests) < quietDigestPollBatch {
+				break
+			}
+		}
+	}
+}
+
+func (p *Pusher) pushQuietDigests(ctx context.Context, digests map[string]int64) {
+	if len(digests) == 0 {
+		return
+	}
+	userIDs := make([]string, 0, len(digests))
+	for userID := range digests {
+		userIDs = append(userIDs, userID)
+	}
+	settings, err := p.userRpcClient.GetUsersSetting(ctx, userIDs)
+	if err != nil {
+		log.ZError(ctx, "GetUsersSetting failed, digests put back", err, "num", len(userIDs))
+		if err := p.database.RestoreQuietDigests(ctx, digests, time.Now().Add(quietDigestRetryDelay)); err != nil {
+			log.ZError(ctx, "RestoreQuietDigests failed", err, "num", len(digests))
+		}
+		return
+	}
+	payloads := make(map[payloadKey][]string)
+	for userID, count := range digests {
+		setting, ok := settings[userID]
+		if !ok || count <= 0 || setting.GlobalRecvMsgOpt == constant.ReceiveNotPushMessage {
+			continue
+		}
+		lang := p.pushLanguage(setting.Language)
+		pk := payloadKey{
+			title:   i18n.Tr(lang, "msg.push.common.title"),
+			content: i18n.TrWithData(lang, "msg.push.common.digest", map[string]any{"count": count}),
+		}
+		if setting.AllowBeep == constant.NewMsgPushSettingAllowed {
+			pk.sound = "default"
+		}
+		payloads[pk] = append(payloads[pk], userID)
+	}
+	batchSize := p.offlinePushBatchSize()
+	for pk, userIDs := range payloads {
+		opts := &offlinepush.Opts{
+			Signal:       &offlinepush.Signal{},
+			Server:       &offlinepush.Server{},
+			Msg:          &offlinepush.Msg{},
+			IOSPushSound: pk.sound,
+		}
+		for _, batch := range splitter.NewSplitter(batchSize, userIDs).GetSplitResult() {
+			if err := p.offlinePusher.Push(ctx, batch.Item, pk.title, pk.content, opts); err != nil {
+				job := newOfflinePushJob(ctx, "", batch.Item, pk.title, pk.content, opts)
+				p.handleOfflinePushFailure(ctx, job, err)
+			}
+		}
+	}
+}
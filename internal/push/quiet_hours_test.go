@@ -0,0 +1,71 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/user"
+
+	tablerelation "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
+)
+
+func TestQuietUntil(t *testing.T) {
+	overnight := &user.QuietHours{Start: 22 * 60, End: 7 * 60, TimeZone: "Asia/Shanghai"}
+	daytime := &user.QuietHours{Start: 9 * 60, End: 18 * 60, TimeZone: "UTC"}
+	tests := []struct {
+		name  string
+		q     *user.QuietHours
+		now   string
+		quiet bool
+		end   string
+	}{
+		{"overnight before midnight", overnight, "2026-10-19T15:00:00Z", true, "2026-10-19T23:00:00Z"},
+		{"overnight after midnight", overnight, "2026-10-19T20:00:00Z", true, "2026-10-19T23:00:00Z"},
+		{"overnight at end", overnight, "2026-10-19T23:00:00Z", false, ""},
+		{"overnight afternoon", overnight, "2026-10-19T05:00:00Z", false, ""},
+		{"daytime inside", daytime, "2026-10-19T12:00:00Z", true, "2026-10-19T18:00:00Z"},
+		{"daytime before", daytime, "2026-10-19T08:59:00Z", false, ""},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.now)
+		end, quiet := quietUntil(tt.q, now)
+		if quiet != tt.quiet {
+			t.Fatalf("%s: quiet = %v, want %v", tt.name, quiet, tt.quiet)
+		}
+		if !quiet {
+			continue
+		}
+		if want, _ := time.Parse(time.RFC3339, tt.end); !end.Equal(want) {
+			t.Fatalf("%s: end = %v, want %v", tt.name, end, want)
+		}
+	}
+}
+
+func TestPickQuietHours(t *testing.T) {
+	global := &user.QuietHours{TargetType: tablerelation.QuietHoursGlobal}
+	server := &user.QuietHours{TargetType: tablerelation.QuietHoursServer, TargetID: "s1"}
+	conversation := &user.QuietHours{TargetType: tablerelation.QuietHoursConversation, TargetID: "sg_1"}
+	if q := pickQuietHours([]*user.QuietHours{global, conversation, server}); q != conversation {
+		t.Fatalf("picked %v, want the conversation quiet hours", q)
+	}
+	if q := pickQuietHours([]*user.QuietHours{server, global}); q != server {
+		t.Fatalf("picked %v, want the server quiet hours", q)
+	}
+	if q := pickQuietHours(nil); q != nil {
+		t.Fatalf("picked %v, want nil", q)
+	}
+}
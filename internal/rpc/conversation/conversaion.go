@@ -57,6 +57,13 @@ func Start(client discoveryregistry.SvcDiscoveryRegistry, server *grpc.Server) e
 		return err
 	}
 	conversationDB := relation.NewConversationGorm(db)
+	backfilled, err := conversationDB.BackfillMsgDestructDueTime(context.Background())
+	if err != nil {
+		return err
+	}
+	if backfilled > 0 {
+		log.ZInfo(context.Background(), "backfill msg destruct due time", "conversations", backfilled)
+	}
 	groupRpcClient := rpcclient.NewGroupRpcClient(client)
 	msgRpcClient := rpcclient.NewMessageRpcClient(client)
 	clubRpcClient := rpcclient.NewClubRpcClient(client)
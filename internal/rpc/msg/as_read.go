@@ -67,6 +67,63 @@ func (m *msgServer) GetConversationsHasReadAndMaxSeq(ctx context.Context, req *m
 	return resp, nil
 }
 
+// GetUsersUnreadCount Get the unread messages of each user summed over the conversations they haven't muted,
+// it is the badge the push service shows.
+func (m *msgServer) GetUsersUnreadCount(ctx context.Context, req *msg.GetUsersUnreadCountReq) (resp *msg.GetUsersUnreadCountResp, err error) {
+	resp = &msg.GetUsersUnreadCountResp{UnreadCounts: make(map[string]int64, len(req.UserIDs))}
+	for _, userID := range req.UserIDs {
+		unreadCount, err := m.getUnreadCount(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		resp.UnreadCounts[userID] = unreadCount
+	}
+	return resp, nil
+}
+
+func (m *msgServer) getUnreadCount(ctx context.Context, userID string) (int64, error) {
+	conversationIDs, err := m.ConversationLocalCache.GetConversationIDs(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	conversations, err := m.Conversation.GetConversations(ctx, userID, conversationIDs)
+	if err != nil {
+		return 0, err
+	}
+	notMutedIDs := make([]string, 0, len(conversations))
+	conversationMaxSeqMap := make(map[string]int64)
+	for _, conversation := range conversations {
+		if conversation.RecvMsgOpt != constant.ReceiveMessage {
+			continue
+		}
+		notMutedIDs = append(notMutedIDs, conversation.ConversationID)
+		if conversation.MaxSeq != 0 {
+			conversationMaxSeqMap[conversation.ConversationID] = conversation.MaxSeq
+		}
+	}
+	if len(notMutedIDs) == 0 {
+		return 0, nil
+	}
+	hasReadSeqs, err := m.MsgDatabase.GetHasReadSeqs(ctx, userID, notMutedIDs)
+	if err != nil {
+		return 0, err
+	}
+	maxSeqs, err := m.MsgDatabase.GetMaxSeqs(ctx, notMutedIDs)
+	if err != nil {
+		return 0, err
+	}
+	var unreadCount int64
+	for conversationID, maxSeq := range maxSeqs {
+		if v, ok := conversationMaxSeqMap[conversationID]; ok {
+			maxSeq = v
+		}
+		if unread := maxSeq - hasReadSeqs[conversationID]; unread > 0 {
+			unreadCount += unread
+		}
+	}
+	return unreadCount, nil
+}
+
 func (m *msgServer) SetConversationHasReadSeq(
 	ctx context.Context,
 	req *msg.SetConversationHasReadSeqReq,
@@ -173,6 +230,9 @@ func (m *msgServer) MarkConversationAsRead(
 			if err != nil {
 				return nil, err
 			}
+			if conversation.ConversationType == constant.SuperGroupChatType {
+				m.sendGroupMsgReadCountNotifications(ctx, req.ConversationID, conversation.GroupID, req.UserID, hasReadSeq, req.HasReadSeq)
+			}
 			hasReadSeq = req.HasReadSeq
 		}
 		if err = m.sendMarkAsReadNotification(ctx, req.ConversationID, constant.SingleChatType, req.UserID,
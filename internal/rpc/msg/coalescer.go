@@ -0,0 +1,51 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"sync"
+	"time"
+)
+
+// coalescer flushes each key at most once per window. The first value added for a key starts its window,
+// the values added until the window ends are merged into it and flushed together.
+type coalescer[K comparable, V any] struct {
+	window  time.Duration
+	merge   func(pending, v V) V
+	flush   func(key K, v V)
+	mu      sync.Mutex
+	pending map[K]V
+}
+
+func newCoalescer[K comparable, V any](window time.Duration, merge func(pending, v V) V, flush func(key K, v V)) *coalescer[K, V] {
+	return &coalescer[K, V]{window: window, merge: merge, flush: flush, pending: make(map[K]V)}
+}
+
+func (c *coalescer[K, V]) Add(key K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pending, ok := c.pending[key]; ok {
+		c.pending[key] = c.merge(pending, v)
+		return
+	}
+	c.pending[key] = v
+	time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		v := c.pending[key]
+		delete(c.pending, key)
+		c.mu.Unlock()
+		c.flush(key, v)
+	})
+}
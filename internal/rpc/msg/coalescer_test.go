@@ -0,0 +1,60 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalescer(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		flushed = make(map[string][]int)
+		done    = make(chan struct{}, 2)
+	)
+	c := newCoalescer(20*time.Millisecond, func(pending, v []int) []int {
+		return append(pending, v...)
+	}, func(key string, v []int) {
+		mu.Lock()
+		flushed[key] = append(flushed[key], v...)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+	c.Add("a", []int{1})
+	c.Add("b", []int{2})
+	c.Add("a", []int{3})
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("not flushed")
+		}
+	}
+	mu.Lock()
+	if fmt.Sprint(flushed) != "map[a:[1 3] b:[2]]" {
+		t.Errorf("flushed %v", flushed)
+	}
+	mu.Unlock()
+	c.Add("a", []int{4})
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(flushed["a"]) != "[1 3 4]" {
+		t.Errorf("a value added after the flush %v", flushed["a"])
+	}
+}
@@ -102,6 +102,9 @@ func (m *msgServer) DeleteMsgs(ctx context.Context, req *msg.DeleteMsgsReq) (*ms
 		if isSyncSelf {
 			tips := &sdkws.DeleteMsgsTips{UserID: req.UserID, ConversationID: req.ConversationID, Seqs: req.Seqs}
 			m.notificationSender.NotificationWithSesstionType(ctx, req.UserID, req.UserID, constant.DeleteMsgsNotification, constant.SingleChatType, tips)
+		} else if err := m.MsgSearchDatabase.DeleteUserMsgs(ctx, req.UserID, req.ConversationID, req.Seqs); err != nil {
+			// msgtransfer only learns of the deletes it is notified of.
+			log.ZError(ctx, "delete user msgs from search err", err, "conversationID", req.ConversationID, "seqs", req.Seqs)
 		}
 	}
 	return &msg.DeleteMsgsResp{}, nil
@@ -115,6 +118,9 @@ func (m *msgServer) DeleteMsgPhysicalBySeq(
 	if err != nil {
 		return nil, err
 	}
+	if err := m.MsgSearchDatabase.DeleteMsgs(ctx, req.ConversationID, req.Seqs); err != nil {
+		log.ZError(ctx, "delete msgs from search err", err, "conversationID", req.ConversationID, "seqs", req.Seqs)
+	}
 	return &msg.DeleteMsgPhysicalBySeqResp{}, nil
 }
 
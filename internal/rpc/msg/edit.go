@@ -0,0 +1,165 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+// EditMsg replaces the content of a text message. Only the sender, or a ManageMsg holder in server
+// channels, can edit within msgEditWindow seconds of sending, the previous content is kept in history.
+// A message is editable once it is stored in mongo, right after sending it may not be yet.
+func (m *msgServer) EditMsg(ctx context.Context, req *msg.EditMsgReq) (*msg.EditMsgResp, error) {
+	if config.Config.MsgEditWindow <= 0 {
+		return nil, errs.ErrArgs.Wrap("msg edit is disabled")
+	}
+	msgData, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEditContent(msgData.ContentType, req.Content); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.Sub(time.UnixMilli(msgData.SendTime)) > time.Duration(config.Config.MsgEditWindow)*time.Second {
+		return nil, errs.ErrArgs.Wrap("msg edit window has passed")
+	}
+	if err := m.checkEditPermission(ctx, req.UserID, msgData); err != nil {
+		return nil, err
+	}
+	if string(msgData.Content) == req.Content {
+		return &msg.EditMsgResp{}, nil
+	}
+	ok, err := m.MsgDatabase.EditMsg(ctx, req.ConversationID, req.Seq, req.Content, req.UserID, now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.ErrArgs.Wrap("msg was edited concurrently, retry")
+	}
+	msgData.Content = []byte(req.Content)
+	if err := m.MsgSearchDatabase.IndexMsgs(ctx, req.ConversationID, []*sdkws.MsgData{msgData}); err != nil {
+		log.ZError(ctx, "index edited msg for search err", err, "conversationID", req.ConversationID, "seq", req.Seq)
+	}
+	tips := &sdkws.MsgEditedTips{
+		ConversationID: req.ConversationID,
+		Seq:            msgData.Seq,
+		ClientMsgID:    msgData.ClientMsgID,
+		SessionType:    msgData.SessionType,
+		EditorUserID:   req.UserID,
+		ContentType:    msgData.ContentType,
+		Content:        req.Content,
+		EditTime:       now.UnixMilli(),
+	}
+	recvID := msgData.GroupID
+	if msgData.SessionType == constant.SingleChatType {
+		recvID = msgData.RecvID
+		if recvID == req.UserID {
+			recvID = msgData.SendID
+		}
+	}
+	if err := m.notificationSender.NotificationWithSesstionType(ctx, req.UserID, recvID, constant.MsgEditedNotification, msgData.SessionType, tips); err != nil {
+		return nil, err
+	}
+	return &msg.EditMsgResp{EditTime: now.UnixMilli()}, nil
+}
+
+// GetMsgEditHistory returns the current content of a message and the contents it had before, oldest first.
+func (m *msgServer) GetMsgEditHistory(ctx context.Context, req *msg.GetMsgEditHistoryReq) (*msg.GetMsgEditHistoryResp, error) {
+	if _, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq); err != nil {
+		return nil, err
+	}
+	info, err := m.MsgDatabase.GetMsgEditHistory(ctx, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	resp := &msg.GetMsgEditHistoryResp{
+		Edited:   info.Edited,
+		EditorID: info.EditorID,
+		EditTime: info.EditTime,
+		Content:  info.Msg.Content,
+	}
+	for _, version := range info.EditHistory {
+		resp.History = append(resp.History, &msg.MsgEditVersion{
+			Content:  version.Content,
+			EditorID: version.EditorID,
+			EditTime: version.EditTime,
+		})
+	}
+	return resp, nil
+}
+
+// checkEditContent checks content is the elem of a text or @ msg, with some text.
+func checkEditContent(contentType int32, content string) error {
+	var text string
+	switch contentType {
+	case constant.Text:
+		var elem apistruct.TextElem
+		if err := json.Unmarshal([]byte(content), &elem); err != nil {
+			return errs.ErrArgs.Wrap("content is not a text elem")
+		}
+		text = elem.Content
+	case constant.AtText:
+		var elem apistruct.AtElem
+		if err := json.Unmarshal([]byte(content), &elem); err != nil {
+			return errs.ErrArgs.Wrap("content is not an at elem")
+		}
+		text = elem.Text
+	default:
+		return errs.ErrArgs.Wrap("only text msg can be edited")
+	}
+	if strings.TrimSpace(text) == "" {
+		return errs.ErrArgs.Wrap("content is empty")
+	}
+	return nil
+}
+
+func (m *msgServer) checkEditPermission(ctx context.Context, userID string, msgData *sdkws.MsgData) error {
+	if userID == msgData.SendID {
+		return nil
+	}
+	if msgData.SessionType != constant.ServerGroupChatType {
+		return errs.ErrNoPermission.Wrap("only the sender can edit the msg")
+	}
+	return m.checkManageMsg(ctx, msgData.GroupID, userID)
+}
+
+// checkManageMsg checks the user holds ManageMsg in the server of the channel.
+func (m *msgServer) checkManageMsg(ctx context.Context, groupID string, userID string) error {
+	groupInfo, err := m.Group.GetGroupInfoCache(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	permissions, err := m.Club.GetServerMemberPermissions(ctx, groupInfo.ServerID, userID)
+	if err != nil {
+		return err
+	}
+	if !permissions.CanManageMsg() {
+		return errs.ErrNoPermission.Wrap("no manage msg permission")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+)
+
+func TestCheckEditContent(t *testing.T) {
+	tests := []struct {
+		contentType int32
+		content     string
+		ok          bool
+	}{
+		{constant.Text, `{"content":"hello"}`, true},
+		{constant.Text, `{"content":"  "}`, false},
+		{constant.Text, `{"text":"hello"}`, false},
+		{constant.Text, `["hello"]`, false},
+		{constant.Text, `hello`, false},
+		{constant.AtText, `{"text":"hi @u2","atUserList":["u2"]}`, true},
+		{constant.AtText, `{"content":"hi"}`, false},
+		{constant.Picture, `{"content":"hello"}`, false},
+	}
+	for _, test := range tests {
+		err := checkEditContent(test.contentType, test.content)
+		if test.ok && err != nil {
+			t.Errorf("%d %s: %v", test.contentType, test.content, err)
+		}
+		if !test.ok && !errs.ErrArgs.Is(err) {
+			t.Errorf("%d %s: accepted or wrong error %v", test.contentType, test.content, err)
+		}
+	}
+}
+
+func TestCheckEditPermission(t *testing.T) {
+	m := &msgServer{}
+	for _, sessionType := range []int32{constant.SingleChatType, constant.SuperGroupChatType} {
+		msgData := &sdkws.MsgData{SendID: "u1", SessionType: sessionType}
+		if err := m.checkEditPermission(context.Background(), "u1", msgData); err != nil {
+			t.Errorf("sender of %d: %v", sessionType, err)
+		}
+		if err := m.checkEditPermission(context.Background(), "u2", msgData); !errs.ErrNoPermission.Is(err) {
+			t.Errorf("other user of %d: %v", sessionType, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// ExportConversation queues an export of the conversation history for the cron service, which notifies the
+// requester with an access url once the archive is uploaded. A user exports the msgs they can still see,
+// an app manager exports every msg left in the conversation.
+func (m *msgServer) ExportConversation(ctx context.Context, req *msg.ExportConversationReq) (*msg.ExportConversationResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	switch req.Format {
+	case unrelationtb.ConversationExportJSON, unrelationtb.ConversationExportHTML:
+	default:
+		return nil, errs.ErrArgs.Wrap("invalid format " + req.Format)
+	}
+	var (
+		seqUserID          string
+		conversationMaxSeq int64
+	)
+	if !authverify.IsManagerUserID(req.UserID) {
+		conversationIDs, err := m.ConversationLocalCache.GetConversationIDs(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !utils.Contain(req.ConversationID, conversationIDs...) {
+			return nil, errs.ErrNoPermission.Wrap("not in the conversation")
+		}
+		conversation, err := m.Conversation.GetConversation(ctx, req.UserID, req.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		seqUserID, conversationMaxSeq = req.UserID, conversation.MaxSeq
+	}
+	minSeq, maxSeq, err := m.MsgDatabase.GetExportSeqRange(ctx, seqUserID, req.ConversationID, conversationMaxSeq)
+	if err != nil {
+		return nil, err
+	}
+	if maxSeq < minSeq {
+		return nil, errs.ErrArgs.Wrap("no msgs to export")
+	}
+	if n, err := m.ExportDatabase.FailStaleConversationExports(ctx, req.UserID); err != nil {
+		return nil, err
+	} else if n > 0 {
+		log.ZWarn(ctx, "failed stale conversation exports", nil, "userID", req.UserID, "count", n)
+	}
+	count, err := m.ExportDatabase.CountUserUnfinishedConversationExports(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= config.Config.ConversationExport.MaxUnfinishedPerUser {
+		return nil, errs.ErrArgs.Wrap("too many unfinished exports")
+	}
+	now := time.Now()
+	export := &unrelationtb.ConversationExportModel{
+		ExportID:       GetMsgID(req.UserID),
+		UserID:         req.UserID,
+		ConversationID: req.ConversationID,
+		Format:         req.Format,
+		MinSeq:         minSeq,
+		MaxSeq:         maxSeq,
+		Status:         unrelationtb.ConversationExportPending,
+		CreateTime:     now,
+		UpdateTime:     now,
+	}
+	if err := m.ExportDatabase.CreateConversationExport(ctx, export); err != nil {
+		return nil, err
+	}
+	if err := m.Cron.SetExportConversationJob(ctx, export.ExportID); err != nil {
+		if failErr := m.ExportDatabase.FailPendingConversationExport(ctx, export.ExportID, err); failErr != nil {
+			log.ZError(ctx, "fail unqueued conversation export failed", failErr, "exportID", export.ExportID)
+		}
+		return nil, err
+	}
+	return &msg.ExportConversationResp{ExportID: export.ExportID}, nil
+}
+
+// GetConversationExport returns the status of an export to its requester, with an access url signed now
+// once the export is done, so the archive stays downloadable after the url sent in the notification expired.
+func (m *msgServer) GetConversationExport(ctx context.Context, req *msg.GetConversationExportReq) (*msg.GetConversationExportResp, error) {
+	export, err := m.ExportDatabase.TakeConversationExport(ctx, req.ExportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, export.UserID); err != nil {
+		return nil, err
+	}
+	resp := &msg.GetConversationExportResp{
+		ExportID:       export.ExportID,
+		ConversationID: export.ConversationID,
+		Format:         export.Format,
+		Status:         export.Status,
+		MsgNum:         export.MsgNum,
+		ErrMsg:         export.ErrMsg,
+	}
+	if export.Status == unrelationtb.ConversationExportDone {
+		var expireTime time.Time
+		resp.AccessURL, expireTime, err = m.ExportDatabase.ConversationExportAccessURL(ctx, export)
+		if err != nil {
+			return nil, err
+		}
+		resp.ExpireTime = expireTime.UnixMilli()
+	}
+	return resp, nil
+}
@@ -0,0 +1,203 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+// maxGroupReadCountSeqs caps how many of the msgs just read are counted for the read count notifications.
+const maxGroupReadCountSeqs = 100
+
+// groupReadSeqs holds what decides who has read a group msg: a member has read it when their has-read seq
+// reached its seq, and a member whose min seq is above it joined later and never saw it.
+type groupReadSeqs struct {
+	memberIDs   []string
+	minSeqs     map[string]int64
+	hasReadSeqs map[string]int64
+}
+
+func (g *groupReadSeqs) readMembers(msgData *sdkws.MsgData) (readUserIDs, unreadUserIDs []string) {
+	for _, userID := range g.memberIDs {
+		if userID == msgData.SendID || g.minSeqs[userID] > msgData.Seq {
+			continue
+		}
+		if g.hasReadSeqs[userID] >= msgData.Seq {
+			readUserIDs = append(readUserIDs, userID)
+		} else {
+			unreadUserIDs = append(unreadUserIDs, userID)
+		}
+	}
+	return readUserIDs, unreadUserIDs
+}
+
+func (m *msgServer) getGroupReadSeqs(ctx context.Context, conversationID string, groupID string) (*groupReadSeqs, error) {
+	memberIDs, err := m.GroupLocalCache.GetGroupMemberIDs(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs) > config.Config.GroupMessageReadReceiptMaxMemberNum {
+		return nil, errs.ErrArgs.Wrap("group is too large to track who read its msgs")
+	}
+	minSeqs, err := m.MsgDatabase.GetConversationUserMinSeqs(ctx, conversationID, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	hasReadSeqs, err := m.MsgDatabase.GetConversationUsersHasReadSeqs(ctx, conversationID, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &groupReadSeqs{memberIDs: memberIDs, minSeqs: minSeqs, hasReadSeqs: hasReadSeqs}, nil
+}
+
+// GetGroupMsgReadMembers returns the members who have read a group msg and those who haven't yet, only the
+// sender or an app manager can ask.
+func (m *msgServer) GetGroupMsgReadMembers(ctx context.Context, req *msg.GetGroupMsgReadMembersReq) (*msg.GetGroupMsgReadMembersResp, error) {
+	if !config.Config.GroupMessageHasReadReceiptEnable {
+		return nil, errs.ErrMessageHasReadDisable.Wrap()
+	}
+	msgData, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	if msgData.SessionType != constant.SuperGroupChatType {
+		return nil, errs.ErrArgs.Wrap("not a group msg")
+	}
+	if req.UserID != msgData.SendID && !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only the sender can see who read the msg")
+	}
+	readSeqs, err := m.getGroupReadSeqs(ctx, req.ConversationID, msgData.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	readUserIDs, unreadUserIDs := readSeqs.readMembers(msgData)
+	return &msg.GetGroupMsgReadMembersResp{
+		ReadCount:     int32(len(readUserIDs)),
+		UnreadCount:   int32(len(unreadUserIDs)),
+		ReadUserIDs:   readUserIDs,
+		UnreadUserIDs: unreadUserIDs,
+	}, nil
+}
+
+// sendGroupMsgReadCountNotifications queues the group msgs the user just read, seqs in (fromSeq, toSeq], for
+// read count notifications to their senders. Failures are logged and not returned.
+func (m *msgServer) sendGroupMsgReadCountNotifications(ctx context.Context, conversationID string, groupID string, userID string, fromSeq, toSeq int64) {
+	if !config.Config.GroupMessageHasReadReceiptEnable || toSeq <= fromSeq {
+		return
+	}
+	if toSeq-fromSeq > maxGroupReadCountSeqs {
+		fromSeq = toSeq - maxGroupReadCountSeqs
+	}
+	seqs := make([]int64, 0, toSeq-fromSeq)
+	for seq := fromSeq + 1; seq <= toSeq; seq++ {
+		seqs = append(seqs, seq)
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, userID, conversationID, seqs)
+	if err != nil {
+		log.ZWarn(ctx, "get read group msgs err", err, "conversationID", conversationID, "seqs", seqs)
+		return
+	}
+	batches := make(map[string]*groupMsgReadCountBatch)
+	for _, msgData := range msgs {
+		if msgData == nil || msgData.ClientMsgID == "" || msgData.SendID == userID ||
+			msgData.ContentType == constant.MsgRevokeNotification ||
+			(msgData.ContentType >= constant.NotificationBegin && msgData.ContentType <= constant.NotificationEnd) {
+			continue
+		}
+		batch, ok := batches[msgData.SendID]
+		if !ok {
+			batch = &groupMsgReadCountBatch{
+				operationID: mcontext.GetOperationID(ctx),
+				groupID:     groupID,
+				readerID:    userID,
+				msgs:        make(map[int64]*sdkws.MsgData),
+			}
+			batches[msgData.SendID] = batch
+		}
+		batch.msgs[msgData.Seq] = msgData
+	}
+	for sendID, batch := range batches {
+		m.groupMsgReadCounts.Add(groupMsgReadCountKey{conversationID: conversationID, sendID: sendID}, batch)
+	}
+}
+
+// groupMsgReadCountWindow is how long the msgs of a sender read by members are gathered into one read count
+// notification, a busy group would notify the sender for every member reading otherwise.
+const groupMsgReadCountWindow = time.Second
+
+type groupMsgReadCountKey struct {
+	conversationID string
+	sendID         string
+}
+
+// groupMsgReadCountBatch is the msgs of a sender read within a window, readerID is the latest reader.
+type groupMsgReadCountBatch struct {
+	operationID string
+	groupID     string
+	readerID    string
+	msgs        map[int64]*sdkws.MsgData
+}
+
+func mergeGroupMsgReadCountBatch(pending, batch *groupMsgReadCountBatch) *groupMsgReadCountBatch {
+	pending.operationID = batch.operationID
+	pending.readerID = batch.readerID
+	for seq, msgData := range batch.msgs {
+		pending.msgs[seq] = msgData
+	}
+	return pending
+}
+
+// sendGroupMsgReadCountNotification tells the sender how many members have read each msg of the batch, the
+// counts are taken when the window ends.
+func (m *msgServer) sendGroupMsgReadCountNotification(key groupMsgReadCountKey, batch *groupMsgReadCountBatch) {
+	ctx := mcontext.WithOpUserIDContext(mcontext.NewCtx(batch.operationID), batch.readerID)
+	readSeqs, err := m.getGroupReadSeqs(ctx, key.conversationID, batch.groupID)
+	if err != nil {
+		log.ZDebug(ctx, "skip group msg read count", "conversationID", key.conversationID, "err", err)
+		return
+	}
+	tips := readSeqs.readCountTips(key.conversationID, batch)
+	if err := m.notificationSender.NotificationWithSesstionType(ctx, batch.readerID, key.sendID, constant.GroupMsgReadCountNotification, constant.SingleChatType, tips); err != nil {
+		log.ZWarn(ctx, "send group msg read count err", err, "conversationID", key.conversationID, "sendID", key.sendID)
+	}
+}
+
+func (g *groupReadSeqs) readCountTips(conversationID string, batch *groupMsgReadCountBatch) *sdkws.GroupMsgReadCountTips {
+	tips := &sdkws.GroupMsgReadCountTips{ConversationID: conversationID, GroupID: batch.groupID}
+	for _, msgData := range batch.msgs {
+		readUserIDs, unreadUserIDs := g.readMembers(msgData)
+		tips.ReadCounts = append(tips.ReadCounts, &sdkws.GroupMsgReadCount{
+			Seq:         msgData.Seq,
+			ReadCount:   int32(len(readUserIDs)),
+			UnreadCount: int32(len(unreadUserIDs)),
+		})
+	}
+	sort.Slice(tips.ReadCounts, func(i, j int) bool {
+		return tips.ReadCounts[i].Seq < tips.ReadCounts[j].Seq
+	})
+	return tips
+}
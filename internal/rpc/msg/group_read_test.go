@@ -0,0 +1,52 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/sdkws"
+)
+
+func TestReadCountTips(t *testing.T) {
+	readSeqs := &groupReadSeqs{
+		memberIDs: []string{"sender", "u1", "u2", "u3"},
+		// u3 joined after seq 10.
+		minSeqs:     map[string]int64{"u3": 11},
+		hasReadSeqs: map[string]int64{"u1": 12, "u2": 10, "u3": 12},
+	}
+	batch := &groupMsgReadCountBatch{groupID: "g1", msgs: map[int64]*sdkws.MsgData{
+		12: {SendID: "sender", Seq: 12},
+		10: {SendID: "sender", Seq: 10},
+	}}
+	tips := readSeqs.readCountTips("sg_g1", batch)
+	var counts []string
+	for _, readCount := range tips.ReadCounts {
+		counts = append(counts, fmt.Sprintf("%d:%d/%d", readCount.Seq, readCount.ReadCount, readCount.UnreadCount))
+	}
+	if tips.ConversationID != "sg_g1" || tips.GroupID != "g1" || fmt.Sprint(counts) != "[10:2/0 12:2/1]" {
+		t.Errorf("tips %s %s %v", tips.ConversationID, tips.GroupID, counts)
+	}
+}
+
+func TestMergeGroupMsgReadCountBatch(t *testing.T) {
+	pending := &groupMsgReadCountBatch{operationID: "op1", readerID: "u1", msgs: map[int64]*sdkws.MsgData{1: {Seq: 1}, 2: {Seq: 2}}}
+	batch := &groupMsgReadCountBatch{operationID: "op2", readerID: "u2", msgs: map[int64]*sdkws.MsgData{2: {Seq: 2}, 3: {Seq: 3}}}
+	merged := mergeGroupMsgReadCountBatch(pending, batch)
+	if merged.operationID != "op2" || merged.readerID != "u2" || len(merged.msgs) != 3 {
+		t.Errorf("merged %+v", merged)
+	}
+}
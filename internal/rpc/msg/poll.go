@@ -0,0 +1,398 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+// CreatePoll posts a poll as a msg to a group or a server channel. Poll msgs can only be posted here, so
+// every poll msg has its poll, and a poll with a close time is closed by the cron service at that time.
+func (m *msgServer) CreatePoll(ctx context.Context, req *msg.CreatePollReq) (*msg.CreatePollResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if req.SessionType != constant.SuperGroupChatType && req.SessionType != constant.ServerGroupChatType {
+		return nil, errs.ErrArgs.Wrap("polls can only be posted to groups and server channels")
+	}
+	if req.GroupID == "" {
+		return nil, errs.ErrArgs.Wrap("groupID is empty")
+	}
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return nil, errs.ErrArgs.Wrap("question is empty")
+	}
+	if len(req.Options) < 2 || len(req.Options) > config.Config.Poll.MaxOptions {
+		return nil, errs.ErrArgs.Wrap("a poll needs 2 to " + strconv.Itoa(config.Config.Poll.MaxOptions) + " options")
+	}
+	if req.ResultVisibility < unrelationtb.PollResultAlways || req.ResultVisibility > unrelationtb.PollResultAfterClose {
+		return nil, errs.ErrArgs.Wrap("resultVisibility is invalid")
+	}
+	now := time.Now()
+	var closeTime time.Time
+	if req.CloseTime != 0 {
+		closeTime = time.UnixMilli(req.CloseTime)
+		if !closeTime.After(now) {
+			return nil, errs.ErrArgs.Wrap("closeTime must be in the future")
+		}
+		if config.Config.Poll.MaxDuration > 0 && closeTime.After(now.Add(time.Duration(config.Config.Poll.MaxDuration)*time.Second)) {
+			return nil, errs.ErrArgs.Wrap("closeTime is too far in the future")
+		}
+	}
+	poll := &unrelationtb.PollModel{
+		PollID:           GetMsgID(req.UserID),
+		CreatorID:        req.UserID,
+		GroupID:          req.GroupID,
+		SessionType:      req.SessionType,
+		Question:         question,
+		MultiChoice:      req.MultiChoice,
+		Anonymous:        req.Anonymous,
+		ResultVisibility: req.ResultVisibility,
+		CloseTime:        closeTime,
+		Status:           unrelationtb.PollOpen,
+		CreateTime:       now,
+		UpdateTime:       now,
+	}
+	texts := make(map[string]struct{}, len(req.Options))
+	for i, text := range req.Options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, errs.ErrArgs.Wrap("option is empty")
+		}
+		if _, ok := texts[text]; ok {
+			return nil, errs.ErrArgs.Wrap("duplicate option " + text)
+		}
+		texts[text] = struct{}{}
+		poll.Options = append(poll.Options, &unrelationtb.PollOptionModel{OptionID: strconv.Itoa(i + 1), Text: text})
+	}
+	user, err := m.User.GetUserInfo(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	msgData := &sdkws.MsgData{
+		SendID:         req.UserID,
+		GroupID:        req.GroupID,
+		ClientMsgID:    GetMsgID(req.UserID),
+		SenderNickname: user.Nickname,
+		SenderFaceURL:  user.FaceURL,
+		SessionType:    req.SessionType,
+		MsgFrom:        constant.UserMsgType,
+		ContentType:    constant.Poll,
+		Content:        []byte(utils.StructToJsonString(pollElem(poll))),
+		CreateTime:     now.UnixMilli(),
+		Options:        make(map[string]bool),
+	}
+	poll.ConversationID = msgprocessor.GetConversationIDByMsg(msgData)
+	poll.ClientMsgID = msgData.ClientMsgID
+	if err := m.PollDatabase.CreatePoll(ctx, poll); err != nil {
+		return nil, err
+	}
+	m.encapsulateMsgData(msgData)
+	// a poll whose msg failed is never bound to a msg and can't be voted on.
+	sendResp, err := m.sendMsgSuperGroupChat(ctx, &msg.SendMsgReq{MsgData: msgData})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.PollDatabase.BindPollMsg(ctx, poll.PollID, sendResp.ServerMsgID); err != nil {
+		return nil, err
+	}
+	if req.CloseTime != 0 {
+		if err := m.Cron.SetClosePollJob(ctx, poll.PollID, req.CloseTime); err != nil {
+			log.ZError(ctx, "set close poll job failed, the poll can still be closed by hand", err, "pollID", poll.PollID)
+		}
+	}
+	return &msg.CreatePollResp{
+		PollID:      poll.PollID,
+		ClientMsgID: sendResp.ClientMsgID,
+		ServerMsgID: sendResp.ServerMsgID,
+		SendTime:    sendResp.SendTime,
+	}, nil
+}
+
+// VotePoll replaces the options the user voted for, a single choice poll takes one option.
+func (m *msgServer) VotePoll(ctx context.Context, req *msg.VotePollReq) (*msg.VotePollResp, error) {
+	poll, err := m.getOpenPoll(ctx, req.UserID, req.PollID)
+	if err != nil {
+		return nil, err
+	}
+	optionIDs := utils.Distinct(req.OptionIDs)
+	if len(optionIDs) == 0 {
+		return nil, errs.ErrArgs.Wrap("optionIDs is empty")
+	}
+	if !poll.MultiChoice && len(optionIDs) > 1 {
+		return nil, errs.ErrArgs.Wrap("the poll takes a single option")
+	}
+	pollOptionIDs := utils.Slice(poll.Options, func(option *unrelationtb.PollOptionModel) string { return option.OptionID })
+	for _, optionID := range optionIDs {
+		if !utils.Contain(optionID, pollOptionIDs...) {
+			return nil, errs.ErrArgs.Wrap("unknown optionID " + optionID)
+		}
+	}
+	if err := m.votePoll(ctx, req.UserID, poll, optionIDs); err != nil {
+		return nil, err
+	}
+	return &msg.VotePollResp{}, nil
+}
+
+// UnvotePoll takes back the vote of the user.
+func (m *msgServer) UnvotePoll(ctx context.Context, req *msg.UnvotePollReq) (*msg.UnvotePollResp, error) {
+	poll, err := m.getOpenPoll(ctx, req.UserID, req.PollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.votePoll(ctx, req.UserID, poll, nil); err != nil {
+		return nil, err
+	}
+	return &msg.UnvotePollResp{}, nil
+}
+
+// ClosePoll closes a poll before its close time. The creator, the group owner and admins, ManageMsg holders
+// in server channels and app managers can close it.
+func (m *msgServer) ClosePoll(ctx context.Context, req *msg.ClosePollReq) (*msg.ClosePollResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	poll, err := m.PollDatabase.TakePoll(ctx, req.PollID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkClosePollPermission(ctx, req.UserID, poll); err != nil {
+		return nil, err
+	}
+	ok, err := m.PollDatabase.ClosePoll(ctx, req.PollID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &msg.ClosePollResp{}, nil
+	}
+	if !poll.CloseTime.IsZero() {
+		if err := m.Cron.SetClosePollJob(ctx, poll.PollID, 0); err != nil {
+			log.ZWarn(ctx, "remove close poll job failed", err, "pollID", poll.PollID)
+		}
+	}
+	m.pollUpdatedNotification(ctx, req.UserID, req.PollID)
+	return &msg.ClosePollResp{}, nil
+}
+
+// GetPollResult returns the poll with the vote of the user. The counts are only filled in when the result
+// visibility allows the user to see them, and the voters only when the poll is not anonymous.
+func (m *msgServer) GetPollResult(ctx context.Context, req *msg.GetPollResultReq) (*msg.GetPollResultResp, error) {
+	poll, err := m.getPoll(ctx, req.UserID, req.PollID)
+	if err != nil {
+		return nil, err
+	}
+	vote, err := m.PollDatabase.TakePollVote(ctx, req.PollID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	result := &msg.PollResult{
+		PollID:           poll.PollID,
+		ConversationID:   poll.ConversationID,
+		ClientMsgID:      poll.ClientMsgID,
+		CreatorID:        poll.CreatorID,
+		Question:         poll.Question,
+		MultiChoice:      poll.MultiChoice,
+		Anonymous:        poll.Anonymous,
+		ResultVisibility: poll.ResultVisibility,
+		Closed:           pollClosed(poll),
+	}
+	if !poll.CloseTime.IsZero() {
+		result.CloseTime = poll.CloseTime.UnixMilli()
+	}
+	if vote != nil {
+		result.MyOptionIDs = vote.OptionIDs
+	}
+	result.ResultVisible = pollResultVisible(poll, req.UserID, vote != nil)
+	voterIDs := make(map[string][]string)
+	if result.ResultVisible && !poll.Anonymous {
+		votes, err := m.PollDatabase.FindPollVotes(ctx, req.PollID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range votes {
+			for _, optionID := range v.OptionIDs {
+				voterIDs[optionID] = append(voterIDs[optionID], v.UserID)
+			}
+		}
+	}
+	for _, option := range poll.Options {
+		optionResult := &msg.PollOptionResult{OptionID: option.OptionID, Text: option.Text}
+		if result.ResultVisible {
+			optionResult.VoteCount = option.VoteCount
+			optionResult.VoterIDs = voterIDs[option.OptionID]
+		}
+		result.Options = append(result.Options, optionResult)
+	}
+	if result.ResultVisible {
+		result.VoterCount = poll.VoterCount
+	}
+	return &msg.GetPollResultResp{Poll: result}, nil
+}
+
+func (m *msgServer) votePoll(ctx context.Context, userID string, poll *unrelationtb.PollModel, optionIDs []string) error {
+	ok, err := m.PollDatabase.VotePoll(ctx, poll.PollID, userID, optionIDs)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrArgs.Wrap("poll is closed")
+	}
+	m.pollUpdates.Add(poll.PollID, pollUpdate{operationID: mcontext.GetOperationID(ctx), userID: userID})
+	return nil
+}
+
+// getPoll returns a posted poll of a conversation the user is in.
+func (m *msgServer) getPoll(ctx context.Context, userID string, pollID string) (*unrelationtb.PollModel, error) {
+	if err := authverify.CheckAccessV3(ctx, userID); err != nil {
+		return nil, err
+	}
+	poll, err := m.PollDatabase.TakePoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.ServerMsgID == "" {
+		return nil, errs.ErrRecordNotFound.Wrap("poll not found")
+	}
+	if err := m.checkReactionAccess(ctx, userID, &sdkws.MsgData{SessionType: poll.SessionType, GroupID: poll.GroupID}); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+func (m *msgServer) getOpenPoll(ctx context.Context, userID string, pollID string) (*unrelationtb.PollModel, error) {
+	poll, err := m.getPoll(ctx, userID, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if pollClosed(poll) {
+		return nil, errs.ErrArgs.Wrap("poll is closed")
+	}
+	return poll, nil
+}
+
+func (m *msgServer) checkClosePollPermission(ctx context.Context, userID string, poll *unrelationtb.PollModel) error {
+	if userID == poll.CreatorID || authverify.IsAppManagerUid(ctx) {
+		return nil
+	}
+	if poll.SessionType == constant.ServerGroupChatType {
+		return m.checkManageMsg(ctx, poll.GroupID, userID)
+	}
+	member, err := m.Group.GetGroupMemberCache(ctx, poll.GroupID, userID)
+	if err != nil {
+		return err
+	}
+	if member.RoleLevel != constant.GroupOwner && member.RoleLevel != constant.GroupAdmin {
+		return errs.ErrNoPermission.Wrap("only the creator or a group admin can close the poll")
+	}
+	return nil
+}
+
+// pollUpdateWindow is how long the votes on a poll are gathered into one updated notification.
+const pollUpdateWindow = time.Second
+
+// pollUpdate is the latest vote on a poll within a window.
+type pollUpdate struct {
+	operationID string
+	userID      string
+}
+
+func mergePollUpdate(_, update pollUpdate) pollUpdate {
+	return update
+}
+
+// flushPollUpdate notifies the poll as it is when the window of its votes ends.
+func (m *msgServer) flushPollUpdate(pollID string, update pollUpdate) {
+	ctx := mcontext.WithOpUserIDContext(mcontext.NewCtx(update.operationID), update.userID)
+	m.pollUpdatedNotification(ctx, update.userID, pollID)
+}
+
+// pollUpdatedNotification tells the conversation the poll changed. It carries the counts only when anyone
+// may see them, the others fetch the result they are allowed to see. Failures are logged and not returned.
+func (m *msgServer) pollUpdatedNotification(ctx context.Context, userID string, pollID string) {
+	poll, err := m.PollDatabase.TakePoll(ctx, pollID)
+	if err != nil {
+		log.ZWarn(ctx, "take updated poll failed", err, "pollID", pollID)
+		return
+	}
+	tips := &sdkws.PollUpdatedTips{
+		ConversationID: poll.ConversationID,
+		PollID:         poll.PollID,
+		ClientMsgID:    poll.ClientMsgID,
+		Closed:         pollClosed(poll),
+	}
+	if tips.Closed || poll.ResultVisibility == unrelationtb.PollResultAlways {
+		tips.VoterCount = poll.VoterCount
+		for _, option := range poll.Options {
+			tips.Options = append(tips.Options, &sdkws.PollOptionCount{OptionID: option.OptionID, VoteCount: option.VoteCount})
+		}
+	}
+	if err := m.notificationSender.NotificationWithSesstionType(ctx, userID, poll.GroupID, constant.PollUpdatedNotification, poll.SessionType, tips); err != nil {
+		log.ZWarn(ctx, "send poll updated notification failed", err, "pollID", pollID)
+	}
+}
+
+// pollClosed reports whether the poll is closed, a poll past its close time is closed even before the close
+// poll job ran.
+func pollClosed(poll *unrelationtb.PollModel) bool {
+	return poll.Status == unrelationtb.PollClosed || (!poll.CloseTime.IsZero() && !time.Now().Before(poll.CloseTime))
+}
+
+func pollResultVisible(poll *unrelationtb.PollModel, userID string, voted bool) bool {
+	if pollClosed(poll) || poll.CreatorID == userID {
+		return true
+	}
+	switch poll.ResultVisibility {
+	case unrelationtb.PollResultAlways:
+		return true
+	case unrelationtb.PollResultAfterVote:
+		return voted
+	default:
+		return false
+	}
+}
+
+func pollElem(poll *unrelationtb.PollModel) *sdkws.PollElem {
+	elem := &sdkws.PollElem{
+		PollID:           poll.PollID,
+		Question:         poll.Question,
+		MultiChoice:      poll.MultiChoice,
+		Anonymous:        poll.Anonymous,
+		ResultVisibility: poll.ResultVisibility,
+	}
+	if !poll.CloseTime.IsZero() {
+		elem.CloseTime = poll.CloseTime.UnixMilli()
+	}
+	for _, option := range poll.Options {
+		elem.Options = append(elem.Options, &sdkws.PollOption{OptionID: option.OptionID, Text: option.Text})
+	}
+	return elem
+}
@@ -0,0 +1,53 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+	"time"
+
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func TestPollResultVisible(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility int32
+		status     int32
+		closeTime  time.Time
+		userID     string
+		voted      bool
+		want       bool
+	}{
+		{"always", unrelationtb.PollResultAlways, unrelationtb.PollOpen, time.Time{}, "u2", false, true},
+		{"after vote, voted", unrelationtb.PollResultAfterVote, unrelationtb.PollOpen, time.Time{}, "u2", true, true},
+		{"after vote, not voted", unrelationtb.PollResultAfterVote, unrelationtb.PollOpen, time.Time{}, "u2", false, false},
+		{"after close, open", unrelationtb.PollResultAfterClose, unrelationtb.PollOpen, time.Time{}, "u2", true, false},
+		{"after close, closed", unrelationtb.PollResultAfterClose, unrelationtb.PollClosed, time.Time{}, "u2", false, true},
+		{"after vote, closed", unrelationtb.PollResultAfterVote, unrelationtb.PollClosed, time.Time{}, "u2", false, true},
+		{"after close, creator", unrelationtb.PollResultAfterClose, unrelationtb.PollOpen, time.Time{}, "u1", false, true},
+		{"after vote, creator", unrelationtb.PollResultAfterVote, unrelationtb.PollOpen, time.Time{}, "u1", false, true},
+		{"after close, close time passed", unrelationtb.PollResultAfterClose, unrelationtb.PollOpen, time.Now().Add(-time.Second), "u2", false, true},
+		{"after close, close time ahead", unrelationtb.PollResultAfterClose, unrelationtb.PollOpen, time.Now().Add(time.Hour), "u2", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := &unrelationtb.PollModel{CreatorID: "u1", ResultVisibility: tt.visibility, Status: tt.status, CloseTime: tt.closeTime}
+			if got := pollResultVisible(poll, tt.userID, tt.voted); got != tt.want {
+				t.Errorf("pollResultVisible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
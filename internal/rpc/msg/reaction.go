@@ -0,0 +1,271 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/mw/specialerror"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+)
+
+const (
+	maxReactionEmojiLen    = 64
+	maxMsgReactionEmojis   = 50
+	maxGetMsgReactionsSeqs = 100
+	defaultReactorsShowNum = 20
+	maxReactorsShowNum     = 100
+)
+
+// AddMsgReaction reacts with Emoji to a message of the conversation, reacting twice with the same emoji
+// changes nothing.
+func (m *msgServer) AddMsgReaction(ctx context.Context, req *msg.AddMsgReactionReq) (*msg.AddMsgReactionResp, error) {
+	if err := checkReactionEmoji(req.Emoji); err != nil {
+		return nil, err
+	}
+	msgData, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := m.MsgDatabase.GetMsgReactions(ctx, req.ConversationID, req.Seq, msgData.ClientMsgID, msgData.SessionType)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsContain(req.UserID, reactions[req.Emoji]) {
+		return &msg.AddMsgReactionResp{}, nil
+	}
+	reactions, err = m.MsgDatabase.AddMsgReaction(ctx, req.ConversationID, req.Seq, msgData.ClientMsgID, msgData.SessionType, req.Emoji, req.UserID, maxMsgReactionEmojis)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.reactionChangedNotification(ctx, req.UserID, req.ConversationID, msgData, req.Emoji, true, reactions); err != nil {
+		return nil, err
+	}
+	return &msg.AddMsgReactionResp{}, nil
+}
+
+// DelMsgReaction takes back the Emoji reaction of the user.
+func (m *msgServer) DelMsgReaction(ctx context.Context, req *msg.DelMsgReactionReq) (*msg.DelMsgReactionResp, error) {
+	if err := checkReactionEmoji(req.Emoji); err != nil {
+		return nil, err
+	}
+	msgData, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := m.MsgDatabase.GetMsgReactions(ctx, req.ConversationID, req.Seq, msgData.ClientMsgID, msgData.SessionType)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsContain(req.UserID, reactions[req.Emoji]) {
+		return &msg.DelMsgReactionResp{}, nil
+	}
+	reactions, err = m.MsgDatabase.DelMsgReaction(ctx, req.ConversationID, req.Seq, msgData.ClientMsgID, msgData.SessionType, req.Emoji, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.reactionChangedNotification(ctx, req.UserID, req.ConversationID, msgData, req.Emoji, false, reactions); err != nil {
+		return nil, err
+	}
+	return &msg.DelMsgReactionResp{}, nil
+}
+
+// GetMsgReactions returns the count of every emoji on the messages, and whether the user reacted with it.
+func (m *msgServer) GetMsgReactions(ctx context.Context, req *msg.GetMsgReactionsReq) (*msg.GetMsgReactionsResp, error) {
+	if len(req.Seqs) == 0 || len(req.Seqs) > maxGetMsgReactionsSeqs {
+		return nil, errs.ErrArgs.Wrap("seqs is empty or too long")
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, req.UserID, req.ConversationID, utils.Distinct(req.Seqs))
+	if err != nil {
+		return nil, err
+	}
+	resp := &msg.GetMsgReactionsResp{}
+	checked := false
+	for _, msgData := range msgs {
+		if msgData == nil || msgData.ClientMsgID == "" {
+			continue
+		}
+		if !checked {
+			// every message is of the same conversation.
+			if err := m.checkReactionAccess(ctx, req.UserID, msgData); err != nil {
+				return nil, err
+			}
+			checked = true
+		}
+		reactions, err := m.MsgDatabase.GetMsgReactions(ctx, req.ConversationID, msgData.Seq, msgData.ClientMsgID, msgData.SessionType)
+		if err != nil {
+			return nil, err
+		}
+		msgReactions := &msg.MsgReactions{Seq: msgData.Seq, ClientMsgID: msgData.ClientMsgID}
+		for emoji, userIDs := range reactions {
+			if len(userIDs) == 0 {
+				continue
+			}
+			msgReactions.Reactions = append(msgReactions.Reactions, &msg.MsgReaction{
+				Emoji:   emoji,
+				Count:   int64(len(userIDs)),
+				Reacted: utils.IsContain(req.UserID, userIDs),
+			})
+		}
+		resp.MsgReactions = append(resp.MsgReactions, msgReactions)
+	}
+	return resp, nil
+}
+
+// GetMsgReactors pages through the users who reacted with Emoji to a message, in reaction order.
+func (m *msgServer) GetMsgReactors(ctx context.Context, req *msg.GetMsgReactorsReq) (*msg.GetMsgReactorsResp, error) {
+	if err := checkReactionEmoji(req.Emoji); err != nil {
+		return nil, err
+	}
+	msgData, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := m.MsgDatabase.GetMsgReactions(ctx, req.ConversationID, req.Seq, msgData.ClientMsgID, msgData.SessionType)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := reactions[req.Emoji]
+	pageNumber, showNumber := int32(1), int32(defaultReactorsShowNum)
+	if req.Pagination != nil {
+		if req.Pagination.PageNumber > 0 {
+			pageNumber = req.Pagination.PageNumber
+		}
+		if req.Pagination.ShowNumber > 0 {
+			showNumber = req.Pagination.ShowNumber
+		}
+	}
+	if showNumber > maxReactorsShowNum {
+		showNumber = maxReactorsShowNum
+	}
+	resp := &msg.GetMsgReactorsResp{Total: int64(len(userIDs))}
+	start := int(pageNumber-1) * int(showNumber)
+	if start >= len(userIDs) {
+		return resp, nil
+	}
+	end := start + int(showNumber)
+	if end > len(userIDs) {
+		end = len(userIDs)
+	}
+	users, err := m.User.GetPublicUserInfos(ctx, userIDs[start:end], false)
+	if err != nil {
+		return nil, err
+	}
+	resp.Users = users
+	return resp, nil
+}
+
+func checkReactionEmoji(emoji string) error {
+	if emoji == "" || len(emoji) > maxReactionEmojiLen {
+		return errs.ErrArgs.Wrap("emoji is empty or too long")
+	}
+	// the emoji is a field name in the msg doc.
+	if strings.ContainsAny(emoji, ".$\x00") {
+		return errs.ErrArgs.Wrap("emoji contains invalid characters")
+	}
+	return nil
+}
+
+// getAccessibleMsg loads a message of the conversation and checks the user can see it.
+func (m *msgServer) getAccessibleMsg(ctx context.Context, userID, conversationID string, seq int64) (*sdkws.MsgData, error) {
+	if userID == "" || conversationID == "" || seq <= 0 {
+		return nil, errs.ErrArgs.Wrap("userID, conversationID or seq is invalid")
+	}
+	if err := authverify.CheckAccessV3(ctx, userID); err != nil {
+		return nil, err
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, userID, conversationID, []int64{seq})
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 || msgs[0] == nil || msgs[0].ClientMsgID == "" {
+		return nil, errs.ErrRecordNotFound.Wrap("msg not found")
+	}
+	if msgs[0].ContentType == constant.MsgRevokeNotification {
+		return nil, errs.ErrMsgAlreadyRevoke.Wrap("msg already revoke")
+	}
+	if err := m.checkReactionAccess(ctx, userID, msgs[0]); err != nil {
+		return nil, err
+	}
+	return msgs[0], nil
+}
+
+func (m *msgServer) checkReactionAccess(ctx context.Context, userID string, msgData *sdkws.MsgData) error {
+	switch msgData.SessionType {
+	case constant.SingleChatType:
+		if userID != msgData.SendID && userID != msgData.RecvID {
+			return errs.ErrNoPermission.Wrap("not in the conversation")
+		}
+	case constant.SuperGroupChatType:
+		if _, err := m.Group.GetGroupMemberCache(ctx, msgData.GroupID, userID); err != nil {
+			if errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))) {
+				return errs.ErrNotInGroupYet.Wrap(err.Error())
+			}
+			return err
+		}
+	case constant.ServerGroupChatType:
+		return m.checkServerGroupMember(ctx, msgData.GroupID, userID)
+	default:
+		return errs.ErrArgs.Wrap("msg sessionType not supported")
+	}
+	return nil
+}
+
+// checkServerGroupMember checks the user is a member of the server of the channel.
+func (m *msgServer) checkServerGroupMember(ctx context.Context, groupID string, userID string) error {
+	groupInfo, err := m.Group.GetGroupInfoCache(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Club.GetServerMemberInfo(ctx, groupInfo.ServerID, userID); err != nil {
+		if errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))) {
+			return errs.ErrNotInGroupYet.Wrap(err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *msgServer) reactionChangedNotification(ctx context.Context, userID, conversationID string, msgData *sdkws.MsgData, emoji string, added bool, reactions map[string][]string) error {
+	tips := &sdkws.MsgReactionChangedTips{
+		ConversationID: conversationID,
+		Seq:            msgData.Seq,
+		ClientMsgID:    msgData.ClientMsgID,
+		OpUserID:       userID,
+		Emoji:          emoji,
+		Added:          added,
+		Count:          int64(len(reactions[emoji])),
+		ChangeTime:     time.Now().UnixMilli(),
+	}
+	recvID := msgData.GroupID
+	if msgData.SessionType == constant.SingleChatType {
+		recvID = msgData.RecvID
+		if recvID == userID {
+			recvID = msgData.SendID
+		}
+	}
+	return m.notificationSender.NotificationWithSesstionType(ctx, userID, recvID, constant.MsgReactionChangedNotification, msgData.SessionType, tips)
+}
@@ -0,0 +1,245 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/convert"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+const maxScheduledMsgsShowNum = 100
+
+var contentValidate = validator.New()
+
+// scheduleMsg stores a msg sent with a future ScheduleTime and asks the cron service to send it then.
+// The msg is verified now for early feedback and again when it is sent.
+func (m *msgServer) scheduleMsg(ctx context.Context, req *msg.SendMsgReq) (*msg.SendMsgResp, error) {
+	msgData := req.MsgData
+	if err := authverify.CheckAccessV3(ctx, msgData.SendID); err != nil {
+		return nil, err
+	}
+	if msgData.SessionType == constant.NotificationChatType ||
+		(msgData.ContentType >= constant.NotificationBegin && msgData.ContentType <= constant.NotificationEnd) {
+		return nil, errs.ErrArgs.Wrap("notifications can not be scheduled")
+	}
+	scheduleTime, err := checkScheduleTime(req.ScheduleTime)
+	if err != nil {
+		return nil, err
+	}
+	if msgData.ThreadID == "" {
+		if err := m.messageVerification(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	count, err := m.ScheduledMsgDatabase.CountUserPendingScheduledMsgs(ctx, msgData.SendID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= config.Config.ScheduledMsg.MaxPendingPerUser {
+		return nil, errs.ErrArgs.Wrap("too many pending scheduled msgs")
+	}
+	now := time.Now()
+	scheduledMsg := &unrelationtb.ScheduledMsgModel{
+		ScheduleID:     GetMsgID(msgData.SendID),
+		SendID:         msgData.SendID,
+		ConversationID: msgprocessor.GetConversationIDByMsg(msgData),
+		Msg:            convert.MsgPb2DB(msgData),
+		ScheduleTime:   scheduleTime,
+		Status:         unrelationtb.ScheduledMsgPending,
+		CreateTime:     now,
+		UpdateTime:     now,
+	}
+	if err := m.ScheduledMsgDatabase.CreateScheduledMsg(ctx, scheduledMsg); err != nil {
+		return nil, err
+	}
+	if err := m.Cron.SetScheduledMsgJob(ctx, scheduledMsg.ScheduleID, req.ScheduleTime); err != nil {
+		if _, cancelErr := m.ScheduledMsgDatabase.CancelScheduledMsg(ctx, scheduledMsg.ScheduleID); cancelErr != nil {
+			log.ZError(ctx, "cancel unscheduled msg failed", cancelErr, "scheduleID", scheduledMsg.ScheduleID)
+		}
+		return nil, err
+	}
+	return &msg.SendMsgResp{
+		ClientMsgID: msgData.ClientMsgID,
+		SendTime:    req.ScheduleTime,
+		ScheduleID:  scheduledMsg.ScheduleID,
+	}, nil
+}
+
+// GetScheduledMsgs pages through the scheduled msgs of the user, the earliest to send first.
+func (m *msgServer) GetScheduledMsgs(ctx context.Context, req *msg.GetScheduledMsgsReq) (*msg.GetScheduledMsgsResp, error) {
+	if err := checkPagination(req.Pagination, maxScheduledMsgsShowNum); err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	total, scheduledMsgs, err := m.ScheduledMsgDatabase.PageUserScheduledMsgs(ctx, req.UserID, req.Statuses, req.Pagination.PageNumber, req.Pagination.ShowNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &msg.GetScheduledMsgsResp{Total: total, ScheduledMsgs: utils.Batch(convert.ScheduledMsgDB2Pb, scheduledMsgs)}, nil
+}
+
+// EditScheduledMsg replaces the content or the schedule time of a pending scheduled msg.
+func (m *msgServer) EditScheduledMsg(ctx context.Context, req *msg.EditScheduledMsgReq) (*msg.EditScheduledMsgResp, error) {
+	if req.Content == "" && req.ScheduleTime == 0 {
+		return nil, errs.ErrArgs.Wrap("nothing to edit")
+	}
+	scheduledMsg, err := m.getPendingScheduledMsg(ctx, req.UserID, req.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	var scheduleTime time.Time
+	if req.ScheduleTime != 0 {
+		if scheduleTime, err = checkScheduleTime(req.ScheduleTime); err != nil {
+			return nil, err
+		}
+	}
+	var msgModel *unrelationtb.MsgDataModel
+	if req.Content != "" {
+		if err := checkSendContent(scheduledMsg.Msg.ContentType, req.Content); err != nil {
+			return nil, err
+		}
+		edited := *scheduledMsg.Msg
+		edited.Content = req.Content
+		msgModel = &edited
+	}
+	ok, err := m.ScheduledMsgDatabase.UpdatePendingScheduledMsg(ctx, req.ScheduleID, msgModel, scheduleTime)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.ErrArgs.Wrap("scheduled msg is no longer pending")
+	}
+	if req.ScheduleTime != 0 {
+		if err := m.Cron.SetScheduledMsgJob(ctx, req.ScheduleID, req.ScheduleTime); err != nil {
+			m.restoreScheduledMsg(ctx, scheduledMsg)
+			return nil, err
+		}
+	}
+	return &msg.EditScheduledMsgResp{}, nil
+}
+
+// restoreScheduledMsg puts back the msg and the job of a scheduled msg whose edit failed halfway, failures
+// are only logged.
+func (m *msgServer) restoreScheduledMsg(ctx context.Context, scheduledMsg *unrelationtb.ScheduledMsgModel) {
+	if _, err := m.ScheduledMsgDatabase.UpdatePendingScheduledMsg(ctx, scheduledMsg.ScheduleID, scheduledMsg.Msg, scheduledMsg.ScheduleTime); err != nil {
+		log.ZError(ctx, "restore scheduled msg failed", err, "scheduleID", scheduledMsg.ScheduleID)
+	}
+	if err := m.Cron.SetScheduledMsgJob(ctx, scheduledMsg.ScheduleID, scheduledMsg.ScheduleTime.UnixMilli()); err != nil {
+		log.ZError(ctx, "restore scheduled msg job failed", err, "scheduleID", scheduledMsg.ScheduleID)
+	}
+}
+
+// CancelScheduledMsg cancels a pending scheduled msg, it will not be sent.
+func (m *msgServer) CancelScheduledMsg(ctx context.Context, req *msg.CancelScheduledMsgReq) (*msg.CancelScheduledMsgResp, error) {
+	if _, err := m.getPendingScheduledMsg(ctx, req.UserID, req.ScheduleID); err != nil {
+		return nil, err
+	}
+	ok, err := m.ScheduledMsgDatabase.CancelScheduledMsg(ctx, req.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.ErrArgs.Wrap("scheduled msg is no longer pending")
+	}
+	// a job left behind finds the msg canceled and sends nothing.
+	if err := m.Cron.SetScheduledMsgJob(ctx, req.ScheduleID, 0); err != nil {
+		log.ZWarn(ctx, "remove scheduled msg job failed", err, "scheduleID", req.ScheduleID)
+	}
+	return &msg.CancelScheduledMsgResp{}, nil
+}
+
+func (m *msgServer) getPendingScheduledMsg(ctx context.Context, userID string, scheduleID string) (*unrelationtb.ScheduledMsgModel, error) {
+	if err := authverify.CheckAccessV3(ctx, userID); err != nil {
+		return nil, err
+	}
+	scheduledMsg, err := m.ScheduledMsgDatabase.TakeScheduledMsg(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if scheduledMsg.SendID != userID {
+		return nil, errs.ErrNoPermission.Wrap("not the sender of the scheduled msg")
+	}
+	if scheduledMsg.Status != unrelationtb.ScheduledMsgPending {
+		return nil, errs.ErrArgs.Wrap("scheduled msg is no longer pending")
+	}
+	return scheduledMsg, nil
+}
+
+func checkScheduleTime(scheduleTime int64) (time.Time, error) {
+	if config.Config.ScheduledMsg.MaxDelay <= 0 {
+		return time.Time{}, errs.ErrArgs.Wrap("scheduled msg is disabled")
+	}
+	t := time.UnixMilli(scheduleTime)
+	now := time.Now()
+	if !t.After(now) {
+		return time.Time{}, errs.ErrArgs.Wrap("schedule time has passed")
+	}
+	if t.After(now.Add(time.Duration(config.Config.ScheduledMsg.MaxDelay) * time.Second)) {
+		return time.Time{}, errs.ErrArgs.Wrap("schedule time is too far ahead")
+	}
+	return t, nil
+}
+
+// checkSendContent checks the content the way the api checks a msg sent through it, the content must decode
+// to the elem of its content type with the required fields set. Text is checked as an edit is.
+func checkSendContent(contentType int32, content string) error {
+	var elem any
+	switch contentType {
+	case constant.Text, constant.AtText:
+		return checkEditContent(contentType, content)
+	case constant.Picture:
+		elem = &apistruct.PictureElem{}
+	case constant.Voice:
+		elem = &apistruct.SoundElem{}
+	case constant.Video:
+		elem = &apistruct.VideoElem{}
+	case constant.File:
+		elem = &apistruct.FileElem{}
+	case constant.Custom:
+		elem = &apistruct.CustomElem{}
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return errs.ErrArgs.Wrap("content is not a json object")
+	}
+	if elem == nil {
+		return nil
+	}
+	if err := mapstructure.WeakDecode(data, elem); err != nil {
+		return errs.ErrArgs.Wrap(err.Error())
+	}
+	if err := contentValidate.Struct(elem); err != nil {
+		return errs.ErrArgs.Wrap(err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	pbcron "github.com/OpenIMSDK/protocol/cron"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"google.golang.org/grpc"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient"
+)
+
+type mockScheduledMsgDatabase struct {
+	controller.ScheduledMsgDatabase
+	scheduledMsg *unrelationtb.ScheduledMsgModel
+}
+
+func (m *mockScheduledMsgDatabase) TakeScheduledMsg(_ context.Context, _ string) (*unrelationtb.ScheduledMsgModel, error) {
+	scheduledMsg := *m.scheduledMsg
+	return &scheduledMsg, nil
+}
+
+func (m *mockScheduledMsgDatabase) UpdatePendingScheduledMsg(_ context.Context, _ string, msg *unrelationtb.MsgDataModel, scheduleTime time.Time) (bool, error) {
+	if msg != nil {
+		m.scheduledMsg.Msg = msg
+	}
+	if !scheduleTime.IsZero() {
+		m.scheduledMsg.ScheduleTime = scheduleTime
+	}
+	return true, nil
+}
+
+// mockCronClient fails to set the job to a time in failTimes.
+type mockCronClient struct {
+	pbcron.CronClient
+	failTimes map[int64]bool
+	jobs      []int64
+}
+
+func (m *mockCronClient) SetScheduledMsgJob(_ context.Context, req *pbcron.SetScheduledMsgJobReq, _ ...grpc.CallOption) (*pbcron.SetScheduledMsgJobResp, error) {
+	if m.failTimes[req.ScheduleTime] {
+		return nil, errors.New("cron unavailable")
+	}
+	m.jobs = append(m.jobs, req.ScheduleTime)
+	return &pbcron.SetScheduledMsgJobResp{}, nil
+}
+
+func TestEditScheduledMsgRestore(t *testing.T) {
+	config.Config.ScheduledMsg.MaxDelay = 86400
+	scheduleTime := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	newTime := scheduleTime.Add(time.Hour).UnixMilli()
+	database := &mockScheduledMsgDatabase{scheduledMsg: &unrelationtb.ScheduledMsgModel{
+		ScheduleID:   "s1",
+		SendID:       "u1",
+		Status:       unrelationtb.ScheduledMsgPending,
+		ScheduleTime: scheduleTime,
+		Msg:          &unrelationtb.MsgDataModel{ContentType: constant.Text, Content: `{"content":"hello"}`},
+	}}
+	cron := &mockCronClient{failTimes: map[int64]bool{newTime: true}}
+	m := &msgServer{ScheduledMsgDatabase: database, Cron: &rpcclient.CronRpcClient{Client: cron}}
+	ctx := mcontext.WithOpUserIDContext(context.Background(), "u1")
+	_, err := m.EditScheduledMsg(ctx, &msg.EditScheduledMsgReq{
+		UserID:       "u1",
+		ScheduleID:   "s1",
+		Content:      `{"content":"bye"}`,
+		ScheduleTime: newTime,
+	})
+	if err == nil {
+		t.Fatal("the edit succeeded without a job")
+	}
+	if !database.scheduledMsg.ScheduleTime.Equal(scheduleTime) || database.scheduledMsg.Msg.Content != `{"content":"hello"}` {
+		t.Errorf("not restored: %v %s", database.scheduledMsg.ScheduleTime, database.scheduledMsg.Msg.Content)
+	}
+	if len(cron.jobs) != 1 || cron.jobs[0] != scheduleTime.UnixMilli() {
+		t.Errorf("the job was not set back: %v", cron.jobs)
+	}
+}
+
+func TestCheckSendContent(t *testing.T) {
+	tests := []struct {
+		contentType int32
+		content     string
+		ok          bool
+	}{
+		{constant.Text, `{"content":"hello"}`, true},
+		{constant.Text, `{"content":""}`, false},
+		{constant.Custom, `{"data":"x","description":"d"}`, true},
+		{constant.Custom, `{"description":"d"}`, false},
+		{constant.Picture, `{"sourcePicture":{"url":"https://x"}}`, true},
+		{constant.Picture, `{"sourcePicture":"x"}`, false},
+		{constant.Card, `{"userID":"u2"}`, true},
+		{constant.Card, `u2`, false},
+	}
+	for _, test := range tests {
+		err := checkSendContent(test.contentType, test.content)
+		if test.ok && err != nil {
+			t.Errorf("%d %s: %v", test.contentType, test.content, err)
+		}
+		if !test.ok && !errs.ErrArgs.Is(err) {
+			t.Errorf("%d %s: accepted or wrong error %v", test.contentType, test.content, err)
+		}
+	}
+}
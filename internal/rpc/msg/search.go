@@ -0,0 +1,124 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+const maxSearchUserMsgsShowNum = 100
+
+// SearchUserMsgs searches the text msgs of the conversations the user belongs to, the latest first. Msgs
+// below the user min seq or above the conversation max seq of a user who left are never returned, revoked
+// and deleted msgs are dropped from the index by msgtransfer. Matches aren't counted, Total is the number
+// of msgs up to this page, plus one when another page follows. The admin SearchMessage over the whole
+// chat log is unchanged.
+func (m *msgServer) SearchUserMsgs(ctx context.Context, req *msg.SearchUserMsgsReq) (*msg.SearchUserMsgsResp, error) {
+	if err := checkPagination(req.Pagination, maxSearchUserMsgsShowNum); err != nil {
+		return nil, err
+	}
+	keywordTokens := msgprocessor.GetSearchKeywordTokens(req.Keyword)
+	if len(keywordTokens) == 0 {
+		return nil, errs.ErrArgs.Wrap("keyword has no letter or digit")
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	conversationIDs, err := m.ConversationLocalCache.GetConversationIDs(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.ConversationIDs) > 0 {
+		conversationIDs = utils.Filter(req.ConversationIDs, func(conversationID string) (string, bool) {
+			return conversationID, utils.Contain(conversationID, conversationIDs...)
+		})
+	}
+	if len(conversationIDs) == 0 {
+		return &msg.SearchUserMsgsResp{}, nil
+	}
+	minSeqs, err := m.MsgDatabase.GetUserConversationsVisibleMinSeqs(ctx, req.UserID, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	conversations, err := m.Conversation.GetConversations(ctx, req.UserID, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	conversationSeqs := make(map[string]unrelation.MsgSearchSeqRange, len(conversationIDs))
+	for _, conversationID := range conversationIDs {
+		conversationSeqs[conversationID] = unrelation.MsgSearchSeqRange{MinSeq: minSeqs[conversationID]}
+	}
+	for _, conversation := range conversations {
+		if seqRange, ok := conversationSeqs[conversation.ConversationID]; ok {
+			// conversation.MaxSeq caps what a user who left the group can see.
+			seqRange.MaxSeq = conversation.MaxSeq
+			conversationSeqs[conversation.ConversationID] = seqRange
+		}
+	}
+	cond := &unrelation.MsgSearchCond{
+		UserID:           req.UserID,
+		ConversationSeqs: conversationSeqs,
+		KeywordTokens:    keywordTokens,
+		SendID:           req.SendID,
+		ContentTypes:     req.ContentTypes,
+		StartTime:        req.StartTime,
+		EndTime:          req.EndTime,
+	}
+	hits, more, err := m.MsgSearchDatabase.SearchMsgs(ctx, cond, req.Pagination.PageNumber, req.Pagination.ShowNumber)
+	if err != nil {
+		return nil, err
+	}
+	total := int64(req.Pagination.PageNumber-1)*int64(req.Pagination.ShowNumber) + int64(len(hits))
+	if more {
+		total++
+	}
+	hitSeqs := make(map[string][]int64)
+	for _, hit := range hits {
+		hitSeqs[hit.ConversationID] = append(hitSeqs[hit.ConversationID], hit.Seq)
+	}
+	msgs := make(map[string]map[int64]*sdkws.MsgData, len(hitSeqs))
+	for conversationID, seqs := range hitSeqs {
+		_, _, msgDatas, err := m.MsgDatabase.GetMsgBySeqs(ctx, req.UserID, conversationID, seqs)
+		if err != nil {
+			return nil, err
+		}
+		msgs[conversationID] = make(map[int64]*sdkws.MsgData, len(msgDatas))
+		for _, msgData := range msgDatas {
+			if msgData == nil || msgData.ClientMsgID == "" {
+				continue
+			}
+			msgs[conversationID][msgData.Seq] = msgData
+		}
+	}
+	resp := &msg.SearchUserMsgsResp{Total: total}
+	for _, hit := range hits {
+		msgData := msgs[hit.ConversationID][hit.Seq]
+		// msgtransfer may not have applied a revoke or delete yet.
+		if msgData == nil || msgData.ContentType != hit.ContentType {
+			continue
+		}
+		resp.Msgs = append(resp.Msgs, &msg.SearchedMsg{ConversationID: hit.ConversationID, MsgData: msgData})
+	}
+	return resp, nil
+}
@@ -38,6 +38,12 @@ func (m *msgServer) SendMsg(ctx context.Context, req *pbmsg.SendMsgReq) (resp *p
 		if !flag {
 			return nil, errs.ErrMessageHasReadDisable.Wrap()
 		}
+		if req.MsgData.ContentType == constant.Poll {
+			return nil, errs.ErrArgs.Wrap("polls are posted through CreatePoll")
+		}
+		if req.ScheduleTime > 0 {
+			return m.scheduleMsg(ctx, req)
+		}
 		m.encapsulateMsgData(req.MsgData)
 		switch req.MsgData.SessionType {
 		case constant.SingleChatType:
@@ -47,6 +53,9 @@ func (m *msgServer) SendMsg(ctx context.Context, req *pbmsg.SendMsgReq) (resp *p
 		case constant.SuperGroupChatType:
 			return m.sendMsgSuperGroupChat(ctx, req)
 		case constant.ServerGroupChatType:
+			if req.MsgData.ThreadID != "" {
+				return m.sendMsgThread(ctx, req)
+			}
 			return m.sendMsgSuperGroupChat(ctx, req)
 		default:
 			return nil, errs.ErrArgs.Wrap("unknown sessionType")
@@ -70,11 +79,16 @@ func (m *msgServer) sendMsgSuperGroupChat(
 	if err := callbackMsgModify(ctx, req); err != nil {
 		return nil, err
 	}
-	err = m.MsgDatabase.MsgToMQ(ctx, utils.GenConversationUniqueKeyForGroup(req.MsgData.GroupID), req.MsgData)
+	key := utils.GenConversationUniqueKeyForGroup(req.MsgData.GroupID)
+	if req.MsgData.ThreadID != "" {
+		// a thread has its own seqs, its msgs are batched apart from the channel ones.
+		key = utils.GenConversationUniqueKeyForGroup(req.MsgData.ThreadID)
+	}
+	err = m.MsgDatabase.MsgToMQ(ctx, key, req.MsgData)
 	if err != nil {
 		return nil, err
 	}
-	if req.MsgData.ContentType == constant.AtText {
+	if req.MsgData.ContentType == constant.AtText && req.MsgData.ThreadID == "" {
 		go m.setConversationAtInfo(ctx, req.MsgData)
 	}
 	if err = callbackAfterSendGroupMsg(ctx, req); err != nil {
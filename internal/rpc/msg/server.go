@@ -24,6 +24,7 @@ import (
 	"github.com/OpenIMSDK/protocol/msg"
 	"github.com/OpenIMSDK/tools/discoveryregistry"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/localcache"
@@ -36,6 +37,12 @@ type (
 	msgServer               struct {
 		RegisterCenter         discoveryregistry.SvcDiscoveryRegistry
 		MsgDatabase            controller.CommonMsgDatabase
+		ThreadDatabase         controller.ThreadDatabase
+		ScheduledMsgDatabase   controller.ScheduledMsgDatabase
+		MsgSearchDatabase      controller.MsgSearchDatabase
+		PollDatabase           controller.PollDatabase
+		ExportDatabase         controller.ConversationExportDatabase
+		PushRecordDatabase     controller.PushRecordDatabase // nil when push analytics is disabled
 		Group                  *rpcclient.GroupRpcClient
 		Club                   *rpcclient.ClubRpcClient
 		User                   *rpcclient.UserRpcClient
@@ -46,6 +53,8 @@ type (
 		ConversationLocalCache *localcache.ConversationLocalCache
 		Handlers               MessageInterceptorChain
 		notificationSender     *rpcclient.NotificationSender
+		groupMsgReadCounts     *coalescer[groupMsgReadCountKey, *groupMsgReadCountBatch]
+		pollUpdates            *coalescer[string, pollUpdate]
 	}
 )
 
@@ -85,12 +94,50 @@ func Start(client discoveryregistry.SvcDiscoveryRegistry, server *grpc.Server) e
 	clubRpcClient := rpcclient.NewClubRpcClient(client)
 	cronClient := rpcclient.NewCronRpcClient(client)
 	msgDatabase := controller.NewCommonMsgDatabase(msgDocModel, cacheModel)
+	threadModel, err := unrelation.NewThreadMongo(mongo.GetDatabase())
+	if err != nil {
+		return err
+	}
+	scheduledMsgModel, err := unrelation.NewScheduledMsgMongo(mongo.GetDatabase())
+	if err != nil {
+		return err
+	}
+	msgSearchModel, err := unrelation.NewMsgSearchMongo(mongo.GetDatabase())
+	if err != nil {
+		return err
+	}
+	pollModel, err := unrelation.NewPollMongo(mongo.GetDatabase())
+	if err != nil {
+		return err
+	}
+	conversationExportModel, err := unrelation.NewConversationExportMongo(mongo.GetDatabase())
+	if err != nil {
+		return err
+	}
+	o, err := controller.NewS3(rdb)
+	if err != nil {
+		return err
+	}
+	var pushRecordDatabase controller.PushRecordDatabase
+	if config.Config.Push.Analytics.Enable {
+		pushRecordModel, err := unrelation.NewPushRecordMongo(mongo.GetDatabase(), controller.PushRecordExpire())
+		if err != nil {
+			return err
+		}
+		pushRecordDatabase = controller.NewPushRecordDatabase(pushRecordModel)
+	}
 	s := &msgServer{
 		Conversation:           &conversationClient,
 		User:                   &userRpcClient,
 		Group:                  &groupRpcClient,
 		Club:                   &clubRpcClient,
 		MsgDatabase:            msgDatabase,
+		ThreadDatabase:         controller.NewThreadDatabase(threadModel),
+		ScheduledMsgDatabase:   controller.NewScheduledMsgDatabase(scheduledMsgModel),
+		MsgSearchDatabase:      controller.NewMsgSearchDatabase(msgSearchModel),
+		PollDatabase:           controller.NewPollDatabase(pollModel, cache.NewPollCacheRedis(rdb)),
+		ExportDatabase:         controller.NewConversationExportDatabase(conversationExportModel, rdb, o),
+		PushRecordDatabase:     pushRecordDatabase,
 		RegisterCenter:         client,
 		GroupLocalCache:        localcache.NewGroupLocalCache(&groupRpcClient),
 		ConversationLocalCache: localcache.NewConversationLocalCache(&conversationClient),
@@ -98,6 +145,8 @@ func Start(client discoveryregistry.SvcDiscoveryRegistry, server *grpc.Server) e
 		Cron:                   &cronClient,
 	}
 	s.notificationSender = rpcclient.NewNotificationSender(rpcclient.WithLocalSendMsg(s.SendMsg), rpcclient.WithUserRpcClient(&userRpcClient))
+	s.groupMsgReadCounts = newCoalescer(groupMsgReadCountWindow, mergeGroupMsgReadCountBatch, s.sendGroupMsgReadCountNotification)
+	s.pollUpdates = newCoalescer(pollUpdateWindow, mergePollUpdate, s.flushPollUpdate)
 	s.addInterceptorHandler(MessageHasReadEnabled)
 	msg.RegisterMsgServer(server, s)
 	return nil
@@ -20,8 +20,10 @@ import (
 
 	"github.com/OpenIMSDK/protocol/msg"
 	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
 	"github.com/OpenIMSDK/tools/utils"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
 )
 
@@ -113,3 +115,50 @@ func (m *msgServer) GetActiveGroup(ctx context.Context, req *msg.GetActiveGroupR
 		Groups:     pbgroups,
 	}, nil
 }
+
+// GetPushFunnel counts the offline pushes sent, failed, delivered and opened between Start and End, in
+// milliseconds, per day, platform and content type, with the attempts of each provider.
+func (m *msgServer) GetPushFunnel(ctx context.Context, req *msg.GetPushFunnelReq) (*msg.GetPushFunnelResp, error) {
+	if !authverify.IsAppManagerUid(ctx) {
+		return nil, errs.ErrNoPermission.Wrap("only app manager")
+	}
+	if req.Start >= req.End {
+		return nil, errs.ErrArgs.Wrap("start must be before end")
+	}
+	if m.PushRecordDatabase == nil {
+		return nil, errs.ErrArgs.Wrap("push analytics is disabled")
+	}
+	start, end := time.UnixMilli(req.Start), time.UnixMilli(req.End)
+	funnels, err := m.PushRecordDatabase.GetPushFunnels(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := m.PushRecordDatabase.GetPushProviderStats(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	resp := &msg.GetPushFunnelResp{
+		Funnels:   make([]*msg.PushFunnel, 0, len(funnels)),
+		Providers: make([]*msg.PushProviderStat, 0, len(providers)),
+	}
+	for _, funnel := range funnels {
+		resp.Funnels = append(resp.Funnels, &msg.PushFunnel{
+			Date:        funnel.Date,
+			PlatformID:  funnel.PlatformID,
+			ContentType: funnel.ContentType,
+			Sent:        funnel.Sent,
+			Failed:      funnel.Failed,
+			Delivered:   funnel.Delivered,
+			Opened:      funnel.Opened,
+		})
+	}
+	for _, provider := range providers {
+		resp.Providers = append(resp.Providers, &msg.PushProviderStat{
+			Provider:   provider.Provider,
+			Sent:       provider.Sent,
+			Failed:     provider.Failed,
+			AvgLatency: provider.AvgLatency,
+		})
+	}
+	return resp, nil
+}
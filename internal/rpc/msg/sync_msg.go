@@ -16,6 +16,7 @@ package msg
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
@@ -24,6 +25,7 @@ import (
 	"github.com/OpenIMSDK/protocol/msg"
 
 	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
 	"github.com/OpenIMSDK/tools/log"
 	"github.com/OpenIMSDK/tools/utils"
 )
@@ -97,6 +99,48 @@ func (m *msgServer) PullMessageBySeqs(
 	return resp, nil
 }
 
+const maxPullMsgsByTimeNum = 100
+
+// PullMsgsByTime locates the first msg sent at or after req.Time in the conversation and returns it with up to
+// req.Before msgs before it and req.After msgs after it, so clients can jump to a date without searching seqs.
+func (m *msgServer) PullMsgsByTime(ctx context.Context, req *msg.PullMsgsByTimeReq) (*msg.PullMsgsByTimeResp, error) {
+	if req.Before < 0 || req.After < 0 || req.Before+req.After+1 > maxPullMsgsByTimeNum {
+		return nil, errs.ErrArgs.Wrap("before and after must be non-negative and pull at most " + strconv.Itoa(maxPullMsgsByTimeNum) + " msgs")
+	}
+	if msgprocessor.IsNotification(req.ConversationID) {
+		return nil, errs.ErrArgs.Wrap("notification conversation is not supported")
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	conversation, err := m.Conversation.GetConversation(ctx, req.UserID, req.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	anchorSeq, err := m.MsgDatabase.FindSeqBySendTime(ctx, req.UserID, req.ConversationID, req.Time)
+	if err != nil {
+		return nil, err
+	}
+	if anchorSeq == 0 {
+		return &msg.PullMsgsByTimeResp{IsOldest: true, IsNewest: true}, nil
+	}
+	// conversation.MaxSeq caps what a user who left the group can see.
+	if conversation.MaxSeq != 0 && anchorSeq > conversation.MaxSeq {
+		anchorSeq = conversation.MaxSeq
+	}
+	begin, end := anchorSeq-req.Before, anchorSeq+req.After
+	minSeq, maxSeq, msgs, err := m.MsgDatabase.GetMsgBySeqsRange(ctx, req.UserID, req.ConversationID, begin, end, end-begin+1, conversation.MaxSeq)
+	if err != nil {
+		return nil, err
+	}
+	return &msg.PullMsgsByTimeResp{
+		AnchorSeq: anchorSeq,
+		Msgs:      msgs,
+		IsOldest:  begin <= minSeq,
+		IsNewest:  maxSeq <= end,
+	}, nil
+}
+
 func (m *msgServer) GetMaxSeq(ctx context.Context, req *sdkws.GetMaxSeqReq) (*sdkws.GetMaxSeqResp, error) {
 	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
 		return nil, err
@@ -106,7 +150,9 @@ func (m *msgServer) GetMaxSeq(ctx context.Context, req *sdkws.GetMaxSeqReq) (*sd
 		return nil, err
 	}
 	for _, conversationID := range conversationIDs {
-		conversationIDs = append(conversationIDs, utils.GetNotificationConversationIDByConversationID(conversationID))
+		if notificationConversationID := msgprocessor.GetNotificationConversationIDByConversationID(conversationID); notificationConversationID != "" {
+			conversationIDs = append(conversationIDs, notificationConversationID)
+		}
 	}
 	conversationIDs = append(conversationIDs, utils.GetSelfNotificationConversationID(req.UserID))
 	log.ZDebug(ctx, "GetMaxSeq", "conversationIDs", conversationIDs)
@@ -0,0 +1,416 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mw/specialerror"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/convert"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+const (
+	maxThreadNameLen     = 100
+	maxThreadsShowNum    = 100
+	maxGetThreadSeqs     = 100
+	defaultThreadArchive = 1440
+)
+
+// thread auto archive durations in minutes, an hour, a day, three days and a week.
+var threadArchiveDurations = []int32{60, 1440, 4320, 10080}
+
+// CreateThread starts a thread from a message of a server channel, a message has at most one thread
+// and creating it again returns the existing one.
+func (m *msgServer) CreateThread(ctx context.Context, req *msg.CreateThreadReq) (*msg.CreateThreadResp, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxThreadNameLen {
+		return nil, errs.ErrArgs.Wrap("thread name is empty or too long")
+	}
+	duration := req.AutoArchiveDuration
+	if duration == 0 {
+		duration = config.Config.Thread.AutoArchiveDuration
+		if duration <= 0 {
+			duration = defaultThreadArchive
+		}
+	} else if !utils.IsContainInt32(duration, threadArchiveDurations) {
+		return nil, errs.ErrArgs.Wrap("invalid auto archive duration")
+	}
+	parent, err := m.getAccessibleMsg(ctx, req.UserID, req.ConversationID, req.Seq)
+	if err != nil {
+		return nil, err
+	}
+	if parent.SessionType != constant.ServerGroupChatType || parent.ThreadID != "" {
+		return nil, errs.ErrArgs.Wrap("threads can only be started from server channel msgs")
+	}
+	if parent.ContentType >= constant.NotificationBegin && parent.ContentType <= constant.NotificationEnd {
+		return nil, errs.ErrArgs.Wrap("threads can't be started from notifications")
+	}
+	threadID := utils.Md5(req.ConversationID + "_" + strconv.FormatInt(req.Seq, 10))
+	if thread, err := m.ThreadDatabase.TakeThread(ctx, threadID); err == nil {
+		return &msg.CreateThreadResp{Thread: convert.ThreadDB2Pb(thread)}, nil
+	} else if !errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))) {
+		return nil, err
+	}
+	groupInfo, err := m.Group.GetGroupInfoCache(ctx, parent.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	thread := &unrelationtb.ThreadModel{
+		ThreadID:             threadID,
+		GroupID:              parent.GroupID,
+		ServerID:             groupInfo.ServerID,
+		ParentConversationID: req.ConversationID,
+		ParentSeq:            parent.Seq,
+		ParentClientMsgID:    parent.ClientMsgID,
+		Name:                 name,
+		CreatorUserID:        req.UserID,
+		AutoArchiveDuration:  duration,
+		LastActiveTime:       now,
+		AutoArchiveTime:      now.Add(time.Duration(duration) * time.Minute),
+		CreateTime:           now,
+	}
+	if err := m.ThreadDatabase.CreateThread(ctx, thread); err != nil {
+		if mongo.IsDuplicateKeyError(errs.Unwrap(err)) {
+			// created concurrently
+			if thread, err = m.ThreadDatabase.TakeThread(ctx, threadID); err != nil {
+				return nil, err
+			}
+			return &msg.CreateThreadResp{Thread: convert.ThreadDB2Pb(thread)}, nil
+		}
+		return nil, err
+	}
+	summary := &unrelationtb.ThreadSummaryModel{ThreadID: threadID, Participants: []string{}}
+	if err := m.MsgDatabase.SetMsgThread(ctx, req.ConversationID, parent.Seq, summary); err != nil {
+		return nil, err
+	}
+	info := convert.ThreadDB2Pb(thread)
+	tips := &sdkws.ThreadCreatedTips{Thread: info, OpUserID: req.UserID}
+	if err := m.notificationSender.NotificationWithSesstionType(ctx, req.UserID, thread.GroupID, constant.ThreadCreatedNotification, constant.ServerGroupChatType, tips); err != nil {
+		return nil, err
+	}
+	return &msg.CreateThreadResp{Thread: info}, nil
+}
+
+// GetThreadSummaries returns the thread summaries attached to messages of a server channel, messages
+// without a thread are left out.
+func (m *msgServer) GetThreadSummaries(ctx context.Context, req *msg.GetThreadSummariesReq) (*msg.GetThreadSummariesResp, error) {
+	if len(req.Seqs) == 0 || len(req.Seqs) > maxGetThreadSeqs {
+		return nil, errs.ErrArgs.Wrap("seqs is empty or too long")
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	_, _, msgs, err := m.MsgDatabase.GetMsgBySeqs(ctx, req.UserID, req.ConversationID, utils.Distinct(req.Seqs))
+	if err != nil {
+		return nil, err
+	}
+	resp := &msg.GetThreadSummariesResp{}
+	checked := false
+	for _, msgData := range msgs {
+		if msgData == nil || msgData.ClientMsgID == "" {
+			continue
+		}
+		if !checked {
+			// every message is of the same conversation.
+			if err := m.checkReactionAccess(ctx, req.UserID, msgData); err != nil {
+				return nil, err
+			}
+			checked = true
+		}
+		summary, err := m.MsgDatabase.GetMsgThreadSummary(ctx, req.ConversationID, msgData.Seq)
+		if err != nil {
+			return nil, err
+		}
+		if summary == nil {
+			continue
+		}
+		resp.Summaries = append(resp.Summaries, convert.ThreadSummaryDB2Pb(msgData.Seq, summary))
+	}
+	return resp, nil
+}
+
+// GetGroupThreads pages through the active or archived threads of a server channel, the most recently
+// active first.
+func (m *msgServer) GetGroupThreads(ctx context.Context, req *msg.GetGroupThreadsReq) (*msg.GetGroupThreadsResp, error) {
+	if err := checkPagination(req.Pagination, maxThreadsShowNum); err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if err := m.checkServerGroupMember(ctx, req.GroupID, req.UserID); err != nil {
+		return nil, err
+	}
+	total, threads, err := m.ThreadDatabase.PageGroupThreads(ctx, req.GroupID, req.Archived, req.Pagination.PageNumber, req.Pagination.ShowNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &msg.GetGroupThreadsResp{Total: total, Threads: utils.Batch(convert.ThreadDB2Pb, threads)}, nil
+}
+
+func (m *msgServer) SubscribeThread(ctx context.Context, req *msg.SubscribeThreadReq) (*msg.SubscribeThreadResp, error) {
+	thread, err := m.getAccessibleThread(ctx, req.UserID, req.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ThreadDatabase.SubscribeThread(ctx, thread.ThreadID, req.UserID); err != nil {
+		return nil, err
+	}
+	return &msg.SubscribeThreadResp{}, nil
+}
+
+func (m *msgServer) UnsubscribeThread(ctx context.Context, req *msg.UnsubscribeThreadReq) (*msg.UnsubscribeThreadResp, error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if err := m.ThreadDatabase.UnsubscribeThread(ctx, req.ThreadID, req.UserID); err != nil {
+		return nil, err
+	}
+	return &msg.UnsubscribeThreadResp{}, nil
+}
+
+// GetSubscribedThreads pages through the threads the user subscribed to with their unread counts.
+func (m *msgServer) GetSubscribedThreads(ctx context.Context, req *msg.GetSubscribedThreadsReq) (*msg.GetSubscribedThreadsResp, error) {
+	if err := checkPagination(req.Pagination, maxThreadsShowNum); err != nil {
+		return nil, err
+	}
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	total, threads, err := m.ThreadDatabase.PageUserSubscribedThreads(ctx, req.UserID, req.Pagination.PageNumber, req.Pagination.ShowNumber)
+	if err != nil {
+		return nil, err
+	}
+	resp := &msg.GetSubscribedThreadsResp{Total: total}
+	if len(threads) == 0 {
+		return resp, nil
+	}
+	conversationIDs := utils.Slice(threads, func(thread *unrelationtb.ThreadModel) string {
+		return msgprocessor.GetThreadConversationID(thread.ThreadID)
+	})
+	maxSeqs, err := m.MsgDatabase.GetMaxSeqs(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	hasReadSeqs, err := m.MsgDatabase.GetHasReadSeqs(ctx, req.UserID, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, thread := range threads {
+		maxSeq, hasReadSeq := maxSeqs[conversationIDs[i]], hasReadSeqs[conversationIDs[i]]
+		unread := maxSeq - hasReadSeq
+		if unread < 0 {
+			unread = 0
+		}
+		resp.Threads = append(resp.Threads, &msg.SubscribedThread{
+			Thread:      convert.ThreadDB2Pb(thread),
+			MaxSeq:      maxSeq,
+			HasReadSeq:  hasReadSeq,
+			UnreadCount: unread,
+		})
+	}
+	return resp, nil
+}
+
+// MarkThreadAsRead sets the read seq of the user in the thread, 0 marks every reply as read.
+func (m *msgServer) MarkThreadAsRead(ctx context.Context, req *msg.MarkThreadAsReadReq) (*msg.MarkThreadAsReadResp, error) {
+	if req.HasReadSeq < 0 {
+		return nil, errs.ErrArgs.Wrap("hasReadSeq is invalid")
+	}
+	thread, err := m.getAccessibleThread(ctx, req.UserID, req.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	conversationID := msgprocessor.GetThreadConversationID(thread.ThreadID)
+	maxSeq, err := m.MsgDatabase.GetMaxSeq(ctx, conversationID)
+	if err != nil && errs.Unwrap(err) != redis.Nil {
+		return nil, err
+	}
+	hasReadSeq := req.HasReadSeq
+	if hasReadSeq == 0 || hasReadSeq > maxSeq {
+		hasReadSeq = maxSeq
+	}
+	if err := m.MsgDatabase.SetHasReadSeq(ctx, req.UserID, conversationID, hasReadSeq); err != nil {
+		return nil, err
+	}
+	return &msg.MarkThreadAsReadResp{}, nil
+}
+
+// PullThreadMsgs pulls the replies of a thread in [Begin, End], at most Num of them.
+func (m *msgServer) PullThreadMsgs(ctx context.Context, req *msg.PullThreadMsgsReq) (*msg.PullThreadMsgsResp, error) {
+	if req.Begin <= 0 || req.End < req.Begin || req.Num <= 0 {
+		return nil, errs.ErrArgs.Wrap("seq range is invalid")
+	}
+	thread, err := m.getAccessibleThread(ctx, req.UserID, req.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	conversationID := msgprocessor.GetThreadConversationID(thread.ThreadID)
+	minSeq, maxSeq, msgs, err := m.MsgDatabase.GetMsgBySeqsRange(ctx, req.UserID, conversationID, req.Begin, req.End, req.Num, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &msg.PullThreadMsgsResp{Msgs: msgs, MinSeq: minSeq, MaxSeq: maxSeq, IsEnd: maxSeq <= req.End}, nil
+}
+
+// SetThreadArchived archives or unarchives a thread, by its creator or a ManageMsg holder.
+func (m *msgServer) SetThreadArchived(ctx context.Context, req *msg.SetThreadArchivedReq) (*msg.SetThreadArchivedResp, error) {
+	thread, err := m.getAccessibleThread(ctx, req.UserID, req.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.Archived == req.Archived {
+		return &msg.SetThreadArchivedResp{}, nil
+	}
+	if req.UserID != thread.CreatorUserID && !authverify.IsAppManagerUid(ctx) {
+		if err := m.checkManageMsg(ctx, thread.GroupID, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	if err := m.ThreadDatabase.SetThreadArchived(ctx, thread, req.Archived, now); err != nil {
+		return nil, err
+	}
+	if err := m.threadArchivedNotification(ctx, req.UserID, thread, req.Archived, now); err != nil {
+		return nil, err
+	}
+	return &msg.SetThreadArchivedResp{}, nil
+}
+
+// sendMsgThread sends a reply to a thread, it is pushed to the subscribers of the thread only and
+// subscribes the sender.
+func (m *msgServer) sendMsgThread(ctx context.Context, req *msg.SendMsgReq) (*msg.SendMsgResp, error) {
+	thread, err := m.ThreadDatabase.TakeThread(ctx, req.MsgData.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.GroupID != req.MsgData.GroupID {
+		return nil, errs.ErrArgs.Wrap("thread is not in the group")
+	}
+	if len(req.MsgData.RecvIDList) == 0 {
+		subscriberIDs, err := m.ThreadDatabase.GetThreadSubscriberIDs(ctx, thread.ThreadID)
+		if err != nil {
+			return nil, err
+		}
+		members, err := m.Club.GetServerMemberInfos(ctx, thread.ServerID, subscriberIDs, false)
+		if err != nil {
+			return nil, err
+		}
+		recvIDs, departedIDs := threadRecipients(subscriberIDs, members, req.MsgData.SendID)
+		for _, userID := range departedIDs {
+			if err := m.ThreadDatabase.UnsubscribeThread(ctx, thread.ThreadID, userID); err != nil {
+				log.ZWarn(ctx, "unsubscribe departed member failed", err, "threadID", thread.ThreadID, "userID", userID)
+			}
+		}
+		req.MsgData.RecvIDList = recvIDs
+	}
+	resp, err := m.sendMsgSuperGroupChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !msgprocessor.IsNotificationByMsg(req.MsgData) {
+		m.threadReplied(ctx, thread, req.MsgData)
+	}
+	return resp, nil
+}
+
+// threadRecipients returns the subscribers still in the server along with the sender, and the subscribers
+// that left the server.
+func threadRecipients(subscriberIDs []string, members []*sdkws.ServerMemberFullInfo, sendID string) (recvIDs []string, departedIDs []string) {
+	memberIDs := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		memberIDs[member.UserID] = struct{}{}
+	}
+	for _, userID := range subscriberIDs {
+		if _, ok := memberIDs[userID]; ok {
+			recvIDs = append(recvIDs, userID)
+		} else if userID != sendID {
+			departedIDs = append(departedIDs, userID)
+		}
+	}
+	return utils.Distinct(append(recvIDs, sendID)), departedIDs
+}
+
+// threadReplied updates the thread after a reply was sent, failures are only logged.
+func (m *msgServer) threadReplied(ctx context.Context, thread *unrelationtb.ThreadModel, msgData *sdkws.MsgData) {
+	if err := m.ThreadDatabase.SubscribeThread(ctx, thread.ThreadID, msgData.SendID); err != nil {
+		log.ZWarn(ctx, "subscribe thread failed", err, "threadID", thread.ThreadID, "userID", msgData.SendID)
+	}
+	if err := m.MsgDatabase.AddMsgThreadReply(ctx, thread.ParentConversationID, thread.ParentSeq, msgData.SendID, msgData.SendTime); err != nil {
+		log.ZWarn(ctx, "add thread reply failed", err, "threadID", thread.ThreadID)
+	}
+	now := time.Now()
+	archived, err := m.ThreadDatabase.TouchThread(ctx, thread.ThreadID, now)
+	if err != nil {
+		log.ZWarn(ctx, "touch thread failed", err, "threadID", thread.ThreadID)
+		return
+	}
+	if archived {
+		// a reply brings an archived thread back.
+		if err := m.threadArchivedNotification(ctx, msgData.SendID, thread, false, now); err != nil {
+			log.ZWarn(ctx, "thread unarchived notification failed", err, "threadID", thread.ThreadID)
+		}
+	}
+}
+
+// getAccessibleThread loads a thread and checks the user is a member of its server.
+func (m *msgServer) getAccessibleThread(ctx context.Context, userID string, threadID string) (*unrelationtb.ThreadModel, error) {
+	if userID == "" || threadID == "" {
+		return nil, errs.ErrArgs.Wrap("userID or threadID is empty")
+	}
+	if err := authverify.CheckAccessV3(ctx, userID); err != nil {
+		return nil, err
+	}
+	thread, err := m.ThreadDatabase.TakeThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Club.GetServerMemberInfo(ctx, thread.ServerID, userID); err != nil {
+		if errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))) {
+			return nil, errs.ErrNotInGroupYet.Wrap(err.Error())
+		}
+		return nil, err
+	}
+	return thread, nil
+}
+
+func (m *msgServer) threadArchivedNotification(ctx context.Context, opUserID string, thread *unrelationtb.ThreadModel, archived bool, now time.Time) error {
+	tips := &sdkws.ThreadArchivedTips{
+		ThreadID:      thread.ThreadID,
+		GroupID:       thread.GroupID,
+		OpUserID:      opUserID,
+		Archived:      archived,
+		OperationTime: now.UnixMilli(),
+	}
+	return m.notificationSender.NotificationWithSesstionType(ctx, opUserID, thread.GroupID, constant.ThreadArchivedNotification, constant.ServerGroupChatType, tips)
+}
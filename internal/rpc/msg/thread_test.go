@@ -0,0 +1,45 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/sdkws"
+)
+
+func TestThreadRecipients(t *testing.T) {
+	members := []*sdkws.ServerMemberFullInfo{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u4"}}
+	tests := []struct {
+		subscriberIDs []string
+		sendID        string
+		recvIDs       string
+		departedIDs   string
+	}{
+		{[]string{"u1", "u2"}, "u4", "[u1 u2 u4]", "[]"},
+		{[]string{"u1", "u3", "u5"}, "u2", "[u1 u2]", "[u3 u5]"},
+		{[]string{"u1", "u3"}, "u3", "[u1 u3]", "[]"},
+		{nil, "u1", "[u1]", "[]"},
+	}
+	for _, test := range tests {
+		recvIDs, departedIDs := threadRecipients(test.subscriberIDs, members, test.sendID)
+		sort.Strings(recvIDs)
+		if fmt.Sprint(recvIDs) != test.recvIDs || fmt.Sprint(departedIDs) != test.departedIDs {
+			t.Errorf("%v from %s: recv %v, departed %v", test.subscriberIDs, test.sendID, recvIDs, departedIDs)
+		}
+	}
+}
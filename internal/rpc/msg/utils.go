@@ -20,6 +20,7 @@ import (
 
 	"github.com/OpenIMSDK/protocol/constant"
 	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
 	"github.com/OpenIMSDK/tools/utils"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
@@ -51,3 +52,13 @@ func IsNotFound(err error) bool {
 		return false
 	}
 }
+
+func checkPagination(pagination *sdkws.RequestPagination, maxShowNum int32) error {
+	if pagination == nil || pagination.PageNumber <= 0 || pagination.ShowNumber <= 0 {
+		return errs.ErrArgs.Wrap("pagination is invalid")
+	}
+	if pagination.ShowNumber > maxShowNum {
+		return errs.ErrArgs.Wrap("showNumber is too large")
+	}
+	return nil
+}
@@ -222,6 +222,8 @@ func (m *msgServer) encapsulateMsgData(msg *sdkws.MsgData) {
 		fallthrough
 	case constant.Custom:
 		fallthrough
+	case constant.Poll:
+		fallthrough
 	case constant.Quote:
 		utils.SetSwitchFromOptions(msg.Options, constant.IsConversationUpdate, true)
 		utils.SetSwitchFromOptions(msg.Options, constant.IsUnreadCount, true)
@@ -20,18 +20,17 @@ import (
 	"net/url"
 	"time"
 
-	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3"
-	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/cos"
-	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/minio"
-	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/oss"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/rtc"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/rtc/agora"
 
 	"google.golang.org/grpc"
 
+	"github.com/OpenIMSDK/protocol/constant"
 	"github.com/OpenIMSDK/protocol/third"
 	"github.com/OpenIMSDK/tools/discoveryregistry"
+	"github.com/OpenIMSDK/tools/errs"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
@@ -65,18 +64,7 @@ func Start(client discoveryregistry.SvcDiscoveryRegistry, server *grpc.Server) e
 		return err
 	}
 	// 根据配置文件策略选择 oss 方式
-	enable := config.Config.Object.Enable
-	var o s3.Interface
-	switch config.Config.Object.Enable {
-	case "minio":
-		o, err = minio.NewMinio(cache.NewMinioCache(rdb))
-	case "cos":
-		o, err = cos.NewCos()
-	case "oss":
-		o, err = oss.NewOSS()
-	default:
-		err = fmt.Errorf("invalid object enable: %s", enable)
-	}
+	o, err := controller.NewS3(rdb)
 	if err != nil {
 		return err
 	}
@@ -116,6 +104,71 @@ func (t *thirdServer) FcmUpdateToken(ctx context.Context, req *third.FcmUpdateTo
 	return &third.FcmUpdateTokenResp{}, nil
 }
 
+func (t *thirdServer) APNsUpdateToken(ctx context.Context, req *third.APNsUpdateTokenReq) (resp *third.APNsUpdateTokenResp, err error) {
+	if req.APNsToken == "" {
+		return nil, errs.ErrArgs.Wrap("apnsToken is empty")
+	}
+	err = t.thirdDatabase.APNsUpdateToken(ctx, req.Account, req.APNsToken, req.ExpireTime)
+	if err != nil {
+		return nil, err
+	}
+	return &third.APNsUpdateTokenResp{}, nil
+}
+
+func (t *thirdServer) VoIPUpdateToken(ctx context.Context, req *third.VoIPUpdateTokenReq) (resp *third.VoIPUpdateTokenResp, err error) {
+	if req.VoIPToken == "" {
+		return nil, errs.ErrArgs.Wrap("voipToken is empty")
+	}
+	err = t.thirdDatabase.VoIPUpdateToken(ctx, req.Account, req.VoIPToken, req.ExpireTime)
+	if err != nil {
+		return nil, err
+	}
+	return &third.VoIPUpdateTokenResp{}, nil
+}
+
+func (t *thirdServer) WebPushSubscribe(ctx context.Context, req *third.WebPushSubscribeReq) (resp *third.WebPushSubscribeResp, err error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if err := checkWebPushSubscription(req.Endpoint, req.P256Dh, req.Auth); err != nil {
+		return nil, err
+	}
+	subscription := &cache.WebPushSubscription{Endpoint: req.Endpoint, P256dh: req.P256Dh, Auth: req.Auth}
+	if err := t.thirdDatabase.WebPushSubscribe(ctx, req.UserID, subscription, req.ExpireTime); err != nil {
+		return nil, err
+	}
+	return &third.WebPushSubscribeResp{}, nil
+}
+
+func (t *thirdServer) WebPushUnsubscribe(ctx context.Context, req *third.WebPushUnsubscribeReq) (resp *third.WebPushUnsubscribeResp, err error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if err := t.thirdDatabase.WebPushUnsubscribe(ctx, req.UserID, req.Endpoint); err != nil {
+		return nil, err
+	}
+	return &third.WebPushUnsubscribeResp{}, nil
+}
+
+func (t *thirdServer) VendorUpdateToken(ctx context.Context, req *third.VendorUpdateTokenReq) (resp *third.VendorUpdateTokenResp, err error) {
+	switch req.Vendor {
+	case cache.PushVendorHuawei, cache.PushVendorHonor, cache.PushVendorXiaomi, cache.PushVendorOPPO, cache.PushVendorVivo:
+	default:
+		return nil, errs.ErrArgs.Wrap("unknown vendor " + req.Vendor)
+	}
+	if req.PlatformID != constant.AndroidPlatformID && req.PlatformID != constant.AndroidPadPlatformID {
+		return nil, errs.ErrArgs.Wrap("vendor push is only available on android")
+	}
+	if req.Token == "" {
+		return nil, errs.ErrArgs.Wrap("token is empty")
+	}
+	token := &cache.VendorPushToken{Vendor: req.Vendor, Token: req.Token}
+	if err := t.thirdDatabase.VendorUpdateToken(ctx, req.Account, int(req.PlatformID), token, req.ExpireTime); err != nil {
+		return nil, err
+	}
+	return &third.VendorUpdateTokenResp{}, nil
+}
+
 func (t *thirdServer) SetAppBadge(ctx context.Context, req *third.SetAppBadgeReq) (resp *third.SetAppBadgeResp, err error) {
 	err = t.thirdDatabase.SetAppBadge(ctx, req.UserID, int(req.AppUnreadCount))
 	if err != nil {
@@ -16,11 +16,13 @@ package third
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/openimsdk/open-im-server/v3/internal/push/offlinepush/webpush"
 	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
 
 	"github.com/OpenIMSDK/protocol/third"
@@ -77,3 +79,20 @@ func checkValidObjectName(objectName string) error {
 	}
 	return checkValidObjectNamePrefix(objectName)
 }
+
+// checkWebPushSubscription checks a subscription as the browser returns it: a public https endpoint,
+// a P-256 public key and a 16 bytes auth secret, base64url encoded.
+func checkWebPushSubscription(endpoint, p256dh, auth string) error {
+	if err := webpush.CheckEndpoint(endpoint); err != nil {
+		return errs.ErrArgs.Wrap(err.Error())
+	}
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(p256dh, "="))
+	if err != nil || len(key) != 65 || key[0] != 4 {
+		return errs.ErrArgs.Wrap("p256dh must be an uncompressed P-256 public key")
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(auth, "="))
+	if err != nil || len(secret) != 16 {
+		return errs.ErrArgs.Wrap("auth must be 16 bytes")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"context"
+	"time"
+
+	pbuser "github.com/OpenIMSDK/protocol/user"
+	"github.com/OpenIMSDK/tools/errs"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/authverify"
+	tablerelation "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
+)
+
+const minutesPerDay = 24 * 60
+
+func (s *userServer) SetQuietHours(ctx context.Context, req *pbuser.SetQuietHoursReq) (resp *pbuser.SetQuietHoursResp, err error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if req.QuietHours == nil {
+		return nil, errs.ErrArgs.Wrap("quietHours is empty")
+	}
+	quietHours, err := quietHoursPb2DB(req.UserID, req.QuietHours)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quietHoursDatabase.SetQuietHours(ctx, quietHours); err != nil {
+		return nil, err
+	}
+	return &pbuser.SetQuietHoursResp{}, nil
+}
+
+func (s *userServer) DelQuietHours(ctx context.Context, req *pbuser.DelQuietHoursReq) (resp *pbuser.DelQuietHoursResp, err error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.quietHoursDatabase.DelQuietHours(ctx, req.UserID, req.TargetType, req.TargetID); err != nil {
+		return nil, err
+	}
+	return &pbuser.DelQuietHoursResp{}, nil
+}
+
+func (s *userServer) GetQuietHours(ctx context.Context, req *pbuser.GetQuietHoursReq) (resp *pbuser.GetQuietHoursResp, err error) {
+	if err := authverify.CheckAccessV3(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	quietHours, err := s.quietHoursDatabase.GetQuietHours(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &pbuser.GetQuietHoursResp{QuietHours: quietHoursDB2Pb(quietHours)}, nil
+}
+
+// GetUsersQuietHours Get the quiet hours of many users that may apply to a push in the conversation, used by the push service.
+func (s *userServer) GetUsersQuietHours(ctx context.Context, req *pbuser.GetUsersQuietHoursReq) (resp *pbuser.GetUsersQuietHoursResp, err error) {
+	quietHours, err := s.quietHoursDatabase.FindPushQuietHours(ctx, req.UserIDs, req.ConversationID, req.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	return &pbuser.GetUsersQuietHoursResp{QuietHours: quietHoursDB2Pb(quietHours)}, nil
+}
+
+func quietHoursPb2DB(userID string, quietHours *pbuser.QuietHours) (*tablerelation.PushQuietHoursModel, error) {
+	switch quietHours.TargetType {
+	case tablerelation.QuietHoursGlobal:
+		if quietHours.TargetID != "" {
+			return nil, errs.ErrArgs.Wrap("targetID must be empty for global quiet hours")
+		}
+	case tablerelation.QuietHoursConversation, tablerelation.QuietHoursServer:
+		if quietHours.TargetID == "" {
+			return nil, errs.ErrArgs.Wrap("targetID is empty")
+		}
+	default:
+		return nil, errs.ErrArgs.Wrap("invalid targetType")
+	}
+	if quietHours.Start < 0 || quietHours.Start >= minutesPerDay || quietHours.End < 0 || quietHours.End >= minutesPerDay {
+		return nil, errs.ErrArgs.Wrap("start and end must be minutes of the day")
+	}
+	if quietHours.Start == quietHours.End {
+		return nil, errs.ErrArgs.Wrap("start and end are equal")
+	}
+	timeZone := quietHours.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return nil, errs.ErrArgs.Wrap("invalid timeZone " + timeZone)
+	}
+	return &tablerelation.PushQuietHoursModel{
+		UserID:       userID,
+		TargetType:   quietHours.TargetType,
+		TargetID:     quietHours.TargetID,
+		Start:        quietHours.Start,
+		End:          quietHours.End,
+		TimeZone:     timeZone,
+		AllowMention: quietHours.AllowMention,
+	}, nil
+}
+
+func quietHoursDB2Pb(quietHours []*tablerelation.PushQuietHoursModel) []*pbuser.QuietHours {
+	res := make([]*pbuser.QuietHours, 0, len(quietHours))
+	for _, v := range quietHours {
+		res = append(res, &pbuser.QuietHours{
+			UserID:       v.UserID,
+			TargetType:   v.TargetType,
+			TargetID:     v.TargetID,
+			Start:        v.Start,
+			End:          v.End,
+			TimeZone:     v.TimeZone,
+			AllowMention: v.AllowMention,
+		})
+	}
+	return res
+}
@@ -52,6 +52,7 @@ type userServer struct {
 	friendRpcClient          *rpcclient.FriendRpcClient
 	groupRpcClient           *rpcclient.GroupRpcClient
 	RegisterCenter           registry.SvcDiscoveryRegistry
+	quietHoursDatabase       controller.QuietHoursDatabase
 }
 
 func Start(client registry.SvcDiscoveryRegistry, server *grpc.Server) error {
@@ -87,6 +88,7 @@ func Start(client registry.SvcDiscoveryRegistry, server *grpc.Server) error {
 	u := &userServer{
 		UserDatabase:             database,
 		RegisterCenter:           client,
+		quietHoursDatabase:       controller.NewQuietHoursDatabase(db),
 		friendRpcClient:          &friendRpcClient,
 		groupRpcClient:           &groupRpcClient,
 		friendNotificationSender: notification.NewFriendNotificationSender(&msgRpcClient, notification.WithDBFunc(database.FindWithError)),
@@ -267,6 +269,27 @@ func (s *userServer) GetUserSetting(ctx context.Context, req *pbuser.GetUserSett
 	}, nil
 }
 
+// GetUsersSetting Get the push related settings of many users at once, unknown userIDs are skipped.
+func (s *userServer) GetUsersSetting(ctx context.Context, req *pbuser.GetUsersSettingReq) (resp *pbuser.GetUsersSettingResp, err error) {
+	users, err := s.Find(ctx, req.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+	resp = &pbuser.GetUsersSettingResp{Settings: make(map[string]*pbuser.GetUserSettingResp, len(users))}
+	for _, user := range users {
+		resp.Settings[user.UserID] = &pbuser.GetUserSettingResp{
+			GlobalRecvMsgOpt: user.GlobalRecvMsgOpt,
+			AllowBeep:        user.AllowBeep,
+			AllowVibration:   user.AllowVibration,
+			AllowPushContent: user.AllowPushContent,
+			AllowOnlinePush:  user.AllowOnlinePush,
+			Language:         user.Language,
+			AllowStrangerMsg: user.AllowStrangerMsg,
+		}
+	}
+	return resp, nil
+}
+
 // GetAllUserID Get user account by page.
 func (s *userServer) GetAllUserID(ctx context.Context, req *pbuser.GetAllUserIDReq) (resp *pbuser.GetAllUserIDResp, err error) {
 	userIDs, err := s.UserDatabase.GetAllUserID(ctx, req.Pagination.PageNumber, req.Pagination.ShowNumber)
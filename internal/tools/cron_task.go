@@ -112,13 +112,30 @@ func StartTask(rpcPort, prometheusPort int) error {
 	// 	log.ZError(context.Background(), "start allConversationClearMsgAndFixSeq cron failed", err)
 	// 	panic(err)
 	// }
-	//
-	// log.ZInfo(context.Background(), "start msgDestruct cron task", "cron config", config.Config.MsgDestructTime)
-	// err = dcron.AddFunc("cron_conversations_destruct_msgs", config.Config.MsgDestructTime, msgTool.ConversationsDestructMsgs)
-	// if err != nil {
-	// 	log.ZError(context.Background(), "start conversationsDestructMsgs cron failed", err)
-	// 	panic(err)
-	// }
+
+	log.ZInfo(context.Background(), "start msgDestruct cron task", "cron config", config.Config.MsgDestructTime, "shards", config.Config.MsgDestructShards)
+	shards := config.Config.MsgDestructShards
+	if shards < 1 {
+		shards = 1
+	}
+	for i := 0; i < shards; i++ {
+		shard := i
+		// one job per shard, dcron spreads the jobs over the cron nodes by name.
+		err = dcron.AddFunc(fmt.Sprintf("cron_conversations_destruct_msgs_%d", shard), config.Config.MsgDestructTime, func() {
+			msgTool.ConversationsDestructMsgs(shard, shards)
+		})
+		if err != nil {
+			log.ZError(context.Background(), "start conversationsDestructMsgs cron failed", err, "shard", shard)
+			panic(err)
+		}
+	}
+
+	log.ZInfo(context.Background(), "start archiveInactiveThreads cron task", "cron config", config.Config.Thread.ArchiveTime)
+	err = dcron.AddFunc("cron_archive_inactive_threads", config.Config.Thread.ArchiveTime, msgTool.ArchiveInactiveThreads)
+	if err != nil {
+		log.ZError(context.Background(), "start archiveInactiveThreads cron failed", err)
+		panic(err)
+	}
 
 	// start crontab
 	dcron.Start()
@@ -193,6 +210,10 @@ func (c *cronServer) recoverAllStableJob(jobs map[string]string) error {
 			continue
 		}
 		log.ZInfo(context.Background(), "recover", "jobName", jobName, "jobBody", v)
+		if overdue, ok := djob.(interface{ Overdue() bool }); ok && overdue.Overdue() {
+			// the time passed while the service was down, run it now instead of waiting for the next match.
+			go djob.Run()
+		}
 	}
 	return nil
 }
@@ -284,6 +305,62 @@ func (c *cronServer) SetCloseVoiceChannelJob(ctx context.Context, req *pbcron.Se
 	return resp, nil
 }
 
+// SetScheduledMsgJob schedules the msg to be sent at ScheduleTime, a ScheduleTime of 0 removes the job.
+func (c *cronServer) SetScheduledMsgJob(ctx context.Context, req *pbcron.SetScheduledMsgJobReq) (*pbcron.SetScheduledMsgJobResp, error) {
+	resp := &pbcron.SetScheduledMsgJobResp{}
+	job := job.NewScheduledMsgJob(req.ScheduleID, req.ScheduleTime, c.msgTool, c.dcron)
+	c.dcron.Remove(job.Name)
+	if req.ScheduleTime == 0 {
+		log.ZInfo(ctx, "remove job", "jobName", job.Name)
+		return resp, nil
+	}
+	if err := c.dcron.AddJob(job.Name, job.CronExpr, job); err != nil {
+		log.ZError(ctx, "add job failed", err, "jobName", job.Name)
+		return nil, err
+	}
+	log.ZInfo(ctx, "add job", "jobName", job.Name, "scheduleTime", req.ScheduleTime)
+	if job.Overdue() {
+		go job.Run()
+	}
+	return resp, nil
+}
+
+// SetClosePollJob closes the poll at CloseTime, a CloseTime of 0 removes the job.
+func (c *cronServer) SetClosePollJob(ctx context.Context, req *pbcron.SetClosePollJobReq) (*pbcron.SetClosePollJobResp, error) {
+	resp := &pbcron.SetClosePollJobResp{}
+	job := job.NewClosePollJob(req.PollID, req.CloseTime, c.msgTool, c.dcron)
+	c.dcron.Remove(job.Name)
+	if req.CloseTime == 0 {
+		log.ZInfo(ctx, "remove job", "jobName", job.Name)
+		return resp, nil
+	}
+	if err := c.dcron.AddJob(job.Name, job.CronExpr, job); err != nil {
+		log.ZError(ctx, "add job failed", err, "jobName", job.Name)
+		return nil, err
+	}
+	log.ZInfo(ctx, "add job", "jobName", job.Name, "closeTime", req.CloseTime)
+	if job.Overdue() {
+		go job.Run()
+	}
+	return resp, nil
+}
+
+// SetExportConversationJob renders and uploads the conversation export now, off the request.
+func (c *cronServer) SetExportConversationJob(ctx context.Context, req *pbcron.SetExportConversationJobReq) (*pbcron.SetExportConversationJobResp, error) {
+	resp := &pbcron.SetExportConversationJobResp{}
+	job := job.NewExportConversationJob(req.ExportID, c.msgTool, c.dcron)
+	c.dcron.Remove(job.Name)
+	if err := c.dcron.AddJob(job.Name, job.CronExpr, job); err != nil {
+		log.ZError(ctx, "add job failed", err, "jobName", job.Name)
+		return nil, err
+	}
+	log.ZInfo(ctx, "add job", "jobName", job.Name)
+	if job.Overdue() {
+		go job.Run()
+	}
+	return resp, nil
+}
+
 // netlock redis lock.
 func netlock(rdb redis.UniversalClient, key string, ttl time.Duration) bool {
 	value := "used"
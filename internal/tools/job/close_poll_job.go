@@ -0,0 +1,66 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	dcron "github.com/openimsdk/open-im-server/v3/internal/tools/cron"
+	"github.com/openimsdk/open-im-server/v3/internal/tools/msg"
+)
+
+// ClosePollJob closes a poll at its close time and removes itself once the poll is closed.
+type ClosePollJob struct {
+	CommonJob
+	PollID    string       `json:"PollID"`
+	CloseTime int64        `json:"CloseTime"`
+	MsgTool   *msg.MsgTool `json:"-"`
+	Cron      *dcron.Dcron `json:"-"`
+}
+
+func NewClosePollJob(pollID string, closeTime int64, msgTool *msg.MsgTool, cron *dcron.Dcron) *ClosePollJob {
+	t := time.UnixMilli(closeTime)
+	return &ClosePollJob{
+		PollID:    pollID,
+		CloseTime: closeTime,
+		MsgTool:   msgTool,
+		Cron:      cron,
+		CommonJob: CommonJob{
+			Name:     ClosePollJobNamePrefix + pollID,
+			CronExpr: fmt.Sprintf("%d %d %d %d %d *", t.Second(), t.Minute(), t.Hour(), t.Day(), int(t.Month())),
+			Type:     TClosePoll,
+		},
+	}
+}
+
+func (c *ClosePollJob) Run() {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	if beforeYearOf(c.CloseTime) {
+		log.ZInfo(ctx, "close poll job matched a year early", "jobName", c.Name, "closeTime", c.CloseTime)
+		return
+	}
+	log.ZInfo(ctx, "start close poll job", "jobName", c.Name)
+	if err := c.MsgTool.ClosePoll(c.PollID); err != nil {
+		log.ZWarn(ctx, "close poll job kept to run again", err, "jobName", c.Name, "pollID", c.PollID)
+		return
+	}
+	c.Cron.Remove(c.Name)
+	log.ZInfo(ctx, "close poll job finished", "jobName", c.Name)
+}
+
+// Overdue reports whether the close time passed while the job was not running.
+func (c *ClosePollJob) Overdue() bool {
+	return time.Now().UnixMilli() >= c.CloseTime
+}
+
+func (c *ClosePollJob) Serialize() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (c *ClosePollJob) UnSerialize(b []byte) error {
+	return json.Unmarshal(b, c)
+}
@@ -1,5 +1,7 @@
 package job
 
+import "time"
+
 type CommonJob struct {
 	CronExpr string `json:"CronExpr"`
 	Name     string `json:"Name"`
@@ -13,3 +15,9 @@ func (commonjob *CommonJob) GetName() string {
 func (commonjob *CommonJob) GetCron() string {
 	return commonjob.CronExpr
 }
+
+// beforeYearOf reports whether the year of the unix milli t has not come yet. The cron expr of a job run once
+// has no year, it matches the same date every year.
+func beforeYearOf(t int64) bool {
+	return time.Now().Year() < time.UnixMilli(t).Year()
+}
@@ -5,6 +5,9 @@ import "reflect"
 const (
 	ClearMsgJobNamePrefix          = "clearMsgJob_"
 	CloseVoiceChannelJobNamePrefix = "closeVoiceChannelJob_"
+	ScheduledMsgJobNamePrefix      = "scheduledMsgJob_"
+	ClosePollJobNamePrefix         = "closePollJob_"
+	ExportJobNamePrefix            = "exportConversationJob_"
 )
 
 const (
@@ -15,9 +18,15 @@ const (
 const (
 	TClearMsg          = 1
 	TCloseVoiceChannel = 2
+	TScheduledMsg      = 3
+	TClosePoll         = 4
+	TExport            = 5
 )
 
 var JobTypeMap = map[int]reflect.Type{
 	TClearMsg:          reflect.TypeOf(ClearMsgJob{}),
 	TCloseVoiceChannel: reflect.TypeOf(CloseVocieChannelJob{}),
+	TScheduledMsg:      reflect.TypeOf(ScheduledMsgJob{}),
+	TClosePoll:         reflect.TypeOf(ClosePollJob{}),
+	TExport:            reflect.TypeOf(ExportConversationJob{}),
 }
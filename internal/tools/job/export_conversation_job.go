@@ -0,0 +1,68 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	dcron "github.com/openimsdk/open-im-server/v3/internal/tools/cron"
+	"github.com/openimsdk/open-im-server/v3/internal/tools/msg"
+)
+
+// ExportConversationJob renders and uploads a conversation export once and removes itself when the export
+// is finished. It is run as soon as it is added, the job is only kept so an export interrupted by a restart
+// is recovered.
+type ExportConversationJob struct {
+	CommonJob
+	ExportID  string       `json:"ExportID"`
+	QueueTime int64        `json:"QueueTime"`
+	MsgTool   *msg.MsgTool `json:"-"`
+	Cron      *dcron.Dcron `json:"-"`
+}
+
+func NewExportConversationJob(exportID string, msgTool *msg.MsgTool, cron *dcron.Dcron) *ExportConversationJob {
+	t := time.Now()
+	return &ExportConversationJob{
+		ExportID:  exportID,
+		QueueTime: t.UnixMilli(),
+		MsgTool:   msgTool,
+		Cron:      cron,
+		CommonJob: CommonJob{
+			Name:     ExportJobNamePrefix + exportID,
+			CronExpr: fmt.Sprintf("%d %d %d %d %d *", t.Second(), t.Minute(), t.Hour(), t.Day(), int(t.Month())),
+			Type:     TExport,
+		},
+	}
+}
+
+func (c *ExportConversationJob) Run() {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	if beforeYearOf(c.QueueTime) {
+		log.ZInfo(ctx, "export conversation job matched a year early", "jobName", c.Name, "queueTime", c.QueueTime)
+		return
+	}
+	log.ZInfo(ctx, "start export conversation job", "jobName", c.Name)
+	if !c.MsgTool.ExportConversation(c.ExportID) {
+		log.ZWarn(ctx, "export conversation job kept to run again", nil, "jobName", c.Name)
+		return
+	}
+	c.Cron.Remove(c.Name)
+	log.ZInfo(ctx, "export conversation job finished", "jobName", c.Name)
+}
+
+// Overdue reports whether the queue time passed, a recovered export was due when it was queued.
+func (c *ExportConversationJob) Overdue() bool {
+	return time.Now().UnixMilli() >= c.QueueTime
+}
+
+func (c *ExportConversationJob) Serialize() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (c *ExportConversationJob) UnSerialize(b []byte) error {
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,63 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	dcron "github.com/openimsdk/open-im-server/v3/internal/tools/cron"
+	"github.com/openimsdk/open-im-server/v3/internal/tools/msg"
+)
+
+// ScheduledMsgJob sends a scheduled msg once at its schedule time and removes itself.
+type ScheduledMsgJob struct {
+	CommonJob
+	ScheduleID   string       `json:"ScheduleID"`
+	ScheduleTime int64        `json:"ScheduleTime"`
+	MsgTool      *msg.MsgTool `json:"-"`
+	Cron         *dcron.Dcron `json:"-"`
+}
+
+func NewScheduledMsgJob(scheduleID string, scheduleTime int64, msgTool *msg.MsgTool, cron *dcron.Dcron) *ScheduledMsgJob {
+	t := time.UnixMilli(scheduleTime)
+	return &ScheduledMsgJob{
+		ScheduleID:   scheduleID,
+		ScheduleTime: scheduleTime,
+		MsgTool:      msgTool,
+		Cron:         cron,
+		CommonJob: CommonJob{
+			Name:     ScheduledMsgJobNamePrefix + scheduleID,
+			CronExpr: fmt.Sprintf("%d %d %d %d %d *", t.Second(), t.Minute(), t.Hour(), t.Day(), int(t.Month())),
+			Type:     TScheduledMsg,
+		},
+	}
+}
+
+func (c *ScheduledMsgJob) Run() {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	if beforeYearOf(c.ScheduleTime) {
+		log.ZInfo(ctx, "scheduled msg job matched a year early", "jobName", c.Name, "scheduleTime", c.ScheduleTime)
+		return
+	}
+	log.ZInfo(ctx, "start scheduled msg job", "jobName", c.Name)
+	c.Cron.Remove(c.Name)
+	c.MsgTool.SendScheduledMsg(c.ScheduleID)
+	log.ZInfo(ctx, "scheduled msg job finished", "jobName", c.Name)
+}
+
+// Overdue reports whether the schedule time passed while the job was not running.
+func (c *ScheduledMsgJob) Overdue() bool {
+	return time.Now().UnixMilli() >= c.ScheduleTime
+}
+
+func (c *ScheduledMsgJob) Serialize() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (c *ScheduledMsgJob) UnSerialize(b []byte) error {
+	return json.Unmarshal(b, c)
+}
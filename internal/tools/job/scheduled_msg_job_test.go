@@ -0,0 +1,34 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledMsgJobYearEarly(t *testing.T) {
+	scheduleTime := time.Now().AddDate(1, 0, 0).UnixMilli()
+	// MsgTool and Cron are nil, a job of next year must return before using them.
+	job := NewScheduledMsgJob("s1", scheduleTime, nil, nil)
+	job.Run()
+	if job.Overdue() {
+		t.Error("a job of next year is overdue")
+	}
+	job = NewScheduledMsgJob("s2", time.Now().Add(-time.Minute).UnixMilli(), nil, nil)
+	if !job.Overdue() || beforeYearOf(job.ScheduleTime) {
+		t.Error("a missed job is not due")
+	}
+}
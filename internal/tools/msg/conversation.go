@@ -15,8 +15,7 @@
 package msg
 
 import (
-	"context"
-	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/OpenIMSDK/tools/log"
@@ -24,125 +23,70 @@ import (
 	"github.com/OpenIMSDK/tools/utils"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/prommetrics"
 )
 
-//func (c *MsgTool) ConversationsDestructMsgs() {
-//	log.ZInfo(context.Background(), "start msg destruct cron task")
-//	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
-//	conversations, err := c.conversationDatabase.GetConversationIDsNeedDestruct(ctx)
-//	if err != nil {
-//		log.ZError(ctx, "get conversation id need destruct failed", err)
-//		return
-//	}
-//	log.ZDebug(context.Background(), "nums conversations need destruct", "nums", len(conversations))
-//	for _, conversation := range conversations {
-//		ctx = mcontext.NewCtx(utils.GetSelfFuncName() + "-" + utils.OperationIDGenerator() + "-" + conversation.ConversationID + "-" + conversation.OwnerUserID)
-//		log.ZDebug(
-//			ctx,
-//			"UserMsgsDestruct",
-//			"conversationID",
-//			conversation.ConversationID,
-//			"ownerUserID",
-//			conversation.OwnerUserID,
-//			"msgDestructTime",
-//			conversation.MsgDestructTime,
-//			"lastMsgDestructTime",
-//			conversation.LatestMsgDestructTime,
-//		)
-//		now := time.Now()
-//		seqs, err := c.msgDatabase.UserMsgsDestruct(ctx, conversation.OwnerUserID, conversation.ConversationID, conversation.MsgDestructTime, conversation.LatestMsgDestructTime)
-//		if err != nil {
-//			log.ZError(ctx, "user msg destruct failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
-//			continue
-//		}
-//		if len(seqs) > 0 {
-// 			if err := c.conversationDatabase.UpdateUsersConversationFiled(ctx, []string{conversation.OwnerUserID}, conversation.ConversationID, map[string]interface{}{"latest_msg_destruct_time": now}); err
-// != nil {
-//				log.ZError(ctx, "updateUsersConversationFiled failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
-//				continue
-//			}
-//			if err := c.msgNotificationSender.UserDeleteMsgsNotification(ctx, conversation.OwnerUserID, conversation.ConversationID, seqs); err != nil {
-//				log.ZError(ctx, "userDeleteMsgsNotification failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
-//			}
-//		}
-//	}
-//}
+const msgDestructBatch = 100
 
-func (c *MsgTool) ConversationsDestructMsgs() {
-	log.ZInfo(context.Background(), "start msg destruct cron task")
+// ConversationsDestructMsgs destructs the expired msgs of the conversations of the shard due for destruct.
+// The msgs are destructed for the owner of each conversation only, the other members of a group keep theirs.
+func (c *MsgTool) ConversationsDestructMsgs(shard, shards int) {
 	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
-	num, err := c.conversationDatabase.GetAllConversationIDsNumber(ctx)
-	if err != nil {
-		log.ZError(ctx, "GetAllConversationIDsNumber failed", err)
-		return
-	}
-	const batchNum = 50
-	log.ZDebug(ctx, "GetAllConversationIDsNumber", "num", num)
-	if num == 0 {
-		return
-	}
-	count := int(num/batchNum + num/batchNum/2)
-	if count < 1 {
-		count = 1
-	}
-	maxPage := 1 + num/batchNum
-	if num%batchNum != 0 {
-		maxPage++
-	}
-	for i := 0; i < count; i++ {
-		pageNumber := rand.Int63() % maxPage
-		conversationIDs, err := c.conversationDatabase.PageConversationIDs(ctx, int32(pageNumber), batchNum)
-		if err != nil {
-			log.ZError(ctx, "PageConversationIDs failed", err, "pageNumber", pageNumber)
-			continue
-		}
-		log.ZError(ctx, "PageConversationIDs failed", err, "pageNumber", pageNumber, "conversationIDsNum", len(conversationIDs), "conversationIDs", conversationIDs)
-		if len(conversationIDs) == 0 {
-			continue
-		}
-		conversations, err := c.conversationDatabase.GetConversationsByConversationID(ctx, conversationIDs)
+	start := time.Now()
+	log.ZInfo(ctx, "start msg destruct cron task", "shard", shard, "shards", shards)
+	var succeeded, failed int
+	for {
+		conversations, err := c.conversationDatabase.FindMsgDestructDueConversations(ctx, start, shard, shards, msgDestructBatch)
 		if err != nil {
-			log.ZError(ctx, "GetConversationsByConversationID failed", err, "conversationIDs", conversationIDs)
-			continue
-		}
-		temp := make([]*relation.ConversationModel, 0, len(conversations))
-		for i, conversation := range conversations {
-			if conversation.IsMsgDestruct && conversation.MsgDestructTime != 0 && (time.Now().Unix() > (conversation.MsgDestructTime+conversation.LatestMsgDestructTime.Unix()+8*60*60)) ||
-				conversation.LatestMsgDestructTime.IsZero() {
-				temp = append(temp, conversations[i])
-			}
+			log.ZError(ctx, "find msg destruct due conversations failed", err, "shard", shard)
+			break
 		}
-		for _, conversation := range temp {
-			ctx = mcontext.NewCtx(utils.GetSelfFuncName() + "-" + utils.OperationIDGenerator() + "-" + conversation.ConversationID + "-" + conversation.OwnerUserID)
-			log.ZDebug(
-				ctx,
-				"UserMsgsDestruct",
-				"conversationID",
-				conversation.ConversationID,
-				"ownerUserID",
-				conversation.OwnerUserID,
-				"msgDestructTime",
-				conversation.MsgDestructTime,
-				"lastMsgDestructTime",
-				conversation.LatestMsgDestructTime,
-			)
-			now := time.Now()
-			seqs, err := c.MsgDatabase.UserMsgsDestruct(ctx, conversation.OwnerUserID, conversation.ConversationID, conversation.MsgDestructTime, conversation.LatestMsgDestructTime)
-			if err != nil {
-				log.ZError(ctx, "user msg destruct failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
+		var progressed bool
+		for _, conversation := range conversations {
+			if err := c.userMsgsDestruct(conversation); err != nil {
+				failed++
+				prommetrics.MsgDestructConversationCounter.WithLabelValues("failed").Inc()
 				continue
 			}
-			if len(seqs) > 0 {
-				if err := c.conversationDatabase.UpdateUsersConversationFiled(ctx, []string{conversation.OwnerUserID}, conversation.ConversationID, map[string]interface{}{"latest_msg_destruct_time": now}); err != nil {
-					log.ZError(ctx, "updateUsersConversationFiled failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
-					continue
-				}
-				if err := c.MsgNotificationSender.UserDeleteMsgsNotification(ctx, conversation.OwnerUserID, conversation.ConversationID, seqs); err != nil {
-					log.ZError(ctx, "userDeleteMsgsNotification failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
-				}
-			}
+			progressed = true
+			succeeded++
+			prommetrics.MsgDestructConversationCounter.WithLabelValues("success").Inc()
+		}
+		// the failed conversations stay due, stop once a batch holds nothing else.
+		if len(conversations) < msgDestructBatch || !progressed {
+			break
 		}
 	}
+	due, err := c.conversationDatabase.CountMsgDestructDueConversations(ctx, time.Now(), shard, shards)
+	if err != nil {
+		log.ZError(ctx, "count msg destruct due conversations failed", err, "shard", shard)
+	} else {
+		prommetrics.MsgDestructDueGauge.WithLabelValues(strconv.Itoa(shard)).Set(float64(due))
+	}
+	log.ZInfo(ctx, "msg destruct cron task finished", "shard", shard, "succeeded", succeeded, "failed", failed, "due", due, "cost", time.Since(start))
+}
+
+func (c *MsgTool) userMsgsDestruct(conversation *relation.ConversationModel) error {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName() + "-" + utils.OperationIDGenerator() + "-" + conversation.ConversationID + "-" + conversation.OwnerUserID)
+	now := time.Now()
+	seqs, err := c.MsgDatabase.UserMsgsDestruct(ctx, conversation.OwnerUserID, conversation.ConversationID, conversation.MsgDestructTime, conversation.LatestMsgDestructTime)
+	if err != nil {
+		log.ZError(ctx, "user msg destruct failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
+		return err
+	}
+	// advanced even without msgs destructed, the conversation is not due again before its destruct time passes.
+	if err := c.conversationDatabase.UpdateUsersConversationFiled(ctx, []string{conversation.OwnerUserID}, conversation.ConversationID, map[string]interface{}{"latest_msg_destruct_time": now}); err != nil {
+		log.ZError(ctx, "updateUsersConversationFiled failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
+		return err
+	}
+	if len(seqs) == 0 {
+		return nil
+	}
+	prommetrics.MsgDestructMsgCounter.Add(float64(len(seqs)))
+	if err := c.MsgNotificationSender.UserDeleteMsgsNotification(ctx, conversation.OwnerUserID, conversation.ConversationID, seqs); err != nil {
+		log.ZError(ctx, "userDeleteMsgsNotification failed", err, "conversationID", conversation.ConversationID, "ownerUserID", conversation.OwnerUserID)
+	}
+	return nil
 }
 
 func (c *MsgTool) ClearMsgsByConversationID(conversationID string, msgDestructTime int64) {
@@ -0,0 +1,316 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"time"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+// exportMsg is a msg as written to a json lines export, one per line.
+type exportMsg struct {
+	Seq         int64                    `json:"seq"`
+	ServerMsgID string                   `json:"serverMsgID"`
+	ClientMsgID string                   `json:"clientMsgID"`
+	SendID      string                   `json:"sendID"`
+	SenderName  string                   `json:"senderName"`
+	SessionType int32                    `json:"sessionType"`
+	ContentType int32                    `json:"contentType"`
+	Content     string                   `json:"content"`
+	SendTime    int64                    `json:"sendTime"`
+	Media       []msgprocessor.MediaLink `json:"media,omitempty"`
+
+	text string
+}
+
+type exportRenderer interface {
+	Begin(conversationID string) error
+	Msgs(msgs []*exportMsg) error
+	End(msgNum int64) error
+}
+
+// ExportConversation renders a queued conversation export, uploads it and notifies the requester with the
+// access url of the archive, or with the error when the export failed. It returns whether the export needs
+// no further run, false when it must be run again or is being rendered by another node.
+func (c *MsgTool) ExportConversation(exportID string) bool {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	export, err := c.exportDatabase.TakeConversationExport(ctx, exportID)
+	if err != nil {
+		if errs.Unwrap(err) == mongo.ErrNoDocuments {
+			log.ZWarn(ctx, "conversation export not found", err, "exportID", exportID)
+			return true
+		}
+		log.ZError(ctx, "take conversation export failed", err, "exportID", exportID)
+		return false
+	}
+	ok, err := c.exportDatabase.StartConversationExport(ctx, exportID)
+	if err != nil {
+		log.ZError(ctx, "start conversation export failed", err, "exportID", exportID)
+		return false
+	}
+	if !ok {
+		// finished already, or claimed by another node which runs it to the end.
+		return export.Status == unrelation.ConversationExportDone || export.Status == unrelation.ConversationExportFailed
+	}
+	tips := &sdkws.ConversationExportTips{
+		ExportID:       export.ExportID,
+		ConversationID: export.ConversationID,
+		Format:         export.Format,
+	}
+	objectKey, msgNum, exportErr := c.UploadConversationExport(ctx, export.UserID, export.ConversationID, export.Format, export.MinSeq, export.MaxSeq)
+	if exportErr == nil {
+		var expireTime time.Time
+		tips.URL, expireTime, exportErr = c.ExportAccessURL(ctx, export.ConversationID, export.Format, objectKey)
+		tips.ExpireTime = expireTime.UnixMilli()
+	}
+	if exportErr != nil {
+		log.ZWarn(ctx, "export conversation failed", exportErr, "exportID", exportID, "conversationID", export.ConversationID)
+		tips.URL, tips.ExpireTime, tips.ErrMsg = "", 0, exportErr.Error()
+	}
+	tips.MsgNum = msgNum
+	if err := c.exportDatabase.FinishConversationExport(ctx, exportID, objectKey, msgNum, exportErr); err != nil {
+		// left running, the export is rendered again once past the running timeout.
+		log.ZError(ctx, "finish conversation export failed", err, "exportID", exportID)
+		return false
+	}
+	if err := c.MsgNotificationSender.ConversationExportNotification(ctx, export.UserID, tips); err != nil {
+		log.ZError(ctx, "conversation export notification failed", err, "exportID", exportID, "userID", export.UserID)
+	}
+	return true
+}
+
+// GetExportSeqRange returns the seqs of a conversation to export, the msgs the user can still see or every
+// msg left in the conversation when userID is empty.
+func (c *MsgTool) GetExportSeqRange(ctx context.Context, userID, conversationID string) (int64, int64, error) {
+	var conversationMaxSeq int64
+	if userID != "" {
+		conversations, err := c.conversationDatabase.FindConversations(ctx, userID, []string{conversationID})
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(conversations) == 0 {
+			return 0, 0, errs.ErrNoPermission.Wrap("not in the conversation")
+		}
+		conversationMaxSeq = conversations[0].MaxSeq
+	}
+	return c.MsgDatabase.GetExportSeqRange(ctx, userID, conversationID, conversationMaxSeq)
+}
+
+// UploadConversationExport renders the export into a temp file and uploads it, it returns the object key.
+func (c *MsgTool) UploadConversationExport(ctx context.Context, userID, conversationID, format string, minSeq, maxSeq int64) (string, int64, error) {
+	file, err := os.CreateTemp("", "conversation_export_*")
+	if err != nil {
+		return "", 0, errs.Wrap(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	msgNum, err := c.ExportConversationMsgs(ctx, userID, conversationID, format, minSeq, maxSeq, file)
+	if err != nil {
+		return "", msgNum, err
+	}
+	if msgNum == 0 {
+		return "", 0, errs.ErrRecordNotFound.Wrap("no msgs to export")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return "", msgNum, errs.Wrap(err)
+	}
+	objectKey, err := c.exportDatabase.UploadConversationExport(ctx, format, file, info.Size())
+	return objectKey, msgNum, err
+}
+
+// ExportAccessURL signs an url to download an uploaded export, valid for the configured access expire.
+func (c *MsgTool) ExportAccessURL(ctx context.Context, conversationID, format, objectKey string) (string, time.Time, error) {
+	return c.exportDatabase.ConversationExportAccessURL(ctx, &unrelation.ConversationExportModel{
+		ConversationID: conversationID,
+		Format:         format,
+		ObjectKey:      objectKey,
+	})
+}
+
+// ExportConversationMsgs writes the msgs of the conversation between minSeq and maxSeq as seen by userID
+// to w, as json lines or as a self-contained html page. It returns the number of msgs written.
+func (c *MsgTool) ExportConversationMsgs(ctx context.Context, userID, conversationID, format string, minSeq, maxSeq int64, w io.Writer) (int64, error) {
+	renderer, err := newExportRenderer(format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := renderer.Begin(conversationID); err != nil {
+		return 0, err
+	}
+	senderNames := make(map[string]string)
+	var msgNum int64
+	err = c.MsgDatabase.WalkMsgDocs(ctx, userID, conversationID, minSeq, maxSeq, func(msgs []*sdkws.MsgData) error {
+		c.resolveSenderNames(ctx, senderNames, msgs)
+		exportMsgs := make([]*exportMsg, 0, len(msgs))
+		for _, msg := range msgs {
+			if msg.ContentType >= constant.NotificationBegin && msg.ContentType <= constant.NotificationEnd &&
+				msg.ContentType != constant.MsgRevokeNotification {
+				continue
+			}
+			senderName := senderNames[msg.SendID]
+			if senderName == "" {
+				senderName = msg.SenderNickname
+			}
+			if senderName == "" {
+				senderName = msg.SendID
+			}
+			exportMsgs = append(exportMsgs, &exportMsg{
+				Seq:         msg.Seq,
+				ServerMsgID: msg.ServerMsgID,
+				ClientMsgID: msg.ClientMsgID,
+				SendID:      msg.SendID,
+				SenderName:  senderName,
+				SessionType: msg.SessionType,
+				ContentType: msg.ContentType,
+				Content:     string(msg.Content),
+				SendTime:    msg.SendTime,
+				Media:       msgprocessor.GetMediaLinks(msg.ContentType, msg.Content),
+				text:        msgprocessor.GetSearchText(msg.ContentType, msg.Content),
+			})
+		}
+		msgNum += int64(len(exportMsgs))
+		return renderer.Msgs(exportMsgs)
+	})
+	if err != nil {
+		return msgNum, err
+	}
+	return msgNum, renderer.End(msgNum)
+}
+
+// resolveSenderNames adds the nicknames of the senders not resolved yet, a sender that can't be found keeps
+// the nickname the msg was sent with.
+func (c *MsgTool) resolveSenderNames(ctx context.Context, senderNames map[string]string, msgs []*sdkws.MsgData) {
+	var userIDs []string
+	for _, msg := range msgs {
+		if _, ok := senderNames[msg.SendID]; !ok {
+			senderNames[msg.SendID] = ""
+			userIDs = append(userIDs, msg.SendID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	users, err := c.userDatabase.Find(ctx, userIDs)
+	if err != nil {
+		log.ZWarn(ctx, "find senders failed", err, "userIDs", userIDs)
+		return
+	}
+	for _, user := range users {
+		senderNames[user.UserID] = user.Nickname
+	}
+}
+
+func newExportRenderer(format string, w io.Writer) (exportRenderer, error) {
+	switch format {
+	case unrelation.ConversationExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &jsonExportRenderer{enc: enc}, nil
+	case unrelation.ConversationExportHTML:
+		return &htmlExportRenderer{w: w}, nil
+	default:
+		return nil, errs.ErrArgs.Wrap("invalid format " + format)
+	}
+}
+
+type jsonExportRenderer struct {
+	enc *json.Encoder
+}
+
+func (r *jsonExportRenderer) Begin(string) error { return nil }
+
+func (r *jsonExportRenderer) Msgs(msgs []*exportMsg) error {
+	for _, msg := range msgs {
+		if err := r.enc.Encode(msg); err != nil {
+			return errs.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r *jsonExportRenderer) End(int64) error { return nil }
+
+var htmlExportTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"time": func(ms int64) string { return time.UnixMilli(ms).UTC().Format("2006-01-02 15:04:05") },
+	"now":  func() int64 { return time.Now().UnixMilli() },
+}).Parse(`{{define "begin"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body{font-family:-apple-system,Helvetica,Arial,sans-serif;max-width:860px;margin:24px auto;color:#222}
+.msg{padding:8px 0;border-bottom:1px solid #eee}
+.meta{color:#888;font-size:12px}
+.name{font-weight:600;color:#0089ff;margin-right:8px}
+.text{white-space:pre-wrap;word-break:break-word;margin-top:4px}
+.other{color:#888;font-style:italic;margin-top:4px}
+</style>
+</head>
+<body>
+<h2>{{.}}</h2>
+{{end}}{{define "msg"}}<div class="msg" id="seq-{{.Msg.Seq}}">
+<div class="meta"><span class="name">{{.Msg.SenderName}}</span>{{time .Msg.SendTime}} UTC · #{{.Msg.Seq}}</div>
+{{if .Revoked}}<div class="other">message revoked</div>
+{{else}}{{if .Text}}<div class="text">{{.Text}}</div>
+{{end}}{{range .Msg.Media}}<div><a href="{{.URL}}" target="_blank">{{if .Name}}{{.Name}}{{else}}{{.Type}}{{end}}</a></div>
+{{end}}{{if and (not .Text) (not .Msg.Media)}}<div class="other">unsupported message, content type {{.Msg.ContentType}}</div>
+{{end}}{{end}}</div>
+{{end}}{{define "end"}}<p class="meta">{{.}} messages, exported {{time now}} UTC</p>
+</body>
+</html>
+{{end}}`))
+
+type htmlExportRenderer struct {
+	w io.Writer
+}
+
+func (r *htmlExportRenderer) Begin(conversationID string) error {
+	return errs.Wrap(htmlExportTemplate.ExecuteTemplate(r.w, "begin", conversationID))
+}
+
+func (r *htmlExportRenderer) Msgs(msgs []*exportMsg) error {
+	for _, msg := range msgs {
+		data := struct {
+			Msg     *exportMsg
+			Text    string
+			Revoked bool
+		}{Msg: msg, Text: msg.text, Revoked: msg.ContentType == constant.MsgRevokeNotification}
+		if err := htmlExportTemplate.ExecuteTemplate(r.w, "msg", data); err != nil {
+			return errs.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r *htmlExportRenderer) End(msgNum int64) error {
+	return errs.Wrap(htmlExportTemplate.ExecuteTemplate(r.w, "end", msgNum))
+}
@@ -0,0 +1,117 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+func renderExport(t *testing.T, format string, msgs []*exportMsg) string {
+	var buf bytes.Buffer
+	renderer, err := newExportRenderer(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renderer.Begin("si_<a>_b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := renderer.Msgs(msgs); err != nil {
+		t.Fatal(err)
+	}
+	if err := renderer.End(int64(len(msgs))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestJSONExportRenderer(t *testing.T) {
+	msgs := []*exportMsg{
+		{Seq: 1, SendID: "a", SenderName: "<b>A</b>", ContentType: constant.Text, Content: `{"content":"<script>&</script>"}`, text: "<script>&</script>"},
+		{Seq: 2, SendID: "b", ContentType: constant.Picture, Media: []msgprocessor.MediaLink{{Type: "picture", URL: "http://a/b?c=1&d=2"}}},
+	}
+	out := renderExport(t, unrelation.ConversationExportJSON, msgs)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != len(msgs) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(msgs), out)
+	}
+	for i, line := range lines {
+		var msg exportMsg
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if msg.Seq != msgs[i].Seq || msg.SenderName != msgs[i].SenderName || msg.Content != msgs[i].Content {
+			t.Errorf("line %d decoded as %+v", i, msg)
+		}
+	}
+	// the text kept for html is not part of the json, and html characters are written as they are.
+	if strings.Contains(out, `"text"`) {
+		t.Errorf("text written to json: %s", lines[0])
+	}
+	if !strings.Contains(lines[0], `<script>&</script>`) || !strings.Contains(lines[1], `c=1&d=2`) {
+		t.Errorf("html escaped in json:\n%s", out)
+	}
+}
+
+func TestHTMLExportRenderer(t *testing.T) {
+	msgs := []*exportMsg{
+		{Seq: 1, SenderName: `<img src=x onerror="alert(1)">`, ContentType: constant.Text, text: "<script>alert(1)</script>\nline & more"},
+		{Seq: 2, SenderName: "b", ContentType: constant.File, Media: []msgprocessor.MediaLink{
+			{Type: "file", Name: "<i>f.txt</i>", URL: "http://a/f.txt?x=1&y=2"},
+			{Type: "file", URL: "javascript:alert(1)"},
+		}},
+		{Seq: 3, SenderName: "c", ContentType: constant.MsgRevokeNotification, text: "<b>revoked text</b>"},
+		{Seq: 4, SenderName: "d", ContentType: constant.Custom},
+	}
+	out := renderExport(t, unrelation.ConversationExportHTML, msgs)
+	for _, unsafe := range []string{"<script>", "<img", "<i>", "<b>revoked", "javascript:", "si_<a>_b"} {
+		if strings.Contains(out, unsafe) {
+			t.Errorf("unescaped %q in html:\n%s", unsafe, out)
+		}
+	}
+	for _, want := range []string{
+		"<title>si_&lt;a&gt;_b</title>",
+		"&lt;script&gt;alert(1)&lt;/script&gt;\nline &amp; more",
+		"&lt;img src=x onerror=&#34;alert(1)&#34;&gt;",
+		`href="http://a/f.txt?x=1&amp;y=2"`,
+		"&lt;i&gt;f.txt&lt;/i&gt;",
+		`href="#ZgotmplZ"`,
+		`<div class="other">message revoked</div>`,
+		"unsupported message, content type 110",
+		"4 messages, exported",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in html:\n%s", want, out)
+		}
+	}
+}
+
+func TestNewExportRendererInvalidFormat(t *testing.T) {
+	if _, err := newExportRenderer("csv", &bytes.Buffer{}); err == nil {
+		t.Error("csv format accepted")
+	}
+}
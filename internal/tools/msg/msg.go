@@ -39,6 +39,7 @@ import (
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/controller"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/relation"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient"
 	"github.com/openimsdk/open-im-server/v3/pkg/rpcclient/notification"
 )
@@ -48,17 +49,27 @@ type MsgTool struct {
 	conversationDatabase  controller.ConversationDatabase
 	userDatabase          controller.UserDatabase
 	groupDatabase         controller.GroupDatabase
+	threadDatabase        controller.ThreadDatabase
+	scheduledMsgDatabase  controller.ScheduledMsgDatabase
+	exportDatabase        controller.ConversationExportDatabase
+	msgRpcClient          *rpcclient.MessageRpcClient
 	MsgNotificationSender *notification.MsgNotificationSender
 }
 
 func NewMsgTool(msgDatabase controller.CommonMsgDatabase, userDatabase controller.UserDatabase,
-	groupDatabase controller.GroupDatabase, conversationDatabase controller.ConversationDatabase, msgNotificationSender *notification.MsgNotificationSender,
+	groupDatabase controller.GroupDatabase, conversationDatabase controller.ConversationDatabase, threadDatabase controller.ThreadDatabase,
+	scheduledMsgDatabase controller.ScheduledMsgDatabase, exportDatabase controller.ConversationExportDatabase,
+	msgRpcClient *rpcclient.MessageRpcClient, msgNotificationSender *notification.MsgNotificationSender,
 ) *MsgTool {
 	return &MsgTool{
 		MsgDatabase:           msgDatabase,
 		userDatabase:          userDatabase,
 		groupDatabase:         groupDatabase,
 		conversationDatabase:  conversationDatabase,
+		threadDatabase:        threadDatabase,
+		scheduledMsgDatabase:  scheduledMsgDatabase,
+		exportDatabase:        exportDatabase,
+		msgRpcClient:          msgRpcClient,
 		MsgNotificationSender: msgNotificationSender,
 	}
 }
@@ -100,9 +111,27 @@ func InitMsgTool() (*MsgTool, error) {
 		cache.NewConversationRedis(rdb, cache.GetDefaultOpt(), relation.NewConversationGorm(db)),
 		tx.NewGorm(db),
 	)
+	threadModel, err := unrelation.NewThreadMongo(mongo.GetDatabase())
+	if err != nil {
+		return nil, err
+	}
+	scheduledMsgModel, err := unrelation.NewScheduledMsgMongo(mongo.GetDatabase())
+	if err != nil {
+		return nil, err
+	}
+	exportModel, err := unrelation.NewConversationExportMongo(mongo.GetDatabase())
+	if err != nil {
+		return nil, err
+	}
+	o, err := controller.NewS3(rdb)
+	if err != nil {
+		return nil, err
+	}
 	msgRpcClient := rpcclient.NewMessageRpcClient(discov)
 	msgNotificationSender := notification.NewMsgNotificationSender(rpcclient.WithRpcClient(&msgRpcClient))
-	msgTool := NewMsgTool(msgDatabase, userDatabase, groupDatabase, conversationDatabase, msgNotificationSender)
+	msgTool := NewMsgTool(msgDatabase, userDatabase, groupDatabase, conversationDatabase, controller.NewThreadDatabase(threadModel),
+		controller.NewScheduledMsgDatabase(scheduledMsgModel), controller.NewConversationExportDatabase(exportModel, rdb, o),
+		&msgRpcClient, msgNotificationSender)
 	return msgTool, nil
 }
 
@@ -201,7 +230,9 @@ func (c *MsgTool) FixAllSeq(ctx context.Context) error {
 		return err
 	}
 	for _, conversationID := range conversationIDs {
-		conversationIDs = append(conversationIDs, utils.GetNotificationConversationIDByConversationID(conversationID))
+		if notificationConversationID := msgprocessor.GetNotificationConversationIDByConversationID(conversationID); notificationConversationID != "" {
+			conversationIDs = append(conversationIDs, notificationConversationID)
+		}
 	}
 	for _, conversationID := range conversationIDs {
 		if err := c.checkMaxSeq(ctx, conversationID); err != nil {
@@ -30,7 +30,9 @@ func (c *MsgTool) ConvertTools() {
 		return
 	}
 	for _, conversationID := range conversationIDs {
-		conversationIDs = append(conversationIDs, msgprocessor.GetNotificationConversationIDByConversationID(conversationID))
+		if notificationConversationID := msgprocessor.GetNotificationConversationIDByConversationID(conversationID); notificationConversationID != "" {
+			conversationIDs = append(conversationIDs, notificationConversationID)
+		}
 	}
 	userIDs, err := c.userDatabase.GetAllUserID(ctx, 0, 0)
 	if err != nil {
@@ -0,0 +1,44 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	pbmsg "github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/mw/specialerror"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+)
+
+// ClosePoll closes a poll at its close time. It goes through the msg rpc as an app manager, so the poll
+// is closed and the conversation notified the same way as a poll closed by hand. A poll deleted meanwhile
+// has nothing left to close.
+func (c *MsgTool) ClosePoll(pollID string) error {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	if len(config.Config.Manager.UserID) == 0 {
+		return errs.ErrInternalServer.Wrap("no app manager configured")
+	}
+	opUserID := config.Config.Manager.UserID[0]
+	ctx = mcontext.SetOpUserID(ctx, opUserID)
+	if _, err := c.msgRpcClient.Client.ClosePoll(ctx, &pbmsg.ClosePollReq{UserID: opUserID, PollID: pollID}); err != nil {
+		if errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"time"
+
+	pbmsg "github.com/OpenIMSDK/protocol/msg"
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/convert"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// SendScheduledMsg sends a pending scheduled msg as its sender. The msg goes through the msg rpc, so the
+// permissions and mutes of the sender are checked at the time of sending, a rejected msg is marked failed.
+func (c *MsgTool) SendScheduledMsg(scheduleID string) {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	scheduledMsg, err := c.scheduledMsgDatabase.TakeScheduledMsg(ctx, scheduleID)
+	if err != nil {
+		log.ZError(ctx, "take scheduled msg failed", err, "scheduleID", scheduleID)
+		return
+	}
+	if scheduledMsg.Status != unrelation.ScheduledMsgPending {
+		log.ZInfo(ctx, "scheduled msg is not pending", "scheduleID", scheduleID, "status", scheduledMsg.Status)
+		return
+	}
+	if scheduledMsg.ScheduleTime.After(time.Now().Add(time.Second)) {
+		log.ZInfo(ctx, "scheduled msg was rescheduled", "scheduleID", scheduleID, "scheduleTime", scheduledMsg.ScheduleTime)
+		return
+	}
+	ok, err := c.scheduledMsgDatabase.StartSendingScheduledMsg(ctx, scheduleID)
+	if err != nil {
+		log.ZError(ctx, "start sending scheduled msg failed", err, "scheduleID", scheduleID)
+		return
+	}
+	if !ok {
+		return // canceled or claimed by another node
+	}
+	ctx = mcontext.SetOpUserID(ctx, scheduledMsg.SendID)
+	msgData := convert.MsgDB2Pb(scheduledMsg.Msg)
+	msgData.SendTime = 0
+	var serverMsgID string
+	resp, sendErr := c.msgRpcClient.SendMsg(ctx, &pbmsg.SendMsgReq{MsgData: msgData})
+	if sendErr != nil {
+		log.ZWarn(ctx, "send scheduled msg failed", sendErr, "scheduleID", scheduleID, "sendID", scheduledMsg.SendID)
+	} else {
+		serverMsgID = resp.ServerMsgID
+	}
+	if err := c.scheduledMsgDatabase.FinishScheduledMsg(ctx, scheduleID, serverMsgID, sendErr); err != nil {
+		log.ZError(ctx, "finish scheduled msg failed", err, "scheduleID", scheduleID)
+	}
+}
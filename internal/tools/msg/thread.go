@@ -0,0 +1,57 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"time"
+
+	"github.com/OpenIMSDK/tools/log"
+	"github.com/OpenIMSDK/tools/mcontext"
+	"github.com/OpenIMSDK/tools/utils"
+)
+
+const archiveThreadsBatch = 100
+
+// ArchiveInactiveThreads archives the threads without replies for their auto archive duration.
+func (c *MsgTool) ArchiveInactiveThreads() {
+	ctx := mcontext.NewCtx(utils.GetSelfFuncName())
+	now := time.Now()
+	var count int
+	for {
+		threads, err := c.threadDatabase.FindInactiveThreads(ctx, now, archiveThreadsBatch)
+		if err != nil {
+			log.ZError(ctx, "find inactive threads failed", err)
+			return
+		}
+		for _, thread := range threads {
+			archived, err := c.threadDatabase.ArchiveInactiveThread(ctx, thread.ThreadID, now)
+			if err != nil {
+				log.ZError(ctx, "archive thread failed", err, "threadID", thread.ThreadID)
+				return
+			}
+			if !archived {
+				continue // replied in the meantime
+			}
+			count++
+			if err := c.MsgNotificationSender.ThreadArchivedNotification(ctx, thread.CreatorUserID, thread.ThreadID, thread.GroupID, true, now.UnixMilli()); err != nil {
+				log.ZWarn(ctx, "thread archived notification failed", err, "threadID", thread.ThreadID)
+			}
+		}
+		if len(threads) < archiveThreadsBatch {
+			break
+		}
+	}
+	log.ZInfo(ctx, "archive inactive threads finished", "count", count)
+}
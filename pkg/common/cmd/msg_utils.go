@@ -15,9 +15,16 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/mcontext"
 	"github.com/spf13/cobra"
 
 	tools "github.com/openimsdk/open-im-server/v3/internal/tools/msg"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 )
 
 type MsgUtilsCmd struct {
@@ -79,6 +86,42 @@ func (m *MsgUtilsCmd) getLimitFlag(cmdLines *cobra.Command) int64 {
 	return limit
 }
 
+func (m *MsgUtilsCmd) AddConfFlag() {
+	m.Command.PersistentFlags().StringP(constant.FlagConf, "c", "", "path to config file folder")
+}
+
+func (m *MsgUtilsCmd) getConfFlag(cmdLines *cobra.Command) string {
+	configFolderPath, _ := cmdLines.Flags().GetString(constant.FlagConf)
+	return configFolderPath
+}
+
+func (m *MsgUtilsCmd) AddConversationIDFlag() {
+	m.Command.PersistentFlags().StringP("conversationID", "i", "", "openIM conversationID")
+}
+
+func (m *MsgUtilsCmd) getConversationIDFlag(cmdLines *cobra.Command) string {
+	conversationID, _ := cmdLines.Flags().GetString("conversationID")
+	return conversationID
+}
+
+func (m *MsgUtilsCmd) AddFormatFlag() {
+	m.Command.PersistentFlags().StringP("format", "t", "json", "export format, json or html")
+}
+
+func (m *MsgUtilsCmd) getFormatFlag(cmdLines *cobra.Command) string {
+	format, _ := cmdLines.Flags().GetString("format")
+	return format
+}
+
+func (m *MsgUtilsCmd) AddOutputFlag() {
+	m.Command.PersistentFlags().StringP("output", "o", "", "export file, uploaded to the object storage when empty")
+}
+
+func (m *MsgUtilsCmd) getOutputFlag(cmdLines *cobra.Command) string {
+	output, _ := cmdLines.Flags().GetString("output")
+	return output
+}
+
 func (m *MsgUtilsCmd) Execute() error {
 	return m.Command.Execute()
 }
@@ -123,6 +166,16 @@ func NewClearCmd() *ClearCmd {
 	}
 }
 
+type ExportCmd struct {
+	*MsgUtilsCmd
+}
+
+func NewExportCmd() *ExportCmd {
+	return &ExportCmd{
+		NewMsgUtilsCmd("export [resource]", "export action", cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)),
+	}
+}
+
 type SeqCmd struct {
 	*MsgUtilsCmd
 }
@@ -183,3 +236,63 @@ func (m *MsgCmd) GetMsgCmd() *cobra.Command {
 func (m *MsgCmd) ClearMsgCmd() *cobra.Command {
 	return &m.Command
 }
+
+type ConversationCmd struct {
+	*MsgUtilsCmd
+}
+
+func NewConversationCmd() *ConversationCmd {
+	return &ConversationCmd{
+		NewMsgUtilsCmd("conversation", "conversation", nil),
+	}
+}
+
+// ExportConversationCmd exports the msgs of a conversation, as seen by the user when userID is set, to a
+// local file or to the object storage, printing the access url of the upload.
+func (c *ConversationCmd) ExportConversationCmd() *cobra.Command {
+	c.Command.RunE = func(cmdLines *cobra.Command, args []string) error {
+		conversationID := c.getConversationIDFlag(cmdLines)
+		if conversationID == "" {
+			return errs.ErrArgs.Wrap("conversationID is empty")
+		}
+		if err := config.InitConfig(c.getConfFlag(cmdLines)); err != nil {
+			return err
+		}
+		msgTool, err := tools.InitMsgTool()
+		if err != nil {
+			return err
+		}
+		ctx := mcontext.NewCtx("exportConversation")
+		userID := c.getUserIDFlag(cmdLines)
+		format := c.getFormatFlag(cmdLines)
+		minSeq, maxSeq, err := msgTool.GetExportSeqRange(ctx, userID, conversationID)
+		if err != nil {
+			return err
+		}
+		output := c.getOutputFlag(cmdLines)
+		if output == "" {
+			objectKey, msgNum, err := msgTool.UploadConversationExport(ctx, userID, conversationID, format, minSeq, maxSeq)
+			if err != nil {
+				return err
+			}
+			rawURL, expireTime, err := msgTool.ExportAccessURL(ctx, conversationID, format, objectKey)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("exported %d msgs to %s\n%s\nexpires at %s\n", msgNum, objectKey, rawURL, expireTime)
+			return nil
+		}
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		msgNum, err := msgTool.ExportConversationMsgs(ctx, userID, conversationID, format, minSeq, maxSeq, file)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("exported %d msgs to %s\n", msgNum, output)
+		return nil
+	}
+	return &c.Command
+}
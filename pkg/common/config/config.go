@@ -27,6 +27,9 @@ var Config configStruct
 
 const ConfKey = "conf"
 
+// PushTemplateConfKey is the config registry key of the offline push templates.
+const PushTemplateConfKey = "pushTemplate"
+
 type CallBackConfig struct {
 	Enable                 bool  `yaml:"enable"`
 	CallbackTimeOut        int   `yaml:"timeout"`
@@ -40,6 +43,17 @@ type NotificationConf struct {
 	OfflinePush      POfflinePush `yaml:"offlinePush"`
 }
 
+type TokenBucketConf struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type RateLimitRule struct {
+	ReqIdentifier int32           `yaml:"reqIdentifier"`
+	Conn          TokenBucketConf `yaml:"conn"`
+	User          TokenBucketConf `yaml:"user"`
+}
+
 type POfflinePush struct {
 	Enable bool   `yaml:"enable"`
 	Title  string `yaml:"title"`
@@ -206,6 +220,29 @@ type configStruct struct {
 		WebsocketMaxMsgLen       int   `yaml:"websocketMaxMsgLen"`
 		WebsocketTimeout         int   `yaml:"websocketTimeout"`
 		WebsocketWriteBufferSize int   `yaml:"websocketWriteBufferSize"`
+		RateLimit                struct {
+			Enable          bool            `yaml:"enable"`
+			MaxViolations   int             `yaml:"maxViolations"`
+			ViolationWindow int             `yaml:"violationWindow"`
+			HandshakePerIP  TokenBucketConf `yaml:"handshakePerIP"`
+			Rules           []RateLimitRule `yaml:"rules"`
+		} `yaml:"rateLimit"`
+		Drain struct {
+			BatchSize     int `yaml:"batchSize"`
+			BatchInterval int `yaml:"batchInterval"`
+			Timeout       int `yaml:"timeout"`
+		} `yaml:"drain"`
+		Compression struct {
+			Zstd         bool   `yaml:"zstd"`
+			Deflate      bool   `yaml:"deflate"`
+			DeflateLevel int    `yaml:"deflateLevel"`
+			Dictionary   string `yaml:"dictionary"`
+			DictionaryID uint32 `yaml:"dictionaryID"`
+		} `yaml:"compression"`
+		Presence struct {
+			Enable          bool `yaml:"enable"`
+			RefreshInterval int  `yaml:"refreshInterval"`
+		} `yaml:"presence"`
 	} `yaml:"longConnSvr"`
 
 	Push struct {
@@ -232,6 +269,63 @@ type configStruct struct {
 			PushUrl  string `yaml:"pushUrl"`
 			BundleID string `yaml:"bundleID"`
 		} `yaml:"gorush"`
+		Apns struct {
+			KeyFile  string `yaml:"keyFile"`
+			KeyID    string `yaml:"keyID"`
+			TeamID   string `yaml:"teamID"`
+			BundleID string `yaml:"bundleID"`
+		} `yaml:"apns"`
+		WebPush struct {
+			Subject    string `yaml:"subject"`
+			PublicKey  string `yaml:"publicKey"`
+			PrivateKey string `yaml:"privateKey"`
+			TTL        int    `yaml:"ttl"`
+		} `yaml:"webPush"`
+		Vendor struct {
+			Huawei struct {
+				AppID     string `yaml:"appID"`
+				AppSecret string `yaml:"appSecret"`
+			} `yaml:"huawei"`
+			Honor struct {
+				AppID        string `yaml:"appID"`
+				ClientID     string `yaml:"clientID"`
+				ClientSecret string `yaml:"clientSecret"`
+			} `yaml:"honor"`
+			Xiaomi struct {
+				AppSecret   string `yaml:"appSecret"`
+				PackageName string `yaml:"packageName"`
+				ChannelID   string `yaml:"channelID"`
+			} `yaml:"xiaomi"`
+			OPPO struct {
+				AppKey       string `yaml:"appKey"`
+				MasterSecret string `yaml:"masterSecret"`
+				ChannelID    string `yaml:"channelID"`
+			} `yaml:"oppo"`
+			Vivo struct {
+				AppID     string `yaml:"appID"`
+				AppKey    string `yaml:"appKey"`
+				AppSecret string `yaml:"appSecret"`
+			} `yaml:"vivo"`
+		} `yaml:"vendor"`
+		Retry struct {
+			Enable           bool `yaml:"enable"`
+			MaxAttempts      int  `yaml:"maxAttempts"`
+			InitialBackoff   int  `yaml:"initialBackoff"`
+			MaxBackoff       int  `yaml:"maxBackoff"`
+			DeadLetterExpire int  `yaml:"deadLetterExpire"`
+		} `yaml:"retry"`
+		QuietHours struct {
+			Enable bool `yaml:"enable"`
+			Digest bool `yaml:"digest"`
+		} `yaml:"quietHours"`
+		Badge struct {
+			Enable bool `yaml:"enable"`
+			Expire int  `yaml:"expire"`
+		} `yaml:"badge"`
+		Analytics struct {
+			Enable bool `yaml:"enable"`
+			Expire int  `yaml:"expire"`
+		} `yaml:"analytics"`
 	}
 
 	Manager struct {
@@ -239,23 +333,43 @@ type configStruct struct {
 		Nickname []string `yaml:"nickname"`
 	} `yaml:"manager"`
 
-	MultiLoginPolicy                  int    `yaml:"multiLoginPolicy"`
-	ChatPersistenceMysql              bool   `yaml:"chatPersistenceMysql"`
-	MsgCacheTimeout                   int    `yaml:"msgCacheTimeout"`
-	GroupMessageHasReadReceiptEnable  bool   `yaml:"groupMessageHasReadReceiptEnable"`
-	SingleMessageHasReadReceiptEnable bool   `yaml:"singleMessageHasReadReceiptEnable"`
-	RetainChatRecords                 int    `yaml:"retainChatRecords"`
-	ChatRecordsClearTime              string `yaml:"chatRecordsClearTime"`
-	MsgDestructTime                   string `yaml:"msgDestructTime"`
-	Secret                            string `yaml:"secret"`
-	EnableCronLocker                  bool   `yaml:"enableCronLocker"`
-	TokenPolicy                       struct {
+	MultiLoginPolicy                    int    `yaml:"multiLoginPolicy"`
+	ChatPersistenceMysql                bool   `yaml:"chatPersistenceMysql"`
+	MsgCacheTimeout                     int    `yaml:"msgCacheTimeout"`
+	GroupMessageHasReadReceiptEnable    bool   `yaml:"groupMessageHasReadReceiptEnable"`
+	GroupMessageReadReceiptMaxMemberNum int    `yaml:"groupMessageReadReceiptMaxMemberNum"`
+	SingleMessageHasReadReceiptEnable   bool   `yaml:"singleMessageHasReadReceiptEnable"`
+	RetainChatRecords                   int    `yaml:"retainChatRecords"`
+	ChatRecordsClearTime                string `yaml:"chatRecordsClearTime"`
+	MsgDestructTime                     string `yaml:"msgDestructTime"`
+	MsgDestructShards                   int    `yaml:"msgDestructShards"`
+	MsgEditWindow                       int    `yaml:"msgEditWindow"`
+	Secret                              string `yaml:"secret"`
+	EnableCronLocker                    bool   `yaml:"enableCronLocker"`
+	TokenPolicy                         struct {
 		Expire int64 `yaml:"expire"`
 	} `yaml:"tokenPolicy"`
 	SingleFriend    bool `yaml:"singleFriend"`
 	VoiceCallPolicy struct {
 		Expire int64 `yaml:"expire"`
 	} `yaml:"voiceCallPolicy"`
+	Thread struct {
+		AutoArchiveDuration int32  `yaml:"autoArchiveDuration"`
+		ArchiveTime         string `yaml:"archiveTime"`
+	} `yaml:"thread"`
+	ScheduledMsg struct {
+		MaxDelay          int   `yaml:"maxDelay"`
+		MaxPendingPerUser int64 `yaml:"maxPendingPerUser"`
+	} `yaml:"scheduledMsg"`
+	Poll struct {
+		MaxOptions  int `yaml:"maxOptions"`
+		MaxDuration int `yaml:"maxDuration"`
+	} `yaml:"poll"`
+	ConversationExport struct {
+		MaxUnfinishedPerUser int64 `yaml:"maxUnfinishedPerUser"`
+		AccessExpire         int   `yaml:"accessExpire"`
+		RunningTimeout       int   `yaml:"runningTimeout"`
+	} `yaml:"conversationExport"`
 	MessageVerify struct {
 		FriendVerify *bool `yaml:"friendVerify"`
 	} `yaml:"messageVerify"`
@@ -29,9 +29,14 @@ import (
 //go:embed version
 var Version string
 
+// PushTemplate is the content of the optional offline push template file, used until the config
+// registry holds a template set.
+var PushTemplate []byte
+
 const (
 	FileName             = "config.yaml"
 	NotificationFileName = "notification.yaml"
+	PushTemplateFileName = "push_template.yaml"
 	DefaultFolderPath    = "../config/"
 )
 
@@ -108,5 +113,13 @@ func InitConfig(configFolderPath string) error {
 		return err
 	}
 
-	return initConfig(&Config.Notification, NotificationFileName, configFolderPath)
+	if err := initConfig(&Config.Notification, NotificationFileName, configFolderPath); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(configFolderPath, PushTemplateFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read file error: %w", err)
+	}
+	PushTemplate = data
+	return nil
 }
@@ -56,6 +56,7 @@ func MsgPb2DB(msg *sdkws.MsgData) *unrelation.MsgDataModel {
 	msgDataModel.AttachedInfo = msg.AttachedInfo
 	msgDataModel.Ex = msg.Ex
 	msgDataModel.RecvIDList = msg.RecvIDList
+	msgDataModel.ThreadID = msg.ThreadID
 	return &msgDataModel
 }
 
@@ -97,5 +98,6 @@ func MsgDB2Pb(msgModel *unrelation.MsgDataModel) *sdkws.MsgData {
 	msg.AttachedInfo = msgModel.AttachedInfo
 	msg.Ex = msgModel.Ex
 	msg.RecvIDList = msgModel.RecvIDList
+	msg.ThreadID = msgModel.ThreadID
 	return &msg
 }
@@ -0,0 +1,34 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	pbmsg "github.com/OpenIMSDK/protocol/msg"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func ScheduledMsgDB2Pb(m *unrelation.ScheduledMsgModel) *pbmsg.ScheduledMsg {
+	return &pbmsg.ScheduledMsg{
+		ScheduleID:     m.ScheduleID,
+		ConversationID: m.ConversationID,
+		MsgData:        MsgDB2Pb(m.Msg),
+		ScheduleTime:   m.ScheduleTime.UnixMilli(),
+		Status:         m.Status,
+		ErrMsg:         m.ErrMsg,
+		ServerMsgID:    m.ServerMsgID,
+		CreateTime:     m.CreateTime.UnixMilli(),
+	}
+}
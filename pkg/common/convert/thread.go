@@ -0,0 +1,55 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	sdkws "github.com/OpenIMSDK/protocol/sdkws"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+func ThreadDB2Pb(m *unrelation.ThreadModel) *sdkws.ThreadInfo {
+	var archiveTime int64
+	if m.Archived {
+		archiveTime = m.ArchiveTime.UnixMilli()
+	}
+	return &sdkws.ThreadInfo{
+		ThreadID:             m.ThreadID,
+		ConversationID:       msgprocessor.GetThreadConversationID(m.ThreadID),
+		GroupID:              m.GroupID,
+		ServerID:             m.ServerID,
+		ParentConversationID: m.ParentConversationID,
+		ParentSeq:            m.ParentSeq,
+		ParentClientMsgID:    m.ParentClientMsgID,
+		Name:                 m.Name,
+		CreatorUserID:        m.CreatorUserID,
+		AutoArchiveDuration:  m.AutoArchiveDuration,
+		Archived:             m.Archived,
+		ArchiveTime:          archiveTime,
+		LastActiveTime:       m.LastActiveTime.UnixMilli(),
+		CreateTime:           m.CreateTime.UnixMilli(),
+	}
+}
+
+func ThreadSummaryDB2Pb(seq int64, m *unrelation.ThreadSummaryModel) *sdkws.ThreadSummary {
+	return &sdkws.ThreadSummary{
+		Seq:           seq,
+		ThreadID:      m.ThreadID,
+		ReplyCount:    m.ReplyCount,
+		LastReplyTime: m.LastReplyTime,
+		Participants:  m.Participants,
+	}
+}
@@ -16,6 +16,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
@@ -44,12 +45,16 @@ const (
 	conversationUserMinSeq = "CON_USER_MIN_SEQ:"
 	hasReadSeq             = "HAS_READ_SEQ:"
 
-	appleDeviceToken = "DEVICE_TOKEN"
-	getuiToken       = "GETUI_TOKEN"
-	getuiTaskID      = "GETUI_TASK_ID"
-	signalCache      = "SIGNAL_CACHE:"
-	signalListCache  = "SIGNAL_LIST_CACHE:"
-	FCM_TOKEN        = "FCM_TOKEN:"
+	appleDeviceToken    = "DEVICE_TOKEN"
+	getuiToken          = "GETUI_TOKEN"
+	getuiTaskID         = "GETUI_TASK_ID"
+	signalCache         = "SIGNAL_CACHE:"
+	signalListCache     = "SIGNAL_LIST_CACHE:"
+	FCM_TOKEN           = "FCM_TOKEN:"
+	voipToken           = "VOIP_TOKEN:"
+	apnsToken           = "APNS_TOKEN:"
+	webPushSubscription = "WEB_PUSH_SUBSCRIPTION:"
+	vendorPushToken     = "VENDOR_PUSH_TOKEN:"
 
 	messageCache            = "MESSAGE_CACHE:"
 	messageDelUserList      = "MESSAGE_DEL_USER_LIST:"
@@ -79,6 +84,7 @@ type SeqCache interface {
 	// seqs map: key userID value minSeq
 	SetConversationUserMinSeqs(ctx context.Context, conversationID string, seqs map[string]int64) (err error)
 	// seqs map: key conversationID value minSeq
+	GetUserConversationsMinSeqs(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error)
 	SetUserConversationsMinSeqs(ctx context.Context, userID string, seqs map[string]int64) error
 	// has read seq
 	SetHasReadSeq(ctx context.Context, userID string, conversationID string, hasReadSeq int64) error
@@ -88,15 +94,39 @@ type SeqCache interface {
 	UserSetHasReadSeqs(ctx context.Context, userID string, hasReadSeqs map[string]int64) error
 	GetHasReadSeqs(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error)
 	GetHasReadSeq(ctx context.Context, userID string, conversationID string) (int64, error)
+	// k: user, v: seq
+	GetConversationUsersHasReadSeqs(ctx context.Context, conversationID string, userIDs []string) (map[string]int64, error)
 }
 
 type thirdCache interface {
 	SetFcmToken(ctx context.Context, account string, platformID int, fcmToken string, expireTime int64) (err error)
 	GetFcmToken(ctx context.Context, account string, platformID int) (string, error)
 	DelFcmToken(ctx context.Context, account string, platformID int) error
+	// APNs tokens are kept apart from FCM tokens, iOS devices may register both when both pushers are enabled.
+	SetAPNsToken(ctx context.Context, account string, token string, expireTime int64) error
+	GetAPNsToken(ctx context.Context, account string) (string, error)
+	DelAPNsToken(ctx context.Context, account string) error
+	// VoIP tokens are registered by iOS devices next to their APNs token, for call invitations.
+	SetVoIPToken(ctx context.Context, account string, voipToken string, expireTime int64) error
+	GetVoIPToken(ctx context.Context, account string) (string, error)
+	DelVoIPToken(ctx context.Context, account string) error
+	// SetWebPushSubscription stores a browser push subscription of the user, replacing the one of the same endpoint.
+	// Each subscription expires on its own, a user keeps at most maxWebPushSubscriptions of them.
+	SetWebPushSubscription(ctx context.Context, account string, subscription *WebPushSubscription, expireTime int64) error
+	GetWebPushSubscriptions(ctx context.Context, account string) ([]*WebPushSubscription, error)
+	DelWebPushSubscription(ctx context.Context, account string, endpoints ...string) error
+	// SetVendorPushToken stores the token of the phone vendor push service the device registered with.
+	SetVendorPushToken(ctx context.Context, account string, platformID int, token *VendorPushToken, expireTime int64) error
+	GetVendorPushToken(ctx context.Context, account string, platformID int) (*VendorPushToken, error)
+	DelVendorPushToken(ctx context.Context, account string, platformID int) error
 	IncrUserBadgeUnreadCountSum(ctx context.Context, userID string) (int, error)
 	SetUserBadgeUnreadCountSum(ctx context.Context, userID string, value int) error
 	GetUserBadgeUnreadCountSum(ctx context.Context, userID string) (int, error)
+	// IncrUsersBadgeUnreadCountSum adds one to the cached badges of userIDs, users without one are left out.
+	IncrUsersBadgeUnreadCountSum(ctx context.Context, userIDs []string) (map[string]int, error)
+	// SetUsersBadgeUnreadCountSum caches badges computed from the seqs of the users for expire.
+	SetUsersBadgeUnreadCountSum(ctx context.Context, badges map[string]int, expire time.Duration) error
+	DelUserBadgeUnreadCountSum(ctx context.Context, userIDs ...string) error
 	SetGetuiToken(ctx context.Context, token string, expireTime int64) error
 	GetGetuiToken(ctx context.Context) (string, error)
 	SetGetuiTaskID(ctx context.Context, taskID string, expireTime int64) error
@@ -249,6 +279,12 @@ func (c *msgCache) SetConversationUserMinSeqs(ctx context.Context, conversationI
 	})
 }
 
+func (c *msgCache) GetUserConversationsMinSeqs(ctx context.Context, userID string, conversationIDs []string) (m map[string]int64, err error) {
+	return c.getSeqs(ctx, conversationIDs, func(conversationID string) string {
+		return c.getConversationUserMinSeqKey(conversationID, userID)
+	})
+}
+
 func (c *msgCache) SetUserConversationsMinSeqs(ctx context.Context, userID string, seqs map[string]int64) (err error) {
 	return c.setSeqs(ctx, seqs, func(conversationID string) string {
 		return c.getConversationUserMinSeqKey(conversationID, userID)
@@ -281,6 +317,12 @@ func (c *msgCache) GetHasReadSeq(ctx context.Context, userID string, conversatio
 	return utils.Wrap2(c.rdb.Get(ctx, c.getHasReadSeqKey(conversationID, userID)).Int64())
 }
 
+func (c *msgCache) GetConversationUsersHasReadSeqs(ctx context.Context, conversationID string, userIDs []string) (map[string]int64, error) {
+	return c.getSeqs(ctx, userIDs, func(userID string) string {
+		return c.getHasReadSeqKey(conversationID, userID)
+	})
+}
+
 func (c *msgCache) AddTokenFlag(ctx context.Context, userID string, platformID int, token string, flag int) error {
 	key := uidPidToken + userID + ":" + constant.PlatformIDToName(platformID)
 
@@ -757,6 +799,183 @@ func (c *msgCache) DelFcmToken(ctx context.Context, account string, platformID i
 	return errs.Wrap(c.rdb.Del(ctx, FCM_TOKEN+account+":"+strconv.Itoa(platformID)).Err())
 }
 
+func (c *msgCache) SetAPNsToken(ctx context.Context, account string, token string, expireTime int64) error {
+	return errs.Wrap(c.rdb.Set(ctx, apnsToken+account, token, time.Duration(expireTime)*time.Second).Err())
+}
+
+func (c *msgCache) GetAPNsToken(ctx context.Context, account string) (string, error) {
+	return utils.Wrap2(c.rdb.Get(ctx, apnsToken+account).Result())
+}
+
+func (c *msgCache) DelAPNsToken(ctx context.Context, account string) error {
+	return errs.Wrap(c.rdb.Del(ctx, apnsToken+account).Err())
+}
+
+func (c *msgCache) SetVoIPToken(ctx context.Context, account string, token string, expireTime int64) error {
+	return errs.Wrap(c.rdb.Set(ctx, voipToken+account, token, time.Duration(expireTime)*time.Second).Err())
+}
+
+func (c *msgCache) GetVoIPToken(ctx context.Context, account string) (string, error) {
+	return utils.Wrap2(c.rdb.Get(ctx, voipToken+account).Result())
+}
+
+func (c *msgCache) DelVoIPToken(ctx context.Context, account string) error {
+	return errs.Wrap(c.rdb.Del(ctx, voipToken+account).Err())
+}
+
+// maxWebPushSubscriptions caps the browsers a user receives web pushes on, a new subscription replaces
+// the one updated the longest ago.
+const maxWebPushSubscriptions = 10
+
+// WebPushSubscription is a browser push subscription: the push service endpoint and the keys
+// encrypting the messages sent to it, base64url encoded. ExpireTime and UpdateTime are in milliseconds,
+// a zero ExpireTime never expires.
+type WebPushSubscription struct {
+	Endpoint   string `json:"endpoint"`
+	P256dh     string `json:"p256dh"`
+	Auth       string `json:"auth"`
+	ExpireTime int64  `json:"expireTime,omitempty"`
+	UpdateTime int64  `json:"updateTime"`
+}
+
+func (s *WebPushSubscription) expired(now int64) bool {
+	return s.ExpireTime > 0 && s.ExpireTime <= now
+}
+
+// setWebPushSubscriptionScript stores the subscription ARGV[2] of endpoint ARGV[1], drops the ones expired
+// at ARGV[3] and the ones updated the longest ago past ARGV[4]. The key expires with the last subscription.
+var setWebPushSubscriptionScript = redis.NewScript(`
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+local now = tonumber(ARGV[3])
+local all = redis.call('HGETALL', KEYS[1])
+local kept = {}
+local expireAt = 0
+for i = 1, #all, 2 do
+	local subscription = cjson.decode(all[i + 1])
+	local expire = tonumber(subscription.expireTime) or 0
+	if expire > 0 and expire <= now then
+		redis.call('HDEL', KEYS[1], all[i])
+	else
+		if all[i] ~= ARGV[1] then
+			table.insert(kept, {endpoint = all[i], update = tonumber(subscription.updateTime) or 0, expire = expire})
+		end
+		if expire == 0 or expireAt < 0 then
+			expireAt = -1
+		elseif expire > expireAt then
+			expireAt = expire
+		end
+	end
+end
+local max = tonumber(ARGV[4]) - 1
+if #kept > max then
+	table.sort(kept, function(a, b) return a.update < b.update end)
+	for i = 1, #kept - max do
+		redis.call('HDEL', KEYS[1], kept[i].endpoint)
+	end
+end
+if expireAt > 0 then
+	redis.call('PEXPIREAT', KEYS[1], expireAt)
+else
+	redis.call('PERSIST', KEYS[1])
+end
+return 1
+`)
+
+// SetWebPushSubscription stores the subscription for expireTime seconds, zero keeps it until it is removed.
+func (c *msgCache) SetWebPushSubscription(ctx context.Context, account string, subscription *WebPushSubscription, expireTime int64) error {
+	now := time.Now().UnixMilli()
+	stored := *subscription
+	stored.UpdateTime = now
+	stored.ExpireTime = 0
+	if expireTime > 0 {
+		stored.ExpireTime = now + expireTime*int64(time.Second/time.Millisecond)
+	}
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(setWebPushSubscriptionScript.Run(ctx, c.rdb, []string{webPushSubscription + account},
+		stored.Endpoint, data, now, maxWebPushSubscriptions).Err())
+}
+
+// GetWebPushSubscriptions returns the subscriptions of the user not expired yet, the expired ones are removed.
+func (c *msgCache) GetWebPushSubscriptions(ctx context.Context, account string) ([]*WebPushSubscription, error) {
+	values, err := c.rdb.HGetAll(ctx, webPushSubscription+account).Result()
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	now := time.Now().UnixMilli()
+	subscriptions := make([]*WebPushSubscription, 0, len(values))
+	var expired []string
+	for endpoint, value := range values {
+		var subscription WebPushSubscription
+		if err := json.Unmarshal([]byte(value), &subscription); err != nil {
+			return nil, errs.Wrap(err)
+		}
+		if subscription.expired(now) {
+			expired = append(expired, endpoint)
+			continue
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+	if len(expired) > 0 {
+		if err := c.DelWebPushSubscription(ctx, account, expired...); err != nil {
+			log.ZWarn(ctx, "remove expired web push subscriptions failed", err, "account", account)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (c *msgCache) DelWebPushSubscription(ctx context.Context, account string, endpoints ...string) error {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	return errs.Wrap(c.rdb.HDel(ctx, webPushSubscription+account, endpoints...).Err())
+}
+
+// phone vendors with a push service of their own.
+const (
+	PushVendorHuawei = "huawei"
+	PushVendorHonor  = "honor"
+	PushVendorXiaomi = "xiaomi"
+	PushVendorOPPO   = "oppo"
+	PushVendorVivo   = "vivo"
+)
+
+// VendorPushToken is the token a device got from the push service of its vendor.
+type VendorPushToken struct {
+	Vendor string `json:"vendor"`
+	Token  string `json:"token"`
+}
+
+func (c *msgCache) getVendorPushTokenKey(account string, platformID int) string {
+	return vendorPushToken + account + ":" + strconv.Itoa(platformID)
+}
+
+func (c *msgCache) SetVendorPushToken(ctx context.Context, account string, platformID int, token *VendorPushToken, expireTime int64) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(c.rdb.Set(ctx, c.getVendorPushTokenKey(account, platformID), data, time.Duration(expireTime)*time.Second).Err())
+}
+
+func (c *msgCache) GetVendorPushToken(ctx context.Context, account string, platformID int) (*VendorPushToken, error) {
+	data, err := c.rdb.Get(ctx, c.getVendorPushTokenKey(account, platformID)).Bytes()
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var token VendorPushToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &token, nil
+}
+
+func (c *msgCache) DelVendorPushToken(ctx context.Context, account string, platformID int) error {
+	return errs.Wrap(c.rdb.Del(ctx, c.getVendorPushTokenKey(account, platformID)).Err())
+}
+
 func (c *msgCache) IncrUserBadgeUnreadCountSum(ctx context.Context, userID string) (int, error) {
 	seq, err := c.rdb.Incr(ctx, userBadgeUnreadCountSum+userID).Result()
 
@@ -771,10 +990,74 @@ func (c *msgCache) GetUserBadgeUnreadCountSum(ctx context.Context, userID string
 	return utils.Wrap2(c.rdb.Get(ctx, userBadgeUnreadCountSum+userID).Int())
 }
 
+// incrIfExistScript increments KEYS[1] only if it exists, returning -1 otherwise.
+var incrIfExistScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('INCR', KEYS[1])
+`)
+
+func (c *msgCache) IncrUsersBadgeUnreadCountSum(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.Cmd, 0, len(userIDs))
+	for _, userID := range userIDs {
+		// EVAL rather than EVALSHA, a pipeline can't fall back when the script is not loaded yet.
+		cmds = append(cmds, incrIfExistScript.Eval(ctx, pipe, []string{userBadgeUnreadCountSum + userID}))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	badges := make(map[string]int, len(userIDs))
+	for i, cmd := range cmds {
+		if badge, err := cmd.Int(); err == nil && badge >= 0 {
+			badges[userIDs[i]] = badge
+		}
+	}
+	return badges, nil
+}
+
+func (c *msgCache) SetUsersBadgeUnreadCountSum(ctx context.Context, badges map[string]int, expire time.Duration) error {
+	if len(badges) == 0 {
+		return nil
+	}
+	pipe := c.rdb.Pipeline()
+	for userID, badge := range badges {
+		pipe.Set(ctx, userBadgeUnreadCountSum+userID, badge, expire)
+	}
+	_, err := pipe.Exec(ctx)
+	return errs.Wrap(err)
+}
+
+func (c *msgCache) DelUserBadgeUnreadCountSum(ctx context.Context, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	pipe := c.rdb.Pipeline()
+	for _, userID := range userIDs {
+		pipe.Del(ctx, userBadgeUnreadCountSum+userID)
+	}
+	_, err := pipe.Exec(ctx)
+	return errs.Wrap(err)
+}
+
+// ErrMessageTypeKeyLocked is returned by LockMessageTypeKey when someone else holds the lock.
+var ErrMessageTypeKeyLocked = errors.New("message type key is locked")
+
 func (c *msgCache) LockMessageTypeKey(ctx context.Context, clientMsgID string, TypeKey string) error {
 	key := exTypeKeyLocker + clientMsgID + "_" + TypeKey
 
-	return errs.Wrap(c.rdb.SetNX(ctx, key, 1, time.Minute).Err())
+	ok, err := c.rdb.SetNX(ctx, key, 1, time.Minute).Result()
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if !ok {
+		return ErrMessageTypeKeyLocked
+	}
+	return nil
 }
 
 func (c *msgCache) UnLockMessageTypeKey(ctx context.Context, clientMsgID string, TypeKey string) error {
@@ -791,6 +1074,8 @@ func (c *msgCache) getMessageReactionExPrefix(clientMsgID string, sessionType in
 		return "EX_GROUP_" + clientMsgID
 	case constant.SuperGroupChatType:
 		return "EX_SUPER_GROUP_" + clientMsgID
+	case constant.ServerGroupChatType:
+		return "EX_SERVER_GROUP_" + clientMsgID
 	case constant.NotificationChatType:
 		return "EX_NOTIFICATION" + clientMsgID
 	}
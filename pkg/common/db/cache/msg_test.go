@@ -2,11 +2,15 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/OpenIMSDK/protocol/sdkws"
+	"github.com/OpenIMSDK/tools/utils"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
@@ -385,3 +389,60 @@ func testParallelDeleteMessagesMix(t *testing.T, cid string, seqs []int64, input
 		assert.EqualValues(t, 1, val) // exists
 	}
 }
+
+func TestSetWebPushSubscriptionExpire(t *testing.T) {
+	rdb := newMiniRedis(t)
+	cacher := msgCache{rdb: rdb}
+	ctx := context.Background()
+	endpoints := func(subscriptions []*WebPushSubscription) []string {
+		return utils.Slice(subscriptions, func(s *WebPushSubscription) string { return s.Endpoint })
+	}
+
+	// no expire time keeps the subscription until it is removed.
+	assert.Nil(t, cacher.SetWebPushSubscription(ctx, "u1", &WebPushSubscription{Endpoint: "e1", P256dh: "key", Auth: "auth"}, 0))
+	subscriptions, err := cacher.GetWebPushSubscriptions(ctx, "u1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"e1"}, endpoints(subscriptions))
+	assert.Equal(t, "key", subscriptions[0].P256dh)
+	assert.Zero(t, subscriptions[0].ExpireTime)
+	assert.Equal(t, time.Duration(-1), rdb.TTL(ctx, webPushSubscription+"u1").Val())
+
+	// a subscription with an expire time doesn't shorten the others.
+	assert.Nil(t, cacher.SetWebPushSubscription(ctx, "u1", &WebPushSubscription{Endpoint: "e2"}, 60))
+	assert.Equal(t, time.Duration(-1), rdb.TTL(ctx, webPushSubscription+"u1").Val())
+	subscriptions, err = cacher.GetWebPushSubscriptions(ctx, "u1")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"e1", "e2"}, endpoints(subscriptions))
+
+	// the key of subscriptions that all expire goes with the last one.
+	assert.Nil(t, cacher.SetWebPushSubscription(ctx, "u2", &WebPushSubscription{Endpoint: "e1"}, 60))
+	assert.Nil(t, cacher.SetWebPushSubscription(ctx, "u2", &WebPushSubscription{Endpoint: "e2"}, 30))
+	ttl := rdb.TTL(ctx, webPushSubscription+"u2").Val()
+	assert.True(t, ttl > 30*time.Second && ttl <= time.Minute, ttl)
+
+	// an expired subscription is dropped on read.
+	expired, _ := json.Marshal(&WebPushSubscription{Endpoint: "e3", ExpireTime: time.Now().Add(-time.Second).UnixMilli()})
+	rdb.HSet(ctx, webPushSubscription+"u1", "e3", expired)
+	subscriptions, err = cacher.GetWebPushSubscriptions(ctx, "u1")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"e1", "e2"}, endpoints(subscriptions))
+	assert.False(t, rdb.HExists(ctx, webPushSubscription+"u1", "e3").Val())
+}
+
+func TestSetWebPushSubscriptionCap(t *testing.T) {
+	rdb := newMiniRedis(t)
+	cacher := msgCache{rdb: rdb}
+	ctx := context.Background()
+	for i := 0; i < maxWebPushSubscriptions; i++ {
+		// the subscriptions are stored in the same millisecond, order them by hand.
+		data, _ := json.Marshal(&WebPushSubscription{Endpoint: "e" + strconv.Itoa(i), UpdateTime: int64(i + 1)})
+		rdb.HSet(ctx, webPushSubscription+"u1", "e"+strconv.Itoa(i), data)
+	}
+	assert.Nil(t, cacher.SetWebPushSubscription(ctx, "u1", &WebPushSubscription{Endpoint: "new"}, 0))
+	assert.Equal(t, int64(maxWebPushSubscriptions), rdb.HLen(ctx, webPushSubscription+"u1").Val())
+	assert.False(t, rdb.HExists(ctx, webPushSubscription+"u1", "e0").Val())
+	assert.True(t, rdb.HExists(ctx, webPushSubscription+"u1", "new").Val())
+	// updating a kept subscription drops nothing.
+	assert.Nil(t, cacher.SetWebPushSubscription(ctx, "u1", &WebPushSubscription{Endpoint: "e1"}, 0))
+	assert.Equal(t, int64(maxWebPushSubscriptions), rdb.HLen(ctx, webPushSubscription+"u1").Val())
+}
@@ -0,0 +1,145 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// the keys of a poll share a hash tag, so the vote script works on redis cluster.
+const (
+	pollCountKey  = "}:COUNT"
+	pollVoteKey   = "}:VOTE"
+	pollClosedKey = "}:CLOSED"
+	pollKeyPrefix = "{POLL:"
+
+	pollVotersField  = "_voters"
+	pollVersionField = "_version"
+
+	// pollClosedExpire keeps a closed poll around long enough for the votes in flight to see it closed.
+	pollClosedExpire = 24 * time.Hour
+)
+
+// votePollScript replaces the options the user voted for and returns the counts.
+// KEYS[1] counts, KEYS[2] votes, KEYS[3] closed flag, ARGV[1] user id, ARGV[2] options joined by ",",
+// an empty ARGV[2] unvotes. It returns {0} when the poll is closed.
+var votePollScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[3]) == 1 then
+	return {0}
+end
+local prev = redis.call('HGET', KEYS[2], ARGV[1])
+if prev then
+	for option in string.gmatch(prev, '[^,]+') do
+		redis.call('HINCRBY', KEYS[1], option, -1)
+	end
+end
+if ARGV[2] == '' then
+	if prev then
+		redis.call('HDEL', KEYS[2], ARGV[1])
+		redis.call('HINCRBY', KEYS[1], '_voters', -1)
+	end
+else
+	for option in string.gmatch(ARGV[2], '[^,]+') do
+		redis.call('HINCRBY', KEYS[1], option, 1)
+	end
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+	if not prev then
+		redis.call('HINCRBY', KEYS[1], '_voters', 1)
+	end
+end
+redis.call('HINCRBY', KEYS[1], '_version', 1)
+return {1, redis.call('HGETALL', KEYS[1])}
+`)
+
+// PollCount is the vote counts of a poll right after a vote.
+type PollCount struct {
+	Counts     map[string]int64
+	VoterCount int64
+	Version    int64
+}
+
+type PollCache interface {
+	// InitPoll seeds the vote counts of a new poll.
+	InitPoll(ctx context.Context, pollID string, optionIDs []string) error
+	// VotePoll atomically replaces the options the user voted for, no options unvotes.
+	// It returns nil when the poll is closed.
+	VotePoll(ctx context.Context, pollID string, userID string, optionIDs []string) (*PollCount, error)
+	// ClosePoll makes the later votes fail and lets the counts expire, mongo keeps the final ones.
+	ClosePoll(ctx context.Context, pollID string) error
+}
+
+func NewPollCacheRedis(rdb redis.UniversalClient) PollCache {
+	return &pollCacheRedis{rdb: rdb}
+}
+
+type pollCacheRedis struct {
+	rdb redis.UniversalClient
+}
+
+func (p *pollCacheRedis) getPollKeys(pollID string) []string {
+	return []string{pollKeyPrefix + pollID + pollCountKey, pollKeyPrefix + pollID + pollVoteKey, pollKeyPrefix + pollID + pollClosedKey}
+}
+
+func (p *pollCacheRedis) InitPoll(ctx context.Context, pollID string, optionIDs []string) error {
+	values := make(map[string]any, len(optionIDs)+2)
+	for _, optionID := range optionIDs {
+		values[optionID] = 0
+	}
+	values[pollVotersField] = 0
+	values[pollVersionField] = 0
+	return errs.Wrap(p.rdb.HSet(ctx, p.getPollKeys(pollID)[0], values).Err())
+}
+
+func (p *pollCacheRedis) VotePoll(ctx context.Context, pollID string, userID string, optionIDs []string) (*PollCount, error) {
+	res, err := votePollScript.Run(ctx, p.rdb, p.getPollKeys(pollID), userID, strings.Join(optionIDs, ",")).Slice()
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if ok, _ := res[0].(int64); ok == 0 {
+		return nil, nil
+	}
+	count := &PollCount{Counts: make(map[string]int64)}
+	fields, _ := res[1].([]any)
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		n := utils.StringToInt64(value)
+		switch field {
+		case pollVotersField:
+			count.VoterCount = n
+		case pollVersionField:
+			count.Version = n
+		default:
+			count.Counts[field] = n
+		}
+	}
+	return count, nil
+}
+
+func (p *pollCacheRedis) ClosePoll(ctx context.Context, pollID string) error {
+	keys := p.getPollKeys(pollID)
+	pipe := p.rdb.TxPipeline()
+	pipe.Set(ctx, keys[2], 1, pollClosedExpire)
+	pipe.Expire(ctx, keys[0], pollClosedExpire)
+	pipe.Expire(ctx, keys[1], pollClosedExpire)
+	_, err := pipe.Exec(ctx)
+	return errs.Wrap(err)
+}
@@ -0,0 +1,60 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVotePollScript(t *testing.T) {
+	p := NewPollCacheRedis(newMiniRedis(t))
+	ctx := context.Background()
+	assert.NoError(t, p.InitPoll(ctx, "p1", []string{"1", "2", "3"}))
+
+	// vote
+	count, err := p.VotePoll(ctx, "p1", "u1", []string{"1", "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"1": 1, "2": 1, "3": 0}, count.Counts)
+	assert.Equal(t, int64(1), count.VoterCount)
+	assert.Equal(t, int64(1), count.Version)
+	count, err = p.VotePoll(ctx, "p1", "u2", []string{"2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"1": 1, "2": 2, "3": 0}, count.Counts)
+	assert.Equal(t, int64(2), count.VoterCount)
+
+	// revote replaces the options and keeps the voter count.
+	count, err = p.VotePoll(ctx, "p1", "u1", []string{"3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"1": 0, "2": 1, "3": 1}, count.Counts)
+	assert.Equal(t, int64(2), count.VoterCount)
+
+	// unvote, twice only counts once.
+	for i := 0; i < 2; i++ {
+		count, err = p.VotePoll(ctx, "p1", "u2", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"1": 0, "2": 0, "3": 1}, count.Counts)
+		assert.Equal(t, int64(1), count.VoterCount)
+	}
+	assert.Equal(t, int64(5), count.Version)
+
+	// closed
+	assert.NoError(t, p.ClosePoll(ctx, "p1"))
+	count, err = p.VotePoll(ctx, "p1", "u2", []string{"1"})
+	assert.NoError(t, err)
+	assert.Nil(t, count)
+}
@@ -0,0 +1,121 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	userPresenceKey = "USER_PRESENCE:"
+	gatewayNodeKey  = "GATEWAY_NODE_ALIVE:"
+)
+
+// PresenceCache maps users to the gateway nodes holding their connections. Each node keeps a
+// heartbeat key alive, a node without heartbeat is not maintaining the registry.
+type PresenceCache interface {
+	// AddUserNodes records that every user of userIDs is connected to node.
+	AddUserNodes(ctx context.Context, node string, userIDs []string, expire time.Duration) error
+	// DelUserNode removes node from the user, once the user has no connection left on it.
+	DelUserNode(ctx context.Context, node string, userID string) error
+	// GetUsersNodes returns the nodes of each user, users without any node are left out.
+	GetUsersNodes(ctx context.Context, userIDs []string) (map[string][]string, error)
+	SetNodeAlive(ctx context.Context, node string, expire time.Duration) error
+	DelNodeAlive(ctx context.Context, node string) error
+	// GetAliveNodes returns which of nodes are maintaining the registry.
+	GetAliveNodes(ctx context.Context, nodes []string) (map[string]bool, error)
+}
+
+func NewPresenceCacheRedis(rdb redis.UniversalClient) PresenceCache {
+	return &presenceCacheRedis{rdb: rdb}
+}
+
+type presenceCacheRedis struct {
+	rdb redis.UniversalClient
+}
+
+func (p *presenceCacheRedis) getUserPresenceKey(userID string) string {
+	return userPresenceKey + userID
+}
+
+func (p *presenceCacheRedis) getGatewayNodeKey(node string) string {
+	return gatewayNodeKey + node
+}
+
+func (p *presenceCacheRedis) AddUserNodes(ctx context.Context, node string, userIDs []string, expire time.Duration) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	now := utils.GetCurrentTimestampByMill()
+	pipe := p.rdb.Pipeline()
+	for _, userID := range userIDs {
+		key := p.getUserPresenceKey(userID)
+		pipe.HSet(ctx, key, node, now)
+		pipe.Expire(ctx, key, expire)
+	}
+	_, err := pipe.Exec(ctx)
+	return errs.Wrap(err)
+}
+
+func (p *presenceCacheRedis) DelUserNode(ctx context.Context, node string, userID string) error {
+	return errs.Wrap(p.rdb.HDel(ctx, p.getUserPresenceKey(userID), node).Err())
+}
+
+func (p *presenceCacheRedis) GetUsersNodes(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	pipe := p.rdb.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, 0, len(userIDs))
+	for _, userID := range userIDs {
+		cmds = append(cmds, pipe.HKeys(ctx, p.getUserPresenceKey(userID)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, errs.Wrap(err)
+	}
+	nodes := make(map[string][]string, len(userIDs))
+	for i, cmd := range cmds {
+		if len(cmd.Val()) > 0 {
+			nodes[userIDs[i]] = cmd.Val()
+		}
+	}
+	return nodes, nil
+}
+
+func (p *presenceCacheRedis) SetNodeAlive(ctx context.Context, node string, expire time.Duration) error {
+	return errs.Wrap(p.rdb.Set(ctx, p.getGatewayNodeKey(node), utils.GetCurrentTimestampByMill(), expire).Err())
+}
+
+func (p *presenceCacheRedis) DelNodeAlive(ctx context.Context, node string) error {
+	return errs.Wrap(p.rdb.Del(ctx, p.getGatewayNodeKey(node)).Err())
+}
+
+func (p *presenceCacheRedis) GetAliveNodes(ctx context.Context, nodes []string) (map[string]bool, error) {
+	pipe := p.rdb.Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(nodes))
+	for _, node := range nodes {
+		cmds = append(cmds, pipe.Exists(ctx, p.getGatewayNodeKey(node)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	alive := make(map[string]bool, len(nodes))
+	for i, cmd := range cmds {
+		alive[nodes[i]] = cmd.Val() > 0
+	}
+	return alive, nil
+}
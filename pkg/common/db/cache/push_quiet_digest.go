@@ -0,0 +1,119 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// the keys share a hash tag, so the scripts work on redis cluster.
+const (
+	quietDigestQueueKey = "{PUSH_QUIET_DIGEST}:QUEUE"
+	quietDigestCountKey = "{PUSH_QUIET_DIGEST}:COUNT"
+)
+
+// addQuietDigestScript adds held back pushes to the count of each user and keeps the latest due time of
+// each user. KEYS[1] queue, KEYS[2] counts, ARGV triples of user id, count and due time.
+var addQuietDigestScript = redis.NewScript(`
+for i = 1, #ARGV, 3 do
+	redis.call('HINCRBY', KEYS[2], ARGV[i], ARGV[i + 1])
+	local due = redis.call('ZSCORE', KEYS[1], ARGV[i])
+	if not due or tonumber(due) < tonumber(ARGV[i + 2]) then
+		redis.call('ZADD', KEYS[1], ARGV[i + 2], ARGV[i])
+	end
+end
+return 1
+`)
+
+// popQuietDigestScript removes up to ARGV[2] users due before ARGV[1] and returns user ids and counts in turn.
+// KEYS[1] queue, KEYS[2] counts.
+var popQuietDigestScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+if #ids == 0 then
+	return {}
+end
+local counts = redis.call('HMGET', KEYS[2], unpack(ids))
+redis.call('ZREM', KEYS[1], unpack(ids))
+redis.call('HDEL', KEYS[2], unpack(ids))
+local res = {}
+for i = 1, #ids do
+	table.insert(res, ids[i])
+	table.insert(res, counts[i] or '0')
+end
+return res
+`)
+
+// QuietDigestCache counts the offline pushes held back by quiet hours, so users get a digest once their
+// quiet hours are over.
+type QuietDigestCache interface {
+	// AddQuietDigest counts one held back push for each user, due is when the user's quiet hours end.
+	AddQuietDigest(ctx context.Context, due map[string]time.Time) error
+	// PopDueQuietDigests removes at most count users due at now and returns their held back push counts.
+	PopDueQuietDigests(ctx context.Context, now time.Time, count int) (map[string]int64, error)
+	// RestoreQuietDigests puts back digests popped but not pushed, adding to the pushes held back since,
+	// the users are due at due or later.
+	RestoreQuietDigests(ctx context.Context, digests map[string]int64, due time.Time) error
+}
+
+func NewQuietDigestCacheRedis(rdb redis.UniversalClient) QuietDigestCache {
+	return &quietDigestCacheRedis{rdb: rdb}
+}
+
+type quietDigestCacheRedis struct {
+	rdb redis.UniversalClient
+}
+
+func (q *quietDigestCacheRedis) AddQuietDigest(ctx context.Context, due map[string]time.Time) error {
+	if len(due) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(due)*3)
+	for userID, t := range due {
+		args = append(args, userID, 1, t.UnixMilli())
+	}
+	return errs.Wrap(addQuietDigestScript.Run(ctx, q.rdb, []string{quietDigestQueueKey, quietDigestCountKey}, args...).Err())
+}
+
+func (q *quietDigestCacheRedis) RestoreQuietDigests(ctx context.Context, digests map[string]int64, due time.Time) error {
+	if len(digests) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(digests)*3)
+	for userID, count := range digests {
+		args = append(args, userID, count, due.UnixMilli())
+	}
+	return errs.Wrap(addQuietDigestScript.Run(ctx, q.rdb, []string{quietDigestQueueKey, quietDigestCountKey}, args...).Err())
+}
+
+func (q *quietDigestCacheRedis) PopDueQuietDigests(ctx context.Context, now time.Time, count int) (map[string]int64, error) {
+	res, err := popQuietDigestScript.Run(ctx, q.rdb, []string{quietDigestQueueKey, quietDigestCountKey}, now.UnixMilli(), count).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, errs.Wrap(err)
+	}
+	digests := make(map[string]int64, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		n, err := strconv.ParseInt(res[i+1], 10, 64)
+		if err != nil {
+			return nil, errs.Wrap(err)
+		}
+		digests[res[i]] = n
+	}
+	return digests, nil
+}
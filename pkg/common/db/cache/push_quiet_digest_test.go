@@ -0,0 +1,45 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietDigestRestore(t *testing.T) {
+	c := NewQuietDigestCacheRedis(newMiniRedis(t))
+	ctx := context.Background()
+	now := time.UnixMilli(100000)
+	assert.NoError(t, c.AddQuietDigest(ctx, map[string]time.Time{"u1": now, "u2": now}))
+	assert.NoError(t, c.AddQuietDigest(ctx, map[string]time.Time{"u1": now}))
+
+	digests, err := c.PopDueQuietDigests(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"u1": 2, "u2": 1}, digests)
+
+	// u1 got another push held back meanwhile, until later than the retry.
+	assert.NoError(t, c.AddQuietDigest(ctx, map[string]time.Time{"u1": now.Add(time.Hour)}))
+	assert.NoError(t, c.RestoreQuietDigests(ctx, digests, now.Add(time.Minute)))
+	digests, err = c.PopDueQuietDigests(ctx, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"u2": 1}, digests)
+	digests, err = c.PopDueQuietDigests(ctx, now.Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"u1": 3}, digests)
+}
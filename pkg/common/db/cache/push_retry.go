@@ -0,0 +1,220 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// the keys share a hash tag, so the scripts work on redis cluster.
+const (
+	pushRetryQueueKey      = "{OFFLINE_PUSH_RETRY}:QUEUE"
+	pushRetryJobKey        = "{OFFLINE_PUSH_RETRY}:JOB"
+	pushRetryProcessingKey = "{OFFLINE_PUSH_RETRY}:PROCESSING"
+	pushDeadLetterKey      = "{OFFLINE_PUSH_RETRY}:DEAD_QUEUE"
+	pushDeadLetterData     = "{OFFLINE_PUSH_RETRY}:DEAD_JOB"
+)
+
+// leaseDueScript first puts the jobs whose lease expired before ARGV[1] back in the queue, then moves up to
+// ARGV[2] jobs due at ARGV[1] to the processing set until ARGV[3] and returns their ids and payloads.
+// KEYS[1] queue, KEYS[2] jobs, KEYS[3] processing.
+var leaseDueScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[1])
+for i = 1, #expired do
+	redis.call('ZADD', KEYS[1], ARGV[1], expired[i])
+end
+if #expired > 0 then
+	redis.call('ZREM', KEYS[3], unpack(expired))
+end
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+if #ids == 0 then
+	return {}
+end
+redis.call('ZREM', KEYS[1], unpack(ids))
+local jobs = redis.call('HMGET', KEYS[2], unpack(ids))
+local res = {}
+for i = 1, #ids do
+	if jobs[i] then
+		redis.call('ZADD', KEYS[3], ARGV[3], ids[i])
+		table.insert(res, ids[i])
+		table.insert(res, jobs[i])
+	end
+end
+return res
+`)
+
+// ackScript drops a leased job, unless it was scheduled again meanwhile.
+// KEYS[1] processing, KEYS[2] jobs, ARGV[1] id.
+var ackScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// addDeadLetterScript stores a dead letter and drops the ones failed before ARGV[4].
+// KEYS[1] queue, KEYS[2] jobs, ARGV[1] id, ARGV[2] payload, ARGV[3] fail time, ARGV[4] oldest kept.
+var addDeadLetterScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[4])
+if #expired > 0 then
+	redis.call('ZREM', KEYS[1], unpack(expired))
+	redis.call('HDEL', KEYS[2], unpack(expired))
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+return 1
+`)
+
+// replayScript schedules the dead letters still stored at ARGV[1] with the payloads that follow their ids,
+// and removes them from the dead letters. It returns the ids replayed.
+// KEYS[1] queue, KEYS[2] jobs, KEYS[3] dead letter queue, KEYS[4] dead letter jobs, ARGV[2..] id, payload pairs.
+var replayScript = redis.NewScript(`
+local res = {}
+for i = 2, #ARGV, 2 do
+	local id = ARGV[i]
+	if redis.call('HEXISTS', KEYS[4], id) == 1 then
+		redis.call('HSET', KEYS[2], id, ARGV[i + 1])
+		redis.call('ZADD', KEYS[1], ARGV[1], id)
+		redis.call('ZREM', KEYS[3], id)
+		redis.call('HDEL', KEYS[4], id)
+		table.insert(res, id)
+	end
+end
+return res
+`)
+
+// PushRetryCache keeps offline pushes waiting for another attempt, and the ones given up on.
+// Jobs are opaque payloads identified by jobID.
+type PushRetryCache interface {
+	// AddRetryJob schedules job to be returned by LeaseDueRetryJobs from due on, releasing its lease if any.
+	AddRetryJob(ctx context.Context, jobID string, job string, due time.Time) error
+	// LeaseDueRetryJobs returns at most count jobs due at now by jobID, leased for lease. A job whose lease
+	// expires before AckRetryJob or AddRetryJob is returned again.
+	LeaseDueRetryJobs(ctx context.Context, now time.Time, count int, lease time.Duration) (map[string]string, error)
+	// AckRetryJob drops a leased job once it was handled.
+	AckRetryJob(ctx context.Context, jobID string) error
+	// AddDeadLetter stores job, dead letters older than expire are dropped.
+	AddDeadLetter(ctx context.Context, jobID string, job string, failTime time.Time, expire time.Duration) error
+	// GetDeadLetters pages through the dead letters, most recent first.
+	GetDeadLetters(ctx context.Context, offset int, count int) (int64, []string, error)
+	// FindDeadLetters returns the given dead letters that exist by jobID.
+	FindDeadLetters(ctx context.Context, jobIDs []string) (map[string]string, error)
+	// ReplayDeadLetters schedules the dead letters of jobs at due with the given payloads and removes them
+	// at once, it returns the jobIDs that were still dead letters.
+	ReplayDeadLetters(ctx context.Context, jobs map[string]string, due time.Time) ([]string, error)
+}
+
+func NewPushRetryCacheRedis(rdb redis.UniversalClient) PushRetryCache {
+	return &pushRetryCacheRedis{rdb: rdb}
+}
+
+type pushRetryCacheRedis struct {
+	rdb redis.UniversalClient
+}
+
+func (p *pushRetryCacheRedis) AddRetryJob(ctx context.Context, jobID string, job string, due time.Time) error {
+	pipe := p.rdb.TxPipeline()
+	pipe.HSet(ctx, pushRetryJobKey, jobID, job)
+	pipe.ZAdd(ctx, pushRetryQueueKey, redis.Z{Score: float64(due.UnixMilli()), Member: jobID})
+	pipe.ZRem(ctx, pushRetryProcessingKey, jobID)
+	_, err := pipe.Exec(ctx)
+	return errs.Wrap(err)
+}
+
+func (p *pushRetryCacheRedis) LeaseDueRetryJobs(ctx context.Context, now time.Time, count int, lease time.Duration) (map[string]string, error) {
+	res, err := leaseDueScript.Run(ctx, p.rdb, []string{pushRetryQueueKey, pushRetryJobKey, pushRetryProcessingKey},
+		now.UnixMilli(), count, now.Add(lease).UnixMilli()).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, errs.Wrap(err)
+	}
+	jobs := make(map[string]string, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		jobs[res[i]] = res[i+1]
+	}
+	return jobs, nil
+}
+
+func (p *pushRetryCacheRedis) AckRetryJob(ctx context.Context, jobID string) error {
+	return errs.Wrap(ackScript.Run(ctx, p.rdb, []string{pushRetryProcessingKey, pushRetryJobKey}, jobID).Err())
+}
+
+func (p *pushRetryCacheRedis) AddDeadLetter(ctx context.Context, jobID string, job string, failTime time.Time, expire time.Duration) error {
+	oldest := failTime.Add(-expire).UnixMilli()
+	return errs.Wrap(addDeadLetterScript.Run(ctx, p.rdb, []string{pushDeadLetterKey, pushDeadLetterData},
+		jobID, job, failTime.UnixMilli(), oldest).Err())
+}
+
+func (p *pushRetryCacheRedis) GetDeadLetters(ctx context.Context, offset int, count int) (int64, []string, error) {
+	total, err := p.rdb.ZCard(ctx, pushDeadLetterKey).Result()
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	ids, err := p.rdb.ZRevRange(ctx, pushDeadLetterKey, int64(offset), int64(offset+count-1)).Result()
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	if len(ids) == 0 {
+		return total, nil, nil
+	}
+	values, err := p.rdb.HMGet(ctx, pushDeadLetterData, ids...).Result()
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	jobs := make([]string, 0, len(values))
+	for _, v := range values {
+		if job, ok := v.(string); ok {
+			jobs = append(jobs, job)
+		}
+	}
+	return total, jobs, nil
+}
+
+func (p *pushRetryCacheRedis) FindDeadLetters(ctx context.Context, jobIDs []string) (map[string]string, error) {
+	if len(jobIDs) == 0 {
+		return nil, nil
+	}
+	values, err := p.rdb.HMGet(ctx, pushDeadLetterData, jobIDs...).Result()
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	jobs := make(map[string]string, len(values))
+	for i, v := range values {
+		if job, ok := v.(string); ok {
+			jobs[jobIDs[i]] = job
+		}
+	}
+	return jobs, nil
+}
+
+func (p *pushRetryCacheRedis) ReplayDeadLetters(ctx context.Context, jobs map[string]string, due time.Time) ([]string, error) {
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, 1+len(jobs)*2)
+	args = append(args, due.UnixMilli())
+	for jobID, job := range jobs {
+		args = append(args, jobID, job)
+	}
+	keys := []string{pushRetryQueueKey, pushRetryJobKey, pushDeadLetterKey, pushDeadLetterData}
+	jobIDs, err := replayScript.Run(ctx, p.rdb, keys, args...).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, errs.Wrap(err)
+	}
+	return jobIDs, nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushRetryLease(t *testing.T) {
+	c := NewPushRetryCacheRedis(newMiniRedis(t))
+	ctx := context.Background()
+	now := time.UnixMilli(100000)
+	assert.NoError(t, c.AddRetryJob(ctx, "j1", "a", now))
+	assert.NoError(t, c.AddRetryJob(ctx, "j2", "b", now.Add(time.Minute)))
+
+	jobs, err := c.LeaseDueRetryJobs(ctx, now, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"j1": "a"}, jobs)
+	// a leased job isn't returned again before its lease expires.
+	jobs, err = c.LeaseDueRetryJobs(ctx, now.Add(30*time.Second), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+	// the instance holding j1 stopped, j1 comes back with j2.
+	jobs, err = c.LeaseDueRetryJobs(ctx, now.Add(2*time.Minute), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"j1": "a", "j2": "b"}, jobs)
+
+	// j1 is scheduled again before being acked, the ack must keep it.
+	assert.NoError(t, c.AddRetryJob(ctx, "j1", "a2", now.Add(3*time.Minute)))
+	assert.NoError(t, c.AckRetryJob(ctx, "j1"))
+	assert.NoError(t, c.AckRetryJob(ctx, "j2"))
+	jobs, err = c.LeaseDueRetryJobs(ctx, now.Add(time.Hour), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"j1": "a2"}, jobs)
+}
+
+func TestPushDeadLetterReplay(t *testing.T) {
+	c := NewPushRetryCacheRedis(newMiniRedis(t))
+	ctx := context.Background()
+	now := time.UnixMilli(100000)
+	assert.NoError(t, c.AddDeadLetter(ctx, "j1", "a", now, time.Hour))
+	assert.NoError(t, c.AddDeadLetter(ctx, "j2", "b", now, time.Hour))
+
+	found, err := c.FindDeadLetters(ctx, []string{"j1", "j3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"j1": "a"}, found)
+
+	replayed, err := c.ReplayDeadLetters(ctx, map[string]string{"j1": "a0", "j3": "c"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"j1"}, replayed)
+	total, deadLetters, err := c.GetDeadLetters(ctx, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"b"}, deadLetters)
+	jobs, err := c.LeaseDueRetryJobs(ctx, now, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"j1": "a0"}, jobs)
+}
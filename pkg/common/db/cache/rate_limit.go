@@ -0,0 +1,83 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	userRateLimitKey = "USER_RATE_LIMIT:"
+)
+
+// tokenBucketScript refills the bucket by elapsed time, then takes one token if available.
+// KEYS[1] bucket key, ARGV[1] rate per second, ARGV[2] burst, ARGV[3] now in milliseconds.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return allowed
+`)
+
+type RateLimitCache interface {
+	// AllowUserRequest takes one token from the user's bucket for reqIdentifier, shared by all gateway nodes.
+	AllowUserRequest(ctx context.Context, userID string, reqIdentifier int32, rate float64, burst int) (bool, error)
+}
+
+func NewRateLimitCacheRedis(rdb redis.UniversalClient) RateLimitCache {
+	return &rateLimitCacheRedis{rdb: rdb}
+}
+
+type rateLimitCacheRedis struct {
+	rdb redis.UniversalClient
+}
+
+func (r *rateLimitCacheRedis) getUserRateLimitKey(userID string, reqIdentifier int32) string {
+	return userRateLimitKey + userID + ":" + strconv.Itoa(int(reqIdentifier))
+}
+
+func (r *rateLimitCacheRedis) AllowUserRequest(ctx context.Context, userID string, reqIdentifier int32, rate float64, burst int) (bool, error) {
+	if rate <= 0 || burst <= 0 {
+		return true, nil
+	}
+	res, err := tokenBucketScript.Run(ctx, r.rdb, []string{r.getUserRateLimitKey(userID, reqIdentifier)},
+		rate, burst, time.Now().UnixMilli()).Int()
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res == 1, nil
+}
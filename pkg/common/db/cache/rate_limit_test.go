@@ -0,0 +1,86 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMiniRedis(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestTokenBucketScriptWindow(t *testing.T) {
+	rdb := newMiniRedis(t)
+	ctx := context.Background()
+	key := []string{"USER_RATE_LIMIT:u1:1003"}
+	take := func(now int64) int {
+		res, err := tokenBucketScript.Run(ctx, rdb, key, 2, 3, now).Int()
+		assert.NoError(t, err)
+		return res
+	}
+	// a new bucket starts full.
+	assert.Equal(t, 1, take(1000))
+	assert.Equal(t, 1, take(1000))
+	assert.Equal(t, 1, take(1000))
+	assert.Equal(t, 0, take(1000))
+	// 2 tokens per second, one token is back after 500ms.
+	assert.Equal(t, 0, take(1400))
+	assert.Equal(t, 1, take(1500))
+	assert.Equal(t, 0, take(1500))
+	// the bucket never holds more than burst.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 1, take(60000))
+	}
+	assert.Equal(t, 0, take(60000))
+}
+
+func TestAllowUserRequest(t *testing.T) {
+	c := NewRateLimitCacheRedis(newMiniRedis(t))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		ok, err := c.AllowUserRequest(ctx, "u1", 1003, 0.001, 2)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, err := c.AllowUserRequest(ctx, "u1", 1003, 0.001, 2)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// buckets are per user and per request identifier.
+	ok, _ = c.AllowUserRequest(ctx, "u2", 1003, 0.001, 2)
+	assert.True(t, ok)
+	ok, err = c.AllowUserRequest(ctx, "u1", 1004, 0.001, 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// no rate or burst means no limit.
+	ok, err = c.AllowUserRequest(ctx, "u1", 1003, 0, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
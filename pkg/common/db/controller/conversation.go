@@ -55,7 +55,9 @@ type ConversationDatabase interface {
 	PageConversationIDs(ctx context.Context, pageNumber, showNumber int32) (conversationIDs []string, err error)
 	//GetUserAllHasReadSeqs(ctx context.Context, ownerUserID string) (map[string]int64, error)
 	GetConversationsByConversationID(ctx context.Context, conversationIDs []string) ([]*relationtb.ConversationModel, error)
-	GetConversationIDsNeedDestruct(ctx context.Context) ([]*relationtb.ConversationModel, error)
+	// FindMsgDestructDueConversations returns the conversations of the shard whose msgs are due for destruct at now.
+	FindMsgDestructDueConversations(ctx context.Context, now time.Time, shard, shards int, limit int) ([]*relationtb.ConversationModel, error)
+	CountMsgDestructDueConversations(ctx context.Context, now time.Time, shard, shards int) (int64, error)
 	GetConversationNotReceiveMessageUserIDs(ctx context.Context, conversationID string) ([]string, error)
 }
 
@@ -360,8 +362,12 @@ func (c *conversationDatabase) GetConversationsByConversationID(ctx context.Cont
 	return c.conversationDB.GetConversationsByConversationID(ctx, conversationIDs)
 }
 
-func (c *conversationDatabase) GetConversationIDsNeedDestruct(ctx context.Context) ([]*relationtb.ConversationModel, error) {
-	return c.conversationDB.GetConversationIDsNeedDestruct(ctx)
+func (c *conversationDatabase) FindMsgDestructDueConversations(ctx context.Context, now time.Time, shard, shards int, limit int) ([]*relationtb.ConversationModel, error) {
+	return c.conversationDB.FindMsgDestructDue(ctx, now, shard, shards, limit)
+}
+
+func (c *conversationDatabase) CountMsgDestructDueConversations(ctx context.Context, now time.Time, shard, shards int) (int64, error) {
+	return c.conversationDB.CountMsgDestructDue(ctx, now, shard, shards)
 }
 
 func (c *conversationDatabase) GetConversationNotReceiveMessageUserIDs(ctx context.Context, conversationID string) ([]string, error) {
@@ -0,0 +1,167 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/cont"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// errConversationExportTimeout fails an export left unfinished for longer than the running timeout.
+var errConversationExportTimeout = errors.New("export timed out")
+
+type ConversationExportDatabase interface {
+	CreateConversationExport(ctx context.Context, export *unrelation.ConversationExportModel) error
+	TakeConversationExport(ctx context.Context, exportID string) (*unrelation.ConversationExportModel, error)
+	CountUserUnfinishedConversationExports(ctx context.Context, userID string) (int64, error)
+	// StartConversationExport claims a pending export for rendering, or a running one whose renderer stopped
+	// before the running timeout, only one caller gets true.
+	StartConversationExport(ctx context.Context, exportID string) (bool, error)
+	// FinishConversationExport records the result of a claimed export.
+	FinishConversationExport(ctx context.Context, exportID string, objectKey string, msgNum int64, exportErr error) error
+	// FailPendingConversationExport fails an export that could not be handed to the cron service.
+	FailPendingConversationExport(ctx context.Context, exportID string, exportErr error) error
+	// FailStaleConversationExports fails the exports of the user left unfinished past the running timeout.
+	FailStaleConversationExports(ctx context.Context, userID string) (int64, error)
+	// UploadConversationExport uploads a rendered export of size bytes, it returns the object key.
+	UploadConversationExport(ctx context.Context, format string, file io.ReaderAt, size int64) (string, error)
+	// ConversationExportAccessURL signs an url to download a done export, valid for the configured access expire.
+	ConversationExportAccessURL(ctx context.Context, export *unrelation.ConversationExportModel) (string, time.Time, error)
+}
+
+type conversationExportDatabase struct {
+	export unrelation.ConversationExportModelInterface
+	s3     *cont.Controller
+}
+
+func NewConversationExportDatabase(export unrelation.ConversationExportModelInterface, rdb redis.UniversalClient, s3 s3.Interface) ConversationExportDatabase {
+	return &conversationExportDatabase{
+		export: export,
+		s3:     cont.New(cache.NewS3Cache(rdb, s3), s3),
+	}
+}
+
+func (c *conversationExportDatabase) CreateConversationExport(ctx context.Context, export *unrelation.ConversationExportModel) error {
+	return c.export.Create(ctx, export)
+}
+
+func (c *conversationExportDatabase) TakeConversationExport(ctx context.Context, exportID string) (*unrelation.ConversationExportModel, error) {
+	return c.export.Take(ctx, exportID)
+}
+
+func (c *conversationExportDatabase) CountUserUnfinishedConversationExports(ctx context.Context, userID string) (int64, error) {
+	return c.export.CountUserUnfinished(ctx, userID)
+}
+
+func (c *conversationExportDatabase) StartConversationExport(ctx context.Context, exportID string) (bool, error) {
+	return c.export.Claim(ctx, exportID, conversationExportStaleTime(), map[string]any{"status": unrelation.ConversationExportRunning, "update_time": time.Now()})
+}
+
+func (c *conversationExportDatabase) FinishConversationExport(ctx context.Context, exportID string, objectKey string, msgNum int64, exportErr error) error {
+	args := map[string]any{"status": unrelation.ConversationExportDone, "object_key": objectKey, "msg_num": msgNum, "update_time": time.Now()}
+	if exportErr != nil {
+		args["status"] = unrelation.ConversationExportFailed
+		args["err_msg"] = exportErr.Error()
+	}
+	_, err := c.export.UpdateStatus(ctx, exportID, unrelation.ConversationExportRunning, args)
+	return err
+}
+
+func (c *conversationExportDatabase) FailPendingConversationExport(ctx context.Context, exportID string, exportErr error) error {
+	_, err := c.export.UpdateStatus(ctx, exportID, unrelation.ConversationExportPending, map[string]any{
+		"status":      unrelation.ConversationExportFailed,
+		"err_msg":     exportErr.Error(),
+		"update_time": time.Now(),
+	})
+	return err
+}
+
+func (c *conversationExportDatabase) FailStaleConversationExports(ctx context.Context, userID string) (int64, error) {
+	return c.export.UpdateUserStale(ctx, userID, conversationExportStaleTime(), map[string]any{
+		"status":      unrelation.ConversationExportFailed,
+		"err_msg":     errConversationExportTimeout.Error(),
+		"update_time": time.Now(),
+	})
+}
+
+func (c *conversationExportDatabase) UploadConversationExport(ctx context.Context, format string, file io.ReaderAt, size int64) (string, error) {
+	partSize, err := c.s3.PartSize(ctx, size)
+	if err != nil {
+		return "", err
+	}
+	// hash the content the way cont hashes a client upload, so the same archive is stored once.
+	var partHashes []string
+	for offset := int64(0); offset < size; offset += partSize {
+		h := md5.New()
+		if _, err := io.Copy(h, io.NewSectionReader(file, offset, partSize)); err != nil {
+			return "", errs.Wrap(err)
+		}
+		partHashes = append(partHashes, hex.EncodeToString(h.Sum(nil)))
+	}
+	hash := md5.Sum([]byte(strings.Join(partHashes, ",")))
+	result, err := c.s3.PutObject(ctx, hex.EncodeToString(hash[:]), size, conversationExportContentType(format), io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return "", err
+	}
+	return result.Key, nil
+}
+
+func (c *conversationExportDatabase) ConversationExportAccessURL(ctx context.Context, export *unrelation.ConversationExportModel) (string, time.Time, error) {
+	expire := time.Duration(config.Config.ConversationExport.AccessExpire) * time.Second
+	opt := &s3.AccessURLOption{
+		ContentType: conversationExportContentType(export.Format),
+		Filename:    fmt.Sprintf("%s.%s", export.ConversationID, conversationExportExt(export.Format)),
+	}
+	expireTime := time.Now().Add(expire)
+	rawURL, err := c.s3.AccessURL(ctx, export.ObjectKey, expire, opt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return rawURL, expireTime, nil
+}
+
+// conversationExportStaleTime is the update time before which an unfinished export is past the running timeout.
+func conversationExportStaleTime() time.Time {
+	return time.Now().Add(-time.Duration(config.Config.ConversationExport.RunningTimeout) * time.Second)
+}
+
+func conversationExportExt(format string) string {
+	if format == unrelation.ConversationExportHTML {
+		return "html"
+	}
+	return "jsonl"
+}
+
+func conversationExportContentType(format string) string {
+	if format == unrelation.ConversationExportHTML {
+		return "text/html; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
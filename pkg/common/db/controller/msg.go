@@ -66,6 +66,12 @@ type CommonMsgDatabase interface {
 	GetMsgBySeqsRange(ctx context.Context, userID string, conversationID string, begin, end, num, userMaxSeq int64) (minSeq int64, maxSeq int64, seqMsg []*sdkws.MsgData, err error)
 	// 通过seqList获取大群在 mongo里面的消息
 	GetMsgBySeqs(ctx context.Context, userID string, conversationID string, seqs []int64) (minSeq int64, maxSeq int64, seqMsg []*sdkws.MsgData, err error)
+	// FindSeqBySendTime returns the first seq the user can see that was sent at or after sendTime, the max seq
+	// if every msg was sent before it, and 0 if the user can't see any msg of the conversation.
+	FindSeqBySendTime(ctx context.Context, userID string, conversationID string, sendTime int64) (int64, error)
+	// WalkMsgDocs reads the msgs between begin and end from mongo one doc at a time and calls fn with the msgs
+	// of each doc in seq order, the msgs deleted by the user are left out and a cleared doc is skipped.
+	WalkMsgDocs(ctx context.Context, userID string, conversationID string, begin, end int64, fn func(msgs []*sdkws.MsgData) error) error
 	// 删除会话消息重置最小seq， remainTime为消息保留的时间单位秒,超时消息删除， 传0删除所有消息(此方法不删除redis cache)
 	DeleteConversationMsgsAndSetMinSeq(ctx context.Context, conversationID string, remainTime int64) error
 	// 用户标记删除过期消息返回标记删除的seq列表
@@ -78,6 +84,21 @@ type CommonMsgDatabase interface {
 
 	// 修改消息
 	ModifyMsgBySeq(ctx context.Context, conversationID string, seq int64, content string) error
+	// EditMsg replaces the content of a message keeping the previous one in its edit history, false means
+	// the message was edited concurrently.
+	EditMsg(ctx context.Context, conversationID string, seq int64, content string, editorID string, editTime int64) (bool, error)
+	GetMsgEditHistory(ctx context.Context, conversationID string, seq int64) (*unrelationtb.MsgInfoModel, error)
+	// AddMsgReaction and DelMsgReaction change the reactors of emoji on a message in mongo and refresh its
+	// reaction cache, they return the reactors of every emoji afterwards. A new emoji is refused when the
+	// message has maxEmojis already, and a message not stored in mongo yet can't get reactions.
+	AddMsgReaction(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32, emoji, userID string, maxEmojis int) (map[string][]string, error)
+	DelMsgReaction(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32, emoji, userID string) (map[string][]string, error)
+	// GetMsgReactions returns the reactors of every emoji on a message.
+	GetMsgReactions(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32) (map[string][]string, error)
+	// SetMsgThread attaches the summary of a new thread to its parent message.
+	SetMsgThread(ctx context.Context, conversationID string, seq int64, summary *unrelationtb.ThreadSummaryModel) error
+	AddMsgThreadReply(ctx context.Context, conversationID string, seq int64, userID string, replyTime int64) error
+	GetMsgThreadSummary(ctx context.Context, conversationID string, seq int64) (*unrelationtb.ThreadSummaryModel, error)
 
 	SetMaxSeq(ctx context.Context, conversationID string, maxSeq int64) error
 	GetMaxSeqs(ctx context.Context, conversationIDs []string) (map[string]int64, error)
@@ -92,9 +113,16 @@ type CommonMsgDatabase interface {
 	SetConversationUserMinSeq(ctx context.Context, conversationID string, userID string, minSeq int64) error
 	SetConversationUserMinSeqs(ctx context.Context, conversationID string, seqs map[string]int64) (err error)
 	SetUserConversationsMinSeqs(ctx context.Context, userID string, seqs map[string]int64) (err error)
+	// GetUserConversationsVisibleMinSeqs returns the lowest seq the user can still see in each conversation,
+	// the greater of the conversation min seq and the user min seq.
+	GetUserConversationsVisibleMinSeqs(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error)
+	// GetExportSeqRange returns the seqs of a conversation to export, the msgs the user can still see up to
+	// conversationMaxSeq, the max seq of a user who left the group, or every msg left when userID is empty.
+	GetExportSeqRange(ctx context.Context, userID string, conversationID string, conversationMaxSeq int64) (minSeq int64, maxSeq int64, err error)
 	SetHasReadSeq(ctx context.Context, userID string, conversationID string, hasReadSeq int64) error
 	GetHasReadSeqs(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error)
 	GetHasReadSeq(ctx context.Context, userID string, conversationID string) (int64, error)
+	GetConversationUsersHasReadSeqs(ctx context.Context, conversationID string, userIDs []string) (map[string]int64, error)
 	UserSetHasReadSeqs(ctx context.Context, userID string, hasReadSeqs map[string]int64) error
 
 	GetMongoMaxAndMinSeq(ctx context.Context, conversationID string) (minSeqMongo, maxSeqMongo int64, err error)
@@ -315,6 +343,35 @@ func (db *commonMsgDatabase) BatchInsertBlock(ctx context.Context, conversationI
 	return nil
 }
 
+// updateMsgInfoOrCreateDoc runs update on the msg info at index, when the doc isn't in mongo yet it is created
+// the way BatchInsertBlock does with init applied to the msg info.
+func (db *commonMsgDatabase) updateMsgInfoOrCreateDoc(ctx context.Context, docID string, index int64, update func() (*mongo.UpdateResult, error), init func(info *unrelationtb.MsgInfoModel)) error {
+	for {
+		res, err := update()
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
+		doc := unrelationtb.MsgDocModel{
+			DocID: docID,
+			Msg:   make([]*unrelationtb.MsgInfoModel, db.msg.GetSingleGocMsgNum()),
+		}
+		for i := range doc.Msg {
+			doc.Msg[i] = &unrelationtb.MsgInfoModel{DelList: []string{}}
+		}
+		init(doc.Msg[index])
+		if err := db.msgDocDatabase.Create(ctx, &doc); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				continue // created concurrently, update it
+			}
+			return err
+		}
+		return nil
+	}
+}
+
 func (db *commonMsgDatabase) BatchInsertChat2DB(ctx context.Context, conversationID string, msgList []*sdkws.MsgData, currentMaxSeq int64) error {
 	if len(msgList) == 0 {
 		return errs.ErrArgs.Wrap("msgList is empty")
@@ -357,6 +414,7 @@ func (db *commonMsgDatabase) BatchInsertChat2DB(ctx context.Context, conversatio
 			AttachedInfo:     msg.AttachedInfo,
 			Ex:               msg.Ex,
 			RecvIDList:       msg.RecvIDList,
+			ThreadID:         msg.ThreadID,
 		}
 	}
 	return db.BatchInsertBlock(ctx, conversationID, msgs, updateKeyMsg, msgList[0].Seq)
@@ -742,6 +800,82 @@ func (db *commonMsgDatabase) GetMsgBySeqs(ctx context.Context, userID string, co
 	return minSeq, maxSeq, successMsgs, nil
 }
 
+func (db *commonMsgDatabase) FindSeqBySendTime(ctx context.Context, userID string, conversationID string, sendTime int64) (int64, error) {
+	minSeqs, err := db.GetUserConversationsVisibleMinSeqs(ctx, userID, []string{conversationID})
+	if err != nil {
+		return 0, err
+	}
+	minSeq := minSeqs[conversationID]
+	if minSeq < 1 {
+		minSeq = 1
+	}
+	maxSeq, err := db.cache.GetMaxSeq(ctx, conversationID)
+	if err != nil && errs.Unwrap(err) != redis.Nil {
+		return 0, err
+	}
+	if maxSeq < minSeq {
+		return 0, nil
+	}
+	// binary search the docs for the first one whose newest msg was sent at or after sendTime,
+	// a doc emptied by deletions counts as sent before it.
+	num := db.msg.GetSingleGocMsgNum()
+	var found []*unrelationtb.MsgSeqTimeModel
+	for begin, end := (minSeq-1)/num, (maxSeq-1)/num; begin <= end; {
+		mid := (begin + end) / 2
+		seqTimes, err := db.msgDocDatabase.GetMsgSeqTimesInOneDoc(ctx, db.msg.GetDocID(conversationID, mid*num+1))
+		if err != nil {
+			return 0, err
+		}
+		if len(seqTimes) > 0 && seqTimes[len(seqTimes)-1].SendTime >= sendTime {
+			found = seqTimes
+			end = mid - 1
+		} else {
+			begin = mid + 1
+		}
+	}
+	if found == nil {
+		return maxSeq, nil
+	}
+	for _, seqTime := range found {
+		if seqTime.Seq >= minSeq && seqTime.SendTime >= sendTime {
+			return seqTime.Seq, nil
+		}
+	}
+	return minSeq, nil
+}
+
+func (db *commonMsgDatabase) WalkMsgDocs(ctx context.Context, userID string, conversationID string, begin, end int64, fn func(msgs []*sdkws.MsgData) error) error {
+	if begin < 1 {
+		begin = 1
+	}
+	num := db.msg.GetSingleGocMsgNum()
+	for begin <= end {
+		docEnd := (begin-1)/num*num + num
+		if docEnd > end {
+			docEnd = end
+		}
+		seqs := make([]int64, 0, docEnd-begin+1)
+		for seq := begin; seq <= docEnd; seq++ {
+			seqs = append(seqs, seq)
+		}
+		msgs, err := db.findMsgInfoBySeq(ctx, userID, db.msg.GetDocID(conversationID, begin), conversationID, seqs)
+		if err != nil && errs.Unwrap(err) != mongo.ErrNoDocuments {
+			return err
+		}
+		if len(msgs) > 0 {
+			msgDatas := make([]*sdkws.MsgData, 0, len(msgs))
+			for _, msg := range msgs {
+				msgDatas = append(msgDatas, convert.MsgDB2Pb(msg.Msg))
+			}
+			if err := fn(msgDatas); err != nil {
+				return err
+			}
+		}
+		begin = docEnd + 1
+	}
+	return nil
+}
+
 func (db *commonMsgDatabase) DeleteConversationMsgsAndSetMinSeq(ctx context.Context, conversationID string, remainTime int64) error {
 	var delStruct delMsgRecursionStruct
 	var skip int64
@@ -763,6 +897,12 @@ func (db *commonMsgDatabase) DeleteConversationMsgsAndSetMinSeq(ctx context.Cont
 }
 
 func (db *commonMsgDatabase) UserMsgsDestruct(ctx context.Context, userID string, conversationID string, destructTime int64, lastMsgDestructTime time.Time) (seqs []int64, err error) {
+	// msgs below the min seq of the user, cleared or sent before the user joined a group, are not the user's to destruct.
+	currentUserMinSeq, err := db.cache.GetConversationUserMinSeq(ctx, conversationID, userID)
+	if err != nil && errs.Unwrap(err) != redis.Nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
 	var index int64
 	for {
 		// from oldest 2 newest
@@ -785,15 +925,20 @@ func (db *commonMsgDatabase) UserMsgsDestruct(ctx context.Context, userID string
 			var over bool
 			for _, msg := range msgDocModel.Msg {
 				i++
-				if msg != nil && msg.Msg != nil && msg.Msg.SendTime+destructTime*1000 <= time.Now().UnixMilli() {
-					if msg.Msg.SendTime+destructTime*1000 > lastMsgDestructTime.UnixMilli() && !utils.Contain(userID, msg.DelList...) {
-						seqs = append(seqs, msg.Msg.Seq)
-					}
-				} else {
+				if msg == nil || msg.Msg == nil {
+					continue // an empty slot or a msg deleted for everyone
+				}
+				if msg.Msg.SendTime+destructTime*1000 > now {
 					log.ZDebug(ctx, "all msg need destruct is found", "conversationID", conversationID, "userID", userID, "index", index, "stop index", i)
 					over = true
 					break
 				}
+				if msg.Msg.Seq < currentUserMinSeq {
+					continue
+				}
+				if msg.Msg.SendTime+destructTime*1000 > lastMsgDestructTime.UnixMilli() && !utils.Contain(userID, msg.DelList...) {
+					seqs = append(seqs, msg.Msg.Seq)
+				}
 			}
 			if over {
 				break
@@ -804,10 +949,6 @@ func (db *commonMsgDatabase) UserMsgsDestruct(ctx context.Context, userID string
 	log.ZDebug(ctx, "UserMsgsDestruct", "conversationID", conversationID, "userID", userID, "seqs", seqs)
 	if len(seqs) > 0 {
 		userMinSeq := seqs[len(seqs)-1] + 1
-		currentUserMinSeq, err := db.cache.GetConversationUserMinSeq(ctx, conversationID, userID)
-		if err != nil && errs.Unwrap(err) != redis.Nil {
-			return nil, err
-		}
 		if currentUserMinSeq < userMinSeq {
 			if err := db.cache.SetConversationUserMinSeq(ctx, conversationID, userID, userMinSeq); err != nil {
 				return nil, err
@@ -998,12 +1139,66 @@ func (db *commonMsgDatabase) SetUserConversationsMinSeqs(ctx context.Context, us
 	return db.cache.SetUserConversationsMinSeqs(ctx, userID, seqs)
 }
 
+func (db *commonMsgDatabase) GetUserConversationsVisibleMinSeqs(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error) {
+	minSeqs, err := db.cache.GetMinSeqs(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMinSeqs, err := db.cache.GetUserConversationsMinSeqs(ctx, userID, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	seqs := make(map[string]int64, len(conversationIDs))
+	for _, conversationID := range conversationIDs {
+		seqs[conversationID] = minSeqs[conversationID]
+		if userMinSeqs[conversationID] > seqs[conversationID] {
+			seqs[conversationID] = userMinSeqs[conversationID]
+		}
+	}
+	return seqs, nil
+}
+
+func (db *commonMsgDatabase) GetExportSeqRange(ctx context.Context, userID string, conversationID string, conversationMaxSeq int64) (int64, int64, error) {
+	var minSeq int64
+	if userID == "" {
+		conversationMinSeq, err := db.cache.GetMinSeq(ctx, conversationID)
+		if err != nil && errs.Unwrap(err) != redis.Nil {
+			return 0, 0, err
+		}
+		minSeq = conversationMinSeq
+	} else {
+		minSeqs, err := db.GetUserConversationsVisibleMinSeqs(ctx, userID, []string{conversationID})
+		if err != nil {
+			return 0, 0, err
+		}
+		minSeq = minSeqs[conversationID]
+	}
+	if minSeq < 1 {
+		minSeq = 1
+	}
+	maxSeq, err := db.cache.GetMaxSeq(ctx, conversationID)
+	if err != nil && errs.Unwrap(err) != redis.Nil {
+		return 0, 0, err
+	}
+	if userID != "" && conversationMaxSeq != 0 && maxSeq > conversationMaxSeq {
+		maxSeq = conversationMaxSeq
+	}
+	return minSeq, maxSeq, nil
+}
+
 func (db *commonMsgDatabase) UserSetHasReadSeqs(ctx context.Context, userID string, hasReadSeqs map[string]int64) error {
-	return db.cache.UserSetHasReadSeqs(ctx, userID, hasReadSeqs)
+	if err := db.cache.UserSetHasReadSeqs(ctx, userID, hasReadSeqs); err != nil {
+		return err
+	}
+	return db.cache.DelUserBadgeUnreadCountSum(ctx, userID)
 }
 
 func (db *commonMsgDatabase) SetHasReadSeq(ctx context.Context, userID string, conversationID string, hasReadSeq int64) error {
-	return db.cache.SetHasReadSeq(ctx, userID, conversationID, hasReadSeq)
+	if err := db.cache.SetHasReadSeq(ctx, userID, conversationID, hasReadSeq); err != nil {
+		return err
+	}
+	// the badge is recomputed from the seqs on the next offline push.
+	return db.cache.DelUserBadgeUnreadCountSum(ctx, userID)
 }
 
 func (db *commonMsgDatabase) GetHasReadSeqs(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error) {
@@ -1014,6 +1209,10 @@ func (db *commonMsgDatabase) GetHasReadSeq(ctx context.Context, userID string, c
 	return db.cache.GetHasReadSeq(ctx, userID, conversationID)
 }
 
+func (db *commonMsgDatabase) GetConversationUsersHasReadSeqs(ctx context.Context, conversationID string, userIDs []string) (map[string]int64, error) {
+	return db.cache.GetConversationUsersHasReadSeqs(ctx, conversationID, userIDs)
+}
+
 func (db *commonMsgDatabase) SetSendMsgStatus(ctx context.Context, id string, status int32) error {
 	return db.cache.SetSendMsgStatus(ctx, id, status)
 }
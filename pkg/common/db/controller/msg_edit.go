@@ -0,0 +1,59 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func (db *commonMsgDatabase) EditMsg(ctx context.Context, conversationID string, seq int64, content string, editorID string, editTime int64) (bool, error) {
+	docID := db.msg.GetDocID(conversationID, seq)
+	index := db.msg.GetMsgIndex(seq)
+	info, err := db.msgDocDatabase.GetMsgEditHistory(ctx, docID, index)
+	if err != nil {
+		if errs.Unwrap(err) == mongo.ErrNoDocuments {
+			// the msg is only cached until msgtransfer stores it, an edit of the cache would be
+			// overwritten then.
+			return false, errs.ErrRecordNotFound.Wrap("msg is not stored yet, retry later")
+		}
+		return false, err
+	}
+	prev := &unrelationtb.MsgEditModel{Content: info.Msg.Content, EditorID: info.EditorID, EditTime: info.EditTime}
+	if !info.Edited {
+		prev.EditorID = info.Msg.SendID
+		prev.EditTime = info.Msg.SendTime
+	}
+	res, err := db.msgDocDatabase.EditMsgContent(ctx, docID, index, prev, content, editorID, editTime)
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount == 0 {
+		return false, nil
+	}
+	// the cached message still has the old content, pulls fall back to mongo without it.
+	if err := db.cache.DeleteMessages(ctx, conversationID, []int64{seq}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (db *commonMsgDatabase) GetMsgEditHistory(ctx context.Context, conversationID string, seq int64) (*unrelationtb.MsgInfoModel, error) {
+	return db.msgDocDatabase.GetMsgEditHistory(ctx, db.msg.GetDocID(conversationID, seq), db.msg.GetMsgIndex(seq))
+}
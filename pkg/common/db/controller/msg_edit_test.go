@@ -0,0 +1,91 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// editMsgDoc is the msg doc of one message, nil when the message isn't stored yet.
+type editMsgDoc struct {
+	unrelationtb.MsgDocModelInterface
+	info *unrelationtb.MsgInfoModel
+}
+
+func (d *editMsgDoc) GetMsgEditHistory(context.Context, string, int64) (*unrelationtb.MsgInfoModel, error) {
+	if d.info == nil {
+		return nil, errs.Wrap(mongo.ErrNoDocuments)
+	}
+	return d.info, nil
+}
+
+func (d *editMsgDoc) EditMsgContent(_ context.Context, _ string, _ int64, prev *unrelationtb.MsgEditModel, newContent string, editorID string, editTime int64) (*mongo.UpdateResult, error) {
+	if d.info.Msg.Content != prev.Content {
+		return &mongo.UpdateResult{}, nil
+	}
+	d.info.Msg.Content = newContent
+	d.info.Edited, d.info.EditorID, d.info.EditTime = true, editorID, editTime
+	d.info.EditHistory = append(d.info.EditHistory, prev)
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+type editMsgCache struct {
+	cache.MsgModel
+	deleted []int64
+}
+
+func (c *editMsgCache) DeleteMessages(_ context.Context, _ string, seqs []int64) error {
+	c.deleted = append(c.deleted, seqs...)
+	return nil
+}
+
+func TestEditMsg(t *testing.T) {
+	ctx := context.Background()
+	doc := &editMsgDoc{info: &unrelationtb.MsgInfoModel{Msg: &unrelationtb.MsgDataModel{
+		SendID: "u1", SendTime: 1000, Content: `{"content":"a"}`,
+	}}}
+	msgCache := &editMsgCache{}
+	db := &commonMsgDatabase{msgDocDatabase: doc, cache: msgCache}
+
+	ok, err := db.EditMsg(ctx, "si_u1_u2", 1, `{"content":"b"}`, "u1", 2000)
+	if err != nil || !ok {
+		t.Fatalf("edit: %v %v", ok, err)
+	}
+	if len(doc.info.EditHistory) != 1 || doc.info.EditHistory[0].EditorID != "u1" || doc.info.EditHistory[0].EditTime != 1000 {
+		t.Errorf("the first version keeps the sender and send time: %+v", doc.info.EditHistory)
+	}
+	if len(msgCache.deleted) != 1 {
+		t.Errorf("the cached msg wasn't dropped: %v", msgCache.deleted)
+	}
+
+	ok, err = db.EditMsg(ctx, "si_u1_u2", 1, `{"content":"c"}`, "u1", 3000)
+	if err != nil || !ok || doc.info.EditHistory[1].EditTime != 2000 {
+		t.Errorf("second edit: %v %v %+v", ok, err, doc.info.EditHistory)
+	}
+}
+
+func TestEditMsgNotStored(t *testing.T) {
+	db := &commonMsgDatabase{msgDocDatabase: &editMsgDoc{}, cache: &editMsgCache{}}
+	if _, err := db.EditMsg(context.Background(), "si_u1_u2", 1, `{"content":"b"}`, "u1", 2000); !errs.ErrRecordNotFound.Is(err) {
+		t.Errorf("edit of a msg only in the cache: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/OpenIMSDK/tools/log"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+)
+
+const (
+	msgReactionLockKey     = "reaction"
+	msgReactionCacheExpire = time.Hour
+	msgReactionLockRetry   = 20
+	msgReactionLockWait    = 50 * time.Millisecond
+)
+
+func (db *commonMsgDatabase) AddMsgReaction(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32, emoji, userID string, maxEmojis int) (map[string][]string, error) {
+	docID := db.msg.GetDocID(conversationID, seq)
+	index := db.msg.GetMsgIndex(seq)
+	res, err := db.msgDocDatabase.AddReaction(ctx, docID, index, emoji, userID, maxEmojis)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		reactions, err := db.msgDocDatabase.GetReactions(ctx, docID, index)
+		if err != nil {
+			return nil, err
+		}
+		if len(reactions) >= maxEmojis {
+			return nil, errs.ErrArgs.Wrap("too many emojis on the message")
+		}
+		return nil, errs.ErrRecordNotFound.Wrap("message is not stored yet")
+	}
+	return db.refreshMsgReactionCache(ctx, conversationID, seq, clientMsgID, sessionType, true)
+}
+
+func (db *commonMsgDatabase) DelMsgReaction(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32, emoji, userID string) (map[string][]string, error) {
+	if err := db.msgDocDatabase.DelReaction(ctx, db.msg.GetDocID(conversationID, seq), db.msg.GetMsgIndex(seq), emoji, userID); err != nil {
+		return nil, err
+	}
+	return db.refreshMsgReactionCache(ctx, conversationID, seq, clientMsgID, sessionType, true)
+}
+
+func (db *commonMsgDatabase) GetMsgReactions(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32) (map[string][]string, error) {
+	exist, err := db.cache.JudgeMessageReactionExist(ctx, clientMsgID, sessionType)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		values, err := db.cache.GetOneMessageAllReactionList(ctx, clientMsgID, sessionType)
+		if err != nil {
+			return nil, err
+		}
+		reactions := make(map[string][]string, len(values))
+		for emoji, value := range values {
+			var userIDs []string
+			if err := json.Unmarshal([]byte(value), &userIDs); err != nil {
+				log.ZWarn(ctx, "unmarshal cached reaction failed", err, "clientMsgID", clientMsgID, "emoji", emoji)
+				return db.refreshMsgReactionCache(ctx, conversationID, seq, clientMsgID, sessionType, false)
+			}
+			reactions[emoji] = userIDs
+		}
+		return reactions, nil
+	}
+	return db.refreshMsgReactionCache(ctx, conversationID, seq, clientMsgID, sessionType, false)
+}
+
+// refreshMsgReactionCache copies the reactions of a message from mongo to its cache hash. Refreshes hold
+// the message lock so an older read never overwrites a newer one. Without wait a busy lock leaves the
+// cache alone, with wait the cache is cleared when the lock can't be had.
+func (db *commonMsgDatabase) refreshMsgReactionCache(ctx context.Context, conversationID string, seq int64, clientMsgID string, sessionType int32, wait bool) (map[string][]string, error) {
+	locked, err := db.lockMsgReaction(ctx, clientMsgID, wait)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		defer func() {
+			if err := db.cache.UnLockMessageTypeKey(ctx, clientMsgID, msgReactionLockKey); err != nil {
+				log.ZWarn(ctx, "UnLockMessageTypeKey failed", err, "clientMsgID", clientMsgID)
+			}
+		}()
+	}
+	reactions, err := db.msgDocDatabase.GetReactions(ctx, db.msg.GetDocID(conversationID, seq), db.msg.GetMsgIndex(seq))
+	if err != nil {
+		return nil, err
+	}
+	if !locked && !wait {
+		return reactions, nil
+	}
+	cached, err := db.cache.GetOneMessageAllReactionList(ctx, clientMsgID, sessionType)
+	if err != nil {
+		return nil, err
+	}
+	for emoji := range cached {
+		if _, ok := reactions[emoji]; !ok || !locked {
+			if err := db.cache.DeleteOneMessageKey(ctx, clientMsgID, sessionType, emoji); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !locked {
+		log.ZWarn(ctx, "message reaction lock busy, cache cleared", nil, "clientMsgID", clientMsgID)
+		return reactions, nil
+	}
+	for emoji, userIDs := range reactions {
+		data, err := json.Marshal(userIDs)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.cache.SetMessageTypeKeyValue(ctx, clientMsgID, sessionType, emoji, string(data)); err != nil {
+			return nil, err
+		}
+	}
+	if len(reactions) > 0 {
+		if _, err := db.cache.SetMessageReactionExpire(ctx, clientMsgID, sessionType, msgReactionCacheExpire); err != nil {
+			return nil, err
+		}
+	}
+	return reactions, nil
+}
+
+func (db *commonMsgDatabase) lockMsgReaction(ctx context.Context, clientMsgID string, wait bool) (bool, error) {
+	for i := 0; ; i++ {
+		err := db.cache.LockMessageTypeKey(ctx, clientMsgID, msgReactionLockKey)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, cache.ErrMessageTypeKeyLocked) {
+			return false, err
+		}
+		if !wait || i >= msgReactionLockRetry {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(msgReactionLockWait):
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/tools/errs"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// reactionMsgDoc keeps the reactions of the stored messages by index the way the msg doc does, other
+// methods are not used.
+type reactionMsgDoc struct {
+	unrelationtb.MsgDocModelInterface
+	stored    map[int64]bool
+	reactions map[int64]map[string][]string
+}
+
+func (d *reactionMsgDoc) AddReaction(_ context.Context, _ string, index int64, emoji string, userID string, maxEmojis int) (*mongo.UpdateResult, error) {
+	reactions := d.reactions[index]
+	if _, ok := reactions[emoji]; !d.stored[index] || (!ok && len(reactions) >= maxEmojis) {
+		return &mongo.UpdateResult{}, nil
+	}
+	if reactions == nil {
+		reactions = make(map[string][]string)
+		d.reactions[index] = reactions
+	}
+	for _, reactor := range reactions[emoji] {
+		if reactor == userID {
+			return &mongo.UpdateResult{MatchedCount: 1}, nil
+		}
+	}
+	reactions[emoji] = append(reactions[emoji], userID)
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (d *reactionMsgDoc) DelReaction(_ context.Context, _ string, index int64, emoji string, userID string) error {
+	var reactors []string
+	for _, reactor := range d.reactions[index][emoji] {
+		if reactor != userID {
+			reactors = append(reactors, reactor)
+		}
+	}
+	if len(reactors) == 0 {
+		delete(d.reactions[index], emoji)
+	} else {
+		d.reactions[index][emoji] = reactors
+	}
+	return nil
+}
+
+func (d *reactionMsgDoc) GetReactions(_ context.Context, _ string, index int64) (map[string][]string, error) {
+	reactions := make(map[string][]string, len(d.reactions[index]))
+	for emoji, userIDs := range d.reactions[index] {
+		reactions[emoji] = append([]string(nil), userIDs...)
+	}
+	return reactions, nil
+}
+
+func newReactionTestDatabase(t *testing.T) (*commonMsgDatabase, *reactionMsgDoc) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	doc := &reactionMsgDoc{stored: map[int64]bool{0: true}, reactions: make(map[int64]map[string][]string)}
+	return &commonMsgDatabase{msgDocDatabase: doc, cache: cache.NewMsgCacheModel(rdb)}, doc
+}
+
+func TestMsgReaction(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newReactionTestDatabase(t)
+	const conversationID, clientMsgID, sessionType = "sg_1", "m1", constant.SuperGroupChatType
+
+	reactions, err := db.AddMsgReaction(ctx, conversationID, 1, clientMsgID, sessionType, "a", "u1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"a": {"u1"}}; !reflect.DeepEqual(reactions, want) {
+		t.Errorf("reactions %v, want %v", reactions, want)
+	}
+	if _, err := db.AddMsgReaction(ctx, conversationID, 1, clientMsgID, sessionType, "b", "u1", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the message is full, a new emoji is refused but existing ones still take reactors.
+	if _, err := db.AddMsgReaction(ctx, conversationID, 1, clientMsgID, sessionType, "c", "u2", 2); !errs.ErrArgs.Is(err) {
+		t.Errorf("new emoji on a full message: %v", err)
+	}
+	if _, err := db.AddMsgReaction(ctx, conversationID, 1, clientMsgID, sessionType, "a", "u2", 2); err != nil {
+		t.Errorf("existing emoji on a full message: %v", err)
+	}
+
+	// reads are served from the refreshed cache.
+	reactions, err = db.GetMsgReactions(ctx, conversationID, 1, clientMsgID, sessionType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"a": {"u1", "u2"}, "b": {"u1"}}; !reflect.DeepEqual(reactions, want) {
+		t.Errorf("cached reactions %v, want %v", reactions, want)
+	}
+
+	reactions, err = db.DelMsgReaction(ctx, conversationID, 1, clientMsgID, sessionType, "b", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"a": {"u1", "u2"}}; !reflect.DeepEqual(reactions, want) {
+		t.Errorf("reactions after del %v, want %v", reactions, want)
+	}
+	if reactions, _ := db.GetMsgReactions(ctx, conversationID, 1, clientMsgID, sessionType); len(reactions) != 1 {
+		t.Errorf("the dropped emoji is still cached: %v", reactions)
+	}
+}
+
+func TestMsgReactionNotStored(t *testing.T) {
+	db, doc := newReactionTestDatabase(t)
+	_, err := db.AddMsgReaction(context.Background(), "sg_1", 2, "m2", constant.SuperGroupChatType, "a", "u1", 2)
+	if !errs.ErrRecordNotFound.Is(err) {
+		t.Errorf("reaction to a message not in mongo: %v", err)
+	}
+	if len(doc.reactions) != 0 {
+		t.Errorf("reactions stored %v", doc.reactions)
+	}
+}
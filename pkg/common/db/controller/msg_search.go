@@ -0,0 +1,87 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"github.com/OpenIMSDK/protocol/sdkws"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+	"github.com/openimsdk/open-im-server/v3/pkg/msgprocessor"
+)
+
+type MsgSearchDatabase interface {
+	// IndexMsgs indexes the searchable text of the msgs, msgs without any are skipped. The revoke and delete
+	// notifications among them are applied to the msgs they name.
+	IndexMsgs(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error
+	// DeleteMsgs removes the msgs from the index, DeleteUserMsgs hides them from the user only.
+	DeleteMsgs(ctx context.Context, conversationID string, seqs []int64) error
+	DeleteUserMsgs(ctx context.Context, userID string, conversationID string, seqs []int64) error
+	SearchMsgs(ctx context.Context, cond *unrelation.MsgSearchCond, pageNumber, showNumber int32) ([]*unrelation.MsgSearchModel, bool, error)
+}
+
+type msgSearchDatabase struct {
+	msgSearch unrelation.MsgSearchModelInterface
+}
+
+func NewMsgSearchDatabase(msgSearch unrelation.MsgSearchModelInterface) MsgSearchDatabase {
+	return &msgSearchDatabase{msgSearch: msgSearch}
+}
+
+func (m *msgSearchDatabase) IndexMsgs(ctx context.Context, conversationID string, msgs []*sdkws.MsgData) error {
+	models := make([]*unrelation.MsgSearchModel, 0, len(msgs))
+	for _, msg := range msgs {
+		if change := msgprocessor.GetSearchIndexChange(msg); change != nil {
+			if err := m.applyChange(ctx, change); err != nil {
+				return err
+			}
+			continue
+		}
+		text := msgprocessor.GetSearchText(msg.ContentType, msg.Content)
+		if text == "" {
+			continue
+		}
+		models = append(models, &unrelation.MsgSearchModel{
+			ConversationID: conversationID,
+			Seq:            msg.Seq,
+			SendID:         msg.SendID,
+			ContentType:    msg.ContentType,
+			Text:           text,
+			Tokens:         msgprocessor.GetSearchTokens(text),
+			SendTime:       msg.SendTime,
+		})
+	}
+	return m.msgSearch.Upsert(ctx, models)
+}
+
+func (m *msgSearchDatabase) applyChange(ctx context.Context, change *msgprocessor.SearchIndexChange) error {
+	if change.UserID != "" {
+		return m.DeleteUserMsgs(ctx, change.UserID, change.ConversationID, change.Seqs)
+	}
+	return m.DeleteMsgs(ctx, change.ConversationID, change.Seqs)
+}
+
+func (m *msgSearchDatabase) DeleteMsgs(ctx context.Context, conversationID string, seqs []int64) error {
+	return m.msgSearch.Delete(ctx, conversationID, seqs)
+}
+
+func (m *msgSearchDatabase) DeleteUserMsgs(ctx context.Context, userID string, conversationID string, seqs []int64) error {
+	return m.msgSearch.AddDelUser(ctx, conversationID, seqs, userID)
+}
+
+func (m *msgSearchDatabase) SearchMsgs(ctx context.Context, cond *unrelation.MsgSearchCond, pageNumber, showNumber int32) ([]*unrelation.MsgSearchModel, bool, error) {
+	return m.msgSearch.Search(ctx, cond, pageNumber, showNumber)
+}
@@ -0,0 +1,41 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	unrelationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func (db *commonMsgDatabase) SetMsgThread(ctx context.Context, conversationID string, seq int64, summary *unrelationtb.ThreadSummaryModel) error {
+	docID := db.msg.GetDocID(conversationID, seq)
+	index := db.msg.GetMsgIndex(seq)
+	return db.updateMsgInfoOrCreateDoc(ctx, docID, index, func() (*mongo.UpdateResult, error) {
+		return db.msgDocDatabase.UpdateMsg(ctx, docID, index, "thread", summary)
+	}, func(info *unrelationtb.MsgInfoModel) {
+		info.Thread = summary
+	})
+}
+
+func (db *commonMsgDatabase) AddMsgThreadReply(ctx context.Context, conversationID string, seq int64, userID string, replyTime int64) error {
+	return db.msgDocDatabase.AddThreadReply(ctx, db.msg.GetDocID(conversationID, seq), db.msg.GetMsgIndex(seq), userID, replyTime)
+}
+
+func (db *commonMsgDatabase) GetMsgThreadSummary(ctx context.Context, conversationID string, seq int64) (*unrelationtb.ThreadSummaryModel, error) {
+	return db.msgDocDatabase.GetThreadSummary(ctx, db.msg.GetDocID(conversationID, seq), db.msg.GetMsgIndex(seq))
+}
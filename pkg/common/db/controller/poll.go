@@ -0,0 +1,101 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+type PollDatabase interface {
+	CreatePoll(ctx context.Context, poll *unrelation.PollModel) error
+	TakePoll(ctx context.Context, pollID string) (*unrelation.PollModel, error)
+	BindPollMsg(ctx context.Context, pollID string, serverMsgID string) error
+	// VotePoll replaces the options the user voted for, no options unvotes. The vote is counted in redis
+	// first and then persisted, it returns false when the poll is closed.
+	VotePoll(ctx context.Context, pollID string, userID string, optionIDs []string) (bool, error)
+	// ClosePoll stops the votes and closes the poll, it returns whether the poll was still open.
+	ClosePoll(ctx context.Context, pollID string) (bool, error)
+	TakePollVote(ctx context.Context, pollID string, userID string) (*unrelation.PollVoteModel, error)
+	FindPollVotes(ctx context.Context, pollID string) ([]*unrelation.PollVoteModel, error)
+}
+
+type pollDatabase struct {
+	poll  unrelation.PollModelInterface
+	cache cache.PollCache
+}
+
+func NewPollDatabase(poll unrelation.PollModelInterface, cache cache.PollCache) PollDatabase {
+	return &pollDatabase{poll: poll, cache: cache}
+}
+
+func (p *pollDatabase) CreatePoll(ctx context.Context, poll *unrelation.PollModel) error {
+	optionIDs := make([]string, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		optionIDs = append(optionIDs, option.OptionID)
+	}
+	if err := p.cache.InitPoll(ctx, poll.PollID, optionIDs); err != nil {
+		return err
+	}
+	return p.poll.Create(ctx, poll)
+}
+
+func (p *pollDatabase) TakePoll(ctx context.Context, pollID string) (*unrelation.PollModel, error) {
+	return p.poll.Take(ctx, pollID)
+}
+
+func (p *pollDatabase) BindPollMsg(ctx context.Context, pollID string, serverMsgID string) error {
+	return p.poll.UpdateMsg(ctx, pollID, serverMsgID)
+}
+
+func (p *pollDatabase) VotePoll(ctx context.Context, pollID string, userID string, optionIDs []string) (bool, error) {
+	count, err := p.cache.VotePoll(ctx, pollID, userID, optionIDs)
+	if err != nil {
+		return false, err
+	}
+	if count == nil {
+		return false, nil
+	}
+	if len(optionIDs) == 0 {
+		err = p.poll.DeleteVote(ctx, pollID, userID)
+	} else {
+		err = p.poll.UpsertVote(ctx, &unrelation.PollVoteModel{PollID: pollID, UserID: userID, OptionIDs: optionIDs, VoteTime: time.Now()})
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := p.poll.UpdateCounts(ctx, pollID, count.Counts, count.VoterCount, count.Version); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *pollDatabase) ClosePoll(ctx context.Context, pollID string) (bool, error) {
+	if err := p.cache.ClosePoll(ctx, pollID); err != nil {
+		return false, err
+	}
+	return p.poll.Close(ctx, pollID)
+}
+
+func (p *pollDatabase) TakePollVote(ctx context.Context, pollID string, userID string) (*unrelation.PollVoteModel, error) {
+	return p.poll.TakeVote(ctx, pollID, userID)
+}
+
+func (p *pollDatabase) FindPollVotes(ctx context.Context, pollID string) ([]*unrelation.PollVoteModel, error) {
+	return p.poll.FindVotes(ctx, pollID)
+}
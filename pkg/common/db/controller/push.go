@@ -16,22 +16,132 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
 )
 
 type PushDatabase interface {
 	DelFcmToken(ctx context.Context, userID string, platformID int) error
+	// GetUsersGatewayNodes returns the gateway nodes holding connections of each user.
+	GetUsersGatewayNodes(ctx context.Context, userIDs []string) (map[string][]string, error)
+	// GetAliveGatewayNodes returns which of nodes maintain the presence registry.
+	GetAliveGatewayNodes(ctx context.Context, nodes []string) (map[string]bool, error)
+	// AddPushRetryJob schedules a failed offline push to be attempted again at due.
+	AddPushRetryJob(ctx context.Context, jobID string, job string, due time.Time) error
+	// LeaseDuePushRetryJobs leases at most count offline pushes due at now by jobID, the ones not acked
+	// or scheduled again within lease are returned again.
+	LeaseDuePushRetryJobs(ctx context.Context, now time.Time, count int, lease time.Duration) (map[string]string, error)
+	// AckPushRetryJob drops a leased offline push once it was handled.
+	AckPushRetryJob(ctx context.Context, jobID string) error
+	// AddPushDeadLetter keeps an offline push that is not retried anymore, for expire.
+	AddPushDeadLetter(ctx context.Context, jobID string, job string, failTime time.Time, expire time.Duration) error
+	GetPushDeadLetters(ctx context.Context, offset int, count int) (int64, []string, error)
+	// FindPushDeadLetters returns the dead letters of jobIDs that exist by jobID.
+	FindPushDeadLetters(ctx context.Context, jobIDs []string) (map[string]string, error)
+	// ReplayPushDeadLetters schedules the given dead letters at due and removes them at once,
+	// it returns the jobIDs replayed.
+	ReplayPushDeadLetters(ctx context.Context, jobs map[string]string, due time.Time) ([]string, error)
+	// IncrUsersBadge adds one to the cached badges of userIDs and returns them, users without one are left out.
+	IncrUsersBadge(ctx context.Context, userIDs []string) (map[string]int, error)
+	// SetUsersBadge caches badges computed from the seqs of the users for expire.
+	SetUsersBadge(ctx context.Context, badges map[string]int, expire time.Duration) error
+	// DelUsersBadge drops the cached badges of userIDs, they are computed from the seqs again.
+	DelUsersBadge(ctx context.Context, userIDs []string) error
+	// CountUsersBadge adds one to the badge counter of each user, creating it if missing, and returns the
+	// counters written before the first failure.
+	CountUsersBadge(ctx context.Context, userIDs []string) (map[string]int, error)
+	// AddQuietDigest counts a push held back by quiet hours for each user until due.
+	AddQuietDigest(ctx context.Context, due map[string]time.Time) error
+	// PopDueQuietDigests takes at most count users whose quiet hours ended at now, with their held back push counts.
+	PopDueQuietDigests(ctx context.Context, now time.Time, count int) (map[string]int64, error)
+	// RestoreQuietDigests puts back popped digests that could not be pushed, due at due or later.
+	RestoreQuietDigests(ctx context.Context, digests map[string]int64, due time.Time) error
 }
 
 type pushDataBase struct {
-	cache cache.MsgModel
+	cache    cache.MsgModel
+	presence cache.PresenceCache
+	retry    cache.PushRetryCache
+	digest   cache.QuietDigestCache
 }
 
-func NewPushDatabase(cache cache.MsgModel) PushDatabase {
-	return &pushDataBase{cache: cache}
+func NewPushDatabase(cache cache.MsgModel, presence cache.PresenceCache, retry cache.PushRetryCache, digest cache.QuietDigestCache) PushDatabase {
+	return &pushDataBase{cache: cache, presence: presence, retry: retry, digest: digest}
 }
 
 func (p *pushDataBase) DelFcmToken(ctx context.Context, userID string, platformID int) error {
 	return p.cache.DelFcmToken(ctx, userID, platformID)
 }
+
+func (p *pushDataBase) GetUsersGatewayNodes(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	return p.presence.GetUsersNodes(ctx, userIDs)
+}
+
+func (p *pushDataBase) GetAliveGatewayNodes(ctx context.Context, nodes []string) (map[string]bool, error) {
+	return p.presence.GetAliveNodes(ctx, nodes)
+}
+
+func (p *pushDataBase) AddPushRetryJob(ctx context.Context, jobID string, job string, due time.Time) error {
+	return p.retry.AddRetryJob(ctx, jobID, job, due)
+}
+
+func (p *pushDataBase) LeaseDuePushRetryJobs(ctx context.Context, now time.Time, count int, lease time.Duration) (map[string]string, error) {
+	return p.retry.LeaseDueRetryJobs(ctx, now, count, lease)
+}
+
+func (p *pushDataBase) AckPushRetryJob(ctx context.Context, jobID string) error {
+	return p.retry.AckRetryJob(ctx, jobID)
+}
+
+func (p *pushDataBase) AddPushDeadLetter(ctx context.Context, jobID string, job string, failTime time.Time, expire time.Duration) error {
+	return p.retry.AddDeadLetter(ctx, jobID, job, failTime, expire)
+}
+
+func (p *pushDataBase) GetPushDeadLetters(ctx context.Context, offset int, count int) (int64, []string, error) {
+	return p.retry.GetDeadLetters(ctx, offset, count)
+}
+
+func (p *pushDataBase) FindPushDeadLetters(ctx context.Context, jobIDs []string) (map[string]string, error) {
+	return p.retry.FindDeadLetters(ctx, jobIDs)
+}
+
+func (p *pushDataBase) ReplayPushDeadLetters(ctx context.Context, jobs map[string]string, due time.Time) ([]string, error) {
+	return p.retry.ReplayDeadLetters(ctx, jobs, due)
+}
+
+func (p *pushDataBase) AddQuietDigest(ctx context.Context, due map[string]time.Time) error {
+	return p.digest.AddQuietDigest(ctx, due)
+}
+
+func (p *pushDataBase) PopDueQuietDigests(ctx context.Context, now time.Time, count int) (map[string]int64, error) {
+	return p.digest.PopDueQuietDigests(ctx, now, count)
+}
+
+func (p *pushDataBase) RestoreQuietDigests(ctx context.Context, digests map[string]int64, due time.Time) error {
+	return p.digest.RestoreQuietDigests(ctx, digests, due)
+}
+
+func (p *pushDataBase) IncrUsersBadge(ctx context.Context, userIDs []string) (map[string]int, error) {
+	return p.cache.IncrUsersBadgeUnreadCountSum(ctx, userIDs)
+}
+
+func (p *pushDataBase) CountUsersBadge(ctx context.Context, userIDs []string) (map[string]int, error) {
+	badges := make(map[string]int, len(userIDs))
+	for _, userID := range userIDs {
+		badge, err := p.cache.IncrUserBadgeUnreadCountSum(ctx, userID)
+		if err != nil {
+			return badges, err
+		}
+		badges[userID] = badge
+	}
+	return badges, nil
+}
+
+func (p *pushDataBase) SetUsersBadge(ctx context.Context, badges map[string]int, expire time.Duration) error {
+	return p.cache.SetUsersBadgeUnreadCountSum(ctx, badges, expire)
+}
+
+func (p *pushDataBase) DelUsersBadge(ctx context.Context, userIDs []string) error {
+	return p.cache.DelUserBadgeUnreadCountSum(ctx, userIDs...)
+}
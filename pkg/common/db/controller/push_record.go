@@ -0,0 +1,102 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/utils"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+const defaultPushRecordExpire = 30 * 24 * time.Hour
+
+// PushRecordExpire is how long push records are kept, the push and msg services create the TTL index
+// with it.
+func PushRecordExpire() time.Duration {
+	if expire := config.Config.Push.Analytics.Expire; expire > 0 {
+		return time.Duration(expire) * 24 * time.Hour
+	}
+	return defaultPushRecordExpire
+}
+
+type PushRecordDatabase interface {
+	// AddPushRecords stores every attempt, the other events are stored once per message, user and platform.
+	AddPushRecords(ctx context.Context, records []*unrelation.PushRecordModel) error
+	// AckPushes stores the delivered or opened acks of a device, acks of messages that were never pushed
+	// to the user are dropped.
+	AckPushes(ctx context.Context, userID string, platformID int32, event int32, clientMsgIDs []string) error
+	GetPushFunnels(ctx context.Context, start, end time.Time) ([]*unrelation.PushFunnel, error)
+	GetPushProviderStats(ctx context.Context, start, end time.Time) ([]*unrelation.PushProviderStat, error)
+}
+
+type pushRecordDatabase struct {
+	pushRecord unrelation.PushRecordModelInterface
+}
+
+func NewPushRecordDatabase(pushRecord unrelation.PushRecordModelInterface) PushRecordDatabase {
+	return &pushRecordDatabase{pushRecord: pushRecord}
+}
+
+func (p *pushRecordDatabase) AddPushRecords(ctx context.Context, records []*unrelation.PushRecordModel) error {
+	var attempts, events []*unrelation.PushRecordModel
+	for _, record := range records {
+		if record.Event == unrelation.PushEventAttempt {
+			attempts = append(attempts, record)
+		} else {
+			events = append(events, record)
+		}
+	}
+	if err := p.pushRecord.Create(ctx, attempts); err != nil {
+		return err
+	}
+	return p.pushRecord.CreateOnce(ctx, events)
+}
+
+func (p *pushRecordDatabase) AckPushes(ctx context.Context, userID string, platformID int32, event int32, clientMsgIDs []string) error {
+	clientMsgIDs = utils.Distinct(clientMsgIDs)
+	contentTypes, err := p.pushRecord.FindSentContentTypes(ctx, userID, clientMsgIDs)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	records := make([]*unrelation.PushRecordModel, 0, len(contentTypes))
+	for _, clientMsgID := range clientMsgIDs {
+		contentType, ok := contentTypes[clientMsgID]
+		if !ok {
+			continue
+		}
+		records = append(records, &unrelation.PushRecordModel{
+			Event:       event,
+			ClientMsgID: clientMsgID,
+			UserID:      userID,
+			PlatformID:  platformID,
+			ContentType: contentType,
+			CreateTime:  now,
+		})
+	}
+	return p.pushRecord.CreateOnce(ctx, records)
+}
+
+func (p *pushRecordDatabase) GetPushFunnels(ctx context.Context, start, end time.Time) ([]*unrelation.PushFunnel, error) {
+	return p.pushRecord.Funnel(ctx, start, end)
+}
+
+func (p *pushRecordDatabase) GetPushProviderStats(ctx context.Context, start, end time.Time) ([]*unrelation.PushProviderStat, error) {
+	return p.pushRecord.ProviderStats(ctx, start, end)
+}
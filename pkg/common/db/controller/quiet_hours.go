@@ -0,0 +1,62 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	dbimpl "github.com/openimsdk/open-im-server/v3/pkg/common/db/relation"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
+)
+
+type QuietHoursDatabase interface {
+	// SetQuietHours creates or replaces the quiet hours of a user for one target.
+	SetQuietHours(ctx context.Context, quietHours *relation.PushQuietHoursModel) error
+	DelQuietHours(ctx context.Context, userID string, targetType int32, targetID string) error
+	GetQuietHours(ctx context.Context, userID string) ([]*relation.PushQuietHoursModel, error)
+	// FindPushQuietHours returns the quiet hours of userIDs that may apply to a push in conversationID of serverID.
+	FindPushQuietHours(ctx context.Context, userIDs []string, conversationID string, serverID string) ([]*relation.PushQuietHoursModel, error)
+}
+
+type quietHoursDatabase struct {
+	quietHoursDB relation.PushQuietHoursModelInterface
+}
+
+func NewQuietHoursDatabase(db *gorm.DB) QuietHoursDatabase {
+	return &quietHoursDatabase{quietHoursDB: dbimpl.NewPushQuietHoursGorm(db)}
+}
+
+func (q *quietHoursDatabase) SetQuietHours(ctx context.Context, quietHours *relation.PushQuietHoursModel) error {
+	quietHours.CreateTime = time.Now()
+	return q.quietHoursDB.Upsert(ctx, quietHours)
+}
+
+func (q *quietHoursDatabase) DelQuietHours(ctx context.Context, userID string, targetType int32, targetID string) error {
+	return q.quietHoursDB.Delete(ctx, userID, targetType, targetID)
+}
+
+func (q *quietHoursDatabase) GetQuietHours(ctx context.Context, userID string) ([]*relation.PushQuietHoursModel, error) {
+	return q.quietHoursDB.Find(ctx, userID)
+}
+
+func (q *quietHoursDatabase) FindPushQuietHours(ctx context.Context, userIDs []string, conversationID string, serverID string) ([]*relation.PushQuietHoursModel, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	return q.quietHoursDB.FindForPush(ctx, userIDs, conversationID, serverID)
+}
@@ -16,12 +16,17 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"time"
 
+	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/cache"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/cont"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/cos"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/minio"
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/s3/oss"
 	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
 	"github.com/redis/go-redis/v9"
 )
@@ -36,6 +41,20 @@ type S3Database interface {
 	SetObject(ctx context.Context, info *relation.ObjectModel) error
 }
 
+// NewS3 returns the object storage the config enables.
+func NewS3(rdb redis.UniversalClient) (s3.Interface, error) {
+	switch enable := config.Config.Object.Enable; enable {
+	case "minio":
+		return minio.NewMinio(cache.NewMinioCache(rdb))
+	case "cos":
+		return cos.NewCos()
+	case "oss":
+		return oss.NewOSS()
+	default:
+		return nil, fmt.Errorf("invalid object enable: %s", enable)
+	}
+}
+
 func NewS3Database(rdb redis.UniversalClient, s3 s3.Interface, obj relation.ObjectInfoModelInterface) S3Database {
 	return &s3Database{
 		s3:    cont.New(cache.NewS3Cache(rdb, s3), s3),
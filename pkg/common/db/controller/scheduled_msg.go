@@ -0,0 +1,90 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+type ScheduledMsgDatabase interface {
+	CreateScheduledMsg(ctx context.Context, msg *unrelation.ScheduledMsgModel) error
+	TakeScheduledMsg(ctx context.Context, scheduleID string) (*unrelation.ScheduledMsgModel, error)
+	CountUserPendingScheduledMsgs(ctx context.Context, sendID string) (int64, error)
+	PageUserScheduledMsgs(ctx context.Context, sendID string, statuses []int32, pageNumber, showNumber int32) (int64, []*unrelation.ScheduledMsgModel, error)
+	// UpdatePendingScheduledMsg replaces the msg and the schedule time of a pending scheduled msg, a nil msg or
+	// a zero schedule time is left unchanged. It returns whether the scheduled msg was still pending.
+	UpdatePendingScheduledMsg(ctx context.Context, scheduleID string, msg *unrelation.MsgDataModel, scheduleTime time.Time) (bool, error)
+	CancelScheduledMsg(ctx context.Context, scheduleID string) (bool, error)
+	// StartSendingScheduledMsg claims a pending scheduled msg for sending, only one caller gets true.
+	StartSendingScheduledMsg(ctx context.Context, scheduleID string) (bool, error)
+	// FinishScheduledMsg records the result of sending a claimed scheduled msg.
+	FinishScheduledMsg(ctx context.Context, scheduleID string, serverMsgID string, sendErr error) error
+}
+
+type scheduledMsgDatabase struct {
+	scheduledMsg unrelation.ScheduledMsgModelInterface
+}
+
+func NewScheduledMsgDatabase(scheduledMsg unrelation.ScheduledMsgModelInterface) ScheduledMsgDatabase {
+	return &scheduledMsgDatabase{scheduledMsg: scheduledMsg}
+}
+
+func (s *scheduledMsgDatabase) CreateScheduledMsg(ctx context.Context, msg *unrelation.ScheduledMsgModel) error {
+	return s.scheduledMsg.Create(ctx, msg)
+}
+
+func (s *scheduledMsgDatabase) TakeScheduledMsg(ctx context.Context, scheduleID string) (*unrelation.ScheduledMsgModel, error) {
+	return s.scheduledMsg.Take(ctx, scheduleID)
+}
+
+func (s *scheduledMsgDatabase) CountUserPendingScheduledMsgs(ctx context.Context, sendID string) (int64, error) {
+	return s.scheduledMsg.CountUserPending(ctx, sendID)
+}
+
+func (s *scheduledMsgDatabase) PageUserScheduledMsgs(ctx context.Context, sendID string, statuses []int32, pageNumber, showNumber int32) (int64, []*unrelation.ScheduledMsgModel, error) {
+	return s.scheduledMsg.PageUser(ctx, sendID, statuses, pageNumber, showNumber)
+}
+
+func (s *scheduledMsgDatabase) UpdatePendingScheduledMsg(ctx context.Context, scheduleID string, msg *unrelation.MsgDataModel, scheduleTime time.Time) (bool, error) {
+	args := map[string]any{"update_time": time.Now()}
+	if msg != nil {
+		args["msg"] = msg
+	}
+	if !scheduleTime.IsZero() {
+		args["schedule_time"] = scheduleTime
+	}
+	return s.scheduledMsg.UpdatePending(ctx, scheduleID, args)
+}
+
+func (s *scheduledMsgDatabase) CancelScheduledMsg(ctx context.Context, scheduleID string) (bool, error) {
+	return s.scheduledMsg.UpdatePending(ctx, scheduleID, map[string]any{"status": unrelation.ScheduledMsgCanceled, "update_time": time.Now()})
+}
+
+func (s *scheduledMsgDatabase) StartSendingScheduledMsg(ctx context.Context, scheduleID string) (bool, error) {
+	return s.scheduledMsg.UpdatePending(ctx, scheduleID, map[string]any{"status": unrelation.ScheduledMsgSending, "update_time": time.Now()})
+}
+
+func (s *scheduledMsgDatabase) FinishScheduledMsg(ctx context.Context, scheduleID string, serverMsgID string, sendErr error) error {
+	args := map[string]any{"status": unrelation.ScheduledMsgSent, "server_msg_id": serverMsgID, "update_time": time.Now()}
+	if sendErr != nil {
+		args["status"] = unrelation.ScheduledMsgFailed
+		args["err_msg"] = sendErr.Error()
+	}
+	_, err := s.scheduledMsg.UpdateStatus(ctx, scheduleID, unrelation.ScheduledMsgSending, args)
+	return err
+}
@@ -27,6 +27,11 @@ import (
 
 type ThirdDatabase interface {
 	FcmUpdateToken(ctx context.Context, account string, platformID int, fcmToken string, expireTime int64) error
+	APNsUpdateToken(ctx context.Context, account string, apnsToken string, expireTime int64) error
+	VoIPUpdateToken(ctx context.Context, account string, voipToken string, expireTime int64) error
+	WebPushSubscribe(ctx context.Context, account string, subscription *cache.WebPushSubscription, expireTime int64) error
+	WebPushUnsubscribe(ctx context.Context, account string, endpoint string) error
+	VendorUpdateToken(ctx context.Context, account string, platformID int, token *cache.VendorPushToken, expireTime int64) error
 	SetAppBadge(ctx context.Context, userID string, value int) error
 	// about log for debug
 	UploadLogs(ctx context.Context, logs []*relation.Log) error
@@ -81,6 +86,26 @@ func (t *thirdDatabase) FcmUpdateToken(
 	return t.cache.SetFcmToken(ctx, account, platformID, fcmToken, expireTime)
 }
 
+func (t *thirdDatabase) APNsUpdateToken(ctx context.Context, account string, apnsToken string, expireTime int64) error {
+	return t.cache.SetAPNsToken(ctx, account, apnsToken, expireTime)
+}
+
+func (t *thirdDatabase) VoIPUpdateToken(ctx context.Context, account string, voipToken string, expireTime int64) error {
+	return t.cache.SetVoIPToken(ctx, account, voipToken, expireTime)
+}
+
+func (t *thirdDatabase) WebPushSubscribe(ctx context.Context, account string, subscription *cache.WebPushSubscription, expireTime int64) error {
+	return t.cache.SetWebPushSubscription(ctx, account, subscription, expireTime)
+}
+
+func (t *thirdDatabase) WebPushUnsubscribe(ctx context.Context, account string, endpoint string) error {
+	return t.cache.DelWebPushSubscription(ctx, account, endpoint)
+}
+
+func (t *thirdDatabase) VendorUpdateToken(ctx context.Context, account string, platformID int, token *cache.VendorPushToken, expireTime int64) error {
+	return t.cache.SetVendorPushToken(ctx, account, platformID, token, expireTime)
+}
+
 func (t *thirdDatabase) SetAppBadge(ctx context.Context, userID string, value int) error {
 	return t.cache.SetUserBadgeUnreadCountSum(ctx, userID, value)
 }
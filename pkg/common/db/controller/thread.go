@@ -0,0 +1,129 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+type ThreadDatabase interface {
+	CreateThread(ctx context.Context, thread *unrelation.ThreadModel) error
+	TakeThread(ctx context.Context, threadID string) (*unrelation.ThreadModel, error)
+	FindThreads(ctx context.Context, threadIDs []string) ([]*unrelation.ThreadModel, error)
+	PageGroupThreads(ctx context.Context, groupID string, archived bool, pageNumber, showNumber int32) (int64, []*unrelation.ThreadModel, error)
+	// TouchThread keeps the thread active after a reply, it returns whether the thread was archived.
+	TouchThread(ctx context.Context, threadID string, activeTime time.Time) (bool, error)
+	// SetThreadArchived archives the thread or unarchives it as active at now.
+	SetThreadArchived(ctx context.Context, thread *unrelation.ThreadModel, archived bool, now time.Time) error
+	FindInactiveThreads(ctx context.Context, now time.Time, limit int64) ([]*unrelation.ThreadModel, error)
+	ArchiveInactiveThread(ctx context.Context, threadID string, now time.Time) (bool, error)
+
+	SubscribeThread(ctx context.Context, threadID string, userID string) error
+	UnsubscribeThread(ctx context.Context, threadID string, userID string) error
+	GetThreadSubscriberIDs(ctx context.Context, threadID string) ([]string, error)
+	PageUserSubscribedThreads(ctx context.Context, userID string, pageNumber, showNumber int32) (int64, []*unrelation.ThreadModel, error)
+}
+
+type threadDatabase struct {
+	thread unrelation.ThreadModelInterface
+}
+
+func NewThreadDatabase(thread unrelation.ThreadModelInterface) ThreadDatabase {
+	return &threadDatabase{thread: thread}
+}
+
+func (t *threadDatabase) CreateThread(ctx context.Context, thread *unrelation.ThreadModel) error {
+	if err := t.thread.Create(ctx, thread); err != nil {
+		return err
+	}
+	return t.thread.Subscribe(ctx, thread.ThreadID, thread.CreatorUserID)
+}
+
+func (t *threadDatabase) TakeThread(ctx context.Context, threadID string) (*unrelation.ThreadModel, error) {
+	return t.thread.Take(ctx, threadID)
+}
+
+func (t *threadDatabase) FindThreads(ctx context.Context, threadIDs []string) ([]*unrelation.ThreadModel, error) {
+	return t.thread.Find(ctx, threadIDs)
+}
+
+func (t *threadDatabase) PageGroupThreads(ctx context.Context, groupID string, archived bool, pageNumber, showNumber int32) (int64, []*unrelation.ThreadModel, error) {
+	return t.thread.PageGroupThreads(ctx, groupID, archived, pageNumber, showNumber)
+}
+
+func (t *threadDatabase) TouchThread(ctx context.Context, threadID string, activeTime time.Time) (bool, error) {
+	thread, err := t.thread.Touch(ctx, threadID, activeTime)
+	if err != nil {
+		return false, err
+	}
+	return thread.Archived, nil
+}
+
+func (t *threadDatabase) SetThreadArchived(ctx context.Context, thread *unrelation.ThreadModel, archived bool, now time.Time) error {
+	if archived {
+		return t.thread.UpdateByMap(ctx, thread.ThreadID, map[string]any{"archived": true, "archive_time": now})
+	}
+	_, err := t.thread.Touch(ctx, thread.ThreadID, now)
+	return err
+}
+
+func (t *threadDatabase) FindInactiveThreads(ctx context.Context, now time.Time, limit int64) ([]*unrelation.ThreadModel, error) {
+	return t.thread.FindInactive(ctx, now, limit)
+}
+
+func (t *threadDatabase) ArchiveInactiveThread(ctx context.Context, threadID string, now time.Time) (bool, error) {
+	return t.thread.ArchiveInactive(ctx, threadID, now)
+}
+
+func (t *threadDatabase) SubscribeThread(ctx context.Context, threadID string, userID string) error {
+	return t.thread.Subscribe(ctx, threadID, userID)
+}
+
+func (t *threadDatabase) UnsubscribeThread(ctx context.Context, threadID string, userID string) error {
+	return t.thread.Unsubscribe(ctx, threadID, userID)
+}
+
+func (t *threadDatabase) GetThreadSubscriberIDs(ctx context.Context, threadID string) ([]string, error) {
+	return t.thread.FindSubscriberIDs(ctx, threadID)
+}
+
+func (t *threadDatabase) PageUserSubscribedThreads(ctx context.Context, userID string, pageNumber, showNumber int32) (int64, []*unrelation.ThreadModel, error) {
+	total, threadIDs, err := t.thread.PageUserSubscriptions(ctx, userID, pageNumber, showNumber)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(threadIDs) == 0 {
+		return total, nil, nil
+	}
+	threads, err := t.thread.Find(ctx, threadIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+	// keep the subscription order.
+	threadMap := make(map[string]*unrelation.ThreadModel, len(threads))
+	for _, thread := range threads {
+		threadMap[thread.ThreadID] = thread
+	}
+	sorted := make([]*unrelation.ThreadModel, 0, len(threads))
+	for _, threadID := range threadIDs {
+		if thread, ok := threadMap[threadID]; ok {
+			sorted = append(sorted, thread)
+		}
+	}
+	return total, sorted, nil
+}
@@ -16,6 +16,7 @@ package relation
 
 import (
 	"context"
+	"time"
 
 	"github.com/OpenIMSDK/tools/errs"
 	"gorm.io/gorm"
@@ -52,10 +53,26 @@ func (c *ConversationGorm) UpdateByMap(
 	conversationID string,
 	args map[string]interface{},
 ) (rows int64, err error) {
-	result := c.db(ctx).Where("owner_user_id IN (?) and  conversation_id=?", userIDList, conversationID).Updates(args)
+	result := c.db(ctx).Where("owner_user_id IN (?) and  conversation_id=?", userIDList, conversationID).Updates(withMsgDestructDueTime(args))
 	return result.RowsAffected, utils.Wrap(result.Error, "")
 }
 
+// withMsgDestructDueTime keeps msg_destruct_due_time in step when the destruct time or the latest destruct time
+// changes. The new values are used, the columns may be assigned after msg_destruct_due_time.
+func withMsgDestructDueTime(args map[string]interface{}) map[string]interface{} {
+	destructTime, setDestructTime := args["msg_destruct_time"]
+	latestTime, setLatestTime := args["latest_msg_destruct_time"]
+	switch {
+	case setDestructTime && setLatestTime:
+		args["msg_destruct_due_time"] = gorm.Expr("DATE_ADD(?, INTERVAL ? SECOND)", latestTime, destructTime)
+	case setDestructTime:
+		args["msg_destruct_due_time"] = gorm.Expr("DATE_ADD(latest_msg_destruct_time, INTERVAL ? SECOND)", destructTime)
+	case setLatestTime:
+		args["msg_destruct_due_time"] = gorm.Expr("DATE_ADD(?, INTERVAL msg_destruct_time SECOND)", latestTime)
+	}
+	return args
+}
+
 func (c *ConversationGorm) Update(ctx context.Context, conversation *relation.ConversationModel) (err error) {
 	return utils.Wrap(
 		c.db(ctx).
@@ -216,18 +233,40 @@ func (c *ConversationGorm) GetConversationsByConversationID(
 	)
 }
 
-func (c *ConversationGorm) GetConversationIDsNeedDestruct(
+func (c *ConversationGorm) msgDestructDue(ctx context.Context, now time.Time, shard, shards int) *gorm.DB {
+	return c.db(ctx).
+		Where("is_msg_destruct = ? and msg_destruct_due_time <= ? and msg_destruct_time != 0", true, now).
+		Where("CRC32(owner_user_id) % ? = ?", shards, shard)
+}
+
+func (c *ConversationGorm) FindMsgDestructDue(
 	ctx context.Context,
+	now time.Time,
+	shard, shards int,
+	limit int,
 ) (conversations []*relation.ConversationModel, err error) {
-	return conversations, utils.Wrap(
-		c.db(ctx).
-			Where("is_msg_destruct = 1 && msg_destruct_time != 0 && (UNIX_TIMESTAMP(NOW()) > (msg_destruct_time + UNIX_TIMESTAMP(latest_msg_destruct_time)) || latest_msg_destruct_time is NULL)").
+	return conversations, errs.Wrap(
+		c.msgDestructDue(ctx, now, shard, shards).
+			Order("msg_destruct_due_time").
+			Limit(limit).
 			Find(&conversations).
 			Error,
-		"",
 	)
 }
 
+func (c *ConversationGorm) CountMsgDestructDue(ctx context.Context, now time.Time, shard, shards int) (int64, error) {
+	var count int64
+	return count, errs.Wrap(c.msgDestructDue(ctx, now, shard, shards).Count(&count).Error)
+}
+
+func (c *ConversationGorm) BackfillMsgDestructDueTime(ctx context.Context) (int64, error) {
+	dueTime := gorm.Expr("DATE_ADD(latest_msg_destruct_time, INTERVAL msg_destruct_time SECOND)")
+	result := c.db(ctx).
+		Where("is_msg_destruct = ? and (msg_destruct_due_time is null or msg_destruct_due_time != ?)", true, dueTime).
+		Updates(map[string]any{"msg_destruct_due_time": dueTime})
+	return result.RowsAffected, errs.Wrap(result.Error)
+}
+
 func (c *ConversationGorm) GetConversationRecvMsgOpt(ctx context.Context, userID string, conversationID string) (int32, error) {
 	var recvMsgOpt int32
 	return recvMsgOpt, errs.Wrap(
@@ -0,0 +1,57 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package relation
+
+import (
+	"context"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	relationtb "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/relation"
+)
+
+type PushQuietHoursGorm struct {
+	db *gorm.DB
+}
+
+func NewPushQuietHoursGorm(db *gorm.DB) relationtb.PushQuietHoursModelInterface {
+	db.AutoMigrate(&relationtb.PushQuietHoursModel{})
+	return &PushQuietHoursGorm{db: db}
+}
+
+func (q *PushQuietHoursGorm) Upsert(ctx context.Context, quietHours *relationtb.PushQuietHoursModel) error {
+	return errs.Wrap(q.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(quietHours).Error)
+}
+
+func (q *PushQuietHoursGorm) Delete(ctx context.Context, userID string, targetType int32, targetID string) error {
+	return errs.Wrap(q.db.WithContext(ctx).Where("user_id = ? and target_type = ? and target_id = ?", userID, targetType, targetID).
+		Delete(&relationtb.PushQuietHoursModel{}).Error)
+}
+
+func (q *PushQuietHoursGorm) Find(ctx context.Context, userID string) ([]*relationtb.PushQuietHoursModel, error) {
+	var quietHours []*relationtb.PushQuietHoursModel
+	return quietHours, errs.Wrap(q.db.WithContext(ctx).Where("user_id = ?", userID).Find(&quietHours).Error)
+}
+
+func (q *PushQuietHoursGorm) FindForPush(ctx context.Context, userIDs []string, conversationID string, serverID string) ([]*relationtb.PushQuietHoursModel, error) {
+	var quietHours []*relationtb.PushQuietHoursModel
+	db := q.db.WithContext(ctx).Where("user_id in ?", userIDs).
+		Where(q.db.Where("target_type = ?", relationtb.QuietHoursGlobal).
+			Or("target_type = ? and target_id = ?", relationtb.QuietHoursConversation, conversationID).
+			Or("target_type = ? and target_id = ?", relationtb.QuietHoursServer, serverID))
+	return quietHours, errs.Wrap(db.Find(&quietHours).Error)
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"path"
 	"strings"
 	"time"
@@ -249,6 +250,32 @@ func (c *Controller) CompleteUpload(ctx context.Context, uploadID string, partHa
 	}, nil
 }
 
+// PutObject uploads the content of hash from the server through the internal endpoint, content uploaded
+// before is not uploaded again.
+func (c *Controller) PutObject(ctx context.Context, hash string, size int64, contentType string, reader io.Reader) (*UploadResult, error) {
+	key := c.HashPath(hash)
+	if info, err := c.StatObject(ctx, key); err == nil {
+		return &UploadResult{
+			Key:  info.Key,
+			Size: info.Size,
+			Hash: hash,
+		}, nil
+	} else if !c.IsNotFound(err) {
+		return nil, err
+	}
+	if err := c.impl.PutObject(ctx, key, reader, size, contentType); err != nil {
+		return nil, err
+	}
+	if err := c.cache.DelS3Key(c.impl.Engine(), key).ExecDel(ctx); err != nil {
+		return nil, err
+	}
+	return &UploadResult{
+		Key:  key,
+		Size: size,
+		Hash: hash,
+	}, nil
+}
+
 func (c *Controller) AuthSign(ctx context.Context, uploadID string, partNumbers []int) (*s3.AuthSignResult, error) {
 	upload, err := parseMultipartUploadID(uploadID)
 	if err != nil {
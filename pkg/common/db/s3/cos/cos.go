@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -168,6 +169,16 @@ func (c *Cos) PresignedPutObject(ctx context.Context, name string, expire time.D
 	return rawURL.String(), nil
 }
 
+func (c *Cos) PutObject(ctx context.Context, name string, reader io.Reader, size int64, contentType string) error {
+	_, err := c.client.Object.Put(ctx, name, reader, &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType:   contentType,
+			ContentLength: size,
+		},
+	})
+	return err
+}
+
 func (c *Cos) DeleteObject(ctx context.Context, name string) error {
 	_, err := c.client.Object.Delete(ctx, name)
 	return err
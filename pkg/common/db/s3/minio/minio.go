@@ -297,6 +297,14 @@ func (m *Minio) PresignedPutObject(ctx context.Context, name string, expire time
 	return rawURL.String(), nil
 }
 
+func (m *Minio) PutObject(ctx context.Context, name string, reader io.Reader, size int64, contentType string) error {
+	if err := m.initMinio(ctx); err != nil {
+		return err
+	}
+	_, err := m.core.Client.PutObject(ctx, m.bucket, name, reader, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
 func (m *Minio) DeleteObject(ctx context.Context, name string) error {
 	if err := m.initMinio(ctx); err != nil {
 		return err
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -182,6 +183,10 @@ func (o *OSS) PresignedPutObject(ctx context.Context, name string, expire time.D
 	return o.bucket.SignURL(name, http.MethodPut, int64(expire/time.Second))
 }
 
+func (o *OSS) PutObject(ctx context.Context, name string, reader io.Reader, size int64, contentType string) error {
+	return o.bucket.PutObject(name, reader, oss.ContentType(contentType), oss.ContentLength(size))
+}
+
 func (o *OSS) StatObject(ctx context.Context, name string) (*s3.ObjectInfo, error) {
 	header, err := o.bucket.GetObjectMeta(name)
 	if err != nil {
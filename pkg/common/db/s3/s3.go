@@ -16,6 +16,7 @@ package s3
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -139,6 +140,7 @@ type Interface interface {
 	AuthSign(ctx context.Context, uploadID string, name string, expire time.Duration, partNumbers []int) (*AuthSignResult, error)
 
 	PresignedPutObject(ctx context.Context, name string, expire time.Duration) (string, error)
+	PutObject(ctx context.Context, name string, reader io.Reader, size int64, contentType string) error
 
 	DeleteObject(ctx context.Context, name string) error
 
@@ -39,9 +39,10 @@ type ConversationModel struct {
 	MaxSeq                int64     `gorm:"column:max_seq"                                      json:"maxSeq"`
 	MinSeq                int64     `gorm:"column:min_seq"                                      json:"minSeq"`
 	CreateTime            time.Time `gorm:"column:create_time;index:create_time;autoCreateTime"`
-	IsMsgDestruct         bool      `gorm:"column:is_msg_destruct;default:false"`
+	IsMsgDestruct         bool      `gorm:"column:is_msg_destruct;default:false;index:msg_destruct_due,priority:1"`
 	MsgDestructTime       int64     `gorm:"column:msg_destruct_time;default:604800"`
 	LatestMsgDestructTime time.Time `gorm:"column:latest_msg_destruct_time;autoCreateTime"`
+	MsgDestructDueTime    time.Time `gorm:"column:msg_destruct_due_time;index:msg_destruct_due,priority:2;autoCreateTime"`
 }
 
 func (ConversationModel) TableName() string {
@@ -67,7 +68,12 @@ type ConversationModelInterface interface {
 	PageConversationIDs(ctx context.Context, pageNumber, showNumber int32) (conversationIDs []string, err error)
 	GetUserAllHasReadSeqs(ctx context.Context, ownerUserID string) (hashReadSeqs map[string]int64, err error)
 	GetConversationsByConversationID(ctx context.Context, conversationIDs []string) ([]*ConversationModel, error)
-	GetConversationIDsNeedDestruct(ctx context.Context) ([]*ConversationModel, error)
+	// FindMsgDestructDue returns the conversations of the shard due for msg destruct at now, the longest due first.
+	FindMsgDestructDue(ctx context.Context, now time.Time, shard, shards int, limit int) ([]*ConversationModel, error)
+	CountMsgDestructDue(ctx context.Context, now time.Time, shard, shards int) (int64, error)
+	// BackfillMsgDestructDueTime sets the msg destruct due time of the conversations stored before it was kept,
+	// it returns the number of conversations updated.
+	BackfillMsgDestructDueTime(ctx context.Context) (int64, error)
 	GetConversationNotReceiveMessageUserIDs(ctx context.Context, conversationID string) ([]string, error)
 	NewTx(tx any) ConversationModelInterface
 }
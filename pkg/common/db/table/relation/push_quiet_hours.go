@@ -0,0 +1,59 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package relation
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	PushQuietHoursModelTableName = "push_quiet_hours"
+)
+
+const (
+	// QuietHoursGlobal applies to every conversation of the user.
+	QuietHoursGlobal = 0
+	// QuietHoursConversation applies to the conversation TargetID.
+	QuietHoursConversation = 1
+	// QuietHoursServer applies to every group of the server TargetID.
+	QuietHoursServer = 2
+)
+
+// PushQuietHoursModel is a daily window during which the user gets no offline push. Start and End are
+// minutes of the day in TimeZone, a window with End before Start ends on the next day.
+type PushQuietHoursModel struct {
+	UserID       string    `gorm:"column:user_id;primary_key;size:64"`
+	TargetType   int32     `gorm:"column:target_type;primary_key;autoIncrement:false"`
+	TargetID     string    `gorm:"column:target_id;primary_key;size:64"`
+	Start        int32     `gorm:"column:start"`
+	End          int32     `gorm:"column:end"`
+	TimeZone     string    `gorm:"column:time_zone;size:64"`
+	AllowMention bool      `gorm:"column:allow_mention"`
+	CreateTime   time.Time `gorm:"column:create_time"`
+}
+
+func (PushQuietHoursModel) TableName() string {
+	return PushQuietHoursModelTableName
+}
+
+type PushQuietHoursModelInterface interface {
+	// Upsert creates the quiet hours or replaces the ones of the same user and target.
+	Upsert(ctx context.Context, quietHours *PushQuietHoursModel) error
+	Delete(ctx context.Context, userID string, targetType int32, targetID string) error
+	Find(ctx context.Context, userID string) ([]*PushQuietHoursModel, error)
+	// FindForPush returns the global quiet hours of userIDs and the ones targeting conversationID or serverID.
+	FindForPush(ctx context.Context, userIDs []string, conversationID string, serverID string) ([]*PushQuietHoursModel, error)
+}
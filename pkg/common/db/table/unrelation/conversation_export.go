@@ -0,0 +1,64 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+)
+
+const CConversationExport = "conversation_export"
+
+const (
+	ConversationExportPending = 0
+	ConversationExportRunning = 1
+	ConversationExportDone    = 2
+	ConversationExportFailed  = 3
+)
+
+const (
+	ConversationExportJSON = "json"
+	ConversationExportHTML = "html"
+)
+
+// ConversationExportModel is an export of the msgs of a conversation between MinSeq and MaxSeq, rendered
+// by the cron service as seen by UserID. ObjectKey is the uploaded archive once the export is done.
+type ConversationExportModel struct {
+	ExportID       string    `bson:"export_id"`
+	UserID         string    `bson:"user_id"`
+	ConversationID string    `bson:"conversation_id"`
+	Format         string    `bson:"format"`
+	MinSeq         int64     `bson:"min_seq"`
+	MaxSeq         int64     `bson:"max_seq"`
+	Status         int32     `bson:"status"`
+	ObjectKey      string    `bson:"object_key"`
+	MsgNum         int64     `bson:"msg_num"`
+	ErrMsg         string    `bson:"err_msg"`
+	CreateTime     time.Time `bson:"create_time"`
+	UpdateTime     time.Time `bson:"update_time"`
+}
+
+type ConversationExportModelInterface interface {
+	Create(ctx context.Context, export *ConversationExportModel) error
+	Take(ctx context.Context, exportID string) (*ConversationExportModel, error)
+	CountUserUnfinished(ctx context.Context, userID string) (int64, error)
+	// UpdateStatus moves the export from status from to the args, it returns whether the export was in status from.
+	UpdateStatus(ctx context.Context, exportID string, from int32, args map[string]any) (bool, error)
+	// Claim sets the args on a pending export or on a running one not updated since staleTime, it returns
+	// whether the export was claimed.
+	Claim(ctx context.Context, exportID string, staleTime time.Time, args map[string]any) (bool, error)
+	// UpdateUserStale sets the args on the unfinished exports of the user not updated since staleTime.
+	UpdateUserStale(ctx context.Context, userID string, staleTime time.Time, args map[string]any) (int64, error)
+}
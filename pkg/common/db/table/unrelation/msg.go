@@ -54,6 +54,13 @@ type OfflinePushModel struct {
 	IOSBadgeCount bool   `bson:"ios_badge_count"`
 }
 
+// MsgEditModel is a version of the message content replaced by an edit.
+type MsgEditModel struct {
+	Content  string `bson:"content"`
+	EditorID string `bson:"editor_id"`
+	EditTime int64  `bson:"edit_time"`
+}
+
 type MsgDataModel struct {
 	SendID           string            `bson:"send_id"`
 	RecvID           string            `bson:"recv_id"`
@@ -78,6 +85,7 @@ type MsgDataModel struct {
 	AttachedInfo     string            `bson:"attached_info"`
 	Ex               string            `bson:"ex"`
 	RecvIDList       []string          `bson:"recv_id_list"`
+	ThreadID         string            `bson:"thread_id"`
 }
 
 type MsgInfoModel struct {
@@ -85,6 +93,21 @@ type MsgInfoModel struct {
 	Revoke  *RevokeModel  `bson:"revoke"`
 	DelList []string      `bson:"del_list"`
 	IsRead  bool          `bson:"is_read"`
+	// Reactions maps each emoji to the users who reacted with it, in reaction order.
+	Reactions map[string][]string `bson:"reactions,omitempty"`
+	Edited    bool                `bson:"edited,omitempty"`
+	EditorID  string              `bson:"editor_id,omitempty"`
+	EditTime  int64               `bson:"edit_time,omitempty"`
+	// EditHistory keeps the previous contents of an edited message, oldest first.
+	EditHistory []*MsgEditModel `bson:"edit_history,omitempty"`
+	// Thread summarizes the thread started from the message.
+	Thread *ThreadSummaryModel `bson:"thread,omitempty"`
+}
+
+// MsgSeqTimeModel is the seq and the send time of a msg in a doc.
+type MsgSeqTimeModel struct {
+	Seq      int64 `bson:"seq"`
+	SendTime int64 `bson:"send_time"`
 }
 
 type UserCount struct {
@@ -110,8 +133,17 @@ type MsgDocModelInterface interface {
 	GetOldestMsg(ctx context.Context, conversationID string) (*MsgInfoModel, error)
 	DeleteDocs(ctx context.Context, docIDs []string) error
 	GetMsgDocModelByIndex(ctx context.Context, conversationID string, index, sort int64) (*MsgDocModel, error)
+	// GetMsgSeqTimesInOneDoc returns the seq and the send time of the msgs still in the doc, in seq order.
+	GetMsgSeqTimesInOneDoc(ctx context.Context, docID string) ([]*MsgSeqTimeModel, error)
 	DeleteMsgsInOneDocByIndex(ctx context.Context, docID string, indexes []int) error
 	MarkSingleChatMsgsAsRead(ctx context.Context, userID string, docID string, indexes []int64) error
+	AddReaction(ctx context.Context, docID string, index int64, emoji string, userID string, maxEmojis int) (*mongo.UpdateResult, error)
+	DelReaction(ctx context.Context, docID string, index int64, emoji string, userID string) error
+	GetReactions(ctx context.Context, docID string, index int64) (map[string][]string, error)
+	EditMsgContent(ctx context.Context, docID string, index int64, prev *MsgEditModel, newContent string, editorID string, editTime int64) (*mongo.UpdateResult, error)
+	GetMsgEditHistory(ctx context.Context, docID string, index int64) (*MsgInfoModel, error)
+	AddThreadReply(ctx context.Context, docID string, index int64, userID string, replyTime int64) error
+	GetThreadSummary(ctx context.Context, docID string, index int64) (*ThreadSummaryModel, error)
 	SearchMessage(ctx context.Context, req *msg.SearchMessageReq) (int32, []*MsgInfoModel, error)
 	RangeUserSendCount(
 		ctx context.Context,
@@ -0,0 +1,64 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import "context"
+
+const CMsgSearch = "msg_search"
+
+// MsgSearchModel is the searchable text of a msg, kept apart from the msg docs for search.
+type MsgSearchModel struct {
+	ConversationID string `bson:"conversation_id"`
+	Seq            int64  `bson:"seq"`
+	SendID         string `bson:"send_id"`
+	ContentType    int32  `bson:"content_type"`
+	Text           string `bson:"text"`
+	// Tokens are the words of Text the msg is found by, see msgprocessor.GetSearchTokens.
+	Tokens   []string `bson:"tokens"`
+	SendTime int64    `bson:"send_time"`
+	// DelUserIDs are the users who deleted the msg for themselves only.
+	DelUserIDs []string `bson:"del_user_ids,omitempty"`
+}
+
+// MsgSearchSeqRange bounds the seqs of a conversation visible to a user, a zero bound doesn't filter.
+type MsgSearchSeqRange struct {
+	MinSeq int64
+	MaxSeq int64
+}
+
+// MsgSearchCond filters a search for UserID. ConversationSeqs maps the conversations to search to the seqs
+// visible in each, KeywordTokens are the tokens a msg must all have, see msgprocessor.GetSearchKeywordTokens.
+// StartTime and EndTime are send times in milliseconds, zero values don't filter.
+type MsgSearchCond struct {
+	UserID           string
+	ConversationSeqs map[string]MsgSearchSeqRange
+	KeywordTokens    []string
+	SendID           string
+	ContentTypes     []int32
+	StartTime        int64
+	EndTime          int64
+}
+
+type MsgSearchModelInterface interface {
+	// Upsert indexes the msgs, a msg indexed again replaces its text and keeps its DelUserIDs.
+	Upsert(ctx context.Context, msgs []*MsgSearchModel) error
+	// Delete removes the msgs of the conversation from the index.
+	Delete(ctx context.Context, conversationID string, seqs []int64) error
+	// AddDelUser hides the msgs of the conversation from the user.
+	AddDelUser(ctx context.Context, conversationID string, seqs []int64, userID string) error
+	// Search pages through the msgs matching the cond, the latest first. Matches aren't counted, more
+	// reports whether another page follows.
+	Search(ctx context.Context, cond *MsgSearchCond, pageNumber, showNumber int32) (msgs []*MsgSearchModel, more bool, err error)
+}
@@ -0,0 +1,89 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	CPoll     = "poll"
+	CPollVote = "poll_vote"
+)
+
+const (
+	PollOpen   = 0
+	PollClosed = 1
+)
+
+// who can see the vote counts of a poll.
+const (
+	PollResultAlways     = 0
+	PollResultAfterVote  = 1
+	PollResultAfterClose = 2
+)
+
+type PollOptionModel struct {
+	OptionID  string `bson:"option_id"`
+	Text      string `bson:"text"`
+	VoteCount int64  `bson:"vote_count"`
+}
+
+// PollModel is a poll posted as a msg. The vote counts are counted in redis and copied here after each
+// vote, Version orders the copies so a late one never overwrites a newer one.
+type PollModel struct {
+	PollID           string             `bson:"poll_id"`
+	CreatorID        string             `bson:"creator_id"`
+	GroupID          string             `bson:"group_id"`
+	SessionType      int32              `bson:"session_type"`
+	ConversationID   string             `bson:"conversation_id"`
+	ClientMsgID      string             `bson:"client_msg_id"`
+	ServerMsgID      string             `bson:"server_msg_id"`
+	Question         string             `bson:"question"`
+	Options          []*PollOptionModel `bson:"options"`
+	MultiChoice      bool               `bson:"multi_choice"`
+	Anonymous        bool               `bson:"anonymous"`
+	ResultVisibility int32              `bson:"result_visibility"`
+	CloseTime        time.Time          `bson:"close_time"`
+	Status           int32              `bson:"status"`
+	VoterCount       int64              `bson:"voter_count"`
+	Version          int64              `bson:"version"`
+	CreateTime       time.Time          `bson:"create_time"`
+	UpdateTime       time.Time          `bson:"update_time"`
+}
+
+type PollVoteModel struct {
+	PollID    string    `bson:"poll_id"`
+	UserID    string    `bson:"user_id"`
+	OptionIDs []string  `bson:"option_ids"`
+	VoteTime  time.Time `bson:"vote_time"`
+}
+
+type PollModelInterface interface {
+	Create(ctx context.Context, poll *PollModel) error
+	Take(ctx context.Context, pollID string) (*PollModel, error)
+	// UpdateMsg records the server msg id of the msg the poll was posted as, a poll without one was never posted.
+	UpdateMsg(ctx context.Context, pollID string, serverMsgID string) error
+	// UpdateCounts copies the vote counts unless a newer version was copied already.
+	UpdateCounts(ctx context.Context, pollID string, counts map[string]int64, voterCount int64, version int64) error
+	// Close closes an open poll, it returns whether the poll was still open.
+	Close(ctx context.Context, pollID string) (bool, error)
+	UpsertVote(ctx context.Context, vote *PollVoteModel) error
+	DeleteVote(ctx context.Context, pollID string, userID string) error
+	// TakeVote returns the vote of the user, nil if the user hasn't voted.
+	TakeVote(ctx context.Context, pollID string, userID string) (*PollVoteModel, error)
+	FindVotes(ctx context.Context, pollID string) ([]*PollVoteModel, error)
+}
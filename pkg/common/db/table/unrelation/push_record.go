@@ -0,0 +1,86 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+)
+
+const CPushRecord = "push_record"
+
+// push record events. Sent and Failed are written by the push service once per message, user and
+// platform, Delivered and Opened are acknowledged by the client, Attempt is written for every provider
+// call and only feeds the provider stats.
+const (
+	PushEventSent      = 1
+	PushEventFailed    = 2
+	PushEventDelivered = 3
+	PushEventOpened    = 4
+	PushEventAttempt   = 5
+)
+
+// result codes of a provider attempt for a user.
+const (
+	PushResultOK           = 0
+	PushResultRetry        = 1
+	PushResultPermanent    = 2
+	PushResultUnregistered = 3
+)
+
+// PushRecordModel is one event of the offline push of a message to a user. PlatformID is 0 when the
+// provider doesn't tell which device it reached, and for attempts.
+type PushRecordModel struct {
+	Event       int32     `bson:"event"`
+	ClientMsgID string    `bson:"client_msg_id"`
+	UserID      string    `bson:"user_id"`
+	PlatformID  int32     `bson:"platform_id"`
+	ContentType int32     `bson:"content_type"`
+	Provider    string    `bson:"provider"`
+	Code        int32     `bson:"code"`
+	Latency     int64     `bson:"latency"` // milliseconds
+	CreateTime  time.Time `bson:"create_time"`
+}
+
+// PushFunnel counts the events of one day, platform and content type.
+type PushFunnel struct {
+	Date        string `bson:"date"`
+	PlatformID  int32  `bson:"platform_id"`
+	ContentType int32  `bson:"content_type"`
+	Sent        int64  `bson:"sent"`
+	Failed      int64  `bson:"failed"`
+	Delivered   int64  `bson:"delivered"`
+	Opened      int64  `bson:"opened"`
+}
+
+// PushProviderStat sums the attempts of a provider, Sent are the attempts that succeeded.
+type PushProviderStat struct {
+	Provider   string `bson:"provider"`
+	Sent       int64  `bson:"sent"`
+	Failed     int64  `bson:"failed"`
+	AvgLatency int64  `bson:"avg_latency"`
+}
+
+type PushRecordModelInterface interface {
+	Create(ctx context.Context, records []*PushRecordModel) error
+	// FindSentContentTypes returns the content type of the messages pushed to userID, by clientMsgID.
+	FindSentContentTypes(ctx context.Context, userID string, clientMsgIDs []string) (map[string]int32, error)
+	// CreateOnce stores records that happen once, a record already stored for the message, user, platform
+	// and event is ignored.
+	CreateOnce(ctx context.Context, records []*PushRecordModel) error
+	// Funnel groups the events but attempts in [start, end) by day, platform and content type.
+	Funnel(ctx context.Context, start, end time.Time) ([]*PushFunnel, error)
+	ProviderStats(ctx context.Context, start, end time.Time) ([]*PushProviderStat, error)
+}
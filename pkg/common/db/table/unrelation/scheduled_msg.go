@@ -0,0 +1,56 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+)
+
+const CScheduledMsg = "scheduled_msg"
+
+const (
+	ScheduledMsgPending  = 0
+	ScheduledMsgSending  = 1
+	ScheduledMsgSent     = 2
+	ScheduledMsgFailed   = 3
+	ScheduledMsgCanceled = 4
+)
+
+// ScheduledMsgModel is a msg waiting to be sent at ScheduleTime by the cron service.
+type ScheduledMsgModel struct {
+	ScheduleID     string        `bson:"schedule_id"`
+	SendID         string        `bson:"send_id"`
+	ConversationID string        `bson:"conversation_id"`
+	Msg            *MsgDataModel `bson:"msg"`
+	ScheduleTime   time.Time     `bson:"schedule_time"`
+	Status         int32         `bson:"status"`
+	ErrMsg         string        `bson:"err_msg"`
+	ServerMsgID    string        `bson:"server_msg_id"`
+	CreateTime     time.Time     `bson:"create_time"`
+	UpdateTime     time.Time     `bson:"update_time"`
+}
+
+type ScheduledMsgModelInterface interface {
+	Create(ctx context.Context, msg *ScheduledMsgModel) error
+	Take(ctx context.Context, scheduleID string) (*ScheduledMsgModel, error)
+	CountUserPending(ctx context.Context, sendID string) (int64, error)
+	// PageUser pages through the scheduled msgs of the user with the given statuses, the earliest to send first.
+	PageUser(ctx context.Context, sendID string, statuses []int32, pageNumber, showNumber int32) (int64, []*ScheduledMsgModel, error)
+	// UpdatePending updates the msg only while it is pending, it returns whether the msg was updated.
+	UpdatePending(ctx context.Context, scheduleID string, args map[string]any) (bool, error)
+	// UpdateStatus moves the msg from status from to the args, it returns whether the msg was in status from.
+	UpdateStatus(ctx context.Context, scheduleID string, from int32, args map[string]any) (bool, error)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	CThread             = "thread"
+	CThreadSubscription = "thread_subscription"
+)
+
+// ThreadModel is a thread started from a message of a server channel. The thread is a conversation of
+// its own, its messages have their own seqs.
+type ThreadModel struct {
+	ThreadID             string    `bson:"thread_id"`
+	GroupID              string    `bson:"group_id"`
+	ServerID             string    `bson:"server_id"`
+	ParentConversationID string    `bson:"parent_conversation_id"`
+	ParentSeq            int64     `bson:"parent_seq"`
+	ParentClientMsgID    string    `bson:"parent_client_msg_id"`
+	Name                 string    `bson:"name"`
+	CreatorUserID        string    `bson:"creator_user_id"`
+	AutoArchiveDuration  int32     `bson:"auto_archive_duration"` // minutes
+	Archived             bool      `bson:"archived"`
+	ArchiveTime          time.Time `bson:"archive_time"`
+	LastActiveTime       time.Time `bson:"last_active_time"`
+	AutoArchiveTime      time.Time `bson:"auto_archive_time"`
+	CreateTime           time.Time `bson:"create_time"`
+}
+
+// ThreadSummaryModel is kept on the parent message of a thread.
+type ThreadSummaryModel struct {
+	ThreadID      string   `bson:"thread_id"`
+	ReplyCount    int64    `bson:"reply_count"`
+	LastReplyTime int64    `bson:"last_reply_time"`
+	Participants  []string `bson:"participants"`
+}
+
+type ThreadSubscriptionModel struct {
+	ThreadID   string    `bson:"thread_id"`
+	UserID     string    `bson:"user_id"`
+	CreateTime time.Time `bson:"create_time"`
+}
+
+type ThreadModelInterface interface {
+	Create(ctx context.Context, thread *ThreadModel) error
+	Take(ctx context.Context, threadID string) (*ThreadModel, error)
+	Find(ctx context.Context, threadIDs []string) ([]*ThreadModel, error)
+	// PageGroupThreads pages through the threads of a channel, the most recently active first.
+	PageGroupThreads(ctx context.Context, groupID string, archived bool, pageNumber, showNumber int32) (int64, []*ThreadModel, error)
+	// Touch marks the thread active at activeTime and unarchives it, it returns the thread before the update.
+	Touch(ctx context.Context, threadID string, activeTime time.Time) (*ThreadModel, error)
+	UpdateByMap(ctx context.Context, threadID string, args map[string]any) error
+	// FindInactive returns threads not archived whose auto archive time is before now.
+	FindInactive(ctx context.Context, now time.Time, limit int64) ([]*ThreadModel, error)
+	// ArchiveInactive archives the thread if it is still inactive at now.
+	ArchiveInactive(ctx context.Context, threadID string, now time.Time) (bool, error)
+
+	Subscribe(ctx context.Context, threadID string, userID string) error
+	Unsubscribe(ctx context.Context, threadID string, userID string) error
+	FindSubscriberIDs(ctx context.Context, threadID string) ([]string, error)
+	// PageUserSubscriptions pages through the threads the user subscribed to, the latest first.
+	PageUserSubscriptions(ctx context.Context, userID string, pageNumber, showNumber int32) (int64, []string, error)
+}
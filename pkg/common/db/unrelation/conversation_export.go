@@ -0,0 +1,116 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func NewConversationExportMongo(database *mongo.Database) (unrelation.ConversationExportModelInterface, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	coll := database.Collection(unrelation.CConversationExport)
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "export_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &ConversationExportMongoDriver{coll: coll}, nil
+}
+
+type ConversationExportMongoDriver struct {
+	coll *mongo.Collection
+}
+
+func (c *ConversationExportMongoDriver) Create(ctx context.Context, export *unrelation.ConversationExportModel) error {
+	_, err := c.coll.InsertOne(ctx, export)
+	return errs.Wrap(err)
+}
+
+func (c *ConversationExportMongoDriver) Take(ctx context.Context, exportID string) (*unrelation.ConversationExportModel, error) {
+	var export unrelation.ConversationExportModel
+	if err := c.coll.FindOne(ctx, bson.M{"export_id": exportID}).Decode(&export); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &export, nil
+}
+
+func (c *ConversationExportMongoDriver) CountUserUnfinished(ctx context.Context, userID string) (int64, error) {
+	count, err := c.coll.CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"status":  bson.M{"$in": []int32{unrelation.ConversationExportPending, unrelation.ConversationExportRunning}},
+	})
+	return count, errs.Wrap(err)
+}
+
+func (c *ConversationExportMongoDriver) UpdateStatus(ctx context.Context, exportID string, from int32, args map[string]any) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	res, err := c.coll.UpdateOne(ctx, bson.M{"export_id": exportID, "status": from}, bson.M{"$set": args})
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (c *ConversationExportMongoDriver) Claim(ctx context.Context, exportID string, staleTime time.Time, args map[string]any) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	filter := bson.M{
+		"export_id": exportID,
+		"$or": bson.A{
+			bson.M{"status": unrelation.ConversationExportPending},
+			bson.M{"status": unrelation.ConversationExportRunning, "update_time": bson.M{"$lt": staleTime}},
+		},
+	}
+	res, err := c.coll.UpdateOne(ctx, filter, bson.M{"$set": args})
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (c *ConversationExportMongoDriver) UpdateUserStale(ctx context.Context, userID string, staleTime time.Time, args map[string]any) (int64, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	filter := bson.M{
+		"user_id":     userID,
+		"status":      bson.M{"$in": []int32{unrelation.ConversationExportPending, unrelation.ConversationExportRunning}},
+		"update_time": bson.M{"$lt": staleTime},
+	}
+	res, err := c.coll.UpdateMany(ctx, filter, bson.M{"$set": args})
+	if err != nil {
+		return 0, errs.Wrap(err)
+	}
+	return res.ModifiedCount, nil
+}
@@ -180,6 +180,29 @@ func (m *MsgMongoDriver) GetMsgDocModelByIndex(
 	return nil, ErrMsgListNotExist
 }
 
+func (m *MsgMongoDriver) GetMsgSeqTimesInOneDoc(ctx context.Context, docID string) ([]*table.MsgSeqTimeModel, error) {
+	pipeline := mongo.Pipeline{
+		{{"$match", bson.D{{"doc_id", docID}}}},
+		{{"$unwind", "$msgs"}},
+		{{"$match", bson.D{{"msgs.msg", bson.D{{"$ne", nil}}}}}},
+		{{"$project", bson.D{
+			{"_id", 0},
+			{"seq", "$msgs.msg.seq"},
+			{"send_time", "$msgs.msg.send_time"},
+		}}},
+	}
+	cur, err := m.MsgCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	defer cur.Close(ctx)
+	var seqTimes []*table.MsgSeqTimeModel
+	if err := cur.All(ctx, &seqTimes); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return seqTimes, nil
+}
+
 func (m *MsgMongoDriver) GetNewestMsg(ctx context.Context, conversationID string) (*table.MsgInfoModel, error) {
 	var skip int64 = 0
 	for {
@@ -285,6 +308,7 @@ func (m *MsgMongoDriver) GetMsgBySeqIndexIn1Doc(
 		{
 			{"$project", bson.D{
 				{"msgs.del_list", 0},
+				{"msgs.edit_history", 0},
 			}},
 		},
 	}
@@ -609,6 +633,18 @@ func (m *MsgMongoDriver) RangeUserSendCount(
 					"$options": "i",
 				},
 			},
+			bson.M{
+				"doc_id": bson.M{
+					"$regex":   "^svg_",
+					"$options": "i",
+				},
+			},
+			bson.M{
+				"doc_id": bson.M{
+					"$regex":   "^svt_",
+					"$options": "i",
+				},
+			},
 		)
 	}
 	pipeline := bson.A{
@@ -871,6 +907,18 @@ func (m *MsgMongoDriver) RangeGroupSendCount(
 									"$options": "i",
 								},
 							},
+							bson.M{
+								"doc_id": bson.M{
+									"$regex":   "^svg_",
+									"$options": "i",
+								},
+							},
+							bson.M{
+								"doc_id": bson.M{
+									"$regex":   "^svt_",
+									"$options": "i",
+								},
+							},
 						},
 					},
 				},
@@ -1118,6 +1166,18 @@ func (m *MsgMongoDriver) searchMessage(ctx context.Context, req *msg.SearchMessa
 				"$options": "i",
 			},
 		},
+		bson.M{
+			"doc_id": bson.M{
+				"$regex":   "^svg_",
+				"$options": "i",
+			},
+		},
+		bson.M{
+			"doc_id": bson.M{
+				"$regex":   "^svt_",
+				"$options": "i",
+			},
+		},
 	)
 
 	pipe = mongo.Pipeline{
@@ -0,0 +1,90 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	table "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// EditMsgContent replaces the content of the message with newContent and appends prev to its edit history.
+// The update only applies while the content is still prev.Content, MatchedCount is 0 when the message
+// was edited concurrently or isn't in mongo yet.
+func (m *MsgMongoDriver) EditMsgContent(
+	ctx context.Context,
+	docID string,
+	index int64,
+	prev *table.MsgEditModel,
+	newContent string,
+	editorID string,
+	editTime int64,
+) (*mongo.UpdateResult, error) {
+	field := fmt.Sprintf("msgs.%d.", index)
+	filter := bson.M{"doc_id": docID, field + "msg.content": prev.Content}
+	update := bson.M{
+		"$set": bson.M{
+			field + "msg.content": newContent,
+			field + "edited":      true,
+			field + "editor_id":   editorID,
+			field + "edit_time":   editTime,
+		},
+		"$push": bson.M{field + "edit_history": prev},
+	}
+	res, err := m.MsgCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return res, nil
+}
+
+// GetMsgEditHistory returns the message at index with its edit marker and history, without the del list
+// and reactions.
+func (m *MsgMongoDriver) GetMsgEditHistory(ctx context.Context, docID string, index int64) (*table.MsgInfoModel, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"doc_id": docID}},
+		bson.M{"$project": bson.M{
+			"_id": 0,
+			"msg": bson.M{"$arrayElemAt": bson.A{"$msgs", index}},
+		}},
+		bson.M{"$project": bson.M{
+			"msg.msg":          1,
+			"msg.revoke":       1,
+			"msg.edited":       1,
+			"msg.editor_id":    1,
+			"msg.edit_time":    1,
+			"msg.edit_history": 1,
+		}},
+	}
+	cur, err := m.MsgCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var docs []struct {
+		Msg *table.MsgInfoModel `bson:"msg"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if len(docs) == 0 || docs[0].Msg == nil || docs[0].Msg.Msg == nil {
+		return nil, errs.Wrap(mongo.ErrNoDocuments)
+	}
+	return docs[0].Msg, nil
+}
@@ -0,0 +1,96 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func reactionField(index int64, emoji string) string {
+	return fmt.Sprintf("msgs.%d.reactions.%s", index, emoji)
+}
+
+// AddReaction adds userID to the reactors of emoji when the message is stored and has fewer than
+// maxEmojis other emojis, the check and the update are one atomic update. MatchedCount is 0 when the
+// message isn't stored or has too many emojis.
+func (m *MsgMongoDriver) AddReaction(ctx context.Context, docID string, index int64, emoji string, userID string, maxEmojis int) (*mongo.UpdateResult, error) {
+	field := reactionField(index, emoji)
+	reactions := bson.M{"$let": bson.M{
+		"vars": bson.M{"msg": bson.M{"$arrayElemAt": bson.A{"$msgs", index}}},
+		"in":   "$$msg.reactions",
+	}}
+	filter := bson.M{
+		"doc_id":                          docID,
+		fmt.Sprintf("msgs.%d.msg", index): bson.M{"$ne": nil},
+		"$or": bson.A{
+			bson.M{field: bson.M{"$exists": true}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{
+				bson.M{"$size": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{reactions, bson.M{}}}}},
+				maxEmojis,
+			}}},
+		},
+	}
+	res, err := m.MsgCollection.UpdateOne(ctx, filter, bson.M{"$addToSet": bson.M{field: userID}})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return res, nil
+}
+
+// DelReaction removes userID from the reactors of emoji, the emoji is dropped with its last reactor.
+func (m *MsgMongoDriver) DelReaction(ctx context.Context, docID string, index int64, emoji string, userID string) error {
+	field := reactionField(index, emoji)
+	filter := bson.M{"doc_id": docID}
+	if _, err := m.MsgCollection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{field: userID}}); err != nil {
+		return errs.Wrap(err)
+	}
+	filter[field] = bson.M{"$size": 0}
+	if _, err := m.MsgCollection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{field: ""}}); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (m *MsgMongoDriver) GetReactions(ctx context.Context, docID string, index int64) (map[string][]string, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"doc_id": docID}},
+		bson.M{"$project": bson.M{
+			"_id": 0,
+			"reactions": bson.M{"$let": bson.M{
+				"vars": bson.M{"msg": bson.M{"$arrayElemAt": bson.A{"$msgs", index}}},
+				"in":   "$$msg.reactions",
+			}},
+		}},
+	}
+	cur, err := m.MsgCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var docs []struct {
+		Reactions map[string][]string `bson:"reactions"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if len(docs) == 0 || docs[0].Reactions == nil {
+		return map[string][]string{}, nil
+	}
+	return docs[0].Reactions, nil
+}
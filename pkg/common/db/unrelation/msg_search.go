@@ -0,0 +1,157 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func NewMsgSearchMongo(database *mongo.Database) (unrelation.MsgSearchModelInterface, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	coll := database.Collection(unrelation.CMsgSearch)
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "conversation_id", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "send_time", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tokens", Value: 1}, {Key: "send_time", Value: -1}},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &MsgSearchMongoDriver{coll: coll}, nil
+}
+
+type MsgSearchMongoDriver struct {
+	coll *mongo.Collection
+}
+
+func (m *MsgSearchMongoDriver) Upsert(ctx context.Context, msgs []*unrelation.MsgSearchModel) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(msgs))
+	for _, msg := range msgs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"conversation_id": msg.ConversationID, "seq": msg.Seq}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"send_id":      msg.SendID,
+				"content_type": msg.ContentType,
+				"text":         msg.Text,
+				"tokens":       msg.Tokens,
+				"send_time":    msg.SendTime,
+			}}).
+			SetUpsert(true))
+	}
+	_, err := m.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return errs.Wrap(err)
+}
+
+func (m *MsgSearchMongoDriver) Delete(ctx context.Context, conversationID string, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	_, err := m.coll.DeleteMany(ctx, bson.M{"conversation_id": conversationID, "seq": bson.M{"$in": seqs}})
+	return errs.Wrap(err)
+}
+
+func (m *MsgSearchMongoDriver) AddDelUser(ctx context.Context, conversationID string, seqs []int64, userID string) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	_, err := m.coll.UpdateMany(ctx, bson.M{"conversation_id": conversationID, "seq": bson.M{"$in": seqs}},
+		bson.M{"$addToSet": bson.M{"del_user_ids": userID}})
+	return errs.Wrap(err)
+}
+
+func (m *MsgSearchMongoDriver) Search(ctx context.Context, cond *unrelation.MsgSearchCond, pageNumber, showNumber int32) ([]*unrelation.MsgSearchModel, bool, error) {
+	// the conversations without seq bounds share one clause, each other one has its own.
+	var (
+		conversations bson.A
+		unbounded     []string
+	)
+	for conversationID, seqRange := range cond.ConversationSeqs {
+		seq := bson.M{}
+		if seqRange.MinSeq > 0 {
+			seq["$gte"] = seqRange.MinSeq
+		}
+		if seqRange.MaxSeq > 0 {
+			seq["$lte"] = seqRange.MaxSeq
+		}
+		if len(seq) > 0 {
+			conversations = append(conversations, bson.M{"conversation_id": conversationID, "seq": seq})
+		} else {
+			unbounded = append(unbounded, conversationID)
+		}
+	}
+	if len(unbounded) > 0 {
+		conversations = append(conversations, bson.M{"conversation_id": bson.M{"$in": unbounded}})
+	}
+	if len(conversations) == 0 || len(cond.KeywordTokens) == 0 {
+		return nil, false, nil
+	}
+	filter := bson.M{
+		"$or":          conversations,
+		"tokens":       bson.M{"$all": cond.KeywordTokens},
+		"del_user_ids": bson.M{"$ne": cond.UserID},
+	}
+	if cond.SendID != "" {
+		filter["send_id"] = cond.SendID
+	}
+	if len(cond.ContentTypes) > 0 {
+		filter["content_type"] = bson.M{"$in": cond.ContentTypes}
+	}
+	if cond.StartTime > 0 || cond.EndTime > 0 {
+		sendTime := bson.M{}
+		if cond.StartTime > 0 {
+			sendTime["$gte"] = cond.StartTime
+		}
+		if cond.EndTime > 0 {
+			sendTime["$lte"] = cond.EndTime
+		}
+		filter["send_time"] = sendTime
+	}
+	// one msg past the page tells whether another page follows.
+	opts := options.Find().
+		SetSort(bson.D{{Key: "send_time", Value: -1}, {Key: "seq", Value: -1}}).
+		SetSkip(int64(pageNumber-1) * int64(showNumber)).
+		SetLimit(int64(showNumber) + 1)
+	cursor, err := m.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, false, errs.Wrap(err)
+	}
+	var msgs []*unrelation.MsgSearchModel
+	if err := cursor.All(ctx, &msgs); err != nil {
+		return nil, false, errs.Wrap(err)
+	}
+	if len(msgs) > int(showNumber) {
+		return msgs[:showNumber], true, nil
+	}
+	return msgs, false, nil
+}
@@ -0,0 +1,65 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+
+	table "github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// AddThreadReply counts a reply of userID in the thread summary of the parent message at index.
+func (m *MsgMongoDriver) AddThreadReply(ctx context.Context, docID string, index int64, userID string, replyTime int64) error {
+	field := fmt.Sprintf("msgs.%d.thread.", index)
+	update := bson.M{
+		"$inc":      bson.M{field + "reply_count": 1},
+		"$max":      bson.M{field + "last_reply_time": replyTime},
+		"$addToSet": bson.M{field + "participants": userID},
+	}
+	_, err := m.MsgCollection.UpdateOne(ctx, bson.M{"doc_id": docID}, update)
+	return errs.Wrap(err)
+}
+
+// GetThreadSummary returns the thread summary of the message at index, nil if no thread was started from it.
+func (m *MsgMongoDriver) GetThreadSummary(ctx context.Context, docID string, index int64) (*table.ThreadSummaryModel, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"doc_id": docID}},
+		bson.M{"$project": bson.M{
+			"_id": 0,
+			"thread": bson.M{"$let": bson.M{
+				"vars": bson.M{"msg": bson.M{"$arrayElemAt": bson.A{"$msgs", index}}},
+				"in":   "$$msg.thread",
+			}},
+		}},
+	}
+	cur, err := m.MsgCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var docs []struct {
+		Thread *table.ThreadSummaryModel `bson:"thread"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	return docs[0].Thread, nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func NewPollMongo(database *mongo.Database) (unrelation.PollModelInterface, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	coll := database.Collection(unrelation.CPoll)
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "poll_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	voteColl := database.Collection(unrelation.CPollVote)
+	_, err = voteColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "poll_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &PollMongoDriver{coll: coll, voteColl: voteColl}, nil
+}
+
+type PollMongoDriver struct {
+	coll     *mongo.Collection
+	voteColl *mongo.Collection
+}
+
+func (p *PollMongoDriver) Create(ctx context.Context, poll *unrelation.PollModel) error {
+	_, err := p.coll.InsertOne(ctx, poll)
+	return errs.Wrap(err)
+}
+
+func (p *PollMongoDriver) Take(ctx context.Context, pollID string) (*unrelation.PollModel, error) {
+	var poll unrelation.PollModel
+	if err := p.coll.FindOne(ctx, bson.M{"poll_id": pollID}).Decode(&poll); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &poll, nil
+}
+
+func (p *PollMongoDriver) UpdateMsg(ctx context.Context, pollID string, serverMsgID string) error {
+	_, err := p.coll.UpdateOne(ctx, bson.M{"poll_id": pollID}, bson.M{"$set": bson.M{
+		"server_msg_id": serverMsgID,
+		"update_time":   time.Now(),
+	}})
+	return errs.Wrap(err)
+}
+
+func (p *PollMongoDriver) UpdateCounts(ctx context.Context, pollID string, counts map[string]int64, voterCount int64, version int64) error {
+	set := bson.M{"voter_count": voterCount, "version": version, "update_time": time.Now()}
+	arrayFilters := make([]any, 0, len(counts))
+	var i int
+	for optionID, count := range counts {
+		name := "o" + strconv.Itoa(i)
+		set["options.$["+name+"].vote_count"] = count
+		arrayFilters = append(arrayFilters, bson.M{name + ".option_id": optionID})
+		i++
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+	_, err := p.coll.UpdateOne(ctx, bson.M{"poll_id": pollID, "version": bson.M{"$lt": version}}, bson.M{"$set": set}, opts)
+	return errs.Wrap(err)
+}
+
+func (p *PollMongoDriver) Close(ctx context.Context, pollID string) (bool, error) {
+	res, err := p.coll.UpdateOne(ctx, bson.M{"poll_id": pollID, "status": unrelation.PollOpen}, bson.M{"$set": bson.M{
+		"status":      unrelation.PollClosed,
+		"update_time": time.Now(),
+	}})
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (p *PollMongoDriver) UpsertVote(ctx context.Context, vote *unrelation.PollVoteModel) error {
+	_, err := p.voteColl.ReplaceOne(ctx, bson.M{"poll_id": vote.PollID, "user_id": vote.UserID}, vote, options.Replace().SetUpsert(true))
+	return errs.Wrap(err)
+}
+
+func (p *PollMongoDriver) DeleteVote(ctx context.Context, pollID string, userID string) error {
+	_, err := p.voteColl.DeleteOne(ctx, bson.M{"poll_id": pollID, "user_id": userID})
+	return errs.Wrap(err)
+}
+
+func (p *PollMongoDriver) TakeVote(ctx context.Context, pollID string, userID string) (*unrelation.PollVoteModel, error) {
+	var vote unrelation.PollVoteModel
+	if err := p.voteColl.FindOne(ctx, bson.M{"poll_id": pollID, "user_id": userID}).Decode(&vote); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return &vote, nil
+}
+
+func (p *PollMongoDriver) FindVotes(ctx context.Context, pollID string) ([]*unrelation.PollVoteModel, error) {
+	cursor, err := p.voteColl.Find(ctx, bson.M{"poll_id": pollID}, options.Find().SetSort(bson.D{{Key: "vote_time", Value: 1}}))
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var votes []*unrelation.PollVoteModel
+	if err := cursor.All(ctx, &votes); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return votes, nil
+}
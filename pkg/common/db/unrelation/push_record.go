@@ -0,0 +1,217 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+// NewPushRecordMongo creates the indexes of the push records, expire is how long records are kept.
+func NewPushRecordMongo(database *mongo.Database, expire time.Duration) (unrelation.PushRecordModelInterface, error) {
+	coll := database.Collection(unrelation.CPushRecord)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "create_time", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(expire / time.Second)),
+		},
+		{
+			// the dedup key of CreateOnce, attempts are stored as many times as they happen.
+			Keys: bson.D{
+				{Key: "client_msg_id", Value: 1},
+				{Key: "user_id", Value: 1},
+				{Key: "event", Value: 1},
+				{Key: "platform_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"event": bson.M{"$lt": unrelation.PushEventAttempt}}),
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &PushRecordMongoDriver{coll: coll}, nil
+}
+
+type PushRecordMongoDriver struct {
+	coll *mongo.Collection
+}
+
+func (p *PushRecordMongoDriver) Create(ctx context.Context, records []*unrelation.PushRecordModel) error {
+	if len(records) == 0 {
+		return nil
+	}
+	docs := make([]any, 0, len(records))
+	for _, record := range records {
+		docs = append(docs, record)
+	}
+	_, err := p.coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return errs.Wrap(err)
+}
+
+func (p *PushRecordMongoDriver) FindSentContentTypes(ctx context.Context, userID string, clientMsgIDs []string) (map[string]int32, error) {
+	filter := bson.M{
+		"client_msg_id": bson.M{"$in": clientMsgIDs},
+		"user_id":       userID,
+		"event":         unrelation.PushEventSent,
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "client_msg_id": 1, "content_type": 1})
+	cursor, err := p.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var records []*unrelation.PushRecordModel
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	contentTypes := make(map[string]int32, len(records))
+	for _, record := range records {
+		contentTypes[record.ClientMsgID] = record.ContentType
+	}
+	return contentTypes, nil
+}
+
+func (p *PushRecordMongoDriver) CreateOnce(ctx context.Context, records []*unrelation.PushRecordModel) error {
+	if len(records) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(records))
+	for _, record := range records {
+		filter := bson.M{
+			"event":         record.Event,
+			"client_msg_id": record.ClientMsgID,
+			"user_id":       record.UserID,
+			"platform_id":   record.PlatformID,
+		}
+		update := bson.M{
+			"$setOnInsert": bson.M{
+				"content_type": record.ContentType,
+				"provider":     record.Provider,
+				"code":         record.Code,
+				"latency":      record.Latency,
+				"create_time":  record.CreateTime,
+			},
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+	_, err := p.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if onlyDuplicateKeyErrors(err) {
+		return nil // stored concurrently
+	}
+	return errs.Wrap(err)
+}
+
+// onlyDuplicateKeyErrors reports whether every write of a failed bulk write hit a duplicate key.
+func onlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+// countEvent sums the records of an event in a $group stage.
+func countEvent(event int32) bson.M {
+	return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$event", event}}, 1, 0}}}
+}
+
+func (p *PushRecordMongoDriver) Funnel(ctx context.Context, start, end time.Time) ([]*unrelation.PushFunnel, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"create_time": bson.M{"$gte": start, "$lt": end},
+			"event": bson.M{"$in": bson.A{
+				unrelation.PushEventSent, unrelation.PushEventFailed, unrelation.PushEventDelivered, unrelation.PushEventOpened,
+			}},
+		}},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"date":         bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$create_time"}},
+				"platform_id":  "$platform_id",
+				"content_type": "$content_type",
+			},
+			"sent":      countEvent(unrelation.PushEventSent),
+			"failed":    countEvent(unrelation.PushEventFailed),
+			"delivered": countEvent(unrelation.PushEventDelivered),
+			"opened":    countEvent(unrelation.PushEventOpened),
+		}},
+		bson.M{"$project": bson.M{
+			"_id":          0,
+			"date":         "$_id.date",
+			"platform_id":  "$_id.platform_id",
+			"content_type": "$_id.content_type",
+			"sent":         1,
+			"failed":       1,
+			"delivered":    1,
+			"opened":       1,
+		}},
+		bson.M{"$sort": bson.D{{Key: "date", Value: 1}, {Key: "platform_id", Value: 1}, {Key: "content_type", Value: 1}}},
+	}
+	cursor, err := p.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var funnels []*unrelation.PushFunnel
+	if err := cursor.All(ctx, &funnels); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return funnels, nil
+}
+
+func (p *PushRecordMongoDriver) ProviderStats(ctx context.Context, start, end time.Time) ([]*unrelation.PushProviderStat, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"create_time": bson.M{"$gte": start, "$lt": end},
+			"event":       unrelation.PushEventAttempt,
+		}},
+		bson.M{"$group": bson.M{
+			"_id":         "$provider",
+			"sent":        bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$code", unrelation.PushResultOK}}, 1, 0}}},
+			"failed":      bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$ne": bson.A{"$code", unrelation.PushResultOK}}, 1, 0}}},
+			"avg_latency": bson.M{"$avg": "$latency"},
+		}},
+		bson.M{"$project": bson.M{
+			"_id":         0,
+			"provider":    "$_id",
+			"sent":        1,
+			"failed":      1,
+			"avg_latency": bson.M{"$toLong": "$avg_latency"},
+		}},
+		bson.M{"$sort": bson.M{"provider": 1}},
+	}
+	cursor, err := p.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var stats []*unrelation.PushProviderStat
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,107 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func NewScheduledMsgMongo(database *mongo.Database) (unrelation.ScheduledMsgModelInterface, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	coll := database.Collection(unrelation.CScheduledMsg)
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "schedule_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "send_id", Value: 1}, {Key: "status", Value: 1}, {Key: "schedule_time", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &ScheduledMsgMongoDriver{coll: coll}, nil
+}
+
+type ScheduledMsgMongoDriver struct {
+	coll *mongo.Collection
+}
+
+func (s *ScheduledMsgMongoDriver) Create(ctx context.Context, msg *unrelation.ScheduledMsgModel) error {
+	_, err := s.coll.InsertOne(ctx, msg)
+	return errs.Wrap(err)
+}
+
+func (s *ScheduledMsgMongoDriver) Take(ctx context.Context, scheduleID string) (*unrelation.ScheduledMsgModel, error) {
+	var msg unrelation.ScheduledMsgModel
+	if err := s.coll.FindOne(ctx, bson.M{"schedule_id": scheduleID}).Decode(&msg); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &msg, nil
+}
+
+func (s *ScheduledMsgMongoDriver) CountUserPending(ctx context.Context, sendID string) (int64, error) {
+	count, err := s.coll.CountDocuments(ctx, bson.M{"send_id": sendID, "status": unrelation.ScheduledMsgPending})
+	return count, errs.Wrap(err)
+}
+
+func (s *ScheduledMsgMongoDriver) PageUser(ctx context.Context, sendID string, statuses []int32, pageNumber, showNumber int32) (int64, []*unrelation.ScheduledMsgModel, error) {
+	filter := bson.M{"send_id": sendID}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	total, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "schedule_time", Value: 1}}).
+		SetSkip(int64(pageNumber-1) * int64(showNumber)).
+		SetLimit(int64(showNumber))
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	var msgs []*unrelation.ScheduledMsgModel
+	if err := cursor.All(ctx, &msgs); err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	return total, msgs, nil
+}
+
+func (s *ScheduledMsgMongoDriver) UpdatePending(ctx context.Context, scheduleID string, args map[string]any) (bool, error) {
+	return s.UpdateStatus(ctx, scheduleID, unrelation.ScheduledMsgPending, args)
+}
+
+func (s *ScheduledMsgMongoDriver) UpdateStatus(ctx context.Context, scheduleID string, from int32, args map[string]any) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	res, err := s.coll.UpdateOne(ctx, bson.M{"schedule_id": scheduleID, "status": from}, bson.M{"$set": args})
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res.MatchedCount > 0, nil
+}
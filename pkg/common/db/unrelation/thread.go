@@ -0,0 +1,220 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unrelation
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenIMSDK/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/common/db/table/unrelation"
+)
+
+func NewThreadMongo(database *mongo.Database) (unrelation.ThreadModelInterface, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	thread := database.Collection(unrelation.CThread)
+	_, err := thread.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "thread_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "archived", Value: 1}, {Key: "last_active_time", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "archived", Value: 1}, {Key: "auto_archive_time", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	subscription := database.Collection(unrelation.CThreadSubscription)
+	_, err = subscription.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "thread_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "create_time", Value: -1}},
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &ThreadMongoDriver{thread: thread, subscription: subscription}, nil
+}
+
+type ThreadMongoDriver struct {
+	thread       *mongo.Collection
+	subscription *mongo.Collection
+}
+
+func (t *ThreadMongoDriver) Create(ctx context.Context, thread *unrelation.ThreadModel) error {
+	_, err := t.thread.InsertOne(ctx, thread)
+	return errs.Wrap(err)
+}
+
+func (t *ThreadMongoDriver) Take(ctx context.Context, threadID string) (*unrelation.ThreadModel, error) {
+	var thread unrelation.ThreadModel
+	if err := t.thread.FindOne(ctx, bson.M{"thread_id": threadID}).Decode(&thread); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &thread, nil
+}
+
+func (t *ThreadMongoDriver) Find(ctx context.Context, threadIDs []string) ([]*unrelation.ThreadModel, error) {
+	cursor, err := t.thread.Find(ctx, bson.M{"thread_id": bson.M{"$in": threadIDs}})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var threads []*unrelation.ThreadModel
+	if err := cursor.All(ctx, &threads); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return threads, nil
+}
+
+func (t *ThreadMongoDriver) PageGroupThreads(ctx context.Context, groupID string, archived bool, pageNumber, showNumber int32) (int64, []*unrelation.ThreadModel, error) {
+	filter := bson.M{"group_id": groupID, "archived": archived}
+	total, err := t.thread.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_active_time", Value: -1}}).
+		SetSkip(int64(pageNumber-1) * int64(showNumber)).
+		SetLimit(int64(showNumber))
+	cursor, err := t.thread.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	var threads []*unrelation.ThreadModel
+	if err := cursor.All(ctx, &threads); err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	return total, threads, nil
+}
+
+func (t *ThreadMongoDriver) Touch(ctx context.Context, threadID string, activeTime time.Time) (*unrelation.ThreadModel, error) {
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"archived":         false,
+			"last_active_time": activeTime,
+			"auto_archive_time": bson.M{"$add": bson.A{
+				activeTime,
+				bson.M{"$multiply": bson.A{"$auto_archive_duration", int64(time.Minute / time.Millisecond)}},
+			}},
+		}},
+	}
+	var thread unrelation.ThreadModel
+	err := t.thread.FindOneAndUpdate(ctx, bson.M{"thread_id": threadID}, update).Decode(&thread)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &thread, nil
+}
+
+func (t *ThreadMongoDriver) UpdateByMap(ctx context.Context, threadID string, args map[string]any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	_, err := t.thread.UpdateOne(ctx, bson.M{"thread_id": threadID}, bson.M{"$set": args})
+	return errs.Wrap(err)
+}
+
+func (t *ThreadMongoDriver) FindInactive(ctx context.Context, now time.Time, limit int64) ([]*unrelation.ThreadModel, error) {
+	filter := bson.M{"archived": false, "auto_archive_time": bson.M{"$lte": now}}
+	cursor, err := t.thread.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "auto_archive_time", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var threads []*unrelation.ThreadModel
+	if err := cursor.All(ctx, &threads); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return threads, nil
+}
+
+func (t *ThreadMongoDriver) ArchiveInactive(ctx context.Context, threadID string, now time.Time) (bool, error) {
+	filter := bson.M{"thread_id": threadID, "archived": false, "auto_archive_time": bson.M{"$lte": now}}
+	res, err := t.thread.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"archived": true, "archive_time": now}})
+	if err != nil {
+		return false, errs.Wrap(err)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (t *ThreadMongoDriver) Subscribe(ctx context.Context, threadID string, userID string) error {
+	filter := bson.M{"thread_id": threadID, "user_id": userID}
+	update := bson.M{"$setOnInsert": &unrelation.ThreadSubscriptionModel{ThreadID: threadID, UserID: userID, CreateTime: time.Now()}}
+	_, err := t.subscription.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil // subscribed concurrently
+	}
+	return errs.Wrap(err)
+}
+
+func (t *ThreadMongoDriver) Unsubscribe(ctx context.Context, threadID string, userID string) error {
+	_, err := t.subscription.DeleteOne(ctx, bson.M{"thread_id": threadID, "user_id": userID})
+	return errs.Wrap(err)
+}
+
+func (t *ThreadMongoDriver) FindSubscriberIDs(ctx context.Context, threadID string) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "user_id": 1})
+	cursor, err := t.subscription.Find(ctx, bson.M{"thread_id": threadID}, opts)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var subscriptions []*unrelation.ThreadSubscriptionModel
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	userIDs := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		userIDs = append(userIDs, subscription.UserID)
+	}
+	return userIDs, nil
+}
+
+func (t *ThreadMongoDriver) PageUserSubscriptions(ctx context.Context, userID string, pageNumber, showNumber int32) (int64, []string, error) {
+	filter := bson.M{"user_id": userID}
+	total, err := t.subscription.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "thread_id": 1}).
+		SetSort(bson.D{{Key: "create_time", Value: -1}}).
+		SetSkip(int64(pageNumber-1) * int64(showNumber)).
+		SetLimit(int64(showNumber))
+	cursor, err := t.subscription.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	var subscriptions []*unrelation.ThreadSubscriptionModel
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return 0, nil, errs.Wrap(err)
+	}
+	threadIDs := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		threadIDs = append(threadIDs, subscription.ThreadID)
+	}
+	return total, threadIDs, nil
+}
@@ -5,4 +5,8 @@ const (
 	VoiceAlreadyInvitationErr = 1802
 
 	MsgBeBlocked = 1405
+
+	ConnRateLimitErr          = 1603
+	ConnHandshakeRateLimitErr = 1604
+	ConnServerDrainingErr     = 1605
 )
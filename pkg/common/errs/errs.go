@@ -6,5 +6,7 @@ var (
 	ErrVoiceChannelClosed     = errs.NewCodeError(VoiceChannelClosedErr, "VoiceChannelClosedError")
 	ErrVoiceAlreadyInvitation = errs.NewCodeError(VoiceAlreadyInvitationErr, "VoiceAlreadyInviationError")
 	ErrMsgBeBlocked           = errs.NewCodeError(MsgBeBlocked, "MsgBeBlocked") //陌生人消息被拦截
-
+	ErrConnRateLimit          = errs.NewCodeError(ConnRateLimitErr, "ConnRateLimitError")
+	ErrConnHandshakeRateLimit = errs.NewCodeError(ConnHandshakeRateLimitErr, "ConnHandshakeRateLimitError")
+	ErrConnServerDraining     = errs.NewCodeError(ConnServerDrainingErr, "ConnServerDrainingError")
 )
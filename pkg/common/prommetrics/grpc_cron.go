@@ -0,0 +1,20 @@
+package prommetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	MsgDestructConversationCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "msg_destruct_conversations_total",
+		Help: "The number of conversations processed by the msg destruct job, by result",
+	}, []string{"result"})
+	MsgDestructMsgCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "msg_destruct_msgs_total",
+		Help: "The number of msgs destructed for their conversation owners",
+	})
+	MsgDestructDueGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "msg_destruct_due_conversations",
+		Help: "The number of conversations still due for msg destruct after the last run of each shard",
+	}, []string{"shard"})
+)
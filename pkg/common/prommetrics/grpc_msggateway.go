@@ -9,4 +9,8 @@ var (
 		Name: "online_user_num",
 		Help: "The number of online user num",
 	})
+	MsgGatewayRateLimitedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "msg_gateway_rate_limited_total",
+		Help: "The number of gateway requests rejected by rate limit",
+	})
 )
@@ -9,4 +9,13 @@ var (
 		Name: "msg_offline_push_failed_total",
 		Help: "The number of msg failed offline pushed",
 	})
+	MsgOfflinePushProviderCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "msg_offline_push_provider_total",
+		Help: "The number of users offline pushed by each provider, by result",
+	}, []string{"provider", "result"})
+	MsgOfflinePushProviderLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "msg_offline_push_provider_latency_seconds",
+		Help:    "The latency of the offline push calls to each provider",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider"})
 )
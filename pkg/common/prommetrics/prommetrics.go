@@ -22,15 +22,17 @@ func NewGrpcPromObj(cusMetrics []prometheus.Collector) (*prometheus.Registry, *g
 func GetGrpcCusMetrics(registerName string) []prometheus.Collector {
 	switch registerName {
 	case config2.Config.RpcRegisterName.OpenImMessageGatewayName:
-		return []prometheus.Collector{OnlineUserGauge}
+		return []prometheus.Collector{OnlineUserGauge, MsgGatewayRateLimitedCounter}
 	case config2.Config.RpcRegisterName.OpenImMsgName:
 		return []prometheus.Collector{SingleChatMsgProcessSuccessCounter, SingleChatMsgProcessFailedCounter, GroupChatMsgProcessSuccessCounter, GroupChatMsgProcessFailedCounter}
 	case "Transfer":
 		return []prometheus.Collector{MsgInsertRedisSuccessCounter, MsgInsertRedisFailedCounter, MsgInsertMongoSuccessCounter, MsgInsertMongoFailedCounter, SeqSetFailedCounter}
 	case config2.Config.RpcRegisterName.OpenImPushName:
-		return []prometheus.Collector{MsgOfflinePushFailedCounter}
+		return []prometheus.Collector{MsgOfflinePushFailedCounter, MsgOfflinePushProviderCounter, MsgOfflinePushProviderLatency}
 	case config2.Config.RpcRegisterName.OpenImAuthName:
 		return []prometheus.Collector{UserLoginCounter}
+	case config2.Config.RpcRegisterName.OpenImCronName:
+		return []prometheus.Collector{MsgDestructConversationCounter, MsgDestructMsgCounter, MsgDestructDueGauge}
 	default:
 		return nil
 	}
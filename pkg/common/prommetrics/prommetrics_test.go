@@ -44,12 +44,19 @@ func TestNewGrpcPromObj(t *testing.T) {
 }
 
 func TestGetGrpcCusMetrics(t *testing.T) {
+	// The register names are empty without a config file, give them distinct values.
+	config2.Config.RpcRegisterName.OpenImMessageGatewayName = "MessageGateway"
+	config2.Config.RpcRegisterName.OpenImPushName = "Push"
+	config2.Config.RpcRegisterName.OpenImCronName = "Cron"
+
 	// Test various cases based on the switch statement in the GetGrpcCusMetrics function.
 	testCases := []struct {
 		name     string
 		expected int // The expected number of metrics for each case.
 	}{
-		{config2.Config.RpcRegisterName.OpenImMessageGatewayName, 1},
+		{config2.Config.RpcRegisterName.OpenImMessageGatewayName, 2},
+		{config2.Config.RpcRegisterName.OpenImPushName, 3},
+		{config2.Config.RpcRegisterName.OpenImCronName, 3},
 	}
 
 	for _, tc := range testCases {
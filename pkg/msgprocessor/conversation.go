@@ -54,11 +54,35 @@ func GetChatConversationIDByMsg(msg *sdkws.MsgData) string {
 	case constant.NotificationChatType:
 		return "sn_" + msg.SendID + "_" + msg.RecvID
 	case constant.ServerGroupChatType:
+		if msg.ThreadID != "" {
+			return GetThreadConversationID(msg.ThreadID)
+		}
 		return "svg_" + msg.GroupID
 	}
 	return ""
 }
 
+const threadConversationPrefix = "svt_"
+
+// GetThreadConversationID returns the conversation of a thread, its messages have their own seqs apart
+// from the server channel.
+func GetThreadConversationID(threadID string) string {
+	return threadConversationPrefix + threadID
+}
+
+// IsThreadConversation reports whether the conversation is the one of a thread.
+func IsThreadConversation(conversationID string) bool {
+	return strings.HasPrefix(conversationID, threadConversationPrefix)
+}
+
+// GetThreadIDByConversationID returns the thread of a thread conversation, or "" for any other conversation.
+func GetThreadIDByConversationID(conversationID string) string {
+	if !IsThreadConversation(conversationID) {
+		return ""
+	}
+	return strings.TrimPrefix(conversationID, threadConversationPrefix)
+}
+
 func GenConversationUniqueKey(msg *sdkws.MsgData) string {
 	switch msg.SessionType {
 	case constant.SingleChatType, constant.NotificationChatType:
@@ -95,6 +119,9 @@ func GetConversationIDByMsg(msg *sdkws.MsgData) string {
 		if !options.IsNotNotification() {
 			return "n_" + msg.GroupID
 		}
+		if msg.ThreadID != "" {
+			return GetThreadConversationID(msg.ThreadID)
+		}
 		return "svg_" + msg.GroupID // server group chat
 	case constant.NotificationChatType:
 		if !options.IsNotNotification() {
@@ -125,7 +152,12 @@ func GetConversationIDBySessionType(sessionType int, ids ...string) string {
 	return ""
 }
 
+// GetNotificationConversationIDByConversationID returns "" for a thread conversation, the notifications of a
+// thread go to the notification conversation of its server channel.
 func GetNotificationConversationIDByConversationID(conversationID string) string {
+	if IsThreadConversation(conversationID) {
+		return ""
+	}
 	l := strings.Split(conversationID, "_")
 	if len(l) > 1 {
 		l[0] = "n"
@@ -176,6 +208,9 @@ func ParseConversationID(msg *sdkws.MsgData) (isNotification bool, conversationI
 		if !options.IsNotNotification() {
 			return true, "n_" + msg.GroupID
 		}
+		if msg.ThreadID != "" {
+			return false, GetThreadConversationID(msg.ThreadID)
+		}
 		return false, "svg_" + msg.GroupID // server group chat
 	case constant.NotificationChatType:
 		if !options.IsNotNotification() {
@@ -131,7 +131,11 @@ func TestGetNotificationConversationIDByConversationID(t *testing.T) {
 		args args
 		want string
 	}{
-		// TODO: Add test cases.
+		{"single", args{"si_u1_u2"}, "n_u1_u2"},
+		{"super group", args{"sg_g1"}, "n_g1"},
+		{"server channel", args{"svg_g1"}, "n_g1"},
+		{"thread", args{"svt_t1"}, ""},
+		{"invalid", args{"g1"}, ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -332,3 +336,22 @@ func TestString2Pb(t *testing.T) {
 		})
 	}
 }
+
+func TestGetThreadIDByConversationID(t *testing.T) {
+	tests := []struct {
+		conversationID string
+		want           string
+	}{
+		{GetThreadConversationID("t1"), "t1"},
+		{"svg_g1", ""},
+		{"sg_svt_t1", ""},
+	}
+	for _, tt := range tests {
+		if got := GetThreadIDByConversationID(tt.conversationID); got != tt.want {
+			t.Errorf("GetThreadIDByConversationID(%s) = %v, want %v", tt.conversationID, got, tt.want)
+		}
+		if IsThreadConversation(tt.conversationID) != (tt.want != "") {
+			t.Errorf("IsThreadConversation(%s) = %v", tt.conversationID, !(tt.want != ""))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"encoding/json"
+
+	"github.com/OpenIMSDK/protocol/constant"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
+)
+
+// MediaLink is a url of the media a msg carries.
+type MediaLink struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+// GetMediaLinks returns the urls of the picture, sound, video or file of a msg, other msgs have none.
+func GetMediaLinks(contentType int32, content []byte) []MediaLink {
+	var links []MediaLink
+	add := func(typ, name, url string) {
+		if url != "" {
+			links = append(links, MediaLink{Type: typ, Name: name, URL: url})
+		}
+	}
+	switch contentType {
+	case constant.Picture:
+		var elem apistruct.PictureElem
+		if err := json.Unmarshal(content, &elem); err != nil {
+			return nil
+		}
+		add("picture", "", elem.SourcePicture.Url)
+	case constant.Voice:
+		var elem apistruct.SoundElem
+		if err := json.Unmarshal(content, &elem); err != nil {
+			return nil
+		}
+		add("sound", "", elem.SourceURL)
+	case constant.Video:
+		var elem apistruct.VideoElem
+		if err := json.Unmarshal(content, &elem); err != nil {
+			return nil
+		}
+		add("video", "", elem.VideoURL)
+		add("snapshot", "", elem.SnapshotURL)
+	case constant.File:
+		var elem apistruct.FileElem
+		if err := json.Unmarshal(content, &elem); err != nil {
+			return nil
+		}
+		add("file", elem.FileName, elem.SourceURL)
+	}
+	return links
+}
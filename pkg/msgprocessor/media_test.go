@@ -0,0 +1,48 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+)
+
+func TestGetMediaLinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType int32
+		content     string
+		want        []MediaLink
+	}{
+		{"picture", constant.Picture, `{"sourcePicture":{"url":"http://s/a.png"}}`, []MediaLink{{Type: "picture", URL: "http://s/a.png"}}},
+		{"video", constant.Video, `{"videoUrl":"http://s/a.mp4","snapshotUrl":"http://s/a.jpg"}`, []MediaLink{
+			{Type: "video", URL: "http://s/a.mp4"},
+			{Type: "snapshot", URL: "http://s/a.jpg"},
+		}},
+		{"file", constant.File, `{"sourceUrl":"http://s/a.pdf","fileName":"a.pdf"}`, []MediaLink{{Type: "file", Name: "a.pdf", URL: "http://s/a.pdf"}}},
+		{"no url", constant.Voice, `{"soundPath":"a.m4a"}`, nil},
+		{"invalid json", constant.Picture, `a.png`, nil},
+		{"text", constant.Text, `{"content":"http://s/a.png"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetMediaLinks(tt.contentType, []byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetMediaLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+
+	"github.com/openimsdk/open-im-server/v3/pkg/apistruct"
+)
+
+// GetSearchText returns the text of a text or @ msg to index for search, other msgs have none.
+func GetSearchText(contentType int32, content []byte) string {
+	switch contentType {
+	case constant.Text:
+		var elem apistruct.TextElem
+		if err := json.Unmarshal(content, &elem); err != nil {
+			return ""
+		}
+		return strings.TrimSpace(elem.Content)
+	case constant.AtText:
+		var elem apistruct.AtElem
+		if err := json.Unmarshal(content, &elem); err != nil {
+			return ""
+		}
+		return strings.TrimSpace(elem.Text)
+	default:
+		return ""
+	}
+}
+
+// maxSearchTokenLen bounds the runes of a token, longer words are matched on their first runes.
+const maxSearchTokenLen = 16
+
+// isSearchIdeograph reports whether r belongs to a script written without spaces between words.
+func isSearchIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// splitSearchText lowercases text and splits it into runs of letters and digits, each run written either
+// with ideographs only or without any.
+func splitSearchText(text string) (runs [][]rune) {
+	var run []rune
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(run) > 0 {
+				runs, run = append(runs, run), nil
+			}
+			continue
+		}
+		if len(run) > 0 && isSearchIdeograph(run[0]) != isSearchIdeograph(r) {
+			runs, run = append(runs, run), nil
+		}
+		run = append(run, r)
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// GetSearchTokens returns the tokens to index text under: the prefixes of its words, and the single
+// ideographs and pairs of ideographs of the ideograph runs.
+func GetSearchTokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]struct{})
+	add := func(token []rune) {
+		if _, ok := seen[string(token)]; !ok {
+			seen[string(token)] = struct{}{}
+			tokens = append(tokens, string(token))
+		}
+	}
+	for _, run := range splitSearchText(text) {
+		if isSearchIdeograph(run[0]) {
+			for i := range run {
+				add(run[i : i+1])
+				if i+1 < len(run) {
+					add(run[i : i+2])
+				}
+			}
+			continue
+		}
+		if len(run) > maxSearchTokenLen {
+			run = run[:maxSearchTokenLen]
+		}
+		for i := 1; i <= len(run); i++ {
+			add(run[:i])
+		}
+	}
+	return tokens
+}
+
+// GetSearchKeywordTokens returns the tokens a msg indexed by GetSearchTokens must all have to match
+// keyword: its words, and the pairs of ideographs of the ideograph runs.
+func GetSearchKeywordTokens(keyword string) []string {
+	var tokens []string
+	for _, run := range splitSearchText(keyword) {
+		switch {
+		case isSearchIdeograph(run[0]) && len(run) > 1:
+			for i := 0; i+1 < len(run); i++ {
+				tokens = append(tokens, string(run[i:i+2]))
+			}
+		case len(run) > maxSearchTokenLen:
+			tokens = append(tokens, string(run[:maxSearchTokenLen]))
+		default:
+			tokens = append(tokens, string(run))
+		}
+	}
+	return tokens
+}
+
+// SearchIndexChange is what a revoke or delete notification changes in the search index, the seqs of the
+// conversation are removed, or hidden from UserID only when it is set.
+type SearchIndexChange struct {
+	ConversationID string
+	Seqs           []int64
+	UserID         string
+}
+
+// GetSearchIndexChange returns the change a revoke or delete notification makes to the search index, nil
+// for any other msg. A delete notified to the user alone deleted the msgs for that user only.
+func GetSearchIndexChange(msg *sdkws.MsgData) *SearchIndexChange {
+	var notification sdkws.NotificationElem
+	switch msg.ContentType {
+	case constant.MsgRevokeNotification:
+		var tips sdkws.RevokeMsgTips
+		if json.Unmarshal(msg.Content, &notification) != nil || json.Unmarshal([]byte(notification.Detail), &tips) != nil {
+			return nil
+		}
+		return &SearchIndexChange{ConversationID: tips.ConversationID, Seqs: []int64{tips.Seq}}
+	case constant.DeleteMsgsNotification:
+		var tips sdkws.DeleteMsgsTips
+		if json.Unmarshal(msg.Content, &notification) != nil || json.Unmarshal([]byte(notification.Detail), &tips) != nil {
+			return nil
+		}
+		change := &SearchIndexChange{ConversationID: tips.ConversationID, Seqs: tips.Seqs}
+		if msg.SendID == msg.RecvID {
+			change.UserID = tips.UserID
+		}
+		return change
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgprocessor
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/OpenIMSDK/protocol/constant"
+	"github.com/OpenIMSDK/protocol/sdkws"
+)
+
+func TestGetSearchText(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType int32
+		content     string
+		want        string
+	}{
+		{"text", constant.Text, `{"content":" hello world "}`, "hello world"},
+		{"at text", constant.AtText, `{"text":"@alice lunch?","atUserList":["alice"]}`, "@alice lunch?"},
+		{"invalid json", constant.Text, `hello`, ""},
+		{"picture", constant.Picture, `{"sourcePath":"a.png"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetSearchText(tt.contentType, []byte(tt.content)); got != tt.want {
+				t.Errorf("GetSearchText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSearchIndexChange(t *testing.T) {
+	notification := func(sendID, recvID string, contentType int32, tips any) *sdkws.MsgData {
+		detail, _ := json.Marshal(tips)
+		content, _ := json.Marshal(&sdkws.NotificationElem{Detail: string(detail)})
+		return &sdkws.MsgData{SendID: sendID, RecvID: recvID, ContentType: contentType, Content: content}
+	}
+	tests := []struct {
+		name string
+		msg  *sdkws.MsgData
+		want string
+	}{
+		{
+			"revoke",
+			notification("u1", "g1", constant.MsgRevokeNotification, &sdkws.RevokeMsgTips{ConversationID: "sg_g1", Seq: 7}),
+			"&{sg_g1 [7] }",
+		},
+		{
+			"delete for everyone",
+			notification("u1", "u2", constant.DeleteMsgsNotification, &sdkws.DeleteMsgsTips{UserID: "u1", ConversationID: "si_u1_u2", Seqs: []int64{3, 4}}),
+			"&{si_u1_u2 [3 4] }",
+		},
+		{
+			"delete for self",
+			notification("u1", "u1", constant.DeleteMsgsNotification, &sdkws.DeleteMsgsTips{UserID: "u1", ConversationID: "sg_g1", Seqs: []int64{5}}),
+			"&{sg_g1 [5] u1}",
+		},
+		{"invalid", &sdkws.MsgData{ContentType: constant.MsgRevokeNotification, Content: []byte("x")}, "<nil>"},
+		{"text", &sdkws.MsgData{ContentType: constant.Text, Content: []byte(`{"content":"hi"}`)}, "<nil>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(GetSearchIndexChange(tt.msg)); got != tt.want {
+				t.Errorf("GetSearchIndexChange() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSearchTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hi, Bob!", []string{"h", "hi", "b", "bo", "bob"}},
+		{"hi hi", []string{"h", "hi"}},
+		{"ok你好吗", []string{"o", "ok", "你", "你好", "好", "好吗", "吗"}},
+		{"abcdefghijklmnopqrs", []string{"a", "ab", "abc", "abcd", "abcde", "abcdef", "abcdefg", "abcdefgh",
+			"abcdefghi", "abcdefghij", "abcdefghijk", "abcdefghijkl", "abcdefghijklm", "abcdefghijklmn",
+			"abcdefghijklmno", "abcdefghijklmnop"}},
+		{" ?! ", nil},
+	}
+	for _, tt := range tests {
+		if got := GetSearchTokens(tt.text); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GetSearchTokens(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGetSearchKeywordTokens(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{"BO", []string{"bo"}},
+		{"lunch 你好吗", []string{"lunch", "你好", "好吗"}},
+		{"好", []string{"好"}},
+		{"abcdefghijklmnopqrs", []string{"abcdefghijklmnop"}},
+	}
+	for _, tt := range tests {
+		if got := GetSearchKeywordTokens(tt.keyword); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GetSearchKeywordTokens(%q) = %q, want %q", tt.keyword, got, tt.want)
+		}
+	}
+}
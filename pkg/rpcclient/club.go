@@ -28,6 +28,7 @@ import (
 	"github.com/OpenIMSDK/tools/utils"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
+	"github.com/openimsdk/open-im-server/v3/pkg/permissions"
 )
 
 type Club struct {
@@ -86,6 +87,26 @@ func (c *ClubRpcClient) GetServerMemberInfo(
 	return members[0], nil
 }
 
+// GetServerMemberPermissions returns the permissions of the server role the member holds.
+func (c *ClubRpcClient) GetServerMemberPermissions(
+	ctx context.Context,
+	serverID string,
+	userID string,
+) (permissions.Permissions, error) {
+	member, err := c.GetServerMemberInfo(ctx, serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.GetServerRolesInfo(ctx, &club.GetServerRolesInfoReq{RoleIDs: []string{member.ServerRoleID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Roles) == 0 {
+		return nil, errs.ErrRecordNotFound.Wrap("server role not found " + member.ServerRoleID)
+	}
+	return permissions.PermissionsFromJSON(resp.Roles[0].Permissions)
+}
+
 func (c *ClubRpcClient) GetServerMemberInfoMap(
 	ctx context.Context,
 	serverID string,
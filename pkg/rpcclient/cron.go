@@ -61,3 +61,18 @@ func (c *CronRpcClient) SetCloseVoiceChannelJob(ctx context.Context, userID, cha
 	}
 	return nil
 }
+
+func (c *CronRpcClient) SetScheduledMsgJob(ctx context.Context, scheduleID string, scheduleTime int64) error {
+	_, err := c.Client.SetScheduledMsgJob(ctx, &pbcron.SetScheduledMsgJobReq{ScheduleID: scheduleID, ScheduleTime: scheduleTime})
+	return err
+}
+
+func (c *CronRpcClient) SetClosePollJob(ctx context.Context, pollID string, closeTime int64) error {
+	_, err := c.Client.SetClosePollJob(ctx, &pbcron.SetClosePollJobReq{PollID: pollID, CloseTime: closeTime})
+	return err
+}
+
+func (c *CronRpcClient) SetExportConversationJob(ctx context.Context, exportID string) error {
+	_, err := c.Client.SetExportConversationJob(ctx, &pbcron.SetExportConversationJobReq{ExportID: exportID})
+	return err
+}
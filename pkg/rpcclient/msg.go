@@ -27,6 +27,7 @@ import (
 	"github.com/OpenIMSDK/tools/discoveryregistry"
 	"github.com/OpenIMSDK/tools/log"
 	"github.com/OpenIMSDK/tools/utils"
+	"github.com/OpenIMSDK/tools/utils/splitter"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 	// "google.golang.org/protobuf/proto".
@@ -36,6 +37,10 @@ const (
 	SuperGroupDesignateMaxRecvID = 5
 )
 
+// getUsersUnreadCountBatch caps the user IDs sent in a single GetUsersUnreadCount call, each one costs the
+// msg service a scan of the user's conversations.
+const getUsersUnreadCountBatch = 100
+
 func newContentTypeConf() map[int32]config.NotificationConf {
 	return map[int32]config.NotificationConf{
 		// group
@@ -80,6 +85,8 @@ func newContentTypeConf() map[int32]config.NotificationConf {
 		constant.MsgRevokeNotification:  {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
 		constant.HasReadReceipt:         {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
 		constant.DeleteMsgsNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		// group read receipt
+		constant.GroupMsgReadCountNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
 		// signaling
 		constant.SignalingInvitedNotification:               config.Config.Notification.SignalingInvited,
 		constant.SignalingGroupInvitedNotification:          {IsSendMsg: true, ReliabilityLevel: constant.UnreliableNotification},
@@ -119,6 +126,17 @@ func newContentTypeConf() map[int32]config.NotificationConf {
 
 		// modifyMsg
 		constant.ModifyMessageNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		// reaction
+		constant.MsgReactionChangedNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		// edit
+		constant.MsgEditedNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		// thread
+		constant.ThreadCreatedNotification:  {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		constant.ThreadArchivedNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		// poll
+		constant.PollUpdatedNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
+		// export
+		constant.ConversationExportNotification: {IsSendMsg: false, ReliabilityLevel: constant.ReliableNotificationNoMsg},
 
 		// cron
 		constant.CronMsgClearSetNotification: config.Config.Notification.CronMsgClearSet,
@@ -252,6 +270,22 @@ func (m *MessageRpcClient) GetMsgBySeqs(ctx context.Context, req *msg.GetMsgBySe
 	return resp, err
 }
 
+// GetUsersUnreadCount returns the unread messages of each user over the conversations they haven't muted,
+// in chunks of getUsersUnreadCountBatch.
+func (m *MessageRpcClient) GetUsersUnreadCount(ctx context.Context, userIDs []string) (map[string]int64, error) {
+	unreadCounts := make(map[string]int64, len(userIDs))
+	for _, v := range splitter.NewSplitter(getUsersUnreadCountBatch, userIDs).GetSplitResult() {
+		resp, err := m.Client.GetUsersUnreadCount(ctx, &msg.GetUsersUnreadCountReq{UserIDs: v.Item})
+		if err != nil {
+			return nil, err
+		}
+		for userID, unreadCount := range resp.UnreadCounts {
+			unreadCounts[userID] = unreadCount
+		}
+	}
+	return unreadCounts, nil
+}
+
 type NotificationSender struct {
 	contentTypeConf map[int32]config.NotificationConf
 	sessionTypeConf map[int32]int32
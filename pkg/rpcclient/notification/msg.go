@@ -49,3 +49,18 @@ func (m *MsgNotificationSender) MarkAsReadNotification(ctx context.Context, conv
 	}
 	return m.NotificationWithSesstionType(ctx, sendID, recvID, constant.HasReadReceipt, sesstionType, tips)
 }
+
+func (m *MsgNotificationSender) ThreadArchivedNotification(ctx context.Context, opUserID, threadID, groupID string, archived bool, operationTime int64) error {
+	tips := &sdkws.ThreadArchivedTips{
+		ThreadID:      threadID,
+		GroupID:       groupID,
+		OpUserID:      opUserID,
+		Archived:      archived,
+		OperationTime: operationTime,
+	}
+	return m.NotificationWithSesstionType(ctx, opUserID, groupID, constant.ThreadArchivedNotification, constant.ServerGroupChatType, tips)
+}
+
+func (m *MsgNotificationSender) ConversationExportNotification(ctx context.Context, userID string, tips *sdkws.ConversationExportTips) error {
+	return m.Notification(ctx, userID, userID, constant.ConversationExportNotification, tips)
+}
@@ -27,10 +27,14 @@ import (
 	"github.com/OpenIMSDK/tools/discoveryregistry"
 	"github.com/OpenIMSDK/tools/errs"
 	"github.com/OpenIMSDK/tools/utils"
+	"github.com/OpenIMSDK/tools/utils/splitter"
 
 	"github.com/openimsdk/open-im-server/v3/pkg/common/config"
 )
 
+// getUsersSettingBatch caps the user IDs sent in a single GetUsersSetting call.
+const getUsersSettingBatch = 1000
+
 // User represents a structure holding connection details for the User RPC client.
 type User struct {
 	conn   grpc.ClientConnInterface
@@ -153,6 +157,37 @@ func (u *UserRpcClient) GetUserGlobalMsgRecvOpt(ctx context.Context, userID stri
 	return resp.GlobalRecvMsgOpt, nil
 }
 
+// GetUsersSetting retrieves the settings of many users keyed by user ID, in chunks of getUsersSettingBatch.
+func (u *UserRpcClient) GetUsersSetting(ctx context.Context, userIDs []string) (map[string]*user.GetUserSettingResp, error) {
+	settings := make(map[string]*user.GetUserSettingResp, len(userIDs))
+	for _, v := range splitter.NewSplitter(getUsersSettingBatch, userIDs).GetSplitResult() {
+		resp, err := u.Client.GetUsersSetting(ctx, &user.GetUsersSettingReq{UserIDs: v.Item})
+		if err != nil {
+			return nil, err
+		}
+		for userID, setting := range resp.Settings {
+			settings[userID] = setting
+		}
+	}
+	return settings, nil
+}
+
+// GetUsersQuietHours retrieves the quiet hours of many users that may apply to a push in conversationID of
+// serverID, keyed by user ID, in chunks of getUsersSettingBatch.
+func (u *UserRpcClient) GetUsersQuietHours(ctx context.Context, userIDs []string, conversationID, serverID string) (map[string][]*user.QuietHours, error) {
+	quietHours := make(map[string][]*user.QuietHours)
+	for _, v := range splitter.NewSplitter(getUsersSettingBatch, userIDs).GetSplitResult() {
+		resp, err := u.Client.GetUsersQuietHours(ctx, &user.GetUsersQuietHoursReq{UserIDs: v.Item, ConversationID: conversationID, ServerID: serverID})
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range resp.QuietHours {
+			quietHours[q.UserID] = append(quietHours[q.UserID], q)
+		}
+	}
+	return quietHours, nil
+}
+
 // Access verifies the access rights for the provided user ID.
 func (u *UserRpcClient) Access(ctx context.Context, ownerUserID string) error {
 	_, err := u.GetUserInfo(ctx, ownerUserID)
@@ -367,12 +367,15 @@ def "MULTILOGIN_POLICY" "1"           # 多登录策略
 def "CHAT_PERSISTENCE_MYSQL" "true"   # 聊天持久化MySQL
 def "MSG_CACHE_TIMEOUT" "86400"       # 消息缓存超时
 def "GROUP_MSG_READ_RECEIPT" "true"   # 群消息已读回执启用
+def "GROUP_MSG_READ_RECEIPT_MAX_MEMBER" "200" # 群消息已读列表的最大群人数
 def "SINGLE_MSG_READ_RECEIPT" "true"  # 单一消息已读回执启用
 def "RETAIN_CHAT_RECORDS" "365"       # 保留聊天记录
 # 聊天记录清理时间
 readonly CHAT_RECORDS_CLEAR_TIME=${CHAT_RECORDS_CLEAR_TIME:-'0 2 * * 3'}
 # 消息销毁时间
-readonly MSG_DESTRUCT_TIME=${MSG_DESTRUCT_TIME:-'0 2 * * *'}
+readonly MSG_DESTRUCT_TIME=${MSG_DESTRUCT_TIME:-'0 */10 * * * *'}
+# 消息销毁分片数
+def "MSG_DESTRUCT_SHARDS" "4"
 # 密钥
 readonly SECRET=${SECRET:-"${PASSWORD}"}
 def "TOKEN_EXPIRE" "90"         # Token到期时间